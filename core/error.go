@@ -40,9 +40,11 @@ const (
 	ErrorCodeUnprocessableEntity         ErrorCode = "UNPROCESSABLE_ENTITY"
 	ErrorCodeUnsupportedMediaType        ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
 
-	ErrorCodeStoreLimitExceeded ErrorCode = "STORE_LIMIT_EXCEEDED"
-	ErrorCodeStoreMagnetInvalid ErrorCode = "STORE_MAGNET_INVALID"
-	ErrorCodeStoreNameInvalid   ErrorCode = "STORE_NAME_INVALID"
+	ErrorCodeStoreIPBlocked      ErrorCode = "STORE_IP_BLOCKED"
+	ErrorCodeStoreLimitExceeded  ErrorCode = "STORE_LIMIT_EXCEEDED"
+	ErrorCodeStoreMagnetInvalid  ErrorCode = "STORE_MAGNET_INVALID"
+	ErrorCodeStoreMagnetTooLarge ErrorCode = "STORE_MAGNET_TOO_LARGE"
+	ErrorCodeStoreNameInvalid    ErrorCode = "STORE_NAME_INVALID"
 )
 
 type StremThruError interface {
@@ -185,8 +187,10 @@ var statusCodeByErrorCode = map[ErrorCode]int{
 	ErrorCodeUnprocessableEntity:         http.StatusUnprocessableEntity,
 	ErrorCodeUnsupportedMediaType:        http.StatusUnsupportedMediaType,
 
-	ErrorCodeStoreMagnetInvalid: http.StatusBadRequest,
-	ErrorCodeStoreNameInvalid:   http.StatusBadRequest,
+	ErrorCodeStoreIPBlocked:      http.StatusForbidden,
+	ErrorCodeStoreMagnetInvalid:  http.StatusBadRequest,
+	ErrorCodeStoreMagnetTooLarge: http.StatusUnprocessableEntity,
+	ErrorCodeStoreNameInvalid:    http.StatusBadRequest,
 }
 
 func (e *Error) Pack(r *http.Request) {
@@ -123,3 +123,44 @@ func UpsertTorrents(items []AniDBTorrent) error {
 
 	return nil
 }
+
+var query_get_anidb_id_by_hashes_before_values = fmt.Sprintf(
+	"SELECT %s, %s FROM %s WHERE %s IN ",
+	TorrentColumn.Hash,
+	TorrentColumn.TId,
+	TorrentTableName,
+	TorrentColumn.Hash,
+)
+
+// GetAniDBIdByHashes returns the anidb id by hash. For torrents spanning
+// multiple AniDB entries, any one of them is returned.
+func GetAniDBIdByHashes(hashes []string) (map[string]string, error) {
+	anidbIdByHash := make(map[string]string, len(hashes))
+	for cHashes := range slices.Chunk(hashes, 500) {
+		count := len(cHashes)
+		args := make([]any, count)
+		for i, hash := range cHashes {
+			args[i] = hash
+		}
+
+		query := query_get_anidb_id_by_hashes_before_values + "(" + util.RepeatJoin("?", count, ",") + ")"
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var hash, tid string
+			if err := rows.Scan(&hash, &tid); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			anidbIdByHash[hash] = tid
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return anidbIdByHash, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"strconv"
 	"strings"
@@ -405,6 +406,59 @@ func (ms AniDBTVDBEpisodeMaps) GetAbsoluteOrderSeasonMap() *AniDBTVDBEpisodeMap
 	return nil
 }
 
+// ToAniDBEpisode returns the AniDB id and episode for the TVDB season and
+// episode, or for the absolute episode if tvSeason is -1. It returns empty
+// id if there is no matching map. If multiple AniDB episodes match, the
+// lowest AniDB id and episode wins.
+func (ms AniDBTVDBEpisodeMaps) ToAniDBEpisode(tvSeason int, tvEpisode int) (string, int) {
+	ms = slices.Clone(ms)
+	ms.Sort()
+
+	for i := range ms {
+		m := &ms[i]
+		if m.TVDBSeason != tvSeason || !m.IsAniDBRegularSeason() {
+			continue
+		}
+		for _, anidbEpisode := range slices.Sorted(maps.Keys(m.Map)) {
+			if slices.Contains(m.Map[anidbEpisode], tvEpisode) {
+				return m.AniDBId, anidbEpisode
+			}
+		}
+	}
+
+	// later maps for the same season continue where the earlier ones end
+	var match *AniDBTVDBEpisodeMap
+	for i := range ms {
+		m := &ms[i]
+		if m.TVDBSeason != tvSeason || !m.IsAniDBRegularSeason() {
+			continue
+		}
+		anidbEpisode := tvEpisode - m.Offset
+		if anidbEpisode < 1 || (m.Start != 0 && anidbEpisode < m.Start) || (m.End != 0 && anidbEpisode > m.End) {
+			continue
+		}
+		if match == nil || match.Offset < m.Offset {
+			match = m
+		}
+	}
+	if match == nil {
+		return "", -1
+	}
+	return match.AniDBId, tvEpisode - match.Offset
+}
+
+// GetAbsoluteEpisode returns the absolute episode for the AniDB episode, or
+// -1 if the AniDB id has no absolute order map.
+func (ms AniDBTVDBEpisodeMaps) GetAbsoluteEpisode(anidbId string, anidbEpisode int) int {
+	for i := range ms {
+		m := &ms[i]
+		if m.AniDBId == anidbId && m.IsAniDBRegularSeason() && m.HasAbsoluteOrder() {
+			return anidbEpisode + m.Offset
+		}
+	}
+	return -1
+}
+
 var query_get_tvdb_episode_maps_by_anidbid = fmt.Sprintf(
 	`SELECT %s FROM %s WHERE %s = ?`,
 	db.JoinColumnNames(TVDBEpisodeMapColumns...),
@@ -421,12 +475,27 @@ var query_get_tvdb_episode_maps_by_anidbid_with_related = fmt.Sprintf(
 	TVDBEpisodeMapColumn.AniDBId,
 )
 
+var query_get_tvdb_episode_maps_by_tvdbid = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ?`,
+	db.JoinColumnNames(TVDBEpisodeMapColumns...),
+	TVDBEpisodeMapTableName,
+	TVDBEpisodeMapColumn.TVDBId,
+)
+
 func GetTVDBEpisodeMaps(anidbId string, includeRelated bool) (AniDBTVDBEpisodeMaps, error) {
 	query := query_get_tvdb_episode_maps_by_anidbid
 	if includeRelated {
 		query = query_get_tvdb_episode_maps_by_anidbid_with_related
 	}
-	rows, err := db.Query(query, anidbId)
+	return queryTVDBEpisodeMaps(query, anidbId)
+}
+
+func GetTVDBEpisodeMapsByTVDBId(tvdbId string) (AniDBTVDBEpisodeMaps, error) {
+	return queryTVDBEpisodeMaps(query_get_tvdb_episode_maps_by_tvdbid, tvdbId)
+}
+
+func queryTVDBEpisodeMaps(query string, args ...any) (AniDBTVDBEpisodeMaps, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
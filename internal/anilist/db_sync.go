@@ -109,15 +109,17 @@ func EnsureIdMap(medias []AniListMedia) error {
 }
 
 func ScheduleIdMapSync(medias []AniListMedia) {
+	items := []worker_queue.AnimeIdMapperQueueItem{}
 	for i := range medias {
 		media := &medias[i]
 		if media.IdMap == nil || media.IdMap.IsStale() {
-			worker_queue.AnimeIdMapperQueue.Queue(worker_queue.AnimeIdMapperQueueItem{
+			items = append(items, worker_queue.AnimeIdMapperQueueItem{
 				Service: anime.IdMapColumn.AniList,
 				Id:      strconv.Itoa(media.Id),
 			})
 		}
 	}
+	worker_queue.AnimeIdMapperQueue.QueueMany(items)
 }
 
 func getListCacheKey(l *AniListList) string {
@@ -123,6 +123,44 @@ var IdMapColumns = []string{
 	IdMapColumn.UpdatedAt,
 }
 
+func scanIdMap(row interface{ Scan(dest ...any) error }) (*AnimeIdMap, error) {
+	var item rawAnimeIdMap
+	if err := row.Scan(
+		&item.Id,
+		&item.Type,
+		&item.AniDB,
+		&item.AniList,
+		&item.AniSearch,
+		&item.AnimePlanet,
+		&item.IMDB,
+		&item.Kitsu,
+		&item.LiveChart,
+		&item.MAL,
+		&item.NotifyMoe,
+		&item.TMDB,
+		&item.TVDB,
+		&item.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &AnimeIdMap{
+		Id:          item.Id,
+		Type:        item.Type,
+		AniList:     item.AniList.String,
+		AniDB:       item.AniDB.String,
+		AniSearch:   item.AniSearch.String,
+		AnimePlanet: item.AnimePlanet.String,
+		IMDB:        item.IMDB.String,
+		Kitsu:       item.Kitsu.String,
+		LiveChart:   item.LiveChart.String,
+		MAL:         item.MAL.String,
+		NotifyMoe:   item.NotifyMoe.String,
+		TMDB:        item.TMDB.String,
+		TVDB:        item.TVDB.String,
+		UpdatedAt:   item.UpdatedAt,
+	}, nil
+}
+
 var query_get_id_map = fmt.Sprintf(
 	"SELECT %s FROM %s WHERE %s IN ",
 	strings.Join(IdMapColumns, ","),
@@ -145,41 +183,11 @@ func GetIdMapsForAniList(ids []int) ([]AnimeIdMap, error) {
 
 	idMaps := []AnimeIdMap{}
 	for rows.Next() {
-		var item rawAnimeIdMap
-		if err := rows.Scan(
-			&item.Id,
-			&item.Type,
-			&item.AniDB,
-			&item.AniList,
-			&item.AniSearch,
-			&item.AnimePlanet,
-			&item.IMDB,
-			&item.Kitsu,
-			&item.LiveChart,
-			&item.MAL,
-			&item.NotifyMoe,
-			&item.TMDB,
-			&item.TVDB,
-			&item.UpdatedAt,
-		); err != nil {
+		idMap, err := scanIdMap(rows)
+		if err != nil {
 			return nil, err
 		}
-		idMaps = append(idMaps, AnimeIdMap{
-			Id:          item.Id,
-			Type:        item.Type,
-			AniList:     item.AniList.String,
-			AniDB:       item.AniDB.String,
-			AniSearch:   item.AniSearch.String,
-			AnimePlanet: item.AnimePlanet.String,
-			IMDB:        item.IMDB.String,
-			Kitsu:       item.Kitsu.String,
-			LiveChart:   item.LiveChart.String,
-			MAL:         item.MAL.String,
-			NotifyMoe:   item.NotifyMoe.String,
-			TMDB:        item.TMDB.String,
-			TVDB:        item.TVDB.String,
-			UpdatedAt:   item.UpdatedAt,
-		})
+		idMaps = append(idMaps, *idMap)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -188,6 +196,39 @@ func GetIdMapsForAniList(ids []int) ([]AnimeIdMap, error) {
 	return idMaps, nil
 }
 
+var query_get_id_map_by_column = fmt.Sprintf(
+	"SELECT %s FROM %s WHERE ",
+	strings.Join(IdMapColumns, ","),
+	IdMapTableName,
+)
+
+func isLookupColumn(column string) bool {
+	switch column {
+	case IdMapColumn.AniDB, IdMapColumn.AniList, IdMapColumn.IMDB, IdMapColumn.Kitsu, IdMapColumn.MAL, IdMapColumn.TMDB, IdMapColumn.TVDB:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetIdMap returns the id map having the id in the column, e.g.
+// `IdMapColumn.Kitsu`. It returns nil if there is none.
+func GetIdMap(column, id string) (*AnimeIdMap, error) {
+	if !isLookupColumn(column) {
+		return nil, fmt.Errorf("unsupported id map column: %s", column)
+	}
+
+	query := query_get_id_map_by_column + column + " = ? LIMIT 1"
+	idMap, err := scanIdMap(db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return idMap, nil
+}
+
 var query_get_type_by_anilist_ids = fmt.Sprintf(
 	"SELECT %s, %s FROM %s WHERE %s IN ",
 	IdMapColumn.AniList,
@@ -274,27 +315,65 @@ func GetTypeByKitsuIds(ids []int) (map[int]AnimeIdMapType, error) {
 	return typeById, nil
 }
 
-var query_get_anidb_id_by_kitsu_id = fmt.Sprintf(
-	`SELECT im.%s, at.%s FROM %s im LEFT JOIN %s at ON at.%s = im.%s WHERE im.%s = ? LIMIT 1`,
+var query_get_anidb_id_by_column = fmt.Sprintf(
+	`SELECT im.%s, at.%s FROM %s im LEFT JOIN %s at ON at.%s = im.%s WHERE im.`,
 	IdMapColumn.AniDB,
 	anidb.TitleColumn.Season,
 	IdMapTableName,
 	anidb.TitleTableName,
 	anidb.TitleColumn.TId,
 	IdMapColumn.AniDB,
-	IdMapColumn.Kitsu,
 )
 
-func GetAniDBIdByKitsuId(kitsuId string) (anidbId, season string, err error) {
-	query := query_get_anidb_id_by_kitsu_id
-	row := db.QueryRow(query, kitsuId)
-	if err = row.Scan(&anidbId, &season); err != nil {
+// GetAniDBIdById returns the AniDB id and season for the id in the column,
+// e.g. `IdMapColumn.MAL`.
+func GetAniDBIdById(column, id string) (anidbId, season string, err error) {
+	if !isLookupColumn(column) {
+		return "", "", fmt.Errorf("unsupported id map column: %s", column)
+	}
+	var rawAniDBId, rawSeason sql.NullString
+	query := query_get_anidb_id_by_column + column + " = ? LIMIT 1"
+	row := db.QueryRow(query, id)
+	if err = row.Scan(&rawAniDBId, &rawSeason); err != nil {
 		if err == sql.ErrNoRows {
+			// anidb ids are usable even without a mapping
+			if column == IdMapColumn.AniDB {
+				return id, "", nil
+			}
 			return "", "", nil
 		}
 		return "", "", err
 	}
-	return anidbId, season, nil
+	return rawAniDBId.String, rawSeason.String, nil
+}
+
+func GetAniDBIdByKitsuId(kitsuId string) (anidbId, season string, err error) {
+	return GetAniDBIdById(IdMapColumn.Kitsu, kitsuId)
+}
+
+var idMapColumnByStremIdPrefix = map[string]string{
+	"anidb":   IdMapColumn.AniDB,
+	"anilist": IdMapColumn.AniList,
+	"kitsu":   IdMapColumn.Kitsu,
+	"mal":     IdMapColumn.MAL,
+}
+
+// StremIdPrefixes are the prefixes of anime strem ids, in the format
+// `<prefix><id>[:<episode>]`.
+var StremIdPrefixes = []string{"anidb:", "anilist:", "kitsu:", "mal:"}
+
+// ParseStremId returns the id map column, id and episode of anime strem ids.
+func ParseStremId(stremId string) (column, id, episode string, ok bool) {
+	prefix, rest, found := strings.Cut(stremId, ":")
+	if !found {
+		return "", "", "", false
+	}
+	column, ok = idMapColumnByStremIdPrefix[prefix]
+	if !ok {
+		return "", "", "", false
+	}
+	id, episode, _ = strings.Cut(rest, ":")
+	return column, id, episode, true
 }
 
 var query_bulk_record_id_maps_before_values = fmt.Sprintf(
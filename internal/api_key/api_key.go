@@ -0,0 +1,216 @@
+package api_key
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+type Scope string
+
+const (
+	ScopeStoreRead    Scope = "store:read"
+	ScopeStoreWrite   Scope = "store:write"
+	ScopeProxyCreate  Scope = "proxy:create"
+	ScopeTorrentsPush Scope = "torrents:push"
+	ScopeAdmin        Scope = "admin"
+)
+
+var Scopes = []Scope{
+	ScopeStoreRead,
+	ScopeStoreWrite,
+	ScopeProxyCreate,
+	ScopeTorrentsPush,
+	ScopeAdmin,
+}
+
+func (s Scope) IsValid() bool {
+	return slices.Contains(Scopes, s)
+}
+
+const keyPrefix = "stk_"
+
+var (
+	ErrInvalidKey    = errors.New("invalid api key")
+	ErrRevokedKey    = errors.New("api key revoked")
+	ErrExpiredKey    = errors.New("api key expired")
+	ErrIPNotAllowed  = errors.New("ip not allowed for api key")
+	ErrScopeRequired = errors.New("api key missing required scope")
+)
+
+// IsKey checks if the token looks like an api key. It can not be confused
+// with basic auth token, as `_` is not used in standard base64.
+func IsKey(token string) bool {
+	return strings.HasPrefix(token, keyPrefix)
+}
+
+// parseKey splits the key `stk_<id>_<secret>`.
+func parseKey(key string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(key, keyPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	return id, secret, ok && id != "" && secret != ""
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func (ak *APIKey) IsRevoked() bool {
+	return !ak.RevokedAt.IsZero()
+}
+
+func (ak *APIKey) IsExpired(now time.Time) bool {
+	return !ak.ExpiresAt.IsZero() && !now.Before(ak.ExpiresAt.Time)
+}
+
+func (ak *APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(ak.Scopes, string(scope))
+}
+
+// IsIPAllowed checks the ip against the allowlist. Any ip is allowed if the
+// allowlist is empty.
+func (ak *APIKey) IsIPAllowed(ip string) bool {
+	if len(ak.IPs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, allowed := range ak.IPs {
+		if prefix, err := netip.ParsePrefix(allowed); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+		} else if allowedAddr, err := netip.ParseAddr(allowed); err == nil && allowedAddr.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidIP checks if the value is an ip or a cidr, for the allowlist.
+func IsValidIP(value string) bool {
+	if _, err := netip.ParsePrefix(value); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(value)
+	return err == nil
+}
+
+func (ak *APIKey) check(secret, ip string, scope Scope, now time.Time) error {
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(ak.SecretHash)) != 1 {
+		return ErrInvalidKey
+	}
+	if ak.IsRevoked() {
+		return ErrRevokedKey
+	}
+	if ak.IsExpired(now) {
+		return ErrExpiredKey
+	}
+	if !ak.IsIPAllowed(ip) {
+		return ErrIPNotAllowed
+	}
+	if !ak.HasScope(scope) {
+		return ErrScopeRequired
+	}
+	return nil
+}
+
+type cachedAPIKey struct {
+	Key *APIKey
+}
+
+// kept in memory only, revocation reaches the other instances when expired
+var apiKeyCache = cache.NewLRUCache[cachedAPIKey](&cache.CacheConfig{
+	Lifetime: 1 * time.Minute,
+	Name:     "api_key",
+})
+
+func invalidate(id string) {
+	apiKeyCache.Remove(id)
+}
+
+func getCached(id string) (*APIKey, error) {
+	cached := cachedAPIKey{}
+	err := apiKeyCache.Fetch(id, &cached, func() (cachedAPIKey, error) {
+		ak, err := GetById(id)
+		if err != nil {
+			return cachedAPIKey{}, err
+		}
+		return cachedAPIKey{Key: ak}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cached.Key, nil
+}
+
+// Verify verifies the key for the request from the ip, requiring the scope.
+// The returned error is one of the Err* values, unless the lookup fails.
+func Verify(key, ip string, scope Scope) (*APIKey, error) {
+	id, secret, ok := parseKey(key)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	ak, err := getCached(id)
+	if err != nil {
+		log.Error("failed to get api key", "error", err, "id", id)
+		return nil, err
+	}
+	if ak == nil {
+		return nil, ErrInvalidKey
+	}
+	if err := ak.check(secret, ip, scope, time.Now()); err != nil {
+		return nil, err
+	}
+	return ak, nil
+}
+
+type CreateParams struct {
+	UserName  string
+	Name      string
+	Scopes    []Scope
+	IPs       []string
+	ExpiresAt time.Time
+}
+
+// Create creates a new key for the user. The returned key is not stored,
+// only the hash of its secret is.
+func Create(params *CreateParams) (string, *APIKey, error) {
+	scopes := db.CommaSeperatedString{}
+	for _, scope := range params.Scopes {
+		if !slices.Contains(scopes, string(scope)) {
+			scopes = append(scopes, string(scope))
+		}
+	}
+	secret := rand.Text()
+	ak := &APIKey{
+		Id:         util.GenerateRandomString(12, util.CharSet.AlphaNumeric),
+		UserName:   params.UserName,
+		Name:       params.Name,
+		SecretHash: hashSecret(secret),
+		Scopes:     scopes,
+		IPs:        append(db.CommaSeperatedString{}, params.IPs...),
+		ExpiresAt:  db.Timestamp{Time: params.ExpiresAt},
+	}
+	if err := insert(ak); err != nil {
+		return "", nil, err
+	}
+	return keyPrefix + ak.Id + "_" + secret, ak, nil
+}
@@ -0,0 +1,100 @@
+package api_key
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKey(t *testing.T) {
+	for _, tc := range []struct {
+		key    string
+		id     string
+		secret string
+		ok     bool
+	}{
+		{"stk_abc_SECRET", "abc", "SECRET", true},
+		{"stk_abc_", "", "", false},
+		{"stk__SECRET", "", "", false},
+		{"stk_abc", "", "", false},
+		{"abc_SECRET", "", "", false},
+	} {
+		t.Run(tc.key, func(t *testing.T) {
+			id, secret, ok := parseKey(tc.key)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.id, id)
+				assert.Equal(t, tc.secret, secret)
+			}
+		})
+	}
+}
+
+func TestIsIPAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		ips     []string
+		ip      string
+		allowed bool
+	}{
+		{"empty allowlist", []string{}, "203.0.113.7", true},
+		{"exact ip", []string{"203.0.113.7"}, "203.0.113.7", true},
+		{"other ip", []string{"203.0.113.7"}, "203.0.113.8", false},
+		{"cidr", []string{"192.168.1.0/24"}, "192.168.1.42", true},
+		{"outside cidr", []string{"192.168.1.0/24"}, "192.168.2.42", false},
+		{"ipv4 mapped ipv6", []string{"192.168.1.0/24"}, "::ffff:192.168.1.42", true},
+		{"ipv6 cidr", []string{"2001:db8::/32"}, "2001:db8::1", true},
+		{"missing ip", []string{"192.168.1.0/24"}, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ak := &APIKey{IPs: tc.ips}
+			assert.Equal(t, tc.allowed, ak.IsIPAllowed(tc.ip))
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	newKey := func() *APIKey {
+		return &APIKey{
+			SecretHash: hashSecret("secret"),
+			Scopes:     db.CommaSeperatedString{string(ScopeStoreRead)},
+			IPs:        db.CommaSeperatedString{"10.0.0.0/8"},
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		modify func(ak *APIKey)
+		secret string
+		ip     string
+		scope  Scope
+		err    error
+	}{
+		{"valid", nil, "secret", "10.1.2.3", ScopeStoreRead, nil},
+		{"wrong secret", nil, "other", "10.1.2.3", ScopeStoreRead, ErrInvalidKey},
+		{"revoked", func(ak *APIKey) { ak.RevokedAt = db.Timestamp{Time: now.Add(-time.Hour)} }, "secret", "10.1.2.3", ScopeStoreRead, ErrRevokedKey},
+		{"expired", func(ak *APIKey) { ak.ExpiresAt = db.Timestamp{Time: now.Add(-time.Minute)} }, "secret", "10.1.2.3", ScopeStoreRead, ErrExpiredKey},
+		{"not expired", func(ak *APIKey) { ak.ExpiresAt = db.Timestamp{Time: now.Add(time.Minute)} }, "secret", "10.1.2.3", ScopeStoreRead, nil},
+		{"ip not allowed", nil, "secret", "172.16.0.1", ScopeStoreRead, ErrIPNotAllowed},
+		{"missing scope", nil, "secret", "10.1.2.3", ScopeStoreWrite, ErrScopeRequired},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ak := newKey()
+			if tc.modify != nil {
+				tc.modify(ak)
+			}
+			assert.Equal(t, tc.err, ak.check(tc.secret, tc.ip, tc.scope, now))
+		})
+	}
+}
+
+func TestIsValidIP(t *testing.T) {
+	assert.True(t, IsValidIP("203.0.113.7"))
+	assert.True(t, IsValidIP("10.0.0.0/8"))
+	assert.True(t, IsValidIP("2001:db8::/32"))
+	assert.False(t, IsValidIP("10.0.0.0/33"))
+	assert.False(t, IsValidIP("example.com"))
+}
@@ -0,0 +1,188 @@
+package api_key
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+const TableName = "api_key"
+
+type APIKey struct {
+	Id       string
+	UserName string
+	Name     string
+	// sha256 of the secret, in hex
+	SecretHash string
+	Scopes     db.CommaSeperatedString
+	// ips or cidrs, empty for any
+	IPs       db.CommaSeperatedString
+	ExpiresAt db.Timestamp
+	RevokedAt db.Timestamp
+	CreatedAt db.Timestamp
+	UpdatedAt db.Timestamp
+}
+
+var Column = struct {
+	Id         string
+	UserName   string
+	Name       string
+	SecretHash string
+	Scopes     string
+	IPs        string
+	ExpiresAt  string
+	RevokedAt  string
+	CreatedAt  string
+	UpdatedAt  string
+}{
+	Id:         "id",
+	UserName:   "user_name",
+	Name:       "name",
+	SecretHash: "secret_hash",
+	Scopes:     "scopes",
+	IPs:        "ips",
+	ExpiresAt:  "expires_at",
+	RevokedAt:  "revoked_at",
+	CreatedAt:  "cat",
+	UpdatedAt:  "uat",
+}
+
+var columns = []string{
+	Column.Id,
+	Column.UserName,
+	Column.Name,
+	Column.SecretHash,
+	Column.Scopes,
+	Column.IPs,
+	Column.ExpiresAt,
+	Column.RevokedAt,
+	Column.CreatedAt,
+	Column.UpdatedAt,
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(row scanner) (*APIKey, error) {
+	ak := APIKey{}
+	if err := row.Scan(
+		&ak.Id,
+		&ak.UserName,
+		&ak.Name,
+		&ak.SecretHash,
+		&ak.Scopes,
+		&ak.IPs,
+		&ak.ExpiresAt,
+		&ak.RevokedAt,
+		&ak.CreatedAt,
+		&ak.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &ak, nil
+}
+
+var query_get_by_id = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ?`,
+	strings.Join(columns, ", "),
+	TableName,
+	Column.Id,
+)
+
+func GetById(id string) (*APIKey, error) {
+	ak, err := scan(db.QueryRow(query_get_by_id, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ak, nil
+}
+
+var query_list_by_user_name = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ? ORDER BY %s DESC`,
+	strings.Join(columns, ", "),
+	TableName,
+	Column.UserName,
+	Column.CreatedAt,
+)
+
+func ListByUserName(userName string) ([]APIKey, error) {
+	rows, err := db.Query(query_list_by_user_name, userName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		ak, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *ak)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+var query_insert = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES (%s)`,
+	TableName,
+	strings.Join(columns, ","),
+	util.RepeatJoin("?", len(columns), ","),
+)
+
+func insert(ak *APIKey) error {
+	now := time.Now()
+	ak.CreatedAt = db.Timestamp{Time: now}
+	ak.UpdatedAt = db.Timestamp{Time: now}
+	_, err := db.Exec(
+		query_insert,
+		ak.Id,
+		ak.UserName,
+		ak.Name,
+		ak.SecretHash,
+		ak.Scopes,
+		ak.IPs,
+		ak.ExpiresAt,
+		ak.RevokedAt,
+		ak.CreatedAt,
+		ak.UpdatedAt,
+	)
+	return err
+}
+
+var query_revoke = fmt.Sprintf(
+	`UPDATE %s SET %s = ?, %s = ? WHERE %s = ? AND %s = ? AND %s IS NULL`,
+	TableName,
+	Column.RevokedAt,
+	Column.UpdatedAt,
+	Column.Id,
+	Column.UserName,
+	Column.RevokedAt,
+)
+
+// Revoke revokes the key of the user. It returns false if not found, or
+// already revoked.
+func Revoke(userName, id string) (bool, error) {
+	now := db.Timestamp{Time: time.Now()}
+	result, err := db.Exec(query_revoke, now, now, id, userName)
+	if err != nil {
+		return false, err
+	}
+	invalidate(id)
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
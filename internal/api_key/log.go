@@ -0,0 +1,7 @@
+package api_key
+
+import (
+	"github.com/MunifTanjim/stremthru/internal/logger"
+)
+
+var log = logger.Scoped("api_key")
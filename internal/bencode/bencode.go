@@ -0,0 +1,214 @@
+package bencode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+//
+// Bencode, the encoding used by .torrent files and the BitTorrent protocol
+// https://www.bittorrent.org/beps/bep_0003.html#bencoding
+//
+// Values are decoded as int64, string, []any and map[string]any.
+//
+
+var ErrUnexpectedEnd = errors.New("bencode: unexpected end of data")
+
+func syntaxError(pos int, msg string) error {
+	return fmt.Errorf("bencode: %s at offset %d", msg, pos)
+}
+
+// DecodePrefix decodes the value at the start of data, and returns it with
+// the number of bytes read.
+func DecodePrefix(data []byte) (any, int, error) {
+	return decode(data, 0)
+}
+
+// Decode decodes data, which must contain exactly one value.
+func Decode(data []byte) (any, error) {
+	value, n, err := decode(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(data) {
+		return nil, syntaxError(n, "trailing data")
+	}
+	return value, nil
+}
+
+// DecodeDict decodes data, which must contain exactly one dictionary.
+func DecodeDict(data []byte) (map[string]any, error) {
+	value, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("bencode: not a dictionary")
+	}
+	return dict, nil
+}
+
+// GetRawValue returns the encoded value of the key in the top-level
+// dictionary, e.g. `info` of .torrent file. It returns nil if the key is
+// missing.
+func GetRawValue(data []byte, key string) ([]byte, error) {
+	if len(data) == 0 || data[0] != 'd' {
+		return nil, errors.New("bencode: not a dictionary")
+	}
+	pos := 1
+	for pos < len(data) && data[pos] != 'e' {
+		k, n, err := decodeString(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos = n
+		_, n, err = decode(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		if k == key {
+			return data[pos:n], nil
+		}
+		pos = n
+	}
+	if pos >= len(data) {
+		return nil, ErrUnexpectedEnd
+	}
+	return nil, nil
+}
+
+func decode(data []byte, pos int) (any, int, error) {
+	if pos >= len(data) {
+		return nil, pos, ErrUnexpectedEnd
+	}
+	switch c := data[pos]; {
+	case c == 'i':
+		end := bytes.IndexByte(data[pos+1:], 'e')
+		if end == -1 {
+			return nil, pos, ErrUnexpectedEnd
+		}
+		end += pos + 1
+		value, err := strconv.ParseInt(string(data[pos+1:end]), 10, 64)
+		if err != nil {
+			return nil, pos, syntaxError(pos, "invalid integer")
+		}
+		return value, end + 1, nil
+	case c == 'l':
+		list := []any{}
+		pos++
+		for pos < len(data) && data[pos] != 'e' {
+			value, n, err := decode(data, pos)
+			if err != nil {
+				return nil, n, err
+			}
+			list = append(list, value)
+			pos = n
+		}
+		if pos >= len(data) {
+			return nil, pos, ErrUnexpectedEnd
+		}
+		return list, pos + 1, nil
+	case c == 'd':
+		dict := map[string]any{}
+		pos++
+		for pos < len(data) && data[pos] != 'e' {
+			key, n, err := decodeString(data, pos)
+			if err != nil {
+				return nil, n, err
+			}
+			value, n, err := decode(data, n)
+			if err != nil {
+				return nil, n, err
+			}
+			dict[key] = value
+			pos = n
+		}
+		if pos >= len(data) {
+			return nil, pos, ErrUnexpectedEnd
+		}
+		return dict, pos + 1, nil
+	case '0' <= c && c <= '9':
+		return decodeString(data, pos)
+	default:
+		return nil, pos, syntaxError(pos, "invalid character "+strconv.QuoteRune(rune(c)))
+	}
+}
+
+func decodeString(data []byte, pos int) (string, int, error) {
+	colon := bytes.IndexByte(data[pos:], ':')
+	if colon == -1 {
+		return "", pos, ErrUnexpectedEnd
+	}
+	colon += pos
+	length, err := strconv.Atoi(string(data[pos:colon]))
+	if err != nil || length < 0 {
+		return "", pos, syntaxError(pos, "invalid string length")
+	}
+	start := colon + 1
+	if len(data)-start < length {
+		return "", pos, ErrUnexpectedEnd
+	}
+	return string(data[start : start+length]), start + length, nil
+}
+
+// Encode encodes int, int64, string, []byte, []any and map[string]any
+// values. Dictionary keys are sorted, as required by the spec.
+func Encode(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encode(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case int:
+		buf.WriteByte('i')
+		buf.WriteString(strconv.Itoa(v))
+		buf.WriteByte('e')
+	case int64:
+		buf.WriteByte('i')
+		buf.WriteString(strconv.FormatInt(v, 10))
+		buf.WriteByte('e')
+	case string:
+		buf.WriteString(strconv.Itoa(len(v)))
+		buf.WriteByte(':')
+		buf.WriteString(v)
+	case []byte:
+		buf.WriteString(strconv.Itoa(len(v)))
+		buf.WriteByte(':')
+		buf.Write(v)
+	case []any:
+		buf.WriteByte('l')
+		for _, item := range v {
+			if err := encode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	case map[string]any:
+		buf.WriteByte('d')
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if err := encode(buf, key); err != nil {
+				return err
+			}
+			if err := encode(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	default:
+		return fmt.Errorf("bencode: unsupported type %T", value)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package bencode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		input  string
+		result any
+		err    bool
+	}{
+		{"integer", "i42e", int64(42), false},
+		{"negative integer", "i-3e", int64(-3), false},
+		{"string", "4:spam", "spam", false},
+		{"empty string", "0:", "", false},
+		{"list", "l4:spami42ee", []any{"spam", int64(42)}, false},
+		{"dict", "d3:cow3:moo4:spaml1:a1:bee", map[string]any{"cow": "moo", "spam": []any{"a", "b"}}, false},
+		{"unterminated list", "l4:spam", nil, true},
+		{"short string", "5:spam", nil, true},
+		{"invalid integer", "i4xe", nil, true},
+		{"trailing data", "i1ei2e", nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Decode([]byte(tc.input))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+}
+
+func TestDecodePrefix(t *testing.T) {
+	result, n, err := DecodePrefix([]byte("d8:msg_typei1e5:piecei0eeRAWDATA"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"msg_type": int64(1), "piece": int64(0)}, result)
+	assert.Equal(t, 25, n)
+}
+
+func TestGetRawValue(t *testing.T) {
+	data := []byte("d8:announce3:url4:infod4:name1:a6:lengthi1ee1:zi0ee")
+
+	raw, err := GetRawValue(data, "info")
+	assert.NoError(t, err)
+	assert.Equal(t, "d4:name1:a6:lengthi1ee", string(raw))
+
+	raw, err = GetRawValue(data, "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, raw)
+}
+
+func TestEncode(t *testing.T) {
+	result, err := Encode(map[string]any{
+		"m":        map[string]any{"ut_metadata": 1},
+		"msg_type": int64(0),
+		"list":     []any{"a", []byte("b")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "d4:listl1:a1:be1:md11:ut_metadatai1ee8:msg_typei0ee", string(result))
+}
@@ -0,0 +1,202 @@
+package bittorrent
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+//
+// Peer wire protocol
+// https://www.bittorrent.org/beps/bep_0003.html
+// https://www.bittorrent.org/beps/bep_0010.html
+//
+
+const protocolName = "BitTorrent protocol"
+
+const (
+	MsgChoke         byte = 0
+	MsgUnchoke       byte = 1
+	MsgInterested    byte = 2
+	MsgNotInterested byte = 3
+	MsgHave          byte = 4
+	MsgBitfield      byte = 5
+	MsgRequest       byte = 6
+	MsgPiece         byte = 7
+	MsgCancel        byte = 8
+	MsgExtended      byte = 20
+)
+
+// ExtHandshakeId is the extension message id of the extended handshake.
+const ExtHandshakeId byte = 0
+
+// BlockSize is the size of the block requested from a peer.
+const BlockSize = 16 * 1024
+
+const maxMessageLength = 4 * 1024 * 1024
+
+var errInvalidHandshake = errors.New("invalid handshake from peer")
+
+func NewPeerId() []byte {
+	peerId := make([]byte, 20)
+	copy(peerId, "-ST0001-")
+	rand.Read(peerId[8:])
+	return peerId
+}
+
+type Message struct {
+	Id      byte
+	Payload []byte
+}
+
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	// SupportsExtension is set if the peer supports the extension protocol.
+	SupportsExtension bool
+}
+
+// Dial connects to the peer at addr and completes the handshake. The
+// timeout applies to both the connection and the handshake.
+func Dial(addr string, infoHash, peerId []byte, timeout time.Duration) (*Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{conn: conn, r: bufio.NewReader(conn)}
+	if err := c.SetDeadline(time.Now().Add(timeout)); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err := c.handshake(infoHash, peerId); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err := c.SetDeadline(time.Time{}); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Conn) handshake(infoHash, peerId []byte) error {
+	reserved := make([]byte, 8)
+	reserved[5] |= 0x10 // extension protocol
+
+	var msg bytes.Buffer
+	msg.WriteByte(byte(len(protocolName)))
+	msg.WriteString(protocolName)
+	msg.Write(reserved)
+	msg.Write(infoHash)
+	msg.Write(peerId)
+	if _, err := c.conn.Write(msg.Bytes()); err != nil {
+		return err
+	}
+
+	res := make([]byte, 1+len(protocolName)+8+20+20)
+	if _, err := io.ReadFull(c.r, res); err != nil {
+		return err
+	}
+	if int(res[0]) != len(protocolName) || string(res[1:1+len(protocolName)]) != protocolName {
+		return errInvalidHandshake
+	}
+	offset := 1 + len(protocolName)
+	if !bytes.Equal(res[offset+8:offset+28], infoHash) {
+		return errors.New("info hash mismatch in handshake")
+	}
+	c.SupportsExtension = res[offset+5]&0x10 != 0
+	return nil
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// WriteMessage writes the message. A nil message is written as keep-alive.
+func (c *Conn) WriteMessage(msg *Message) error {
+	if msg == nil {
+		_, err := c.conn.Write([]byte{0, 0, 0, 0})
+		return err
+	}
+	buf := make([]byte, 4+1+len(msg.Payload))
+	binary.BigEndian.PutUint32(buf, uint32(1+len(msg.Payload)))
+	buf[4] = msg.Id
+	copy(buf[5:], msg.Payload)
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+func (c *Conn) WriteExtended(extId byte, payload []byte) error {
+	return c.WriteMessage(&Message{Id: MsgExtended, Payload: append([]byte{extId}, payload...)})
+}
+
+// ReadMessage reads the next message. It returns nil for keep-alive.
+func (c *Conn) ReadMessage() (*Message, error) {
+	var length uint32
+	if err := binary.Read(c.r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if length == 0 {
+		return nil, nil
+	}
+	if length > maxMessageLength {
+		return nil, fmt.Errorf("message too long: %d", length)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return nil, err
+	}
+	return &Message{Id: buf[0], Payload: buf[1:]}, nil
+}
+
+// ReadExtended skips other messages, and returns the extension message id
+// and payload of the next extended message.
+func (c *Conn) ReadExtended() (byte, []byte, error) {
+	for {
+		msg, err := c.ReadMessage()
+		if err != nil {
+			return 0, nil, err
+		}
+		if msg == nil || msg.Id != MsgExtended || len(msg.Payload) < 1 {
+			continue
+		}
+		return msg.Payload[0], msg.Payload[1:], nil
+	}
+}
+
+func NewRequestMessage(piece int, begin, length int) *Message {
+	payload := make([]byte, 12)
+	binary.BigEndian.PutUint32(payload[0:], uint32(piece))
+	binary.BigEndian.PutUint32(payload[4:], uint32(begin))
+	binary.BigEndian.PutUint32(payload[8:], uint32(length))
+	return &Message{Id: MsgRequest, Payload: payload}
+}
+
+// ParsePieceMessage returns the piece index, offset and data of the block.
+func ParsePieceMessage(msg *Message) (int, int, []byte, error) {
+	if msg.Id != MsgPiece || len(msg.Payload) < 8 {
+		return 0, 0, nil, errors.New("invalid piece message")
+	}
+	piece := int(binary.BigEndian.Uint32(msg.Payload[0:]))
+	begin := int(binary.BigEndian.Uint32(msg.Payload[4:]))
+	return piece, begin, msg.Payload[8:], nil
+}
+
+// ParseHaveMessage returns the piece index.
+func ParseHaveMessage(msg *Message) (int, error) {
+	if msg.Id != MsgHave || len(msg.Payload) != 4 {
+		return 0, errors.New("invalid have message")
+	}
+	return int(binary.BigEndian.Uint32(msg.Payload)), nil
+}
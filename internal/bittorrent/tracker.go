@@ -0,0 +1,97 @@
+package bittorrent
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/bencode"
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
+
+var trackerHTTPClient = config.GetHTTPClient(config.TUNNEL_TYPE_NONE)
+
+type AnnounceParams struct {
+	InfoHash []byte
+	PeerId   []byte
+	Port     int
+	Left     int64
+}
+
+// Announce announces to the HTTP tracker, and returns the peer addresses.
+// Only HTTP(S) trackers are supported.
+func Announce(trackerUrl string, params *AnnounceParams) ([]string, error) {
+	if !strings.HasPrefix(trackerUrl, "http://") && !strings.HasPrefix(trackerUrl, "https://") {
+		return nil, errors.New("unsupported tracker: " + trackerUrl)
+	}
+
+	query := url.Values{}
+	query.Set("info_hash", string(params.InfoHash))
+	query.Set("peer_id", string(params.PeerId))
+	query.Set("port", strconv.Itoa(params.Port))
+	query.Set("uploaded", "0")
+	query.Set("downloaded", "0")
+	query.Set("left", strconv.FormatInt(params.Left, 10))
+	query.Set("compact", "1")
+	query.Set("event", "started")
+
+	sep := "?"
+	if strings.Contains(trackerUrl, "?") {
+		sep = "&"
+	}
+	res, err := trackerHTTPClient.Get(trackerUrl + sep + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("tracker responded with status " + res.Status)
+	}
+
+	dict, err := bencode.DecodeDict(body)
+	if err != nil {
+		return nil, err
+	}
+	if reason, ok := dict["failure reason"].(string); ok {
+		return nil, errors.New("tracker failure: " + reason)
+	}
+
+	return parsePeers(dict["peers"]), nil
+}
+
+func parsePeers(value any) []string {
+	peers := []string{}
+	switch v := value.(type) {
+	case string:
+		// compact, https://www.bittorrent.org/beps/bep_0023.html
+		b := []byte(v)
+		for i := 0; i+6 <= len(b); i += 6 {
+			ip := net.IP(b[i : i+4])
+			port := binary.BigEndian.Uint16(b[i+4 : i+6])
+			peers = append(peers, net.JoinHostPort(ip.String(), strconv.Itoa(int(port))))
+		}
+	case []any:
+		for _, item := range v {
+			peer, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			ip, _ := peer["ip"].(string)
+			port, _ := peer["port"].(int64)
+			if ip != "" && port > 0 {
+				peers = append(peers, net.JoinHostPort(ip, strconv.FormatInt(port, 10)))
+			}
+		}
+	}
+	return peers
+}
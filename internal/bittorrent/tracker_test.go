@@ -0,0 +1,27 @@
+package bittorrent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePeers(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		input  any
+		result []string
+	}{
+		{"compact", string([]byte{127, 0, 0, 1, 0x1a, 0xe1, 10, 0, 0, 2, 0x00, 0x50}), []string{"127.0.0.1:6881", "10.0.0.2:80"}},
+		{"dictionary", []any{
+			map[string]any{"ip": "::1", "port": int64(6881)},
+			map[string]any{"ip": "example.com", "port": int64(51413)},
+			map[string]any{"ip": "", "port": int64(1)},
+		}, []string{"[::1]:6881", "example.com:51413"}},
+		{"missing", nil, []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.result, parsePeers(tc.input))
+		})
+	}
+}
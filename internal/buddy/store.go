@@ -227,15 +227,17 @@ func CheckMagnet(s store.Store, hashes []string, storeToken string, clientIp str
 	if config.HasPeer {
 		if config.LazyPeer {
 			storeCode := string(s.GetName().Code())
-			for _, hash := range staleOrMissingHashes {
-				worker_queue.MagnetCachePullerQueue.Queue(worker_queue.MagnetCachePullerQueueItem{
-					ClientIP:   clientIp,
+			items := make([]worker_queue.MagnetCachePullerQueueItem, len(staleOrMissingHashes))
+			for i, hash := range staleOrMissingHashes {
+				items[i] = worker_queue.MagnetCachePullerQueueItem{
+					ClientIP:   worker_queue.Secret(clientIp),
 					Hash:       hash,
 					SId:        sid,
 					StoreCode:  storeCode,
-					StoreToken: storeToken,
-				})
+					StoreToken: worker_queue.Secret(storeToken),
+				}
 			}
+			worker_queue.MagnetCachePullerQueue.QueueMany(items)
 			return data, nil
 		}
 
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/MunifTanjim/stremthru/internal/config"
+	"golang.org/x/sync/singleflight"
 )
 
 type Cache[V any] interface {
@@ -11,13 +12,24 @@ type Cache[V any] interface {
 	Add(key string, value V) error
 	AddWithLifetime(key string, value V, lifetime time.Duration) error
 	Get(key string, value *V) bool
+	// Fetch reads the value for key, calling fetch on miss. If the value is
+	// stale (see CacheConfig.StaleTime), it is served as-is and refreshed
+	// in background.
+	Fetch(key string, value *V, fetch func() (V, error)) error
 	Remove(key string)
+	GetStats() CacheStats
 }
 
 type CacheConfig struct {
 	Lifetime      time.Duration
 	Name          string
 	LocalCapacity uint32
+	// Duration after Lifetime during which Fetch serves the stale value
+	// while revalidating it. Get never returns stale values.
+	StaleTime time.Duration
+	// For LRUCache, publishes the removals to the other replicas sharing the
+	// redis, for values kept only in memory (e.g. credentials).
+	PublishRemove bool
 }
 
 func NewCache[V any](conf *CacheConfig) Cache[V] {
@@ -25,9 +37,75 @@ func NewCache[V any](conf *CacheConfig) Cache[V] {
 		conf.LocalCapacity = 1024
 	}
 
+	var c Cache[V]
 	if config.RedisURI != "" {
-		return newRedisCache[V](conf)
+		c = newRedisCache[V](conf)
+	} else {
+		c = NewLRUCache[V](conf)
 	}
+	registerStats(c)
+	return c
+}
+
+// now is replaceable in tests.
+var now = time.Now
+
+type cacheEntry[V any] struct {
+	Value   V     `msgpack:"v"`
+	StaleAt int64 `msgpack:"s"`
+}
+
+func (e cacheEntry[V]) isStale() bool {
+	return e.StaleAt != 0 && e.StaleAt <= now().Unix()
+}
+
+type entryStore[V any] interface {
+	GetName() string
+	getEntry(key string) (*cacheEntry[V], bool)
+	AddWithLifetime(key string, value V, lifetime time.Duration) error
+}
 
-	return NewLRUCache[V](conf)
+type revalidator struct {
+	group singleflight.Group
+}
+
+func (rv *revalidator) fetch(key string, fetch func() (any, error)) (any, error) {
+	v, err, _ := rv.group.Do(key, fetch)
+	return v, err
+}
+
+func fetchEntry[V any](c entryStore[V], rv *revalidator, stats *cacheStats, lifetime time.Duration, key string, value *V, fetch func() (V, error)) error {
+	refresh := func() (any, error) {
+		v, err := fetch()
+		if err != nil {
+			stats.fetchErrors.Add(1)
+			return v, err
+		}
+		if err := c.AddWithLifetime(key, v, lifetime); err != nil {
+			log.Warn("failed to add fetched value", "cache", c.GetName(), "error", err)
+		}
+		return v, nil
+	}
+
+	if entry, ok := c.getEntry(key); ok {
+		*value = entry.Value
+		if !entry.isStale() {
+			stats.hits.Add(1)
+			return nil
+		}
+		stats.staleHits.Add(1)
+		go func() {
+			stats.revalidations.Add(1)
+			rv.fetch(key, refresh)
+		}()
+		return nil
+	}
+
+	stats.misses.Add(1)
+	v, err := rv.fetch(key, refresh)
+	if err != nil {
+		return err
+	}
+	*value = v.(V)
+	return nil
 }
@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	rc "github.com/go-redis/cache/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func setNow(t *testing.T, at time.Time) {
+	t.Helper()
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = time.Now })
+}
+
+// newLocalOnlyRedisCache returns a RedisCache without redis, backed only by
+// the local cache.
+func newLocalOnlyRedisCache[V any](conf *CacheConfig) *RedisCache[V] {
+	return &RedisCache[V]{
+		c: rc.New(&rc.Options{
+			LocalCache: newLocalCache(16, time.Hour),
+		}),
+		name:      conf.Name,
+		keyPrefix: getRedisKeyPrefix(conf),
+		lifetime:  conf.Lifetime,
+		staleTime: conf.StaleTime,
+	}
+}
+
+func TestFetchStaleWhileRevalidate(t *testing.T) {
+	c := NewLRUCache[string](&CacheConfig{
+		Name:      "test",
+		Lifetime:  time.Minute,
+		StaleTime: time.Hour,
+	})
+
+	start := time.Now()
+	setNow(t, start)
+
+	var value string
+	assert.NoError(t, c.Fetch("k", &value, func() (string, error) {
+		return "v1", nil
+	}))
+	assert.Equal(t, "v1", value)
+
+	assert.NoError(t, c.Fetch("k", &value, func() (string, error) {
+		t.Fatal("fresh value should not be fetched")
+		return "", nil
+	}))
+	assert.Equal(t, "v1", value)
+
+	setNow(t, start.Add(2*time.Minute))
+
+	assert.False(t, c.Get("k", &value), "Get should not return stale value")
+
+	refreshed := make(chan struct{})
+	assert.NoError(t, c.Fetch("k", &value, func() (string, error) {
+		defer close(refreshed)
+		return "v2", nil
+	}))
+	assert.Equal(t, "v1", value, "stale value should be served")
+
+	<-refreshed
+	assert.Eventually(t, func() bool {
+		return c.Get("k", &value) && value == "v2"
+	}, time.Second, 10*time.Millisecond)
+
+	stats := c.GetStats()
+	assert.Equal(t, uint64(1), stats.StaleHits)
+	assert.Equal(t, uint64(1), stats.Revalidations)
+}
+
+func TestFetchStaleRevalidateError(t *testing.T) {
+	c := NewLRUCache[string](&CacheConfig{
+		Name:      "test",
+		Lifetime:  time.Minute,
+		StaleTime: time.Hour,
+	})
+
+	start := time.Now()
+	setNow(t, start)
+
+	var value string
+	assert.NoError(t, c.Add("k", "v1"))
+
+	setNow(t, start.Add(2*time.Minute))
+
+	refreshed := make(chan struct{})
+	assert.NoError(t, c.Fetch("k", &value, func() (string, error) {
+		defer close(refreshed)
+		return "", errors.New("upstream down")
+	}))
+	assert.Equal(t, "v1", value)
+
+	<-refreshed
+	assert.Eventually(t, func() bool {
+		return c.GetStats().FetchErrors == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, c.Fetch("k", &value, func() (string, error) {
+		return "", errors.New("upstream down")
+	}))
+	assert.Equal(t, "v1", value, "stale value should be kept on error")
+}
+
+func TestFetchSingleflight(t *testing.T) {
+	c := NewLRUCache[string](&CacheConfig{
+		Name:     "test",
+		Lifetime: time.Minute,
+	})
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func() (string, error) {
+		calls.Add(1)
+		<-release
+		return "v", nil
+	}
+
+	var wg sync.WaitGroup
+	values := make([]string, 10)
+	for i := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Fetch("k", &values[i], fetch))
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		return calls.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, v := range values {
+		assert.Equal(t, "v", v)
+	}
+}
+
+func TestRedisCacheEntry(t *testing.T) {
+	start := time.Now()
+	setNow(t, start)
+
+	t.Run("without lifetime", func(t *testing.T) {
+		c := newLocalOnlyRedisCache[string](&CacheConfig{
+			Name:      "test",
+			Lifetime:  time.Minute,
+			StaleTime: time.Hour,
+		})
+
+		assert.NoError(t, c.AddWithLifetime("k", "v", 0))
+
+		var value string
+		assert.True(t, c.Get("k", &value), "should not be stale immediately")
+		assert.Equal(t, "v", value)
+	})
+
+	t.Run("with lifetime", func(t *testing.T) {
+		c := newLocalOnlyRedisCache[string](&CacheConfig{
+			Name:      "test",
+			Lifetime:  time.Minute,
+			StaleTime: time.Hour,
+		})
+
+		assert.NoError(t, c.Add("k", "v"))
+
+		var value string
+		assert.True(t, c.Get("k", &value))
+
+		setNow(t, start.Add(2*time.Minute))
+		assert.False(t, c.Get("k", &value))
+	})
+
+	t.Run("key version", func(t *testing.T) {
+		plain := newLocalOnlyRedisCache[string](&CacheConfig{Name: "test"})
+		assert.Equal(t, "test:k", plain.getKey("k"))
+
+		swr := newLocalOnlyRedisCache[string](&CacheConfig{Name: "test", StaleTime: time.Hour})
+		assert.Equal(t, "test:"+entryKeyVersion+":k", swr.getKey("k"))
+	})
+}
+
+func TestHandleInvalidation(t *testing.T) {
+	c := newLocalOnlyRedisCache[string](&CacheConfig{
+		Name:      "test:invalidation",
+		Lifetime:  time.Minute,
+		StaleTime: time.Hour,
+	})
+	addInvalidationTarget(c.name, invalidationTarget{
+		remove: c.removeLocal,
+		stats:  &c.stats,
+	})
+
+	publish := func(instanceId, name, key string) {
+		t.Helper()
+		msg, err := json.Marshal(invalidationMessage{InstanceId: instanceId, Name: name, Key: key})
+		assert.NoError(t, err)
+		handleInvalidation(string(msg))
+	}
+
+	var value string
+	assert.NoError(t, c.Add("a", "1"))
+	assert.NoError(t, c.Add("b", "2"))
+
+	publish(config.InstanceId, c.name, "a")
+	assert.True(t, c.Get("a", &value), "own message should be ignored")
+
+	publish("other-instance", "test:other", "a")
+	assert.True(t, c.Get("a", &value), "other cache should not be affected")
+
+	publish("other-instance", c.name, "a")
+	assert.False(t, c.Get("a", &value))
+	assert.True(t, c.Get("b", &value))
+	assert.Equal(t, uint64(1), c.GetStats().Invalidations)
+
+	handleInvalidation("not json")
+	assert.True(t, c.Get("b", &value))
+}
+
+func TestHandleInvalidationLRU(t *testing.T) {
+	c := NewLRUCache[string](&CacheConfig{
+		Name:          "test:invalidation:lru",
+		Lifetime:      time.Minute,
+		PublishRemove: true,
+	})
+	addInvalidationTarget(c.name, invalidationTarget{
+		remove: c.removeLocal,
+		stats:  &c.stats,
+	})
+
+	var value string
+	assert.NoError(t, c.Add("a", "1"))
+
+	msg, err := json.Marshal(invalidationMessage{InstanceId: "other-instance", Name: c.name, Key: "a"})
+	assert.NoError(t, err)
+	handleInvalidation(string(msg))
+
+	assert.False(t, c.Get("a", &value))
+	assert.Equal(t, uint64(1), c.GetStats().Invalidations)
+}
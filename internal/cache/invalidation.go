@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
+
+// Values are kept in local memory for at most this long, so that a value
+// updated by another replica, or a missed invalidation message, can not keep
+// a replica inconsistent for long. Only removals are published.
+const maxLocalLifetime = 1 * time.Minute
+
+const invalidationChannel = "stremthru:cache:invalidate"
+
+type invalidationMessage struct {
+	InstanceId string `json:"i"`
+	Name       string `json:"n"`
+	Key        string `json:"k"`
+}
+
+type invalidationTarget struct {
+	remove func(key string)
+	stats  *cacheStats
+}
+
+var invalidationTargets = struct {
+	sync.RWMutex
+	byName map[string][]invalidationTarget
+}{byName: map[string][]invalidationTarget{}}
+
+func publishInvalidation(name, key string) {
+	msg, err := json.Marshal(invalidationMessage{
+		InstanceId: config.InstanceId,
+		Name:       name,
+		Key:        key,
+	})
+	if err != nil {
+		log.Error("failed to encode invalidation message", "error", err)
+		return
+	}
+	if err := redis.Publish(context.Background(), invalidationChannel, msg).Err(); err != nil {
+		log.Warn("failed to publish invalidation message", "cache", name, "error", err)
+	}
+}
+
+func handleInvalidation(payload string) {
+	msg := invalidationMessage{}
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Warn("failed to decode invalidation message", "error", err)
+		return
+	}
+	if msg.InstanceId == config.InstanceId {
+		return
+	}
+
+	invalidationTargets.RLock()
+	defer invalidationTargets.RUnlock()
+
+	for _, target := range invalidationTargets.byName[msg.Name] {
+		target.remove(msg.Key)
+		target.stats.invalidations.Add(1)
+	}
+}
+
+var startInvalidationListener = sync.OnceFunc(func() {
+	pubsub := redis.Subscribe(context.Background(), invalidationChannel)
+	go func() {
+		for msg := range pubsub.Channel() {
+			handleInvalidation(msg.Payload)
+		}
+	}()
+})
+
+func addInvalidationTarget(name string, target invalidationTarget) {
+	invalidationTargets.Lock()
+	defer invalidationTargets.Unlock()
+
+	invalidationTargets.byName[name] = append(invalidationTargets.byName[name], target)
+}
+
+func subscribeInvalidation(name string, target invalidationTarget) {
+	addInvalidationTarget(name, target)
+
+	startInvalidationListener()
+}
@@ -0,0 +1,5 @@
+package cache
+
+import "github.com/MunifTanjim/stremthru/internal/logger"
+
+var log = logger.Scoped("cache")
@@ -9,9 +9,15 @@ import (
 )
 
 type LRUCache[V any] struct {
-	c    *freelru.LRU[string, V]
-	name string
-	m    sync.Mutex
+	c           *freelru.LRU[string, cacheEntry[V]]
+	name        string
+	lifetime    time.Duration
+	staleTime   time.Duration
+	m           sync.Mutex
+	stats       cacheStats
+	revalidator revalidator
+	// removals are published to the other replicas
+	publishRemove bool
 }
 
 func (cache *LRUCache[V]) GetName() string {
@@ -19,37 +25,70 @@ func (cache *LRUCache[V]) GetName() string {
 }
 
 func (cache *LRUCache[V]) Add(key string, value V) error {
+	return cache.AddWithLifetime(key, value, cache.lifetime)
+}
+
+func (cache *LRUCache[V]) AddWithLifetime(key string, value V, lifetime time.Duration) error {
 	cache.m.Lock()
 	defer cache.m.Unlock()
 
-	cache.c.Add(key, value)
+	entry := cacheEntry[V]{Value: value}
+	if cache.staleTime > 0 && lifetime > 0 {
+		entry.StaleAt = now().Add(lifetime).Unix()
+		lifetime += cache.staleTime
+	}
+	cache.c.AddWithLifetime(key, entry, lifetime)
+	cache.stats.sets.Add(1)
 	return nil
 }
 
-func (cache *LRUCache[V]) AddWithLifetime(key string, value V, lifetime time.Duration) error {
+func (cache *LRUCache[V]) getEntry(key string) (*cacheEntry[V], bool) {
 	cache.m.Lock()
 	defer cache.m.Unlock()
 
-	cache.c.AddWithLifetime(key, value, lifetime)
-	return nil
+	entry, ok := cache.c.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return &entry, true
 }
 
 func (cache *LRUCache[V]) Get(key string, value *V) bool {
-	cache.m.Lock()
-	defer cache.m.Unlock()
+	entry, ok := cache.getEntry(key)
+	if !ok || entry.isStale() {
+		cache.stats.misses.Add(1)
+		var zero V
+		*value = zero
+		return false
+	}
+	cache.stats.hits.Add(1)
+	*value = entry.Value
+	return true
+}
 
-	val, ok := cache.c.Get(key)
-	*value = val
-	return ok
+func (cache *LRUCache[V]) Fetch(key string, value *V, fetch func() (V, error)) error {
+	return fetchEntry(cache, &cache.revalidator, &cache.stats, cache.lifetime, key, value, fetch)
 }
 
-func (cache *LRUCache[V]) Remove(key string) {
+func (cache *LRUCache[V]) removeLocal(key string) {
 	cache.m.Lock()
 	defer cache.m.Unlock()
 
 	cache.c.Remove(key)
 }
 
+func (cache *LRUCache[V]) Remove(key string) {
+	cache.removeLocal(key)
+	cache.stats.removes.Add(1)
+	if cache.publishRemove {
+		publishInvalidation(cache.name, key)
+	}
+}
+
+func (cache *LRUCache[V]) GetStats() CacheStats {
+	return cache.stats.snapshot(cache.name)
+}
+
 func CacheHashKeyString(key string) uint32 {
 	return uint32(xxh3.HashString(key))
 }
@@ -59,7 +98,7 @@ func NewLRUCache[V any](config *CacheConfig) *LRUCache[V] {
 		config.LocalCapacity = 1024
 	}
 
-	lru, err := freelru.New[string, V](config.LocalCapacity, CacheHashKeyString)
+	lru, err := freelru.New[string, cacheEntry[V]](config.LocalCapacity, CacheHashKeyString)
 	if err != nil {
 		errMsg := "failed to create cache"
 		if config.Name != "" {
@@ -67,9 +106,24 @@ func NewLRUCache[V any](config *CacheConfig) *LRUCache[V] {
 		}
 		panic(errMsg)
 	}
-	if config.Lifetime != 0 {
-		lru.SetLifetime(config.Lifetime)
+	lifetime := config.Lifetime
+	if config.StaleTime > 0 && lifetime != 0 {
+		lru.SetLifetime(lifetime + config.StaleTime)
+	} else if lifetime != 0 {
+		lru.SetLifetime(lifetime)
+	}
+	cache := &LRUCache[V]{
+		c:             lru,
+		name:          config.Name,
+		lifetime:      lifetime,
+		staleTime:     config.StaleTime,
+		publishRemove: config.PublishRemove && redis != nil,
+	}
+	if cache.publishRemove {
+		subscribeInvalidation(cache.name, invalidationTarget{
+			remove: cache.removeLocal,
+			stats:  &cache.stats,
+		})
 	}
-	cache := &LRUCache[V]{c: lru, name: config.Name}
 	return cache
 }
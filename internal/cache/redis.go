@@ -84,44 +84,107 @@ var redis = func() *r.Client {
 	return redis
 }()
 
+// Values written with StaleTime are wrapped in cacheEntry, and keyed with this
+// version so that values written in the plain format are never decoded as
+// cacheEntry (and vice versa).
+const entryKeyVersion = "v2"
+
+func getRedisKeyPrefix(conf *CacheConfig) string {
+	if conf.StaleTime > 0 {
+		return conf.Name + ":" + entryKeyVersion + ":"
+	}
+	return conf.Name + ":"
+}
+
 type RedisCache[V any] struct {
-	c        *rc.Cache
-	name     string
-	lifetime time.Duration
+	c             *rc.Cache
+	name          string
+	keyPrefix     string
+	lifetime      time.Duration
+	localLifetime time.Duration
+	staleTime     time.Duration
+	stats         cacheStats
+	revalidator   revalidator
 }
 
 func (cache *RedisCache[V]) GetName() string {
 	return cache.name
 }
 
+func (cache *RedisCache[V]) getKey(key string) string {
+	return cache.keyPrefix + key
+}
+
 func (cache *RedisCache[V]) Add(key string, value V) error {
-	err := cache.c.Set(&rc.Item{
-		Key:   cache.name + ":" + key,
-		Value: value,
-		TTL:   cache.lifetime,
-	})
-	return err
+	return cache.AddWithLifetime(key, value, cache.lifetime)
 }
 
 func (cache *RedisCache[V]) AddWithLifetime(key string, value V, lifetime time.Duration) error {
-	err := cache.c.Set(&rc.Item{
-		Key:   cache.name + ":" + key,
-		Value: value,
-		TTL:   lifetime,
-	})
+	item := &rc.Item{
+		Key:            cache.getKey(key),
+		Value:          value,
+		TTL:            lifetime,
+		SkipLocalCache: lifetime < cache.localLifetime,
+	}
+	if cache.staleTime > 0 {
+		entry := cacheEntry[V]{Value: value}
+		if lifetime > 0 {
+			entry.StaleAt = now().Add(lifetime).Unix()
+			item.TTL += cache.staleTime
+		}
+		item.Value = entry
+	}
+	err := cache.c.Set(item)
+	if err == nil {
+		cache.stats.sets.Add(1)
+	}
 	return err
 }
 
-func (cache *RedisCache[V]) Get(key string, value *V) bool {
-	err := cache.c.Get(context.Background(), cache.name+":"+key, value)
+func (cache *RedisCache[V]) getEntry(key string) (*cacheEntry[V], bool) {
+	entry := cacheEntry[V]{}
+	var err error
+	if cache.staleTime > 0 {
+		err = cache.c.Get(context.Background(), cache.getKey(key), &entry)
+	} else {
+		err = cache.c.Get(context.Background(), cache.getKey(key), &entry.Value)
+	}
 	if err != nil {
+		if err != rc.ErrCacheMiss {
+			log.Warn("failed to get", "cache", cache.name, "error", err)
+		}
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (cache *RedisCache[V]) Get(key string, value *V) bool {
+	entry, ok := cache.getEntry(key)
+	if !ok || entry.isStale() {
+		cache.stats.misses.Add(1)
 		return false
 	}
+	cache.stats.hits.Add(1)
+	*value = entry.Value
 	return true
 }
 
+func (cache *RedisCache[V]) Fetch(key string, value *V, fetch func() (V, error)) error {
+	return fetchEntry(cache, &cache.revalidator, &cache.stats, cache.lifetime, key, value, fetch)
+}
+
 func (cache *RedisCache[V]) Remove(key string) {
-	cache.c.Delete(context.Background(), cache.name+":"+key)
+	cache.c.Delete(context.Background(), cache.getKey(key))
+	cache.stats.removes.Add(1)
+	publishInvalidation(cache.name, key)
+}
+
+func (cache *RedisCache[V]) removeLocal(key string) {
+	cache.c.DeleteFromLocalCache(cache.getKey(key))
+}
+
+func (cache *RedisCache[V]) GetStats() CacheStats {
+	return cache.stats.snapshot(cache.name)
 }
 
 func newRedisCache[V any](conf *CacheConfig) *RedisCache[V] {
@@ -137,14 +200,24 @@ func newRedisCache[V any](conf *CacheConfig) *RedisCache[V] {
 		conf.Lifetime = 5 * time.Minute
 	}
 
+	localLifetime := min(conf.Lifetime/2, maxLocalLifetime)
+
 	cache := &RedisCache[V]{
 		c: rc.New(&rc.Options{
-			Redis: redis,
-			// LocalCache: newLocalCache(1024, conf.Lifetime/2),
+			Redis:      redis,
+			LocalCache: newLocalCache(conf.LocalCapacity, localLifetime),
 		}),
-		name:     conf.Name,
-		lifetime: conf.Lifetime,
+		name:          conf.Name,
+		keyPrefix:     getRedisKeyPrefix(conf),
+		lifetime:      conf.Lifetime,
+		localLifetime: localLifetime,
+		staleTime:     conf.StaleTime,
 	}
 
+	subscribeInvalidation(cache.name, invalidationTarget{
+		remove: cache.removeLocal,
+		stats:  &cache.stats,
+	})
+
 	return cache
 }
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type CacheStats struct {
+	Name          string `json:"name"`
+	Hits          uint64 `json:"hits"`
+	StaleHits     uint64 `json:"stale_hits"`
+	Misses        uint64 `json:"misses"`
+	Sets          uint64 `json:"sets"`
+	Removes       uint64 `json:"removes"`
+	Invalidations uint64 `json:"invalidations"`
+	Revalidations uint64 `json:"revalidations"`
+	FetchErrors   uint64 `json:"fetch_errors"`
+}
+
+type cacheStats struct {
+	hits          atomic.Uint64
+	staleHits     atomic.Uint64
+	misses        atomic.Uint64
+	sets          atomic.Uint64
+	removes       atomic.Uint64
+	invalidations atomic.Uint64
+	revalidations atomic.Uint64
+	fetchErrors   atomic.Uint64
+}
+
+func (s *cacheStats) snapshot(name string) CacheStats {
+	return CacheStats{
+		Name:          name,
+		Hits:          s.hits.Load(),
+		StaleHits:     s.staleHits.Load(),
+		Misses:        s.misses.Load(),
+		Sets:          s.sets.Load(),
+		Removes:       s.removes.Load(),
+		Invalidations: s.invalidations.Load(),
+		Revalidations: s.revalidations.Load(),
+		FetchErrors:   s.fetchErrors.Load(),
+	}
+}
+
+type statsProvider interface {
+	GetStats() CacheStats
+}
+
+var statsRegistry = struct {
+	sync.Mutex
+	providers []statsProvider
+}{}
+
+func registerStats(p statsProvider) {
+	statsRegistry.Lock()
+	defer statsRegistry.Unlock()
+
+	statsRegistry.providers = append(statsRegistry.providers, p)
+}
+
+// ListStats returns the stats for every cache created with NewCache.
+func ListStats() []CacheStats {
+	statsRegistry.Lock()
+	defer statsRegistry.Unlock()
+
+	stats := make([]CacheStats, len(statsRegistry.providers))
+	for i, p := range statsRegistry.providers {
+		stats[i] = p.GetStats()
+	}
+	slices.SortStableFunc(stats, func(a, b CacheStats) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return stats
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"log"
 	"log/slog"
@@ -52,6 +53,8 @@ var defaultValueByEnv = map[string]map[string]string{
 		"STREMTHRU_LOG_FORMAT": "text",
 		"STREMTHRU_LOG_LEVEL":  "DEBUG",
 		"STREMTHRU_DATA_DIR":   os.TempDir(),
+		// one per test binary, as packages are tested in parallel
+		"STREMTHRU_DATABASE_URI": "sqlite://" + filepath.Join(os.TempDir(), "stremthru-test-"+strconv.Itoa(os.Getpid())+".db"),
 	},
 	"": {
 		"STREMTHRU_BASE_URL":                            "http://localhost:8080",
@@ -65,6 +68,9 @@ var defaultValueByEnv = map[string]map[string]string{
 		"STREMTHRU_STORE_CONTENT_PROXY":                 "*:true",
 		"STREMTHRU_STORE_TUNNEL":                        "*:true",
 		"STREMTHRU_STORE_CLIENT_USER_AGENT":             "stremthru",
+		"STREMTHRU_TORRENT_META_PEER_TIMEOUT":           "30s",
+		"STREMTHRU_P2P_CACHE_SIZE":                      "10GB",
+		"STREMTHRU_P2P_IDLE_TIMEOUT":                    "5m",
 		"STREMTHRU_INTEGRATION_ANILIST_LIST_STALE_TIME": "12h",
 		"STREMTHRU_INTEGRATION_MDBLIST_LIST_STALE_TIME": "12h",
 		"STREMTHRU_INTEGRATION_TRAKT_LIST_STALE_TIME":   "12h",
@@ -106,7 +112,18 @@ func mustParseDuration(key string, value string, minDuration time.Duration) time
 
 type StoreAuthTokenMap map[string]map[string]string
 
+// GetToken returns the token of the user for the store. The user managed at
+// runtime only has its own tokens, without falling back to the ones of `*`.
 func (m StoreAuthTokenMap) GetToken(user, store string) string {
+	if account := lookupUserAccount(user); account != nil {
+		if account.IsDisabled {
+			return ""
+		}
+		if store == "*" {
+			return strings.Join(account.StoreNames, " ")
+		}
+		return account.StoreTokens[store]
+	}
 	if um, ok := m[user]; ok {
 		if token, ok := um[store]; ok {
 			return token
@@ -164,9 +181,42 @@ func (m UserPasswordMap) GetPassword(user string) string {
 	return ""
 }
 
+// ProxyAuthPasswordMap is the UserPasswordMap for the users configured with
+// the env, overridden by the users managed at runtime.
+type ProxyAuthPasswordMap map[string]string
+
+// VerifyPassword checks the password of the user, that is not disabled.
+func (m ProxyAuthPasswordMap) VerifyPassword(user, password string) bool {
+	if password == "" {
+		return false
+	}
+	if account := lookupUserAccount(user); account != nil {
+		return !account.IsDisabled && util.VerifyPassword(account.PasswordHash, password)
+	}
+	expected := UserPasswordMap(m).GetPassword(user)
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+}
+
+// GetSecret returns the secret for signing the proxy links of the user, or
+// empty string if the user is not found or disabled. It is the password for
+// the user configured with the env, and the random secret for the one managed
+// at runtime.
+func (m ProxyAuthPasswordMap) GetSecret(user string) string {
+	if account := lookupUserAccount(user); account != nil {
+		if account.IsDisabled {
+			return ""
+		}
+		return account.Secret
+	}
+	return UserPasswordMap(m).GetPassword(user)
+}
+
 type AuthAdminMap map[string]bool
 
 func (m AuthAdminMap) IsAdmin(userName string) bool {
+	if account := lookupUserAccount(userName); account != nil {
+		return account.IsAdmin && !account.IsDisabled
+	}
 	if isAdmin, ok := m[userName]; ok {
 		return isAdmin
 	}
@@ -183,6 +233,7 @@ const (
 	FeatureAnime           string = "anime"
 	FeatureDMMHashlist     string = "dmm_hashlist"
 	FeatureIMDBTitle       string = "imdb_title"
+	FeatureP2PStream       string = "p2p_stream"
 	FeatureStremioList     string = "stremio_list"
 	FeatureStremioP2P      string = "stremio_p2p"
 	FeatureStremioSidekick string = "stremio_sidekick"
@@ -195,6 +246,7 @@ var features = []string{
 	FeatureAnime,
 	FeatureDMMHashlist,
 	FeatureIMDBTitle,
+	FeatureP2PStream,
 	FeatureStremioList,
 	FeatureStremioP2P,
 	FeatureStremioSidekick,
@@ -237,6 +289,9 @@ func (scp StoreContentProxyMap) IsEnabled(name string) bool {
 type ContentProxyConnectionLimitMap map[string]int
 
 func (cpcl ContentProxyConnectionLimitMap) Get(user string) int {
+	if account := lookupUserAccount(user); account != nil && account.ContentProxyConnectionLimit >= 0 {
+		return account.ContentProxyConnectionLimit
+	}
 	if limit, ok := cpcl[user]; ok {
 		return limit
 	}
@@ -318,7 +373,7 @@ type Config struct {
 
 	Port                        string
 	StoreAuthToken              StoreAuthTokenMap
-	ProxyAuthPassword           UserPasswordMap
+	ProxyAuthPassword           ProxyAuthPasswordMap
 	AuthAdmin                   AuthAdminMap
 	AdminPassword               UserPasswordMap
 	BuddyURL                    string
@@ -362,7 +417,7 @@ var config = func() Config {
 	proxyAuthCredList := strings.FieldsFunc(getEnv("STREMTHRU_PROXY_AUTH"), func(c rune) bool {
 		return c == ','
 	})
-	proxyAuthPasswordMap := make(UserPasswordMap)
+	proxyAuthPasswordMap := make(ProxyAuthPasswordMap)
 
 	for _, cred := range proxyAuthCredList {
 		if basicAuth, err := core.ParseBasicAuth(cred); err == nil {
@@ -429,7 +484,7 @@ var config = func() Config {
 	databaseUri := getEnv("STREMTHRU_DATABASE_URI")
 
 	feature := FeatureConfig{
-		disabled: []string{FeatureAnime, FeatureStremioP2P},
+		disabled: []string{FeatureAnime, FeatureP2PStream, FeatureStremioP2P},
 	}
 	for _, name := range strings.FieldsFunc(strings.TrimSpace(getEnv("STREMTHRU_FEATURE")), func(c rune) bool {
 		return c == ','
@@ -768,6 +823,31 @@ func PrintConfig(state *AppState) {
 	l.Println("   " + uri)
 	l.Println()
 
+	if EncryptionKey.IsEnabled() {
+		keyId, _ := EncryptionKey.GetActive()
+		l.Println(" Encryption Key:")
+		l.Println("   active: " + keyId)
+		if len(EncryptionKey) > 1 {
+			keyIds := make([]string, 0, len(EncryptionKey)-1)
+			for _, key := range EncryptionKey[1:] {
+				keyIds = append(keyIds, GetEncryptionKeyId(key))
+			}
+			l.Println("   old: " + strings.Join(keyIds, ", "))
+		}
+		l.Println()
+	}
+
+	if TorrentMeta.IsEnabled() {
+		l.Println(" Torrent Meta:")
+		if TorrentMeta.CacheDir != "" {
+			l.Println("   cache dir: " + TorrentMeta.CacheDir)
+		}
+		if TorrentMeta.PeerAddr != "" {
+			l.Println("   peer addr: " + TorrentMeta.PeerAddr + " (timeout: " + TorrentMeta.PeerTimeout.String() + ")")
+		}
+		l.Println()
+	}
+
 	l.Println(" Features:")
 	for _, feature := range features {
 		disabled := ""
@@ -776,6 +856,18 @@ func PrintConfig(state *AppState) {
 		}
 		l.Println("   - " + feature + disabled)
 		switch feature {
+		case FeatureP2PStream:
+			if disabled == "" {
+				l.Println("       cache dir: " + P2P.CacheDir)
+				l.Println("      cache size: " + util.ToSize(P2P.CacheSize))
+				l.Println("    idle timeout: " + P2P.IdleTimeout.String())
+				if len(P2P.Peers) > 0 {
+					l.Println("           peers: " + strings.Join(P2P.Peers, ", "))
+				}
+				if len(P2P.Trackers) > 0 {
+					l.Println("        trackers: " + strings.Join(P2P.Trackers, ", "))
+				}
+			}
 		case FeatureStremioTorz:
 			if Stremio.Torz.LazyPull {
 				l.Println("      [lazy pull]")
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/MunifTanjim/stremthru/internal/util"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -43,6 +45,83 @@ func (s *StoreContentCachedStaleTimeTestSuite) TestStoreContentCachedStaleTime()
 	s.Equal(staleTime.GetStaleTime(false, "torbox"), 8*time.Hour)
 }
 
+type UserAccountTestSuite struct {
+	suite.Suite
+}
+
+func (s *UserAccountTestSuite) TestUserAccount() {
+	newPassHash, err := util.HashPassword("new-pass")
+	s.Require().NoError(err)
+	passHash, err := util.HashPassword("pass")
+	s.Require().NoError(err)
+
+	defer SetUserAccountGetter(func(name string) (*UserAccount, error) { return nil, nil })
+	SetUserAccountGetter(func(name string) (*UserAccount, error) {
+		switch name {
+		case "alice":
+			return &UserAccount{
+				PasswordHash:                newPassHash,
+				Secret:                      "alice-secret",
+				IsAdmin:                     true,
+				StoreNames:                  []string{"torbox", "realdebrid"},
+				StoreTokens:                 map[string]string{"torbox": "tb-token"},
+				ContentProxyConnectionLimit: -1,
+			}, nil
+		case "bob":
+			return &UserAccount{
+				PasswordHash:                passHash,
+				IsAdmin:                     true,
+				IsDisabled:                  true,
+				ContentProxyConnectionLimit: 0,
+			}, nil
+		case "dave":
+			return nil, errors.New("database unavailable")
+		}
+		return nil, nil
+	})
+
+	passwords := ProxyAuthPasswordMap{"alice": "pass", "bob": "pass", "carol": "pass", "dave": "pass"}
+	s.True(passwords.VerifyPassword("alice", "new-pass"))
+	s.False(passwords.VerifyPassword("alice", "pass"))
+	s.False(passwords.VerifyPassword("bob", "pass"))
+	s.True(passwords.VerifyPassword("carol", "pass"))
+	s.False(passwords.VerifyPassword("carol", ""))
+	s.False(passwords.VerifyPassword("dave", "pass"))
+	s.False(passwords.VerifyPassword("erin", ""))
+	s.Equal("alice-secret", passwords.GetSecret("alice"))
+	s.Equal("", passwords.GetSecret("bob"))
+	s.Equal("pass", passwords.GetSecret("carol"))
+	s.Equal("", passwords.GetSecret("dave"))
+
+	admins := AuthAdminMap{"bob": true, "carol": true, "dave": true}
+	s.True(admins.IsAdmin("alice"))
+	s.False(admins.IsAdmin("bob"))
+	s.True(admins.IsAdmin("carol"))
+	s.False(admins.IsAdmin("dave"))
+
+	tokens := StoreAuthTokenMap{}
+	tokens.addStore("alice", "alldebrid")
+	tokens.setToken("alice", "realdebrid", "rd-token")
+	tokens.addStore("*", "premiumize")
+	tokens.setToken("*", "premiumize", "pm-token")
+	s.Equal("torbox", tokens.GetPreferredStore("alice"))
+	s.Equal([]string{"torbox", "realdebrid"}, tokens.ListStores("alice"))
+	s.Equal("tb-token", tokens.GetToken("alice", "torbox"))
+	// not inherited from the env
+	s.Equal("", tokens.GetToken("alice", "realdebrid"))
+	s.Equal("", tokens.GetToken("alice", "premiumize"))
+	s.Equal("", tokens.GetPreferredStore("bob"))
+	s.Equal("", tokens.GetToken("dave", "premiumize"))
+	s.Equal("premiumize", tokens.GetPreferredStore("carol"))
+	s.Equal("pm-token", tokens.GetToken("carol", "premiumize"))
+
+	limits := ContentProxyConnectionLimitMap{"*": 2, "alice": 3, "bob": 3}
+	s.Equal(3, limits.Get("alice"))
+	s.Equal(0, limits.Get("bob"))
+	s.Equal(2, limits.Get("carol"))
+}
+
 func TestConfig(t *testing.T) {
 	suite.Run(t, new(StoreContentCachedStaleTimeTestSuite))
+	suite.Run(t, new(UserAccountTestSuite))
 }
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/core"
+)
+
+// EncryptionKeyList is the keys for encrypting the data at rest. The first one
+// is used for encrypting, the rest are only used for decrypting, for rotation.
+type EncryptionKeyList []string
+
+func (l EncryptionKeyList) IsEnabled() bool {
+	return len(l) > 0
+}
+
+// GetEncryptionKeyId returns the id of the key, safe to be saved alongside
+// the data.
+func GetEncryptionKeyId(key string) string {
+	hash := sha256.Sum256([]byte("stremthru:encryption_key:" + key))
+	return hex.EncodeToString(hash[:4])
+}
+
+// GetActive returns the key used for encrypting, with its id.
+func (l EncryptionKeyList) GetActive() (id, key string) {
+	if !l.IsEnabled() {
+		return "", ""
+	}
+	return GetEncryptionKeyId(l[0]), l[0]
+}
+
+// Get returns the key with the id, or empty string if not found.
+func (l EncryptionKeyList) Get(id string) string {
+	for _, key := range l {
+		if GetEncryptionKeyId(key) == id {
+			return key
+		}
+	}
+	return ""
+}
+
+// Encrypt encrypts the value with the active key, as `<key id>.<blob>`. The
+// blob is url safe.
+func (l EncryptionKeyList) Encrypt(value string) (string, error) {
+	keyId, key := l.GetActive()
+	if key == "" {
+		return "", errors.New("missing encryption key")
+	}
+	encrypted, err := core.Encrypt(key, value)
+	if err != nil {
+		return "", err
+	}
+	raw, err := core.Base64DecodeToByte(encrypted)
+	if err != nil {
+		return "", err
+	}
+	return keyId + "." + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// GetKeyId returns the id of the key the value was encrypted with.
+func (l EncryptionKeyList) GetKeyId(value string) string {
+	keyId, _, _ := strings.Cut(value, ".")
+	return keyId
+}
+
+// Decrypt decrypts the value encrypted with Encrypt.
+func (l EncryptionKeyList) Decrypt(value string) (string, error) {
+	keyId, blob, ok := strings.Cut(value, ".")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	key := l.Get(keyId)
+	if key == "" {
+		return "", fmt.Errorf("missing encryption key: %s", keyId)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(blob)
+	if err != nil {
+		return "", err
+	}
+	// nonce and tag of aes-gcm
+	if len(raw) < 12+16 {
+		return "", errors.New("malformed encrypted value")
+	}
+	return core.Decrypt(key, core.Base64EncodeByte(raw))
+}
+
+func parseEncryptionKey() EncryptionKeyList {
+	keys := EncryptionKeyList{}
+	for key := range strings.SplitSeq(getEnv("STREMTHRU_ENCRYPTION_KEY"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+var EncryptionKey = parseEncryptionKey()
@@ -16,9 +16,24 @@ var BaseURL = func() *url.URL {
 }()
 
 type integrationConfigAniList struct {
+	ClientId      string
+	ClientSecret  string
 	ListStaleTime time.Duration
 }
 
+func (c integrationConfigAniList) IsEnabled() bool {
+	return c.ClientId != "" && c.ClientSecret != ""
+}
+
+type integrationConfigMAL struct {
+	ClientId     string
+	ClientSecret string
+}
+
+func (c integrationConfigMAL) IsEnabled() bool {
+	return c.ClientId != ""
+}
+
 type integrationConfigMDBList struct {
 	ListStaleTime time.Duration
 }
@@ -33,6 +48,15 @@ func (c integrationConfigTrakt) IsEnabled() bool {
 	return c.ClientId != "" && c.ClientSecret != ""
 }
 
+type integrationConfigSimkl struct {
+	ClientId     string
+	ClientSecret string
+}
+
+func (c integrationConfigSimkl) IsEnabled() bool {
+	return c.ClientId != "" && c.ClientSecret != ""
+}
+
 type integrationConfigKitsu struct {
 	ClientId     string
 	ClientSecret string
@@ -46,7 +70,9 @@ func (c integrationConfigKitsu) HasDefaultCredentials() bool {
 
 type IntegrationConfig struct {
 	AniList integrationConfigAniList
+	MAL     integrationConfigMAL
 	MDBList integrationConfigMDBList
+	Simkl   integrationConfigSimkl
 	Trakt   integrationConfigTrakt
 	Kitsu   integrationConfigKitsu
 }
@@ -54,11 +80,21 @@ type IntegrationConfig struct {
 func parseIntegration() IntegrationConfig {
 	integration := IntegrationConfig{
 		AniList: integrationConfigAniList{
+			ClientId:      getEnv("STREMTHRU_INTEGRATION_ANILIST_CLIENT_ID"),
+			ClientSecret:  getEnv("STREMTHRU_INTEGRATION_ANILIST_CLIENT_SECRET"),
 			ListStaleTime: mustParseDuration("anilist list stale time", getEnv("STREMTHRU_INTEGRATION_ANILIST_LIST_STALE_TIME"), 15*time.Minute),
 		},
+		MAL: integrationConfigMAL{
+			ClientId:     getEnv("STREMTHRU_INTEGRATION_MAL_CLIENT_ID"),
+			ClientSecret: getEnv("STREMTHRU_INTEGRATION_MAL_CLIENT_SECRET"),
+		},
 		MDBList: integrationConfigMDBList{
 			ListStaleTime: mustParseDuration("mdblist list stale time", getEnv("STREMTHRU_INTEGRATION_MDBLIST_LIST_STALE_TIME"), 15*time.Minute),
 		},
+		Simkl: integrationConfigSimkl{
+			ClientId:     getEnv("STREMTHRU_INTEGRATION_SIMKL_CLIENT_ID"),
+			ClientSecret: getEnv("STREMTHRU_INTEGRATION_SIMKL_CLIENT_SECRET"),
+		},
 		Trakt: integrationConfigTrakt{
 			ClientId:      getEnv("STREMTHRU_INTEGRATION_TRAKT_CLIENT_ID"),
 			ClientSecret:  getEnv("STREMTHRU_INTEGRATION_TRAKT_CLIENT_SECRET"),
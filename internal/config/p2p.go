@@ -0,0 +1,48 @@
+package config
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+type P2PConfig struct {
+	CacheDir    string
+	CacheSize   int64
+	IdleTimeout time.Duration
+	Peers       []string
+	Trackers    []string
+}
+
+func (c P2PConfig) IsEnabled() bool {
+	return Feature.IsEnabled(FeatureP2PStream)
+}
+
+func parseP2P() P2PConfig {
+	cacheDir := strings.TrimSpace(getEnv("STREMTHRU_P2P_CACHE_DIR"))
+	if cacheDir == "" {
+		cacheDir = filepath.Join(DataDir, "p2p")
+	}
+
+	cacheSize := util.ToBytes(getEnv("STREMTHRU_P2P_CACHE_SIZE"))
+	if cacheSize <= 0 {
+		log.Fatalf("invalid p2p cache size: %s", getEnv("STREMTHRU_P2P_CACHE_SIZE"))
+	}
+
+	isComma := func(c rune) bool {
+		return c == ','
+	}
+
+	return P2PConfig{
+		CacheDir:    cacheDir,
+		CacheSize:   cacheSize,
+		IdleTimeout: mustParseDuration("p2p idle timeout", getEnv("STREMTHRU_P2P_IDLE_TIMEOUT"), 30*time.Second),
+		Peers:       strings.FieldsFunc(strings.TrimSpace(getEnv("STREMTHRU_P2P_PEERS")), isComma),
+		Trackers:    strings.FieldsFunc(strings.TrimSpace(getEnv("STREMTHRU_P2P_TRACKERS")), isComma),
+	}
+}
+
+var P2P = parseP2P()
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+type TorrentMetaConfig struct {
+	CacheDir    string
+	PeerAddr    string
+	PeerTimeout time.Duration
+}
+
+func (c TorrentMetaConfig) IsEnabled() bool {
+	return c.CacheDir != "" || c.PeerAddr != ""
+}
+
+func parseTorrentMeta() TorrentMetaConfig {
+	return TorrentMetaConfig{
+		CacheDir:    strings.TrimSpace(getEnv("STREMTHRU_TORRENT_META_CACHE_DIR")),
+		PeerAddr:    strings.TrimSpace(getEnv("STREMTHRU_TORRENT_META_PEER_ADDR")),
+		PeerTimeout: mustParseDuration("torrent meta peer timeout", getEnv("STREMTHRU_TORRENT_META_PEER_TIMEOUT"), 5*time.Second),
+	}
+}
+
+var TorrentMeta = parseTorrentMeta()
@@ -0,0 +1,45 @@
+package config
+
+// UserAccount is the user managed at runtime, taking precedence over the one
+// configured with the env.
+type UserAccount struct {
+	// hashed with util.HashPassword
+	PasswordHash string
+	// for signing the proxy links
+	Secret     string
+	IsAdmin    bool
+	IsDisabled bool
+	// preferred store first
+	StoreNames  []string
+	StoreTokens map[string]string
+	// negative for the default limit
+	ContentProxyConnectionLimit int
+}
+
+var getUserAccount = func(name string) (*UserAccount, error) {
+	return nil, nil
+}
+
+// SetUserAccountGetter sets the lookup for the users managed at runtime. The
+// getter returns nil for unknown user.
+func SetUserAccountGetter(getter func(name string) (*UserAccount, error)) {
+	getUserAccount = getter
+}
+
+// failed lookup is treated as disabled user, instead of falling back to the
+// one configured with the env
+var unavailableUserAccount = &UserAccount{
+	IsDisabled:                  true,
+	ContentProxyConnectionLimit: -1,
+}
+
+func lookupUserAccount(name string) *UserAccount {
+	if name == "" || name == "*" {
+		return nil
+	}
+	account, err := getUserAccount(name)
+	if err != nil {
+		return unavailableUserAccount
+	}
+	return account
+}
@@ -0,0 +1,42 @@
+// Package dbtest sets up the database for tests.
+package dbtest
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/pressly/goose/v3"
+)
+
+var setupErr error
+var setupOnce sync.Once
+
+func getMigrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "migrations", string(db.Dialect))
+}
+
+// Setup opens the database with the migrations applied. The database is
+// shared by the tests of the package.
+func Setup(t *testing.T) {
+	t.Helper()
+
+	setupOnce.Do(func() {
+		database := db.Open()
+		goose.SetBaseFS(os.DirFS(getMigrationsDir()))
+		goose.SetTableName("db_migration_version")
+		goose.SetLogger(goose.NopLogger())
+		if setupErr = goose.SetDialect(string(db.Dialect)); setupErr != nil {
+			return
+		}
+		setupErr = goose.Up(database.DB, ".")
+	})
+
+	if setupErr != nil {
+		t.Fatalf("failed to setup database: %v", setupErr)
+	}
+}
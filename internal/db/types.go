@@ -181,3 +181,32 @@ func (list *JSONStringList) Scan(value any) error {
 	}
 	return json.Unmarshal(bytes, list)
 }
+
+type JSONStringMap map[string]string
+
+func (m JSONStringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	blob, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(blob), nil
+}
+
+func (m *JSONStringMap) Scan(value any) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case string:
+		bytes = []byte(v)
+	case []byte:
+		bytes = v
+	case nil:
+		*m = JSONStringMap{}
+		return nil
+	default:
+		return errors.New("failed to convert value to []byte")
+	}
+	return json.Unmarshal(bytes, m)
+}
@@ -0,0 +1,211 @@
+package endpoint
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/api_key"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+)
+
+type APIKeyData struct {
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	IPs       []string   `json:"ips"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	// only included when created
+	Key string `json:"key,omitempty"`
+}
+
+func toAPIKeyData(ak *api_key.APIKey) *APIKeyData {
+	data := &APIKeyData{
+		Id:        ak.Id,
+		Name:      ak.Name,
+		Scopes:    ak.Scopes,
+		IPs:       ak.IPs,
+		CreatedAt: ak.CreatedAt.Time,
+	}
+	if !ak.ExpiresAt.IsZero() {
+		data.ExpiresAt = &ak.ExpiresAt.Time
+	}
+	if ak.IsRevoked() {
+		data.RevokedAt = &ak.RevokedAt.Time
+	}
+	return data
+}
+
+type APIKeyPayload struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ips or cidrs, empty for any
+	IPs []string `json:"ips"`
+	// never expires if empty
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func handleAPIKeysList(w http.ResponseWriter, r *http.Request, userName string) {
+	keys, err := api_key.ListByUserName(userName)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	items := make([]APIKeyData, len(keys))
+	for i := range keys {
+		items[i] = *toAPIKeyData(&keys[i])
+	}
+	SendResponse(w, r, 200, items, nil)
+}
+
+func handleAPIKeyCreate(w http.ResponseWriter, r *http.Request, userName string) {
+	payload := &APIKeyPayload{}
+	if err := shared.ReadRequestBodyJSON(r, payload); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	if len(payload.Scopes) == 0 {
+		shared.ErrorBadRequest(r, "missing scopes").Send(w, r)
+		return
+	}
+	scopes := make([]api_key.Scope, len(payload.Scopes))
+	for i, s := range payload.Scopes {
+		scope := api_key.Scope(s)
+		if !scope.IsValid() {
+			shared.ErrorBadRequest(r, "invalid scope: "+s).Send(w, r)
+			return
+		}
+		if scope == api_key.ScopeAdmin && !config.AuthAdmin.IsAdmin(userName) {
+			shared.ErrorBadRequest(r, "scope not allowed for user: "+s).Send(w, r)
+			return
+		}
+		scopes[i] = scope
+	}
+	for _, ip := range payload.IPs {
+		if !api_key.IsValidIP(ip) {
+			shared.ErrorBadRequest(r, "invalid ip: "+ip).Send(w, r)
+			return
+		}
+	}
+	expiresAt := time.Time{}
+	if payload.ExpiresAt != nil {
+		if !payload.ExpiresAt.After(time.Now()) {
+			shared.ErrorBadRequest(r, "invalid expires_at").Send(w, r)
+			return
+		}
+		expiresAt = *payload.ExpiresAt
+	}
+
+	key, ak, err := api_key.Create(&api_key.CreateParams{
+		UserName:  userName,
+		Name:      payload.Name,
+		Scopes:    scopes,
+		IPs:       payload.IPs,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	data := toAPIKeyData(ak)
+	data.Key = key
+	SendResponse(w, r, 201, data, nil)
+}
+
+func handleAPIKeyRevoke(w http.ResponseWriter, r *http.Request, userName string) {
+	found, err := api_key.Revoke(userName, r.PathValue("id"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if !found {
+		shared.ErrorNotFound(r).Send(w, r)
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// getAPIKeyOwner returns the user authorized with `user:password`. Api key
+// can not be used to manage the api keys.
+func getAPIKeyOwner(w http.ResponseWriter, r *http.Request) string {
+	token, hasToken := extractProxyAuthToken(r, false)
+	if hasToken && !api_key.IsKey(token) {
+		auth, err := core.ParseBasicAuth(token)
+		if err == nil && config.ProxyAuthPassword.VerifyPassword(auth.Username, auth.Password) {
+			return auth.Username
+		}
+	}
+	shared.ErrorUnauthorized(r).Send(w, r)
+	return ""
+}
+
+func handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userName := getAPIKeyOwner(w, r)
+	if userName == "" {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		handleAPIKeysList(w, r, userName)
+	case http.MethodPost:
+		handleAPIKeyCreate(w, r, userName)
+	default:
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+	}
+}
+
+func handleAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodDelete) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	userName := getAPIKeyOwner(w, r)
+	if userName == "" {
+		return
+	}
+
+	handleAPIKeyRevoke(w, r, userName)
+}
+
+func handleUserAccountAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ua := getUserAccount(w, r)
+	if ua == nil {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		handleAPIKeysList(w, r, ua.Name)
+	case http.MethodPost:
+		handleAPIKeyCreate(w, r, ua.Name)
+	default:
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+	}
+}
+
+func handleUserAccountAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodDelete) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	handleAPIKeyRevoke(w, r, r.PathValue("name"))
+}
+
+func AddAPIKeyEndpoints(mux *http.ServeMux) {
+	withCors := shared.Middleware(shared.EnableCORS)
+	withAdminAuth := shared.Middleware(AdminAuthed)
+
+	mux.HandleFunc("/v0/auth/api-keys", withCors(handleAPIKeys))
+	mux.HandleFunc("/v0/auth/api-keys/{id}", withCors(handleAPIKey))
+
+	mux.HandleFunc("/__admin__/api/users/{name}/api-keys", withAdminAuth(handleUserAccountAPIKeys))
+	mux.HandleFunc("/__admin__/api/users/{name}/api-keys/{id}", withAdminAuth(handleUserAccountAPIKey))
+}
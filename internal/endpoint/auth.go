@@ -2,13 +2,21 @@ package endpoint
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"errors"
 	"html/template"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/oauth"
 	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/google/uuid"
 )
 
 //go:embed auth_callback.html
@@ -26,6 +34,9 @@ type AuthCallbackTemplateData struct {
 	Error   string
 
 	Provider string
+
+	UserCode        string
+	VerificationURL string
 }
 
 var ExecuteAuthCallbackTemplate = func() func(data *AuthCallbackTemplateData) (bytes.Buffer, error) {
@@ -37,28 +48,138 @@ var ExecuteAuthCallbackTemplate = func() func(data *AuthCallbackTemplateData) (b
 	}
 }()
 
-func handleTraktAuthCallback(w http.ResponseWriter, r *http.Request) {
+func getAuthProvider(w http.ResponseWriter, r *http.Request) *oauth.ProviderConfig {
+	provider := oauth.GetProvider(oauth.Provider(r.PathValue("provider")))
+	if provider == nil || provider.OAuth.Endpoint.AuthURL == "" || !provider.IsEnabled() {
+		shared.ErrorNotFound(r).Send(w, r)
+		return nil
+	}
+	return provider
+}
+
+// the state is signed with the active encryption key, or a random one if
+// not configured, so that it is valid only for this process
+var authStateKey = func() []byte {
+	if _, key := config.EncryptionKey.GetActive(); key != "" {
+		return []byte("auth-state:" + key)
+	}
+	return []byte(rand.Text())
+}()
+
+const authStateCookieLifetime = 15 * time.Minute
+
+var errAuthStateMismatch = errors.New("invalid or expired state")
+
+func getAuthStateCookieName(provider *oauth.ProviderConfig) string {
+	return "auth.state." + strings.ReplaceAll(string(provider.Provider), ".", "_")
+}
+
+func getAuthStateCookiePath(provider *oauth.ProviderConfig) string {
+	return "/auth/" + string(provider.Provider) + "/"
+}
+
+func signAuthState(provider *oauth.ProviderConfig, state string) string {
+	mac := hmac.New(sha256.New, authStateKey)
+	mac.Write([]byte(string(provider.Provider) + ":" + state))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setAuthStateCookie binds the state to the browser, to be checked when the
+// authorization is completed.
+func setAuthStateCookie(w http.ResponseWriter, provider *oauth.ProviderConfig, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     getAuthStateCookieName(provider),
+		Value:    state + "." + signAuthState(provider, state),
+		Path:     getAuthStateCookiePath(provider),
+		MaxAge:   int(authStateCookieLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func unsetAuthStateCookie(w http.ResponseWriter, provider *oauth.ProviderConfig) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   getAuthStateCookieName(provider),
+		Path:   getAuthStateCookiePath(provider),
+		MaxAge: -1,
+	})
+}
+
+// getAuthState returns the state from the signed cookie.
+func getAuthState(r *http.Request, provider *oauth.ProviderConfig) (string, error) {
+	cookie, err := r.Cookie(getAuthStateCookieName(provider))
+	if err != nil {
+		return "", errAuthStateMismatch
+	}
+	state, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok || state == "" || !hmac.Equal([]byte(signature), []byte(signAuthState(provider, state))) {
+		return "", errAuthStateMismatch
+	}
+	return state, nil
+}
+
+// verifyAuthState checks the state received by the callback against the one
+// set for the browser.
+func verifyAuthState(r *http.Request, provider *oauth.ProviderConfig, state string) error {
+	expectedState, err := getAuthState(r, provider)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(state), []byte(expectedState)) {
+		return errAuthStateMismatch
+	}
+	return nil
+}
+
+func handleAuthAuthorize(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	provider := getAuthProvider(w, r)
+	if provider == nil {
+		return
+	}
+
+	state := uuid.NewString()
+	setAuthStateCookie(w, provider, state)
+	http.Redirect(w, r, provider.AuthCodeURL(state), http.StatusFound)
+}
+
+func handleAuthCallback(w http.ResponseWriter, r *http.Request) {
 	if !shared.IsMethod(r, http.MethodGet) {
 		shared.ErrorMethodNotAllowed(r).Send(w, r)
 		return
 	}
 
+	provider := getAuthProvider(w, r)
+	if provider == nil {
+		return
+	}
+
 	code := r.URL.Query().Get("code")
 	state := r.URL.Query().Get("state")
 
 	td := &AuthCallbackTemplateData{
 		Title:    "StremThru",
 		Version:  config.Version,
-		Provider: "Trakt.tv",
+		Provider: provider.Name,
 	}
 
-	tok, err := oauth.TraktOAuthConfig.Exchange(code, state)
-	if err != nil {
+	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
+		td.Error = errMsg
+	} else if err := verifyAuthState(r, provider, state); err != nil {
+		td.Error = err.Error()
+	} else if tok, err := provider.Exchange(code, state); err != nil {
 		td.Error = err.Error()
 	} else {
 		td.Code = tok.Extra("id").(string)
 	}
 
+	unsetAuthStateCookie(w, provider)
+
 	buf, err := ExecuteAuthCallbackTemplate(td)
 	if err != nil {
 		SendError(w, r, err)
@@ -67,8 +188,94 @@ func handleTraktAuthCallback(w http.ResponseWriter, r *http.Request) {
 	SendHTML(w, 200, buf)
 }
 
-func AddAuthEndpoints(mux *http.ServeMux) {
-	if config.Integration.Trakt.IsEnabled() {
-		mux.HandleFunc("/auth/trakt.tv/callback", handleTraktAuthCallback)
+// time to wait for the user to authorize, per request for the device token
+const authDeviceTokenWait = 20 * time.Second
+
+func handleAuthDevice(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	provider := getAuthProvider(w, r)
+	if provider == nil {
+		return
+	}
+	if !provider.SupportsDeviceAuth() {
+		shared.ErrorNotFound(r).Send(w, r)
+		return
+	}
+
+	td := &AuthCallbackTemplateData{
+		Title:    "StremThru",
+		Version:  config.Version,
+		Provider: provider.Name,
+	}
+
+	state := uuid.NewString()
+	if da, err := provider.DeviceAuth(state); err != nil {
+		td.Error = err.Error()
+	} else {
+		setAuthStateCookie(w, provider, state)
+		td.UserCode = da.UserCode
+		td.VerificationURL = da.VerificationURI
+		if da.VerificationURIComplete != "" {
+			td.VerificationURL = da.VerificationURIComplete
+		}
+	}
+
+	buf, err := ExecuteAuthCallbackTemplate(td)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	SendHTML(w, 200, buf)
+}
+
+type AuthDeviceTokenData struct {
+	Code    string `json:"code,omitempty"`
+	Pending bool   `json:"pending,omitempty"`
+}
+
+func handleAuthDeviceToken(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodPost) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	provider := getAuthProvider(w, r)
+	if provider == nil {
+		return
+	}
+	if !provider.SupportsDeviceAuth() {
+		shared.ErrorNotFound(r).Send(w, r)
+		return
+	}
+
+	state, err := getAuthState(r, provider)
+	if err != nil {
+		shared.ErrorBadRequest(r, err.Error()).Send(w, r)
+		return
+	}
+
+	tok, err := provider.DeviceAccessToken(state, authDeviceTokenWait)
+	if err != nil {
+		if errors.Is(err, oauth.ErrDeviceAuthPending) {
+			SendResponse(w, r, 200, AuthDeviceTokenData{Pending: true}, nil)
+			return
+		}
+		unsetAuthStateCookie(w, provider)
+		SendError(w, r, err)
+		return
 	}
+
+	unsetAuthStateCookie(w, provider)
+	SendResponse(w, r, 200, AuthDeviceTokenData{Code: tok.Extra("id").(string)}, nil)
+}
+
+func AddAuthEndpoints(mux *http.ServeMux) {
+	mux.HandleFunc("/auth/{provider}/authorize", handleAuthAuthorize)
+	mux.HandleFunc("/auth/{provider}/callback", handleAuthCallback)
+	mux.HandleFunc("/auth/{provider}/device", handleAuthDevice)
+	mux.HandleFunc("/auth/{provider}/device/token", handleAuthDeviceToken)
 }
@@ -0,0 +1,35 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/oauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthStateCookie(t *testing.T) {
+	provider := &oauth.ProviderConfig{TokenSourceConfig: oauth.TokenSourceConfig{Provider: oauth.ProviderTraktTv}}
+	otherProvider := &oauth.ProviderConfig{TokenSourceConfig: oauth.TokenSourceConfig{Provider: oauth.ProviderAniList}}
+
+	w := httptest.NewRecorder()
+	setAuthStateCookie(w, provider, "state")
+	cookie := w.Result().Cookies()[0]
+
+	withCookie := func(value string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/auth/trakt.tv/callback", nil)
+		r.AddCookie(&http.Cookie{Name: cookie.Name, Value: value})
+		return r
+	}
+
+	assert.NoError(t, verifyAuthState(withCookie(cookie.Value), provider, "state"))
+	assert.ErrorIs(t, verifyAuthState(withCookie(cookie.Value), provider, "other-state"), errAuthStateMismatch)
+	assert.ErrorIs(t, verifyAuthState(withCookie("state.tampered"), provider, "state"), errAuthStateMismatch)
+	assert.ErrorIs(t, verifyAuthState(withCookie("other-state"+cookie.Value[len("state"):]), provider, "other-state"), errAuthStateMismatch)
+	assert.ErrorIs(t, verifyAuthState(httptest.NewRequest(http.MethodGet, "/", nil), provider, "state"), errAuthStateMismatch)
+
+	r := httptest.NewRequest(http.MethodGet, "/auth/anilist.co/callback", nil)
+	r.AddCookie(&http.Cookie{Name: getAuthStateCookieName(otherProvider), Value: cookie.Value})
+	assert.ErrorIs(t, verifyAuthState(r, otherProvider, "state"), errAuthStateMismatch)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/MunifTanjim/stremthru/internal/cache"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/context"
 	"github.com/MunifTanjim/stremthru/internal/server"
@@ -43,6 +44,7 @@ type HealthDebugData struct {
 	Version string               `json:"version"`
 	User    *HealthDebugDataUser `json:"user,omitempty"`
 	IP      *HealthDebugDataIP   `json:"ip,omitempty"`
+	Cache   []cache.CacheStats   `json:"cache,omitempty"`
 }
 
 func handleHealthDebug(w http.ResponseWriter, r *http.Request) {
@@ -118,6 +120,8 @@ func handleHealthDebug(w http.ResponseWriter, r *http.Request) {
 			Tunnel:  tunnel,
 			Exposed: exposed,
 		}
+
+		data.Cache = cache.ListStats()
 	}
 
 	SendResponse(w, r, 200, data, nil)
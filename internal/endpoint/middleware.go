@@ -1,10 +1,12 @@
 package endpoint
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
 	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/api_key"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/context"
 	"github.com/MunifTanjim/stremthru/internal/server"
@@ -34,22 +36,79 @@ func extractProxyAuthToken(r *http.Request, readQuery bool) (token string, hasTo
 		token = r.URL.Query().Get("token")
 	}
 	token = strings.TrimPrefix(token, "Basic ")
+	token = strings.TrimPrefix(token, "Bearer ")
 	return token, token != ""
 }
 
-func getProxyAuthorization(r *http.Request, readQuery bool) (isAuthorized bool, user, pass string) {
+type proxyAuthorization struct {
+	IsAuthorized bool
+	User         string
+	// empty if authorized with api key
+	Password string
+	// authorized with api key
+	APIKey *api_key.APIKey
+}
+
+func toAPIKeyError(r *http.Request, err error) error {
+	var e *core.APIError
+	switch err {
+	case api_key.ErrIPNotAllowed, api_key.ErrScopeRequired:
+		e = shared.ErrorForbidden(r)
+	case api_key.ErrInvalidKey, api_key.ErrRevokedKey, api_key.ErrExpiredKey:
+		e = shared.ErrorUnauthorized(r)
+	default:
+		return err
+	}
+	e.Msg = err.Error()
+	return e
+}
+
+// verifyAPIKey verifies the api key for the scope, for the user that is not
+// disabled.
+func verifyAPIKey(r *http.Request, key string, scope api_key.Scope) (*api_key.APIKey, error) {
+	ak, err := api_key.Verify(key, core.GetRequestIP(r), scope)
+	if err != nil {
+		return nil, toAPIKeyError(r, err)
+	}
+	if config.ProxyAuthPassword.GetSecret(ak.UserName) == "" {
+		return nil, toAPIKeyError(r, api_key.ErrInvalidKey)
+	}
+	return ak, nil
+}
+
+// getProxyAuthorization authorizes with `user:password`, or with api key
+// having the scope. The error is only for invalid api key.
+func getProxyAuthorization(r *http.Request, readQuery bool, scope api_key.Scope) (*proxyAuthorization, error) {
 	token, hasToken := extractProxyAuthToken(r, readQuery)
+	if api_key.IsKey(token) {
+		ak, err := verifyAPIKey(r, token, scope)
+		if err != nil {
+			return nil, err
+		}
+		return &proxyAuthorization{IsAuthorized: true, User: ak.UserName, APIKey: ak}, nil
+	}
+
 	auth, err := core.ParseBasicAuth(token)
-	isAuthorized = hasToken && err == nil && config.ProxyAuthPassword.GetPassword(auth.Username) == auth.Password
-	user = auth.Username
-	pass = auth.Password
-	return isAuthorized, user, pass
+	return &proxyAuthorization{
+		IsAuthorized: hasToken && err == nil && config.ProxyAuthPassword.VerifyPassword(auth.Username, auth.Password),
+		User:         auth.Username,
+		Password:     auth.Password,
+	}, nil
 }
 
 func ProxyAuthContext(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := api_key.ScopeStoreWrite
+		if shared.IsMethod(r, http.MethodGet) || shared.IsMethod(r, http.MethodHead) {
+			scope = api_key.ScopeStoreRead
+		}
+		auth, err := getProxyAuthorization(r, false, scope)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
 		ctx := context.GetStoreContext(r)
-		ctx.IsProxyAuthorized, ctx.ProxyAuthUser, ctx.ProxyAuthPassword = getProxyAuthorization(r, false)
+		ctx.IsProxyAuthorized, ctx.ProxyAuthUser, ctx.ProxyAuthPassword = auth.IsAuthorized, auth.User, auth.Password
 		next.ServeHTTP(w, r)
 	})
 }
@@ -134,14 +193,38 @@ func StoreRequired(next http.HandlerFunc) http.HandlerFunc {
 	})
 }
 
+// isAdminCredential checks the credential of the admin configured with the
+// env, or the admin user.
+func isAdminCredential(user, password string) bool {
+	if expected := config.AdminPassword.GetPassword(user); expected != "" && password != "" {
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+	}
+	return config.ProxyAuthPassword.VerifyPassword(user, password) && config.AuthAdmin.IsAdmin(user)
+}
+
 func AdminAuthed(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Basic "))
+		authHeader := r.Header.Get("Authorization")
+		if key, ok := strings.CutPrefix(authHeader, "Bearer "); ok && api_key.IsKey(key) {
+			ak, err := verifyAPIKey(r, strings.TrimSpace(key), api_key.ScopeAdmin)
+			if err != nil {
+				SendError(w, r, err)
+				return
+			}
+			if !config.AuthAdmin.IsAdmin(ak.UserName) {
+				shared.ErrorForbidden(r).Send(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Basic "))
 		if token == "" {
 			shared.ErrorUnauthorized(r).Send(w, r)
 			return
 		}
-		if auth, err := core.ParseBasicAuth(token); err != nil || config.AdminPassword.GetPassword(auth.Username) != auth.Password {
+		if auth, err := core.ParseBasicAuth(token); err != nil || !isAdminCredential(auth.Username, auth.Password) {
 			shared.ErrorUnauthorized(r).Send(w, r)
 			return
 		}
@@ -0,0 +1,51 @@
+package endpoint
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/MunifTanjim/stremthru/internal/user_account"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAdminCredential(t *testing.T) {
+	dbtest.Setup(t)
+
+	adminPassword := config.AdminPassword
+	config.AdminPassword = config.UserPasswordMap{"env-admin": "env-password"}
+	t.Cleanup(func() { config.AdminPassword = adminPassword })
+
+	for _, ua := range []*user_account.UserAccount{
+		{Name: "test-admin", IsAdmin: true},
+		{Name: "test-user"},
+		{Name: "test-disabled-admin", IsAdmin: true, IsDisabled: true},
+	} {
+		ua.StoreNames = db.CommaSeperatedString{}
+		ua.StoreTokens = db.JSONStringMap{}
+		ua.ContentProxyConnectionLimit = -1
+		assert.NoError(t, ua.SetPassword("password"))
+		assert.NoError(t, user_account.Save(ua))
+		t.Cleanup(func() { user_account.Delete(ua.Name) })
+	}
+
+	for _, tc := range []struct {
+		name     string
+		user     string
+		password string
+		expected bool
+	}{
+		{"env admin", "env-admin", "env-password", true},
+		{"env admin with wrong password", "env-admin", "password", false},
+		{"admin user", "test-admin", "password", true},
+		{"admin user with wrong password", "test-admin", "wrong-password", false},
+		{"non-admin user", "test-user", "password", false},
+		{"disabled admin user", "test-disabled-admin", "password", false},
+		{"unknown user", "test-unknown", "password", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isAdminCredential(tc.user, tc.password))
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/openapi"
+	"github.com/MunifTanjim/stremthru/internal/server"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/internal/torrent_info"
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+func queryParam(name, description string, required bool, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Required: required, Schema: schema}
+}
+
+func pathParam(name string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}
+}
+
+func headerParam(name, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "header", Description: description, Schema: &openapi.Schema{Type: "string"}}
+}
+
+var (
+	stringSchema      = &openapi.Schema{Type: "string"}
+	stringArraySchema = &openapi.Schema{Type: "array", Items: stringSchema}
+	integerSchema     = &openapi.Schema{Type: "integer"}
+	booleanSchema     = &openapi.Schema{Type: "boolean"}
+)
+
+var storeParams = []openapi.Parameter{
+	headerParam("X-StremThru-Store-Name", "Store name, defaults to the preferred store of the user"),
+	headerParam("X-StremThru-Store-Authorization", "Store token, `Bearer <token>`. Defaults to the store token of the user"),
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// apiRoutes are the documented /v0 endpoints, with the types used by their
+// handlers.
+var apiRoutes = []openapi.Route{
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/health",
+		OperationId: "getHealth",
+		Tag:         "health",
+		Responses:   map[int]reflect.Type{200: typeOf[HealthData]()},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/health/__debug__",
+		OperationId: "getHealthDebug",
+		Tag:         "health",
+		Responses:   map[int]reflect.Type{200: typeOf[HealthDebugData]()},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/store/user",
+		OperationId: "getStoreUser",
+		Tag:         "store",
+		Parameters:  storeParams,
+		Responses:   map[int]reflect.Type{200: typeOf[store.User]()},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/store/magnets",
+		OperationId: "listStoreMagnets",
+		Tag:         "store",
+		Parameters: append([]openapi.Parameter{
+			queryParam("limit", "Min `1`, Max `500`, Default `100`", false, integerSchema),
+			queryParam("offset", "Default `0`", false, integerSchema),
+		}, storeParams...),
+		Responses: map[int]reflect.Type{200: typeOf[store.ListMagnetsData]()},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/v0/store/magnets",
+		OperationId: "addStoreMagnet",
+		Tag:         "store",
+		Parameters:  storeParams,
+		Request:     typeOf[AddMagnetPayload](),
+		Responses:   map[int]reflect.Type{201: typeOf[store.AddMagnetData]()},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/store/magnets/check",
+		OperationId: "checkStoreMagnets",
+		Tag:         "store",
+		Parameters: append([]openapi.Parameter{
+			queryParam("magnet", "Magnet links or info hashes", true, stringArraySchema),
+			queryParam("sid", "Stremio id, for the files of the content", false, stringSchema),
+			queryParam("local_only", "Skip checking with the peer", false, booleanSchema),
+		}, storeParams...),
+		Responses: map[int]reflect.Type{200: typeOf[store.CheckMagnetData]()},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/store/magnets/{magnetId}",
+		OperationId: "getStoreMagnet",
+		Tag:         "store",
+		Parameters:  append([]openapi.Parameter{pathParam("magnetId")}, storeParams...),
+		Responses:   map[int]reflect.Type{200: typeOf[store.GetMagnetData]()},
+	},
+	{
+		Method:      http.MethodDelete,
+		Path:        "/v0/store/magnets/{magnetId}",
+		OperationId: "removeStoreMagnet",
+		Tag:         "store",
+		Parameters:  append([]openapi.Parameter{pathParam("magnetId")}, storeParams...),
+		Responses:   map[int]reflect.Type{200: typeOf[store.RemoveMagnetData]()},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/v0/store/link/generate",
+		OperationId: "generateStoreLink",
+		Tag:         "store",
+		Parameters:  storeParams,
+		Request:     typeOf[GenerateLinkPayload](),
+		Responses:   map[int]reflect.Type{200: typeOf[store.GenerateLinkData]()},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/proxy",
+		OperationId: "proxifyLinks",
+		Tag:         "proxy",
+		Parameters: []openapi.Parameter{
+			queryParam("url", "Links to proxify", true, stringArraySchema),
+			queryParam("exp", "Expiration, e.g. `1h`, or seconds", false, stringSchema),
+			queryParam("redirect", "Redirect to the proxified link, for single link", false, booleanSchema),
+			queryParam("req_headers", "Request headers for the links, separated by newline", false, stringSchema),
+			queryParam("token", "Proxy auth token, for unencrypted link", false, stringSchema),
+		},
+		Responses: map[int]reflect.Type{200: typeOf[proxifyLinksData](), 302: nil},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/torrents",
+		OperationId: "listTorrents",
+		Tag:         "torrents",
+		Parameters: []openapi.Parameter{
+			queryParam("sid", "IMDb id, with season and episode for series", true, stringSchema),
+			queryParam("local_only", "Skip fetching from the peer", false, booleanSchema),
+			queryParam("no_missing_size", "Skip the torrents with missing size", false, booleanSchema),
+		},
+		Responses: map[int]reflect.Type{200: typeOf[torrent_info.ListTorrentsData]()},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/v0/torrents",
+		OperationId: "recordTorrents",
+		Tag:         "torrents",
+		Parameters:  []openapi.Parameter{headerParam("X-StremThru-Peer-Token", "Peer token")},
+		Request:     typeOf[RecordTorrentsPayload](),
+		Responses:   map[int]reflect.Type{204: nil},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/torrents/stats",
+		OperationId: "getTorrentStats",
+		Tag:         "torrents",
+		Responses:   map[int]reflect.Type{200: typeOf[torrent_info.Stats]()},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/auth/api-keys",
+		OperationId: "listAPIKeys",
+		Tag:         "auth",
+		Responses:   map[int]reflect.Type{200: typeOf[[]APIKeyData]()},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/v0/auth/api-keys",
+		OperationId: "createAPIKey",
+		Tag:         "auth",
+		Request:     typeOf[APIKeyPayload](),
+		Responses:   map[int]reflect.Type{201: typeOf[APIKeyData]()},
+	},
+	{
+		Method:      http.MethodDelete,
+		Path:        "/v0/auth/api-keys/{id}",
+		OperationId: "revokeAPIKey",
+		Tag:         "auth",
+		Parameters:  []openapi.Parameter{pathParam("id")},
+		Responses:   map[int]reflect.Type{204: nil},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/v0/torznab/api",
+		OperationId: "torznab",
+		Tag:         "torznab",
+		Summary:     "Torznab API, responds with XML",
+		Parameters: []openapi.Parameter{
+			queryParam("t", "Function, e.g. `caps`, `search`, `tvsearch`, `movie`", true, stringSchema),
+			queryParam("q", "Search query", false, stringSchema),
+			queryParam("imdbid", "IMDb id", false, stringSchema),
+			queryParam("year", "Year", false, integerSchema),
+			queryParam("season", "Season", false, integerSchema),
+			queryParam("ep", "Episode", false, integerSchema),
+			queryParam("cat", "Categories, comma separated", false, stringSchema),
+			queryParam("limit", "Limit", false, integerSchema),
+			queryParam("offset", "Offset", false, integerSchema),
+		},
+		ContentType: map[int]string{200: "application/xml"},
+		Responses:   map[int]reflect.Type{200: typeOf[string]()},
+	},
+}
+
+func getOpenAPIDocument() *openapi.Document {
+	g := openapi.NewGenerator(openapi.Info{
+		Title:   "StremThru",
+		Version: "0",
+	})
+	openapi.SetEnum(g,
+		store.MagnetStatusCached,
+		store.MagnetStatusQueued,
+		store.MagnetStatusDownloading,
+		store.MagnetStatusProcessing,
+		store.MagnetStatusDownloaded,
+		store.MagnetStatusUploading,
+		store.MagnetStatusFailed,
+		store.MagnetStatusInvalid,
+		store.MagnetStatusUnknown,
+	)
+	openapi.SetEnum(g,
+		store.UserSubscriptionStatusPremium,
+		store.UserSubscriptionStatusTrial,
+		store.UserSubscriptionStatusExpired,
+	)
+	for _, route := range apiRoutes {
+		g.AddRoute(route, typeOf[core.Error]())
+	}
+	return g.Doc
+}
+
+var openAPIDocument = sync.OnceValue(getOpenAPIDocument)
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	doc := *openAPIDocument()
+	doc.Info.Version = config.Version
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		server.GetReqCtx(r).Log.Error("failed to encode json", "error", err)
+	}
+}
+
+func AddOpenAPIEndpoints(mux *http.ServeMux) {
+	withCors := shared.Middleware(shared.EnableCORS)
+
+	mux.HandleFunc("/v0/openapi.json", withCors(handleOpenAPI))
+}
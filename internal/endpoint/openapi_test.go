@@ -0,0 +1,243 @@
+package endpoint
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/api_key"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/MunifTanjim/stremthru/internal/server"
+	"github.com/MunifTanjim/stremthru/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateOpenAPI = flag.Bool("update", false, "update sdk/openapi.json")
+
+const openAPIDocumentPath = "../../sdk/openapi.json"
+
+func TestOpenAPIDocument(t *testing.T) {
+	blob, err := json.MarshalIndent(getOpenAPIDocument(), "", "  ")
+	assert.NoError(t, err)
+	blob = append(blob, '\n')
+
+	if *updateOpenAPI {
+		assert.NoError(t, os.WriteFile(openAPIDocumentPath, blob, 0644))
+		return
+	}
+
+	expected, err := os.ReadFile(openAPIDocumentPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(blob), "outdated sdk/openapi.json, update with: go test ./internal/endpoint -run TestOpenAPIDocument -update")
+}
+
+var pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+// TestOpenAPIRoutes checks the documented routes against the ones registered
+// by the endpoints.
+func TestOpenAPIRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	AddHealthEndpoints(mux)
+	AddProxyEndpoints(mux)
+	AddStoreEndpoints(mux)
+	AddTorrentEndpoints(mux)
+	AddTorznabEndpoints(mux)
+	AddAPIKeyEndpoints(mux)
+
+	doc := getOpenAPIDocument()
+
+	for _, route := range apiRoutes {
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			r := httptest.NewRequest(route.Method, pathParamRegex.ReplaceAllString(route.Path, "$1"), nil)
+			_, pattern := mux.Handler(r)
+			assert.Equal(t, route.Path, pattern, "not registered")
+
+			op := doc.Paths[route.Path][strings.ToLower(route.Method)]
+			if !assert.NotNil(t, op) {
+				return
+			}
+			pathParams := []string{}
+			for _, param := range op.Parameters {
+				if param.In == "path" {
+					pathParams = append(pathParams, param.Name)
+				}
+			}
+			expectedPathParams := []string{}
+			for _, match := range pathParamRegex.FindAllStringSubmatch(route.Path, -1) {
+				expectedPathParams = append(expectedPathParams, match[1])
+			}
+			assert.ElementsMatch(t, expectedPathParams, pathParams)
+		})
+	}
+}
+
+type testStore struct {
+	store.Store
+}
+
+func (s testStore) GetName() store.StoreName {
+	return store.StoreNameRealDebrid
+}
+
+func (s testStore) GetUser(params *store.GetUserParams) (*store.User, error) {
+	return &store.User{Id: "1", Email: "user@example.com", SubscriptionStatus: store.UserSubscriptionStatusPremium}, nil
+}
+
+func (s testStore) RemoveMagnet(params *store.RemoveMagnetParams) (*store.RemoveMagnetData, error) {
+	return &store.RemoveMagnetData{Id: params.Id}, nil
+}
+
+const testMagnetHash = "0123456789abcdef0123456789abcdef01234567"
+
+func (s testStore) ListMagnets(params *store.ListMagnetsParams) (*store.ListMagnetsData, error) {
+	return &store.ListMagnetsData{
+		Items: []store.ListMagnetsDataItem{
+			{Id: "1", Hash: testMagnetHash, Name: "Title", Size: 1024, Status: store.MagnetStatusDownloaded, AddedAt: time.Now()},
+		},
+		TotalItems: 1,
+	}, nil
+}
+
+func (s testStore) AddMagnet(params *store.AddMagnetParams) (*store.AddMagnetData, error) {
+	return &store.AddMagnetData{
+		Id:      "1",
+		Hash:    testMagnetHash,
+		Magnet:  params.Magnet,
+		Name:    "Title",
+		Size:    1024,
+		Status:  store.MagnetStatusDownloaded,
+		Files:   []store.MagnetFile{{Idx: 0, Path: "/Title.mkv", Name: "Title.mkv", Size: 1024}},
+		AddedAt: time.Now(),
+	}, nil
+}
+
+func (s testStore) CheckMagnet(params *store.CheckMagnetParams) (*store.CheckMagnetData, error) {
+	data := &store.CheckMagnetData{}
+	for _, magnet := range params.Magnets {
+		data.Items = append(data.Items, store.CheckMagnetDataItem{Hash: magnet, Magnet: "magnet:?xt=urn:btih:" + magnet, Status: store.MagnetStatusUnknown, Files: []store.MagnetFile{}})
+	}
+	return data, nil
+}
+
+func (s testStore) GenerateLink(params *store.GenerateLinkParams) (*store.GenerateLinkData, error) {
+	return &store.GenerateLinkData{Link: params.Link}, nil
+}
+
+func basicAuth(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+	dbtest.Setup(t)
+
+	const user, password = "openapi-test", "openapi-test-password"
+	config.ProxyAuthPassword[user] = password
+	t.Cleanup(func() {
+		delete(config.ProxyAuthPassword, user)
+	})
+	userAuth := basicAuth(user, password)
+
+	_, ak, err := api_key.Create(&api_key.CreateParams{
+		UserName: user,
+		Name:     "revoke",
+		Scopes:   []api_key.Scope{api_key.ScopeStoreRead},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	const peerToken = "openapi-test-peer-token"
+	_, err = db.Exec("INSERT INTO peer_token (id, name) VALUES (?, ?)", peerToken, "openapi-test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	doc := getOpenAPIDocument()
+
+	for _, test := range []struct {
+		method     string
+		path       string
+		target     string
+		pathValues map[string]string
+		headers    map[string]string
+		body       string
+		handler    http.HandlerFunc
+		status     int
+	}{
+		{method: http.MethodGet, path: "/v0/health", target: "/v0/health", handler: handleHealth, status: 200},
+		{method: http.MethodGet, path: "/v0/store/user", target: "/v0/store/user", handler: handleStoreUser, status: 200},
+		{method: http.MethodDelete, path: "/v0/store/magnets/{magnetId}", target: "/v0/store/magnets/1", pathValues: map[string]string{"magnetId": "1"}, handler: handleStoreMagnetRemove, status: 200},
+		{method: http.MethodGet, path: "/v0/store/magnets/{magnetId}", target: "/v0/store/magnets/", pathValues: map[string]string{"magnetId": ""}, handler: handleStoreMagnetGet, status: 400},
+		{method: http.MethodGet, path: "/v0/store/magnets", target: "/v0/store/magnets?limit=10", handler: handleStoreMagnets, status: 200},
+		{method: http.MethodGet, path: "/v0/store/magnets", target: "/v0/store/magnets?limit=x", handler: handleStoreMagnets, status: 400},
+		{method: http.MethodPost, path: "/v0/store/magnets", target: "/v0/store/magnets", body: `{"magnet":"` + testMagnetHash + `"}`, handler: handleStoreMagnets, status: 201},
+		{method: http.MethodGet, path: "/v0/store/magnets/check", target: "/v0/store/magnets/check?magnet=" + testMagnetHash, handler: handleStoreMagnetsCheck, status: 200},
+		{method: http.MethodGet, path: "/v0/store/magnets/check", target: "/v0/store/magnets/check", handler: handleStoreMagnetsCheck, status: 400},
+		{method: http.MethodPost, path: "/v0/store/link/generate", target: "/v0/store/link/generate", body: `{"link":"https://example.com/file.mkv"}`, handler: handleStoreLinkGenerate, status: 200},
+		{method: http.MethodGet, path: "/v0/proxy", target: "/v0/proxy?url=https://example.com/file.mkv&exp=1h", headers: map[string]string{server.HEADER_STREMTHRU_AUTHORIZATION: userAuth}, handler: handleProxifyLinks, status: 200},
+		{method: http.MethodGet, path: "/v0/proxy", target: "/v0/proxy?url=https://example.com/file.mkv&redirect=1", headers: map[string]string{server.HEADER_STREMTHRU_AUTHORIZATION: userAuth}, handler: handleProxifyLinks, status: 302},
+		{method: http.MethodGet, path: "/v0/proxy", target: "/v0/proxy?url=https://example.com/file.mkv", handler: handleProxifyLinks, status: 403},
+		{method: http.MethodGet, path: "/v0/torrents", target: "/v0/torrents?sid=tt0000001&local_only=1", handler: handleTorrents, status: 200},
+		{method: http.MethodGet, path: "/v0/torrents", target: "/v0/torrents", handler: handleTorrents, status: 400},
+		{method: http.MethodPost, path: "/v0/torrents", target: "/v0/torrents", headers: map[string]string{"X-StremThru-Peer-Token": peerToken}, body: `{"items":[]}`, handler: handleTorrents, status: 204},
+		{method: http.MethodPost, path: "/v0/torrents", target: "/v0/torrents", body: `{"items":[]}`, handler: handleTorrents, status: 401},
+		{method: http.MethodGet, path: "/v0/torrents/stats", target: "/v0/torrents/stats", handler: handleTorrentStats, status: 200},
+		{method: http.MethodGet, path: "/v0/auth/api-keys", target: "/v0/auth/api-keys", headers: map[string]string{server.HEADER_STREMTHRU_AUTHORIZATION: userAuth}, handler: handleAPIKeys, status: 200},
+		{method: http.MethodGet, path: "/v0/auth/api-keys", target: "/v0/auth/api-keys", handler: handleAPIKeys, status: 401},
+		{method: http.MethodPost, path: "/v0/auth/api-keys", target: "/v0/auth/api-keys", headers: map[string]string{server.HEADER_STREMTHRU_AUTHORIZATION: userAuth}, body: `{"name":"test","scopes":["store:read"],"ips":[]}`, handler: handleAPIKeys, status: 201},
+		{method: http.MethodPost, path: "/v0/auth/api-keys", target: "/v0/auth/api-keys", headers: map[string]string{server.HEADER_STREMTHRU_AUTHORIZATION: userAuth}, body: `{"name":"test","scopes":["unknown"]}`, handler: handleAPIKeys, status: 400},
+		{method: http.MethodDelete, path: "/v0/auth/api-keys/{id}", target: "/v0/auth/api-keys/" + ak.Id, pathValues: map[string]string{"id": ak.Id}, headers: map[string]string{server.HEADER_STREMTHRU_AUTHORIZATION: userAuth}, handler: handleAPIKey, status: 204},
+		{method: http.MethodDelete, path: "/v0/auth/api-keys/{id}", target: "/v0/auth/api-keys/unknown", pathValues: map[string]string{"id": "unknown"}, headers: map[string]string{server.HEADER_STREMTHRU_AUTHORIZATION: userAuth}, handler: handleAPIKey, status: 404},
+	} {
+		t.Run(test.method+" "+test.target, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			if test.body != "" {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			for name, value := range test.headers {
+				r.Header.Set(name, value)
+			}
+			for name, value := range test.pathValues {
+				r.SetPathValue(name, value)
+			}
+			r = server.SetReqCtx(r, &server.ReqCtx{StartTime: time.Now(), Log: slog.Default(), ReqQuery: r.URL.Query()})
+			r = context.SetStoreContext(r)
+			context.GetStoreContext(r).Store = testStore{}
+			w := httptest.NewRecorder()
+
+			test.handler(w, r)
+
+			assert.Equal(t, test.status, w.Code, w.Body.String())
+
+			op := doc.Paths[test.path][strings.ToLower(test.method)]
+			if !assert.NotNil(t, op) {
+				return
+			}
+			res, ok := op.Responses[strconv.Itoa(w.Code)]
+			if !ok {
+				res = op.Responses["default"]
+			}
+			content, ok := res.Content["application/json"]
+			if !ok {
+				assert.Empty(t, res.Content)
+				assert.NotContains(t, w.Header().Get("Content-Type"), "application/json")
+				return
+			}
+			var resBody any
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resBody))
+			assert.NoError(t, doc.Validate(content.Schema, resBody))
+		})
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/api_key"
 	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/p2p"
 	"github.com/MunifTanjim/stremthru/internal/server"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	store_video "github.com/MunifTanjim/stremthru/internal/store/video"
@@ -61,7 +63,17 @@ func handleProxyLinkAccess(w http.ResponseWriter, r *http.Request) {
 			defer cpStore.Del(ctx.RequestId)
 		}
 	}
-	bytesWritten, err := shared.ProxyResponse(w, r, link, tunnelType)
+
+	var bytesWritten int64
+	if p2p.IsMagnetLink(link) {
+		bytesWritten, err = p2p.ServeFile(w, r, link)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+	} else {
+		bytesWritten, err = shared.ProxyResponse(w, r, link, tunnelType)
+	}
 	ctx.Log.Info("[proxy] connection closed", "user", user, "size", util.ToSize(bytesWritten), "error", err)
 }
 
@@ -79,14 +91,19 @@ func handleProxifyLinks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isAuthorized, user, password := getProxyAuthorization(r, true)
-	if !isAuthorized {
+	auth, err := getProxyAuthorization(r, true, api_key.ScopeProxyCreate)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if !auth.IsAuthorized {
 		w.Header().Add(server.HEADER_STREMTHRU_AUTHENTICATE, "Basic")
 		shared.ErrorForbidden(r).Send(w, r)
 		return
 	}
+	user, password := auth.User, auth.Password
 
-	err := r.ParseForm()
+	err = r.ParseForm()
 	if err != nil {
 		shared.ErrorBadRequest(r, "failed to parse data").Send(w, r)
 		return
@@ -131,6 +148,10 @@ func handleProxifyLinks(w http.ResponseWriter, r *http.Request) {
 	if !shouldEncrypt {
 		ctx.RedactURLQueryParams(r, "token")
 	}
+	if auth.APIKey != nil {
+		// unencrypted link would carry the password of the user
+		shouldEncrypt = true
+	}
 
 	proxyLinks := make([]string, count)
 	for i, link := range links {
@@ -6,6 +6,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/stremio/disabled"
 	stremio_list "github.com/MunifTanjim/stremthru/internal/stremio/list"
+	stremio_meta "github.com/MunifTanjim/stremthru/internal/stremio/meta"
 	"github.com/MunifTanjim/stremthru/internal/stremio/root"
 	"github.com/MunifTanjim/stremthru/internal/stremio/sidekick"
 	"github.com/MunifTanjim/stremthru/internal/stremio/store"
@@ -16,6 +17,9 @@ import (
 func AddStremioEndpoints(mux *http.ServeMux) {
 	stremio_root.AddStremioEndpoints(mux)
 
+	if config.Feature.IsEnabled(config.FeatureIMDBTitle) {
+		stremio_meta.AddStremioMetaEndpoints(mux)
+	}
 	if config.Feature.IsEnabled(config.FeatureStremioList) {
 		stremio_list.AddEndpoints(mux)
 	}
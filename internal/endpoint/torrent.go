@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/MunifTanjim/stremthru/internal/api_key"
 	"github.com/MunifTanjim/stremthru/internal/buddy"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/peer_token"
@@ -21,14 +22,21 @@ type RecordTorrentsPayload struct {
 
 func handleRecordTorrents(w http.ResponseWriter, r *http.Request) {
 	peerToken := r.Header.Get("X-StremThru-Peer-Token")
-	isValidToken, err := peer_token.IsValid(peerToken)
-	if err != nil {
-		SendError(w, r, err)
-		return
-	}
-	if !isValidToken {
-		shared.ErrorUnauthorized(r).Send(w, r)
-		return
+	if token, _ := extractProxyAuthToken(r, false); peerToken == "" && api_key.IsKey(token) {
+		if _, err := verifyAPIKey(r, token, api_key.ScopeTorrentsPush); err != nil {
+			SendError(w, r, err)
+			return
+		}
+	} else {
+		isValidToken, err := peer_token.IsValid(peerToken)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+		if !isValidToken {
+			shared.ErrorUnauthorized(r).Send(w, r)
+			return
+		}
 	}
 
 	payload := &RecordTorrentsPayload{}
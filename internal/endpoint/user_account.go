@@ -0,0 +1,320 @@
+package endpoint
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/internal/user_account"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+//go:embed user_account.html
+var userAccountPageBlob []byte
+
+type UserAccountStore struct {
+	Name     string `json:"name"`
+	Token    string `json:"token,omitempty"`
+	HasToken bool   `json:"has_token"`
+}
+
+type UserAccountData struct {
+	Name                        string             `json:"name"`
+	Source                      string             `json:"source"`
+	IsAdmin                     bool               `json:"is_admin"`
+	IsDisabled                  bool               `json:"is_disabled"`
+	Stores                      []UserAccountStore `json:"stores"`
+	ContentProxyConnectionLimit int                `json:"content_proxy_connection_limit"`
+	// only included when generated
+	Password  string     `json:"password,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func toUserAccountData(ua *user_account.UserAccount) *UserAccountData {
+	data := &UserAccountData{
+		Name:                        ua.Name,
+		Source:                      "db",
+		IsAdmin:                     ua.IsAdmin,
+		IsDisabled:                  ua.IsDisabled,
+		Stores:                      []UserAccountStore{},
+		ContentProxyConnectionLimit: ua.ContentProxyConnectionLimit,
+	}
+	if ua.IsFromEnv() {
+		data.Source = "env"
+	} else {
+		data.CreatedAt = &ua.CreatedAt.Time
+		data.UpdatedAt = &ua.UpdatedAt.Time
+	}
+	for _, name := range ua.StoreNames {
+		data.Stores = append(data.Stores, UserAccountStore{
+			Name:     name,
+			HasToken: ua.StoreTokens[name] != "",
+		})
+	}
+	return data
+}
+
+type UserAccountPayload struct {
+	Name string `json:"name"`
+	// generated for new user if empty
+	Password   *string `json:"password"`
+	IsAdmin    *bool   `json:"is_admin"`
+	IsDisabled *bool   `json:"is_disabled"`
+	// preferred store first, empty token keeps the existing one
+	Stores                      *[]UserAccountStore `json:"stores"`
+	ContentProxyConnectionLimit *int                `json:"content_proxy_connection_limit"`
+}
+
+func generateUserPassword() string {
+	return util.GenerateRandomString(27, util.CharSet.AlphaNumericMixedCase)
+}
+
+func applyUserAccountPayload(r *http.Request, ua *user_account.UserAccount, payload *UserAccountPayload) error {
+	if payload.Password != nil {
+		if *payload.Password == "" || strings.Contains(*payload.Password, ",") {
+			return shared.ErrorBadRequest(r, "invalid password")
+		}
+		if err := ua.SetPassword(*payload.Password); err != nil {
+			return err
+		}
+	}
+	if payload.IsAdmin != nil {
+		ua.IsAdmin = *payload.IsAdmin
+	}
+	if payload.IsDisabled != nil {
+		ua.IsDisabled = *payload.IsDisabled
+	}
+	if payload.Stores != nil {
+		storeNames := db.CommaSeperatedString{}
+		storeTokens := db.JSONStringMap{}
+		for _, s := range *payload.Stores {
+			if _, err := store.StoreName(s.Name).Validate(); err != nil {
+				return shared.ErrorBadRequest(r, "invalid store: "+s.Name)
+			}
+			storeNames = append(storeNames, s.Name)
+			if s.Token != "" {
+				storeTokens[s.Name] = s.Token
+			} else if token := ua.StoreTokens[s.Name]; token != "" {
+				storeTokens[s.Name] = token
+			}
+		}
+		ua.StoreNames = storeNames
+		ua.StoreTokens = storeTokens
+	}
+	if payload.ContentProxyConnectionLimit != nil {
+		ua.ContentProxyConnectionLimit = max(-1, *payload.ContentProxyConnectionLimit)
+	}
+	return nil
+}
+
+func handleUserAccountsList(w http.ResponseWriter, r *http.Request) {
+	accounts, err := user_account.ListWithEnv()
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	items := make([]UserAccountData, len(accounts))
+	for i := range accounts {
+		items[i] = *toUserAccountData(&accounts[i])
+	}
+	SendResponse(w, r, 200, items, nil)
+}
+
+func handleUserAccountCreate(w http.ResponseWriter, r *http.Request) {
+	if config.IsPublicInstance {
+		err := shared.ErrorForbidden(r)
+		err.Msg = "requires STREMTHRU_PROXY_AUTH"
+		err.Send(w, r)
+		return
+	}
+
+	payload := &UserAccountPayload{}
+	if err := shared.ReadRequestBodyJSON(r, payload); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	if payload.Name == "" || payload.Name == "*" || strings.ContainsAny(payload.Name, ":, ") {
+		shared.ErrorBadRequest(r, "invalid name").Send(w, r)
+		return
+	}
+
+	existing, err := user_account.GetWithEnv(payload.Name)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if existing != nil {
+		shared.ErrorConflict(r, "user already exists").Send(w, r)
+		return
+	}
+
+	ua := &user_account.UserAccount{
+		Name:                        payload.Name,
+		StoreNames:                  db.CommaSeperatedString{},
+		StoreTokens:                 db.JSONStringMap{},
+		ContentProxyConnectionLimit: -1,
+	}
+	generatedPassword := ""
+	if payload.Password == nil || *payload.Password == "" {
+		generatedPassword = generateUserPassword()
+		payload.Password = &generatedPassword
+	}
+	if err := applyUserAccountPayload(r, ua, payload); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if err := user_account.Save(ua); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	data := toUserAccountData(ua)
+	data.Password = generatedPassword
+	SendResponse(w, r, 201, data, nil)
+}
+
+func handleUserAccounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		handleUserAccountsList(w, r)
+	case http.MethodPost:
+		handleUserAccountCreate(w, r)
+	default:
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+	}
+}
+
+func getUserAccount(w http.ResponseWriter, r *http.Request) *user_account.UserAccount {
+	ua, err := user_account.GetWithEnv(r.PathValue("name"))
+	if err != nil {
+		SendError(w, r, err)
+		return nil
+	}
+	if ua == nil {
+		shared.ErrorNotFound(r).Send(w, r)
+		return nil
+	}
+	return ua
+}
+
+func handleUserAccountUpdate(w http.ResponseWriter, r *http.Request) {
+	payload := &UserAccountPayload{}
+	if err := shared.ReadRequestBodyJSON(r, payload); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	ua := getUserAccount(w, r)
+	if ua == nil {
+		return
+	}
+	// user configured with the env is overridden with the saved one
+	if err := applyUserAccountPayload(r, ua, payload); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if err := user_account.Save(ua); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	SendResponse(w, r, 200, toUserAccountData(ua), nil)
+}
+
+func handleUserAccountDelete(w http.ResponseWriter, r *http.Request) {
+	ua := getUserAccount(w, r)
+	if ua == nil {
+		return
+	}
+	if ua.IsFromEnv() {
+		shared.ErrorBadRequest(r, "user configured with env, disable it instead").Send(w, r)
+		return
+	}
+	if err := user_account.Delete(ua.Name); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func handleUserAccount(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if ua := getUserAccount(w, r); ua != nil {
+			SendResponse(w, r, 200, toUserAccountData(ua), nil)
+		}
+	case http.MethodPatch:
+		handleUserAccountUpdate(w, r)
+	case http.MethodDelete:
+		handleUserAccountDelete(w, r)
+	default:
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+	}
+}
+
+type UserAccountPasswordPayload struct {
+	// generated if empty
+	Password string `json:"password"`
+}
+
+func handleUserAccountPassword(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodPost) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	payload := &UserAccountPasswordPayload{}
+	if r.ContentLength != 0 {
+		if err := shared.ReadRequestBodyJSON(r, payload); err != nil {
+			SendError(w, r, err)
+			return
+		}
+	}
+
+	ua := getUserAccount(w, r)
+	if ua == nil {
+		return
+	}
+	generatedPassword := ""
+	if payload.Password == "" {
+		generatedPassword = generateUserPassword()
+		payload.Password = generatedPassword
+	}
+	if err := applyUserAccountPayload(r, ua, &UserAccountPayload{Password: &payload.Password}); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if err := user_account.Save(ua); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	data := toUserAccountData(ua)
+	data.Password = generatedPassword
+	SendResponse(w, r, 200, data, nil)
+}
+
+func handleUserAccountPage(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write(userAccountPageBlob)
+}
+
+func AddUserAccountEndpoints(mux *http.ServeMux) {
+	withAdminAuth := shared.Middleware(AdminAuthed)
+
+	mux.HandleFunc("/__admin__/users", handleUserAccountPage)
+	mux.HandleFunc("/__admin__/api/users", withAdminAuth(handleUserAccounts))
+	mux.HandleFunc("/__admin__/api/users/{name}", withAdminAuth(handleUserAccount))
+	mux.HandleFunc("/__admin__/api/users/{name}/password", withAdminAuth(handleUserAccountPassword))
+}
@@ -0,0 +1,71 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
+)
+
+func handleWorkerQueues(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	stats, err := worker_queue.GetStats()
+	SendResponse(w, r, 200, stats, err)
+}
+
+func handleWorkerQueueItems(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+
+	status := worker_queue.QueueItemStatus(q.Get("status"))
+	switch status {
+	case "", worker_queue.QueueItemStatusPending, worker_queue.QueueItemStatusDead:
+	default:
+		shared.ErrorBadRequest(r, "invalid status").Send(w, r)
+		return
+	}
+
+	limit, err := shared.GetQueryInt(q, "limit", 100)
+	if err != nil || limit < 1 || limit > 500 {
+		shared.ErrorBadRequest(r, "invalid limit").Send(w, r)
+		return
+	}
+	offset, err := shared.GetQueryInt(q, "offset", 0)
+	if err != nil || offset < 0 {
+		shared.ErrorBadRequest(r, "invalid offset").Send(w, r)
+		return
+	}
+
+	items, err := worker_queue.ListItems(r.PathValue("name"), status, limit, offset)
+	SendResponse(w, r, 200, items, err)
+}
+
+type WorkerQueueRetryData struct {
+	Count int64 `json:"count"`
+}
+
+func handleWorkerQueueRetry(w http.ResponseWriter, r *http.Request) {
+	if !shared.IsMethod(r, http.MethodPost) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	count, err := worker_queue.RetryDeadItems(r.PathValue("name"))
+	SendResponse(w, r, 200, &WorkerQueueRetryData{Count: count}, err)
+}
+
+func AddWorkerEndpoints(mux *http.ServeMux) {
+	withAdminAuth := shared.Middleware(AdminAuthed)
+
+	mux.HandleFunc("/__worker__/queues", withAdminAuth(handleWorkerQueues))
+	mux.HandleFunc("/__worker__/queues/{name}/items", withAdminAuth(handleWorkerQueueItems))
+	mux.HandleFunc("/__worker__/queues/{name}/retry", withAdminAuth(handleWorkerQueueRetry))
+}
@@ -0,0 +1,102 @@
+package imdb_title
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/MunifTanjim/stremthru/internal/anidb"
+	"github.com/MunifTanjim/stremthru/internal/anime"
+	"github.com/MunifTanjim/stremthru/internal/db"
+)
+
+type AniDBEpisode struct {
+	AniDBId string
+	// AniDB title season, empty if unknown
+	Season  string
+	Episode int
+	// -1 if unknown
+	AbsoluteEpisode int
+}
+
+// StremId returns the anime strem id, i.e. `<anidb-id>:<episode>`, used for
+// `torrent_stream.ASId`.
+func (e AniDBEpisode) StremId() string {
+	return e.AniDBId + ":" + strconv.Itoa(e.Episode)
+}
+
+var query_get_tvdb_id_by_imdb_id = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ?`,
+	MapColumn.TVDBId,
+	MapTableName,
+	MapColumn.IMDBId,
+)
+
+func getTVDBId(imdbId string) (string, error) {
+	idMap, err := anime.GetIdMap(anime.IdMapColumn.IMDB, imdbId)
+	if err != nil {
+		return "", err
+	}
+	if idMap != nil && idMap.TVDB != "" {
+		return idMap.TVDB, nil
+	}
+
+	var tvdbId sql.NullString
+	if err := db.QueryRow(query_get_tvdb_id_by_imdb_id, imdbId).Scan(&tvdbId); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return tvdbId.String, nil
+}
+
+// GetAniDBEpisode translates the season and episode of the IMDb series to
+// AniDB id and episode, using the AniDB-TVDB episode map. Seasons that are
+// absolute ordered on AniDB are resolved using the absolute episode from the
+// synced IMDb episodes. It returns nil if there is no match.
+func GetAniDBEpisode(imdbId string, season, episode int) (*AniDBEpisode, error) {
+	if season < 1 || episode < 1 {
+		return nil, nil
+	}
+
+	tvdbId, err := getTVDBId(imdbId)
+	if err != nil || tvdbId == "" {
+		return nil, err
+	}
+
+	maps, err := anidb.GetTVDBEpisodeMapsByTVDBId(tvdbId)
+	if err != nil || len(maps) == 0 {
+		return nil, err
+	}
+
+	absEpisode := -1
+	anidbId, anidbEpisode := maps.ToAniDBEpisode(season, episode)
+	if anidbId == "" {
+		if !maps.HasAbsoluteOrder() {
+			return nil, nil
+		}
+		absEpisode, err = GetAbsoluteEpisode(imdbId, season, episode)
+		if err != nil || absEpisode == -1 {
+			return nil, err
+		}
+		anidbId, anidbEpisode = maps.ToAniDBEpisode(-1, absEpisode)
+		if anidbId == "" {
+			return nil, nil
+		}
+	} else {
+		absEpisode = maps.GetAbsoluteEpisode(anidbId, anidbEpisode)
+	}
+
+	e := &AniDBEpisode{
+		AniDBId:         anidbId,
+		Episode:         anidbEpisode,
+		AbsoluteEpisode: absEpisode,
+	}
+	if titles, err := anidb.GetTitlesByIds([]string{anidbId}); err != nil {
+		return nil, err
+	} else if s := titles.GetSeason(anidbId); s != -1 {
+		e.Season = strconv.Itoa(s)
+	}
+	return e, nil
+}
@@ -1,8 +1,10 @@
 package imdb_title
 
 import (
+	"log/slog"
 	"path"
 	"slices"
+	"strconv"
 	"sync"
 	"time"
 
@@ -12,65 +14,84 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/util"
 )
 
-var datasetSyncMutex sync.Mutex
-
-func SyncDataset() error {
-	log = logger.Scoped("imdb_title/dataset")
-
-	if !datasetSyncMutex.TryLock() {
-		log.Warn("dataset sync already in progress, skipping")
-		return nil
-	}
-	defer datasetSyncMutex.Unlock()
-
-	isAllowedType := func(tType string) bool {
-		switch tType {
-		case "short", "movie", "tvShort", "tvMovie", "tvSeries", "tvMiniSeries", "tvSpecial":
-			return true
-		default:
-			return false
-		}
-	}
+type datasetConfig[T any] struct {
+	Log      *slog.Logger
+	Name     string // e.g. title.basics
+	Headers  []string
+	ParseRow func(row []string) (*T, error)
+	Upsert   func(items []T) error
+}
 
+// syncDataset downloads the dataset, and upserts the parsed rows in batches.
+func syncDataset[T any](conf *datasetConfig[T]) error {
 	batch_size := 1000
 	if db.Dialect == db.DBDialectPostgres {
 		batch_size = 10000
 	}
-	writer := util.NewDatasetWriter(util.DatasetWriterConfig[IMDBTitle]{
-		BatchSize: batch_size,
-		Log:       log,
-		Upsert: func(titles []IMDBTitle) error {
-			return Upsert(titles)
-		},
+	writer := util.NewDatasetWriter(util.DatasetWriterConfig[T]{
+		BatchSize:     batch_size,
+		Log:           conf.Log,
+		Upsert:        conf.Upsert,
 		SleepDuration: 200 * time.Millisecond,
 	})
 
-	ds := util.NewTSVDataset(&util.TSVDatasetConfig[IMDBTitle]{
+	ds := util.NewTSVDataset(&util.TSVDatasetConfig[T]{
 		DatasetConfig: util.DatasetConfig{
 			Archive:     "gz",
 			DownloadDir: path.Join(config.DataDir, "imdb"),
 			IsStale: func(t time.Time) bool {
 				return t.Before(time.Now().Add(-24 * time.Hour))
 			},
-			Log: log,
-			URL: "https://datasets.imdbws.com/title.basics.tsv.gz",
+			Log: conf.Log,
+			URL: "https://datasets.imdbws.com/" + conf.Name + ".tsv.gz",
 		},
 		GetRowKey: func(row []string) string {
 			return row[0]
 		},
 		HasHeaders: true,
 		IsValidHeaders: func(headers []string) bool {
-			return slices.Equal(headers, []string{
-				"tconst",
-				"titleType",
-				"primaryTitle",
-				"originalTitle",
-				"isAdult",
-				"startYear",
-				"endYear",
-				"runtimeMinutes",
-				"genres",
-			})
+			return slices.Equal(headers, conf.Headers)
+		},
+		ParseRow: conf.ParseRow,
+		Writer:   writer,
+	})
+
+	return ds.Process()
+}
+
+var datasetSyncMutex sync.Mutex
+
+func SyncDataset() error {
+	log = logger.Scoped("imdb_title/dataset")
+
+	if !datasetSyncMutex.TryLock() {
+		log.Warn("dataset sync already in progress, skipping")
+		return nil
+	}
+	defer datasetSyncMutex.Unlock()
+
+	isAllowedType := func(tType string) bool {
+		switch tType {
+		case "short", "movie", "tvShort", "tvMovie", "tvSeries", "tvMiniSeries", "tvSpecial":
+			return true
+		default:
+			return false
+		}
+	}
+
+	err := syncDataset(&datasetConfig[IMDBTitle]{
+		Log:  log,
+		Name: "title.basics",
+		Headers: []string{
+			"tconst",
+			"titleType",
+			"primaryTitle",
+			"originalTitle",
+			"isAdult",
+			"startYear",
+			"endYear",
+			"runtimeMinutes",
+			"genres",
 		},
 		ParseRow: func(row []string) (*IMDBTitle, error) {
 			nilValue := `\N`
@@ -117,10 +138,9 @@ func SyncDataset() error {
 				IsAdult:   isAdult,
 			}, nil
 		},
-		Writer: writer,
+		Upsert: Upsert,
 	})
-
-	if err := ds.Process(); err != nil {
+	if err != nil {
 		return err
 	}
 
@@ -131,3 +151,111 @@ func SyncDataset() error {
 
 	return nil
 }
+
+func parseEpisodeRow(row []string) (*IMDBTitleEpisode, error) {
+	nilValue := `\N`
+
+	season, err := util.TSVGetValue(row, 2, -1, nilValue)
+	if err != nil {
+		return nil, err
+	}
+	episode, err := util.TSVGetValue(row, 3, -1, nilValue)
+	if err != nil {
+		return nil, err
+	}
+	if season == -1 || episode == -1 {
+		return nil, nil
+	}
+
+	tId, err := util.TSVGetValue(row, 0, "", nilValue)
+	if err != nil {
+		return nil, err
+	}
+	parentTId, err := util.TSVGetValue(row, 1, "", nilValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IMDBTitleEpisode{
+		TId:       tId,
+		ParentTId: parentTId,
+		Season:    season,
+		Episode:   episode,
+	}, nil
+}
+
+var episodeDatasetSyncMutex sync.Mutex
+
+func SyncEpisodeDataset() error {
+	log := logger.Scoped("imdb_title/dataset/episode")
+
+	if !episodeDatasetSyncMutex.TryLock() {
+		log.Warn("dataset sync already in progress, skipping")
+		return nil
+	}
+	defer episodeDatasetSyncMutex.Unlock()
+
+	return syncDataset(&datasetConfig[IMDBTitleEpisode]{
+		Log:  log,
+		Name: "title.episode",
+		Headers: []string{
+			"tconst",
+			"parentTconst",
+			"seasonNumber",
+			"episodeNumber",
+		},
+		ParseRow: parseEpisodeRow,
+		Upsert:   UpsertEpisodes,
+	})
+}
+
+func parseRatingRow(row []string) (*IMDBTitleRating, error) {
+	nilValue := `\N`
+
+	tId, err := util.TSVGetValue(row, 0, "", nilValue)
+	if err != nil {
+		return nil, err
+	}
+	ratingStr, err := util.TSVGetValue(row, 1, "", nilValue)
+	if err != nil || ratingStr == "" {
+		return nil, err
+	}
+	rating, err := strconv.ParseFloat(ratingStr, 64)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := util.TSVGetValue(row, 2, 0, nilValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IMDBTitleRating{
+		TId:    tId,
+		Rating: rating,
+		Votes:  votes,
+	}, nil
+}
+
+var ratingDatasetSyncMutex sync.Mutex
+
+func SyncRatingDataset() error {
+	log := logger.Scoped("imdb_title/dataset/rating")
+
+	if !ratingDatasetSyncMutex.TryLock() {
+		log.Warn("dataset sync already in progress, skipping")
+		return nil
+	}
+	defer ratingDatasetSyncMutex.Unlock()
+
+	return syncDataset(&datasetConfig[IMDBTitleRating]{
+		Log:  log,
+		Name: "title.ratings",
+		Headers: []string{
+			"tconst",
+			"averageRating",
+			"numVotes",
+		},
+		ParseRow: parseRatingRow,
+		Upsert:   UpsertRatings,
+	})
+}
@@ -0,0 +1,46 @@
+package imdb_title
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEpisodeRow(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		row      []string
+		expected *IMDBTitleEpisode
+	}{
+		{"episode", []string{"tt0959621", "tt0903747", "1", "1"}, &IMDBTitleEpisode{TId: "tt0959621", ParentTId: "tt0903747", Season: 1, Episode: 1}},
+		{"special", []string{"tt1234567", "tt0903747", "0", "3"}, &IMDBTitleEpisode{TId: "tt1234567", ParentTId: "tt0903747", Season: 0, Episode: 3}},
+		{"missing season", []string{"tt1234567", "tt0903747", `\N`, "3"}, nil},
+		{"missing episode", []string{"tt1234567", "tt0903747", "1", `\N`}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			episode, err := parseEpisodeRow(tc.row)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, episode)
+		})
+	}
+}
+
+func TestParseRatingRow(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		row      []string
+		expected *IMDBTitleRating
+	}{
+		{"rating", []string{"tt0903747", "9.5", "2200000"}, &IMDBTitleRating{TId: "tt0903747", Rating: 9.5, Votes: 2200000}},
+		{"missing rating", []string{"tt0903747", `\N`, "0"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rating, err := parseRatingRow(tc.row)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, rating)
+		})
+	}
+
+	_, err := parseRatingRow([]string{"tt0903747", "nine", "0"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package imdb_title
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+const EpisodeTableName = "imdb_title_episode"
+
+type IMDBTitleEpisode struct {
+	TId       string `json:"tid"`
+	ParentTId string `json:"parent_tid"`
+	Season    int    `json:"season"`
+	Episode   int    `json:"episode"`
+}
+
+type EpisodeColumnStruct struct {
+	TId       string
+	ParentTId string
+	Season    string
+	Episode   string
+}
+
+var EpisodeColumn = EpisodeColumnStruct{
+	TId:       "tid",
+	ParentTId: "parent_tid",
+	Season:    "season",
+	Episode:   "episode",
+}
+
+var EpisodeColumns = []string{
+	EpisodeColumn.TId,
+	EpisodeColumn.ParentTId,
+	EpisodeColumn.Season,
+	EpisodeColumn.Episode,
+}
+
+var query_upsert_episodes_before_values = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES `,
+	EpisodeTableName,
+	strings.Join(EpisodeColumns, ","),
+)
+var query_upsert_episodes_values_placeholder = "(" + util.RepeatJoin("?", len(EpisodeColumns), ",") + ")"
+var query_upsert_episodes_after_values = fmt.Sprintf(
+	` ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = EXCLUDED.%s`,
+	EpisodeColumn.TId,
+	EpisodeColumn.ParentTId,
+	EpisodeColumn.ParentTId,
+	EpisodeColumn.Season,
+	EpisodeColumn.Season,
+	EpisodeColumn.Episode,
+	EpisodeColumn.Episode,
+)
+
+func UpsertEpisodes(episodes []IMDBTitleEpisode) error {
+	if len(episodes) == 0 {
+		return nil
+	}
+
+	query := query_upsert_episodes_before_values +
+		util.RepeatJoin(query_upsert_episodes_values_placeholder, len(episodes), ",") +
+		query_upsert_episodes_after_values
+	args := make([]any, 0, len(episodes)*len(EpisodeColumns))
+	for _, e := range episodes {
+		args = append(args, e.TId, e.ParentTId, e.Season, e.Episode)
+	}
+
+	_, err := db.Exec(query, args...)
+	return err
+}
+
+var query_list_episodes_by_parent_id = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ? ORDER BY %s, %s`,
+	strings.Join(EpisodeColumns, ","),
+	EpisodeTableName,
+	EpisodeColumn.ParentTId,
+	EpisodeColumn.Season,
+	EpisodeColumn.Episode,
+)
+
+func ListEpisodesByParentId(parentTId string) ([]IMDBTitleEpisode, error) {
+	rows, err := db.Query(query_list_episodes_by_parent_id, parentTId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	episodes := []IMDBTitleEpisode{}
+	for rows.Next() {
+		var episode IMDBTitleEpisode
+		if err := rows.Scan(
+			&episode.TId,
+			&episode.ParentTId,
+			&episode.Season,
+			&episode.Episode,
+		); err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, episode)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+var query_has_episode = fmt.Sprintf(
+	`SELECT COUNT(*), COALESCE(SUM(CASE WHEN %s = ? AND (? = -1 OR %s = ?) THEN 1 ELSE 0 END), 0) FROM %s WHERE %s = ?`,
+	EpisodeColumn.Season,
+	EpisodeColumn.Episode,
+	EpisodeTableName,
+	EpisodeColumn.ParentTId,
+)
+
+// HasEpisode reports whether the series has the episode, or the season if
+// episode is -1. Series without any synced episode are assumed to have it.
+func HasEpisode(parentTId string, season, episode int) (bool, error) {
+	var total, matched int
+	row := db.QueryRow(query_has_episode, season, episode, episode, parentTId)
+	if err := row.Scan(&total, &matched); err != nil {
+		return false, err
+	}
+	return total == 0 || matched > 0, nil
+}
+
+// CheckEpisode is HasEpisode for the season and episode from ids or
+// queries. Non-numeric values are not checked, and empty episode checks the
+// season.
+func CheckEpisode(parentTId, season, episode string) (bool, error) {
+	s, err := strconv.Atoi(season)
+	if err != nil {
+		return true, nil
+	}
+	e := -1
+	if episode != "" {
+		if e, err = strconv.Atoi(episode); err != nil {
+			return true, nil
+		}
+	}
+	return HasEpisode(parentTId, s, e)
+}
+
+var query_count_episodes_before_season = fmt.Sprintf(
+	`SELECT COUNT(*) FROM %s WHERE %s = ? AND %s >= 1 AND %s < ?`,
+	EpisodeTableName,
+	EpisodeColumn.ParentTId,
+	EpisodeColumn.Season,
+	EpisodeColumn.Season,
+)
+
+// GetAbsoluteEpisode returns the absolute episode for the season and episode,
+// counting the synced episodes of the earlier seasons. It returns -1 if the
+// earlier seasons are not synced.
+func GetAbsoluteEpisode(parentTId string, season, episode int) (int, error) {
+	if season < 1 || episode < 1 {
+		return -1, nil
+	}
+	if season == 1 {
+		return episode, nil
+	}
+	var count int
+	row := db.QueryRow(query_count_episodes_before_season, parentTId, season)
+	if err := row.Scan(&count); err != nil {
+		return -1, err
+	}
+	if count == 0 {
+		return -1, nil
+	}
+	return count + episode, nil
+}
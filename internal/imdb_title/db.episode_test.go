@@ -0,0 +1,95 @@
+package imdb_title
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasEpisode(t *testing.T) {
+	dbtest.Setup(t)
+
+	assert.NoError(t, UpsertEpisodes([]IMDBTitleEpisode{
+		{TId: "tt9000001", ParentTId: "tt9000000", Season: 1, Episode: 1},
+		{TId: "tt9000002", ParentTId: "tt9000000", Season: 1, Episode: 2},
+		{TId: "tt9000003", ParentTId: "tt9000000", Season: 2, Episode: 1},
+	}))
+
+	for _, tc := range []struct {
+		name     string
+		tId      string
+		season   int
+		episode  int
+		expected bool
+	}{
+		{"episode", "tt9000000", 1, 2, true},
+		{"missing episode", "tt9000000", 1, 3, false},
+		{"season", "tt9000000", 2, -1, true},
+		{"missing season", "tt9000000", 3, -1, false},
+		{"series not synced", "tt9999999", 1, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exists, err := HasEpisode(tc.tId, tc.season, tc.episode)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, exists)
+		})
+	}
+}
+
+func TestCheckEpisode(t *testing.T) {
+	dbtest.Setup(t)
+
+	assert.NoError(t, UpsertEpisodes([]IMDBTitleEpisode{
+		{TId: "tt9000101", ParentTId: "tt9000100", Season: 1, Episode: 1},
+	}))
+
+	for _, tc := range []struct {
+		name     string
+		season   string
+		episode  string
+		expected bool
+	}{
+		{"episode", "1", "1", true},
+		{"missing episode", "1", "2", false},
+		{"season", "1", "", true},
+		{"missing season", "2", "", false},
+		{"non-numeric season", "S2", "1", true},
+		{"non-numeric episode", "2", "E1", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exists, err := CheckEpisode("tt9000100", tc.season, tc.episode)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, exists)
+		})
+	}
+}
+
+func TestGetAbsoluteEpisode(t *testing.T) {
+	dbtest.Setup(t)
+
+	assert.NoError(t, UpsertEpisodes([]IMDBTitleEpisode{
+		{TId: "tt9100001", ParentTId: "tt9100000", Season: 1, Episode: 1},
+		{TId: "tt9100002", ParentTId: "tt9100000", Season: 1, Episode: 2},
+		{TId: "tt9100003", ParentTId: "tt9100000", Season: 2, Episode: 1},
+	}))
+
+	for _, tc := range []struct {
+		name     string
+		tId      string
+		season   int
+		episode  int
+		expected int
+	}{
+		{"first season", "tt9100000", 1, 2, 2},
+		{"later season", "tt9100000", 2, 1, 3},
+		{"not synced", "tt9999999", 2, 1, -1},
+		{"invalid", "tt9100000", 0, 1, -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			episode, err := GetAbsoluteEpisode(tc.tId, tc.season, tc.episode)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, episode)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package imdb_title
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -10,8 +11,33 @@ import (
 
 const MapTableName = "imdb_title_map"
 
+// IMDBTitleMapType is the type of the title the ids belong to. TMDB (and
+// TVDB) movie and tv ids share the same number space, so the type is needed
+// to pick the right title.
+type IMDBTitleMapType = string
+
+const (
+	IMDBTitleMapTypeMovie   IMDBTitleMapType = "movie"
+	IMDBTitleMapTypeShow    IMDBTitleMapType = "show"
+	IMDBTitleMapTypeUnknown IMDBTitleMapType = ""
+)
+
+// NormalizeMapType returns the IMDBTitleMapType for the type used by
+// MDBList, Trakt and Stremio.
+func NormalizeMapType(t string) IMDBTitleMapType {
+	switch t {
+	case "movie":
+		return IMDBTitleMapTypeMovie
+	case "show", "series":
+		return IMDBTitleMapTypeShow
+	default:
+		return IMDBTitleMapTypeUnknown
+	}
+}
+
 type IMDBTitleMap struct {
 	IMDBId    string       `json:"imdb"`
+	Type      string       `json:"type"`
 	TMDBId    string       `json:"tmdb"`
 	TVDBId    string       `json:"tvdb"`
 	TraktId   string       `json:"trakt"`
@@ -21,6 +47,7 @@ type IMDBTitleMap struct {
 
 type MapColumnStruct struct {
 	IMDBId    string
+	Type      string
 	TMDBId    string
 	TVDBId    string
 	TraktId   string
@@ -30,6 +57,7 @@ type MapColumnStruct struct {
 
 var MapColumn = MapColumnStruct{
 	IMDBId:    "imdb",
+	Type:      "type",
 	TMDBId:    "tmdb",
 	TVDBId:    "tvdb",
 	TraktId:   "trakt",
@@ -75,14 +103,41 @@ func GetIMDBIdByTraktId(traktIds []string) (map[string]string, error) {
 	return imdbIdByTraktId, nil
 }
 
-func RecordMappingFromMDBList(tx *db.Tx, imdbId, tmdbId, tvdbId, traktId, malId string) error {
+var query_get_map_by_imdb_id = fmt.Sprintf(
+	`SELECT %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ? LIMIT 1`,
+	MapColumn.IMDBId,
+	MapColumn.Type,
+	MapColumn.TMDBId,
+	MapColumn.TVDBId,
+	MapColumn.TraktId,
+	MapColumn.MALId,
+	MapTableName,
+	MapColumn.IMDBId,
+)
+
+// GetMapByIMDBId returns the ids mapped to the IMDb id. It returns nil if
+// there is none.
+func GetMapByIMDBId(imdbId string) (*IMDBTitleMap, error) {
+	m := IMDBTitleMap{}
+	row := db.QueryRow(query_get_map_by_imdb_id, imdbId)
+	if err := row.Scan(&m.IMDBId, &m.Type, &m.TMDBId, &m.TVDBId, &m.TraktId, &m.MALId); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+func RecordMappingFromMDBList(tx *db.Tx, imdbId, mType, tmdbId, tvdbId, traktId, malId string) error {
 	query := fmt.Sprintf(
-		`INSERT INTO %s AS itm (%s) VALUES (?,?,?,?,?) ON CONFLICT (%s) DO UPDATE SET %s, %s = %s`,
+		`INSERT INTO %s AS itm (%s) VALUES (?,?,?,?,?,?) ON CONFLICT (%s) DO UPDATE SET %s, %s = %s`,
 		MapTableName,
-		db.JoinColumnNames(MapColumn.IMDBId, MapColumn.TMDBId, MapColumn.TVDBId, MapColumn.TraktId, MapColumn.MALId),
+		db.JoinColumnNames(MapColumn.IMDBId, MapColumn.Type, MapColumn.TMDBId, MapColumn.TVDBId, MapColumn.TraktId, MapColumn.MALId),
 		MapColumn.IMDBId,
 		strings.Join(
 			[]string{
+				fmt.Sprintf("%s = CASE WHEN itm.%s = '' THEN EXCLUDED.%s ELSE itm.%s END", MapColumn.Type, MapColumn.Type, MapColumn.Type, MapColumn.Type),
 				fmt.Sprintf("%s = CASE WHEN itm.%s = '' THEN EXCLUDED.%s ELSE itm.%s END", MapColumn.TMDBId, MapColumn.TMDBId, MapColumn.TMDBId, MapColumn.TMDBId),
 				fmt.Sprintf("%s = CASE WHEN itm.%s = '' THEN EXCLUDED.%s ELSE itm.%s END", MapColumn.TVDBId, MapColumn.TVDBId, MapColumn.TVDBId, MapColumn.TVDBId),
 				fmt.Sprintf("%s = CASE WHEN itm.%s = '' THEN EXCLUDED.%s ELSE itm.%s END", MapColumn.TraktId, MapColumn.TraktId, MapColumn.TraktId, MapColumn.TraktId),
@@ -94,12 +149,13 @@ func RecordMappingFromMDBList(tx *db.Tx, imdbId, tmdbId, tvdbId, traktId, malId
 		db.CurrentTimestamp,
 	)
 
-	_, err := tx.Exec(query, imdbId, tmdbId, tvdbId, traktId, malId)
+	_, err := tx.Exec(query, imdbId, NormalizeMapType(mType), tmdbId, tvdbId, traktId, malId)
 	return err
 }
 
 type BulkRecordMappingInputItem struct {
 	IMDBId  string
+	Type    string
 	TMDBId  string
 	TVDBId  string
 	TraktId string
@@ -107,22 +163,24 @@ type BulkRecordMappingInputItem struct {
 }
 
 var query_bulk_record_mapping_before_values = fmt.Sprintf(
-	`INSERT INTO %s AS itm (%s,%s,%s,%s,%s) VALUES `,
+	`INSERT INTO %s AS itm (%s,%s,%s,%s,%s,%s) VALUES `,
 	MapTableName,
 	MapColumn.IMDBId,
+	MapColumn.Type,
 	MapColumn.TMDBId,
 	MapColumn.TVDBId,
 	MapColumn.TraktId,
 	MapColumn.MALId,
 )
 var query_bulk_record_mapping_placeholder = fmt.Sprintf(
-	`(?,?,?,?,?)`,
+	`(?,?,?,?,?,?)`,
 )
 var query_bulk_record_mapping_after_values = fmt.Sprintf(
 	` ON CONFLICT (%s) DO UPDATE SET %s, %s = %s`,
 	MapColumn.IMDBId,
 	strings.Join(
 		[]string{
+			fmt.Sprintf("%s = CASE WHEN itm.%s = '' THEN EXCLUDED.%s ELSE itm.%s END", MapColumn.Type, MapColumn.Type, MapColumn.Type, MapColumn.Type),
 			fmt.Sprintf("%s = CASE WHEN itm.%s = '' THEN EXCLUDED.%s ELSE itm.%s END", MapColumn.TMDBId, MapColumn.TMDBId, MapColumn.TMDBId, MapColumn.TMDBId),
 			fmt.Sprintf("%s = CASE WHEN itm.%s = '' THEN EXCLUDED.%s ELSE itm.%s END", MapColumn.TVDBId, MapColumn.TVDBId, MapColumn.TVDBId, MapColumn.TVDBId),
 			fmt.Sprintf("%s = CASE WHEN itm.%s = '' THEN EXCLUDED.%s ELSE itm.%s END", MapColumn.TraktId, MapColumn.TraktId, MapColumn.TraktId, MapColumn.TraktId),
@@ -147,13 +205,14 @@ func BulkRecordMapping(items []BulkRecordMappingInputItem) {
 		util.RepeatJoin(query_bulk_record_mapping_placeholder, count, ",") +
 		query_bulk_record_mapping_after_values
 
-	args := make([]any, count*5)
+	args := make([]any, count*6)
 	for i, item := range items {
-		args[i*5+0] = item.IMDBId
-		args[i*5+1] = normalizeOptionalId(item.TMDBId)
-		args[i*5+2] = normalizeOptionalId(item.TVDBId)
-		args[i*5+3] = normalizeOptionalId(item.TraktId)
-		args[i*5+4] = normalizeOptionalId(item.MALId)
+		args[i*6+0] = item.IMDBId
+		args[i*6+1] = NormalizeMapType(item.Type)
+		args[i*6+2] = normalizeOptionalId(item.TMDBId)
+		args[i*6+3] = normalizeOptionalId(item.TVDBId)
+		args[i*6+4] = normalizeOptionalId(item.TraktId)
+		args[i*6+5] = normalizeOptionalId(item.MALId)
 	}
 
 	_, err := db.Exec(query, args...)
@@ -0,0 +1,112 @@
+package imdb_title
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+const RatingTableName = "imdb_title_rating"
+
+type IMDBTitleRating struct {
+	TId    string  `json:"tid"`
+	Rating float64 `json:"rating"`
+	Votes  int     `json:"votes"`
+}
+
+func (r IMDBTitleRating) String() string {
+	return strconv.FormatFloat(r.Rating, 'f', 1, 32)
+}
+
+type RatingColumnStruct struct {
+	TId    string
+	Rating string
+	Votes  string
+}
+
+var RatingColumn = RatingColumnStruct{
+	TId:    "tid",
+	Rating: "rating",
+	Votes:  "votes",
+}
+
+var RatingColumns = []string{
+	RatingColumn.TId,
+	RatingColumn.Rating,
+	RatingColumn.Votes,
+}
+
+var query_upsert_ratings_before_values = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES `,
+	RatingTableName,
+	strings.Join(RatingColumns, ","),
+)
+var query_upsert_ratings_values_placeholder = "(" + util.RepeatJoin("?", len(RatingColumns), ",") + ")"
+var query_upsert_ratings_after_values = fmt.Sprintf(
+	` ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s`,
+	RatingColumn.TId,
+	RatingColumn.Rating,
+	RatingColumn.Rating,
+	RatingColumn.Votes,
+	RatingColumn.Votes,
+)
+
+func UpsertRatings(ratings []IMDBTitleRating) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	query := query_upsert_ratings_before_values +
+		util.RepeatJoin(query_upsert_ratings_values_placeholder, len(ratings), ",") +
+		query_upsert_ratings_after_values
+	args := make([]any, 0, len(ratings)*len(RatingColumns))
+	for _, r := range ratings {
+		args = append(args, r.TId, r.Rating, r.Votes)
+	}
+
+	_, err := db.Exec(query, args...)
+	return err
+}
+
+var query_get_ratings_by_ids = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s IN `,
+	strings.Join(RatingColumns, ","),
+	RatingTableName,
+	RatingColumn.TId,
+)
+
+func GetRatingsByIds(tids []string) (map[string]IMDBTitleRating, error) {
+	count := len(tids)
+	ratingById := make(map[string]IMDBTitleRating, count)
+	if count == 0 {
+		return ratingById, nil
+	}
+
+	query := query_get_ratings_by_ids + "(" + util.RepeatJoin("?", count, ",") + ")"
+	args := make([]any, count)
+	for i, tid := range tids {
+		args[i] = tid
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rating IMDBTitleRating
+		if err := rows.Scan(&rating.TId, &rating.Rating, &rating.Votes); err != nil {
+			return nil, err
+		}
+		ratingById[rating.TId] = rating
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ratingById, nil
+}
@@ -0,0 +1,231 @@
+package imdb_title
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/anime"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+var query_get_imdb_id_by_map_column = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE `,
+	MapColumn.IMDBId,
+	MapTableName,
+)
+
+var query_get_imdb_id_by_map_column_type_cond = fmt.Sprintf(
+	` = ? AND %s IN (?, '') ORDER BY %s DESC LIMIT 1`,
+	MapColumn.Type,
+	MapColumn.Type,
+)
+
+// getIMDBIdByMapColumn prefers the title with the same type. Titles with
+// unknown type are used only if there is none.
+func getIMDBIdByMapColumn(column, id string, mType IMDBTitleMapType) (string, error) {
+	var imdbId string
+	var row *sql.Row
+	if mType == IMDBTitleMapTypeUnknown {
+		row = db.QueryRow(query_get_imdb_id_by_map_column+column+" = ? LIMIT 1", id)
+	} else {
+		row = db.QueryRow(query_get_imdb_id_by_map_column+column+query_get_imdb_id_by_map_column_type_cond, id, mType)
+	}
+	if err := row.Scan(&imdbId); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return imdbId, nil
+}
+
+func getMapType(contentType stremio.ContentType, specs string) IMDBTitleMapType {
+	switch contentType {
+	case stremio.ContentTypeMovie:
+		return IMDBTitleMapTypeMovie
+	case stremio.ContentTypeSeries:
+		return IMDBTitleMapTypeShow
+	}
+	if specs != "" {
+		return IMDBTitleMapTypeShow
+	}
+	return IMDBTitleMapTypeUnknown
+}
+
+func isAnimeIdMapTypeMismatch(mType IMDBTitleMapType, animeType anime.AnimeIdMapType) bool {
+	switch mType {
+	case IMDBTitleMapTypeMovie:
+		return animeType == anime.AnimeIdMapTypeTV || animeType == anime.AnimeIdMapTypeTVShort
+	case IMDBTitleMapTypeShow:
+		return animeType == anime.AnimeIdMapTypeMovie
+	}
+	return false
+}
+
+// ResolveStremId returns the IMDb strem id, i.e. `tt…[:<season>:<episode>]`,
+// for `tmdb:`, `tvdb:` and anime strem ids. The content type is used to pick
+// between movie and tv ids, and can be empty if unknown. It returns empty
+// string if the id can't be resolved.
+func ResolveStremId(contentType stremio.ContentType, stremId string) (string, error) {
+	if strings.HasPrefix(stremId, "tt") {
+		return stremId, nil
+	}
+
+	if column, animeId, episode, ok := anime.ParseStremId(stremId); ok {
+		idMap, err := anime.GetIdMap(column, animeId)
+		if err != nil || idMap == nil || idMap.IMDB == "" {
+			return "", err
+		}
+		if episode == "" {
+			return idMap.IMDB, nil
+		}
+		_, season, err := anime.GetAniDBIdById(column, animeId)
+		if err != nil {
+			return "", err
+		}
+		if season == "" {
+			season = "1"
+		}
+		return idMap.IMDB + ":" + season + ":" + episode, nil
+	}
+
+	service, rest, _ := strings.Cut(stremId, ":")
+	mapColumn, idMapColumn := "", ""
+	switch service {
+	case "tmdb":
+		mapColumn, idMapColumn = MapColumn.TMDBId, anime.IdMapColumn.TMDB
+	case "tvdb":
+		mapColumn, idMapColumn = MapColumn.TVDBId, anime.IdMapColumn.TVDB
+	default:
+		return "", nil
+	}
+
+	id, specs, _ := strings.Cut(rest, ":")
+	mType := getMapType(contentType, specs)
+	imdbId, err := getIMDBIdByMapColumn(mapColumn, id, mType)
+	if err != nil {
+		return "", err
+	}
+	if imdbId == "" {
+		idMap, err := anime.GetIdMap(idMapColumn, id)
+		if err != nil {
+			return "", err
+		}
+		if idMap != nil && !isAnimeIdMapTypeMismatch(mType, idMap.Type) {
+			imdbId = idMap.IMDB
+		}
+	}
+	if imdbId == "" {
+		return "", nil
+	}
+	if specs != "" {
+		imdbId += ":" + specs
+	}
+	return imdbId, nil
+}
+
+var query_get_imdb_ids_by_map_column = fmt.Sprintf(
+	`SELECT %s, %s, `,
+	MapColumn.IMDBId,
+	MapColumn.Type,
+)
+
+// getIMDBIdsByMapColumn is the batched getIMDBIdByMapColumn.
+func getIMDBIdsByMapColumn(column string, ids []string, mType IMDBTitleMapType) (map[string]string, error) {
+	imdbIdById := make(map[string]string, len(ids))
+	for cIds := range slices.Chunk(ids, 500) {
+		query := query_get_imdb_ids_by_map_column + column + " FROM " + MapTableName + " WHERE " + column + " IN (" + util.RepeatJoin("?", len(cIds), ",") + ")"
+		args := make([]any, 0, len(cIds)+1)
+		for _, id := range cIds {
+			args = append(args, id)
+		}
+		if mType != IMDBTitleMapTypeUnknown {
+			query += " AND " + MapColumn.Type + " IN (?, '')"
+			args = append(args, mType)
+		}
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var imdbId, tType, id string
+			if err := rows.Scan(&imdbId, &tType, &id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if _, ok := imdbIdById[id]; !ok || tType != IMDBTitleMapTypeUnknown {
+				imdbIdById[id] = imdbId
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return imdbIdById, nil
+}
+
+// ResolveStremIds is the batched ResolveStremId, for the strem ids of titles
+// (without season or episode). The `tmdb:` and `tvdb:` ids are resolved
+// together, and the rest one by one. Unresolved ids are not in the result.
+func ResolveStremIds(contentType stremio.ContentType, stremIds []string) (map[string]string, error) {
+	imdbIdByStremId := make(map[string]string, len(stremIds))
+
+	mType := getMapType(contentType, "")
+	idsByMapColumn := map[string][]string{}
+	remaining := []string{}
+	for _, stremId := range stremIds {
+		if strings.HasPrefix(stremId, "tt") {
+			imdbIdByStremId[stremId] = stremId
+			continue
+		}
+		service, id, _ := strings.Cut(stremId, ":")
+		if id == "" || strings.Contains(id, ":") {
+			remaining = append(remaining, stremId)
+			continue
+		}
+		switch service {
+		case "tmdb":
+			idsByMapColumn[MapColumn.TMDBId] = append(idsByMapColumn[MapColumn.TMDBId], id)
+		case "tvdb":
+			idsByMapColumn[MapColumn.TVDBId] = append(idsByMapColumn[MapColumn.TVDBId], id)
+		default:
+			remaining = append(remaining, stremId)
+		}
+	}
+
+	for column, ids := range idsByMapColumn {
+		imdbIdById, err := getIMDBIdsByMapColumn(column, ids, mType)
+		if err != nil {
+			return nil, err
+		}
+		service := "tmdb:"
+		if column == MapColumn.TVDBId {
+			service = "tvdb:"
+		}
+		for _, id := range ids {
+			if imdbId, ok := imdbIdById[id]; ok {
+				imdbIdByStremId[service+id] = imdbId
+			} else {
+				// falls back to the anime id map
+				remaining = append(remaining, service+id)
+			}
+		}
+	}
+
+	for _, stremId := range remaining {
+		imdbId, err := ResolveStremId(contentType, stremId)
+		if err != nil {
+			return nil, err
+		}
+		if imdbId != "" {
+			imdbIdByStremId[stremId] = imdbId
+		}
+	}
+	return imdbIdByStremId, nil
+}
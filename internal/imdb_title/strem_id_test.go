@@ -0,0 +1,71 @@
+package imdb_title
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveStremId(t *testing.T) {
+	dbtest.Setup(t)
+
+	BulkRecordMapping([]BulkRecordMappingInputItem{
+		{IMDBId: "tt9200001", Type: "movie", TMDBId: "920001"},
+		{IMDBId: "tt9200002", Type: "show", TMDBId: "920001", TVDBId: "920002"},
+		{IMDBId: "tt9200003", TMDBId: "920003"},
+	})
+
+	for _, tc := range []struct {
+		name        string
+		contentType stremio.ContentType
+		stremId     string
+		expected    string
+	}{
+		{"imdb", stremio.ContentTypeMovie, "tt9200001", "tt9200001"},
+		{"tmdb movie", stremio.ContentTypeMovie, "tmdb:920001", "tt9200001"},
+		{"tmdb series", stremio.ContentTypeSeries, "tmdb:920001", "tt9200002"},
+		{"tmdb episode", "", "tmdb:920001:1:2", "tt9200002:1:2"},
+		{"tvdb episode", stremio.ContentTypeSeries, "tvdb:920002:1:2", "tt9200002:1:2"},
+		{"unknown type", stremio.ContentTypeMovie, "tmdb:920003", "tt9200003"},
+		{"missing", stremio.ContentTypeMovie, "tmdb:929999", ""},
+		{"unsupported", stremio.ContentTypeMovie, "trakt:920001", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			imdbId, err := ResolveStremId(tc.contentType, tc.stremId)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, imdbId)
+		})
+	}
+}
+
+func TestResolveStremIds(t *testing.T) {
+	dbtest.Setup(t)
+
+	BulkRecordMapping([]BulkRecordMappingInputItem{
+		{IMDBId: "tt9200001", Type: "movie", TMDBId: "920001"},
+		{IMDBId: "tt9200002", Type: "show", TMDBId: "920001", TVDBId: "920002"},
+		{IMDBId: "tt9200003", TMDBId: "920003"},
+	})
+
+	imdbIdByStremId, err := ResolveStremIds(stremio.ContentTypeSeries, []string{
+		"tt9200001",
+		"tmdb:920001",
+		"tvdb:920002",
+		"tmdb:920003",
+		"tmdb:929999",
+		"trakt:920001",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"tt9200001":   "tt9200001",
+		"tmdb:920001": "tt9200002",
+		"tvdb:920002": "tt9200002",
+		"tmdb:920003": "tt9200003",
+	}, imdbIdByStremId)
+
+	imdbIdByStremId, err = ResolveStremIds(stremio.ContentTypeMovie, []string{"tmdb:920001"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"tmdb:920001": "tt9200001"}, imdbIdByStremId)
+}
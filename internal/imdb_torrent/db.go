@@ -83,3 +83,45 @@ func GetLastMappedIMDBId() (string, error) {
 	err := row.Scan(&lastIMDBId)
 	return lastIMDBId, err
 }
+
+var query_get_imdb_id_by_hashes_before_values = fmt.Sprintf(
+	"SELECT %s, %s FROM %s WHERE %s != '' AND %s IN ",
+	Column.Hash,
+	Column.TId,
+	TableName,
+	Column.TId,
+	Column.Hash,
+)
+
+// GetIMDBIdByHashes returns the mapped imdb id by hash. Unmapped hashes are
+// not included.
+func GetIMDBIdByHashes(hashes []string) (map[string]string, error) {
+	imdbIdByHash := make(map[string]string, len(hashes))
+	for cHashes := range slices.Chunk(hashes, 500) {
+		count := len(cHashes)
+		args := make([]any, count)
+		for i, hash := range cHashes {
+			args[i] = hash
+		}
+
+		query := query_get_imdb_id_by_hashes_before_values + "(" + util.RepeatJoin("?", count, ",") + ")"
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var hash, tid string
+			if err := rows.Scan(&hash, &tid); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			imdbIdByHash[hash] = tid
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return imdbIdByHash, nil
+}
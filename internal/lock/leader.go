@@ -0,0 +1,64 @@
+package lock
+
+import (
+	"time"
+)
+
+// Leader campaigns for a lock in background, so that only one of the
+// instances sharing the database is the leader at a time. When the leader
+// goes away, another instance takes over once its lease expires.
+//
+// It campaigns with SQLite too, since multiple processes can share the same
+// database file.
+type Leader struct {
+	lock *Lock
+	stop chan struct{}
+}
+
+func (l *Leader) campaign() {
+	wasLeader := l.lock.IsHeld()
+	ok, err := l.lock.TryAcquire()
+	if err != nil {
+		log.Warn("failed to campaign for leadership", "name", l.lock.GetName(), "error", err)
+		return
+	}
+	if ok && !wasLeader {
+		log.Info("elected as leader", "name", l.lock.GetName())
+	}
+}
+
+func (l *Leader) IsLeader() bool {
+	return l.lock.IsHeld()
+}
+
+func (l *Leader) Stop() {
+	close(l.stop)
+	if err := l.lock.Release(); err != nil {
+		log.Warn("failed to release leadership", "name", l.lock.GetName(), "error", err)
+	}
+}
+
+func NewLeader(name string) *Leader {
+	leader := &Leader{
+		lock: NewLock(&LockConfig{Name: "leader:" + name}),
+		stop: make(chan struct{}),
+	}
+
+	leader.campaign()
+
+	go func() {
+		ticker := time.NewTicker(leader.lock.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-leader.stop:
+				return
+			case <-ticker.C:
+				leader.campaign()
+			}
+		}
+	}()
+
+	return leader
+}
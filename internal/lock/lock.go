@@ -0,0 +1,209 @@
+package lock
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+)
+
+const TableName = "distributed_lock"
+
+type ColumnStruct struct {
+	Name      string
+	Owner     string
+	ExpiresAt string
+	CreatedAt string
+	UpdatedAt string
+}
+
+var Column = ColumnStruct{
+	Name:      "name",
+	Owner:     "owner",
+	ExpiresAt: "eat",
+	CreatedAt: "cat",
+	UpdatedAt: "uat",
+}
+
+var query_acquire = fmt.Sprintf(
+	`INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?) ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = %s WHERE %s.%s = EXCLUDED.%s OR %s.%s < ?`,
+	TableName,
+	Column.Name,
+	Column.Owner,
+	Column.ExpiresAt,
+	Column.Name,
+	Column.Owner,
+	Column.Owner,
+	Column.ExpiresAt,
+	Column.ExpiresAt,
+	Column.UpdatedAt,
+	db.CurrentTimestamp,
+	TableName,
+	Column.Owner,
+	Column.Owner,
+	TableName,
+	Column.ExpiresAt,
+)
+
+func acquire(name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	result, err := db.Exec(query_acquire, name, owner, db.Timestamp{Time: now.Add(ttl)}, db.Timestamp{Time: now})
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+var query_release = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ? AND %s = ?`,
+	TableName,
+	Column.Name,
+	Column.Owner,
+)
+
+func release(name, owner string) error {
+	_, err := db.Exec(query_release, name, owner)
+	return err
+}
+
+var query_get_owner = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ? AND %s > ?`,
+	Column.Owner,
+	TableName,
+	Column.Name,
+	Column.ExpiresAt,
+)
+
+// GetOwner returns the owner of the named lock, or an empty string if it
+// is not held by anyone.
+func GetOwner(name string) (string, error) {
+	var owner string
+	row := db.QueryRow(query_get_owner, name, db.Timestamp{Time: time.Now()})
+	if err := row.Scan(&owner); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return owner, nil
+}
+
+// Lock is a lease stored in the database, shared by every instance using
+// the same database. The lease is kept alive in background while held, and
+// expires after TTL if the holder goes away without releasing it.
+type Lock struct {
+	name  string
+	owner string
+	ttl   time.Duration
+	m     sync.Mutex
+	held  bool
+	stop  chan struct{}
+}
+
+type LockConfig struct {
+	Name string
+	TTL  time.Duration
+}
+
+func NewLock(conf *LockConfig) *Lock {
+	if conf.Name == "" {
+		panic("lock name is required")
+	}
+	if conf.TTL == 0 {
+		conf.TTL = 30 * time.Second
+	}
+	return &Lock{
+		name:  conf.Name,
+		owner: config.InstanceId,
+		ttl:   conf.TTL,
+	}
+}
+
+func (l *Lock) GetName() string {
+	return l.name
+}
+
+func (l *Lock) IsHeld() bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.held
+}
+
+// TryAcquire acquires the lock if it is free or expired. It does not block.
+func (l *Lock) TryAcquire() (bool, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.held {
+		return true, nil
+	}
+
+	ok, err := acquire(l.name, l.owner, l.ttl)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	l.held = true
+	l.stop = make(chan struct{})
+	go l.keepAlive(l.stop)
+
+	return true, nil
+}
+
+func (l *Lock) keepAlive(stop chan struct{}) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	lastRenewed := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ok, err := acquire(l.name, l.owner, l.ttl)
+			if err != nil {
+				log.Warn("failed to renew lease", "name", l.name, "error", err)
+				if time.Since(lastRenewed) < l.ttl {
+					continue
+				}
+				// the lease may have been taken over by now
+				log.Warn("lease expired", "name", l.name)
+			} else if ok {
+				lastRenewed = time.Now()
+				continue
+			} else {
+				log.Warn("lost lease", "name", l.name)
+			}
+			l.m.Lock()
+			if l.stop == stop {
+				l.held = false
+				l.stop = nil
+			}
+			l.m.Unlock()
+			return
+		}
+	}
+}
+
+func (l *Lock) Release() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if !l.held {
+		return nil
+	}
+
+	close(l.stop)
+	l.stop = nil
+	l.held = false
+
+	return release(l.name, l.owner)
+}
@@ -0,0 +1,5 @@
+package lock
+
+import "github.com/MunifTanjim/stremthru/internal/logger"
+
+var log = logger.Scoped("lock")
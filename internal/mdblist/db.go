@@ -500,7 +500,7 @@ func upsertItems(tx *db.Tx, items []MDBListItem) error {
 			if err != nil {
 				return err
 			}
-			err = imdb_title.RecordMappingFromMDBList(tx, item.IMDBId, item.TmdbId, item.TvdbId, "", "")
+			err = imdb_title.RecordMappingFromMDBList(tx, item.IMDBId, string(item.Mediatype), item.TmdbId, item.TvdbId, "", "")
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,64 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/request"
+	"golang.org/x/oauth2"
+)
+
+var AniListTokenSourceConfig = TokenSourceConfig{
+	Provider: ProviderAniList,
+	GetUser: func(client *http.Client, oauthConfig *oauth2.Config) (userId, userName string, err error) {
+		body, err := json.Marshal(map[string]string{
+			"query": "query { Viewer { id name } }",
+		})
+		if err != nil {
+			return "", "", err
+		}
+		req, err := http.NewRequest("POST", "https://graphql.anilist.co", bytes.NewReader(body))
+		if err != nil {
+			return "", "", err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		res, err := client.Do(req)
+		var response struct {
+			responseError
+			Data struct {
+				Viewer struct {
+					Id   int    `json:"id"`
+					Name string `json:"name"`
+				} `json:"Viewer"`
+			} `json:"data"`
+		}
+		err = request.ProcessResponseBody(res, err, &response)
+		if err != nil {
+			return "", "", err
+		}
+		return strconv.Itoa(response.Data.Viewer.Id), response.Data.Viewer.Name, nil
+	},
+	PrepareToken: prepareToken(ProviderAniList),
+}
+
+var aniListOAuthConfig = oauth2.Config{
+	ClientID:     config.Integration.AniList.ClientId,
+	ClientSecret: config.Integration.AniList.ClientSecret,
+	Endpoint: oauth2.Endpoint{
+		AuthURL:   "https://anilist.co/api/v2/oauth/authorize",
+		TokenURL:  "https://anilist.co/api/v2/oauth/token",
+		AuthStyle: oauth2.AuthStyleInParams,
+	},
+	RedirectURL: config.BaseURL.JoinPath("/auth/" + string(ProviderAniList) + "/callback").String(),
+}
+
+var _ = registerProvider(&ProviderConfig{
+	Name:              "AniList",
+	IsEnabled:         config.Integration.AniList.IsEnabled,
+	OAuth:             &aniListOAuthConfig,
+	TokenSourceConfig: AniListTokenSourceConfig,
+})
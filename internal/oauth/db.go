@@ -18,6 +18,9 @@ type Provider string
 const (
 	ProviderTraktTv Provider = "trakt.tv"
 	ProviderKitsu   Provider = "kitsu.app"
+	ProviderAniList Provider = "anilist.co"
+	ProviderMAL     Provider = "myanimelist.net"
+	ProviderSimkl   Provider = "simkl.com"
 )
 
 type OAuthToken struct {
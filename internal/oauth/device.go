@@ -0,0 +1,100 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"golang.org/x/oauth2"
+)
+
+var ErrDeviceAuthPending = errors.New("authorization pending")
+
+// device codes are kept by state, till the token is issued or the code
+// expires
+var deviceAuthCache = cache.NewCache[oauth2.DeviceAuthResponse](&cache.CacheConfig{
+	Lifetime: 15 * time.Minute,
+	Name:     "oauth:device_auth",
+})
+
+func (p *ProviderConfig) SupportsDeviceAuth() bool {
+	return p.DeviceOAuth != nil && p.DeviceOAuth.Endpoint.DeviceAuthURL != ""
+}
+
+// DeviceAuth starts the device authorization, returning the code for the
+// user to enter at the verification url.
+func (p *ProviderConfig) DeviceAuth(state string) (*oauth2.DeviceAuthResponse, error) {
+	if !p.SupportsDeviceAuth() {
+		return nil, errors.New("device authorization not supported")
+	}
+	da, err := p.DeviceOAuth.DeviceAuth(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if err := deviceAuthCache.Add(state, *da); err != nil {
+		return nil, err
+	}
+	return da, nil
+}
+
+// isDeviceAuthPending also handles the providers (e.g. Trakt.tv) responding
+// with bare status code, instead of the standard error codes.
+func isDeviceAuthPending(err error) bool {
+	var rErr *oauth2.RetrieveError
+	if !errors.As(err, &rErr) {
+		return false
+	}
+	switch rErr.ErrorCode {
+	case "authorization_pending", "slow_down":
+		return true
+	case "":
+		if rErr.Response != nil {
+			return rErr.Response.StatusCode == http.StatusBadRequest || rErr.Response.StatusCode == http.StatusTooManyRequests
+		}
+	}
+	return false
+}
+
+// DeviceAccessToken polls for the token of the device authorization, upto
+// wait duration. It returns ErrDeviceAuthPending if the user has not
+// authorized yet.
+func (p *ProviderConfig) DeviceAccessToken(state string, wait time.Duration) (*oauth2.Token, error) {
+	if !p.SupportsDeviceAuth() {
+		return nil, errors.New("device authorization not supported")
+	}
+	da := oauth2.DeviceAuthResponse{}
+	if !deviceAuthCache.Get(state, &da) {
+		return nil, errors.New("invalid or expired state")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+
+	opts := []oauth2.AuthCodeOption{}
+	if p.DeviceCodeParam != "" {
+		opts = append(opts, oauth2.SetAuthURLParam(p.DeviceCodeParam, da.DeviceCode))
+	}
+
+	interval := time.Duration(max(da.Interval, 5)) * time.Second
+	for {
+		tok, err := p.DeviceOAuth.DeviceAccessToken(ctx, &da, opts...)
+		if err == nil {
+			deviceAuthCache.Remove(state)
+			return p.SaveToken(tok)
+		}
+		if ctx.Err() != nil {
+			return nil, ErrDeviceAuthPending
+		}
+		if !isDeviceAuthPending(err) {
+			deviceAuthCache.Remove(state)
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ErrDeviceAuthPending
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestIsDeviceAuthPending(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		err    error
+		result bool
+	}{
+		{"authorization_pending", &oauth2.RetrieveError{ErrorCode: "authorization_pending"}, true},
+		{"slow_down", &oauth2.RetrieveError{ErrorCode: "slow_down"}, true},
+		{"access_denied", &oauth2.RetrieveError{ErrorCode: "access_denied"}, false},
+		{"bare 400", &oauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusBadRequest}}, true},
+		{"bare 429", &oauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusTooManyRequests}}, true},
+		{"bare 410", &oauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusGone}}, false},
+		{"other error", errors.New("boom"), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.result, isDeviceAuthPending(test.err))
+		})
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/request"
-	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 )
 
@@ -101,6 +100,15 @@ var kitsuOAuthConfig = oauth2.Config{
 	},
 }
 
+var kitsuProvider = registerProvider(&ProviderConfig{
+	Name: "Kitsu",
+	IsEnabled: func() bool {
+		return config.Integration.Kitsu.ClientId != ""
+	},
+	OAuth:             &kitsuOAuthConfig,
+	TokenSourceConfig: KitsuTokenSourceConfig,
+})
+
 var KitsuOAuthConfig = OAuthConfig{
 	Config: kitsuOAuthConfig,
 	PasswordCredentialsToken: func(username, password string) (*oauth2.Token, error) {
@@ -108,60 +116,6 @@ var KitsuOAuthConfig = OAuthConfig{
 		if err != nil {
 			return nil, err
 		}
-
-		kitsuLog.Debug("fetching user info for new token")
-		userId, userName, err := KitsuTokenSourceConfig.GetUser(
-			oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(tok)),
-			&kitsuOAuthConfig,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		existingOTok, err := GetOAuthTokenByUserId(KitsuTokenSourceConfig.Provider, userId)
-		if err != nil {
-			return nil, err
-		}
-
-		if existingOTok != nil {
-			client := oauth2.NewClient(
-				context.Background(),
-				DatabaseTokenSource(&DatabaseTokenSourceConfig{
-					OAuth:             &kitsuOAuthConfig,
-					TokenSourceConfig: KitsuTokenSourceConfig,
-				}, existingOTok.ToToken()),
-			)
-
-			kitsuLog.Debug("fetching user info for existing token")
-			uId, _, err := KitsuTokenSourceConfig.GetUser(
-				client,
-				&kitsuOAuthConfig,
-			)
-			if err != nil || uId != userId {
-				existingOTok.AccessToken = ""
-				existingOTok.RefreshToken = ""
-				err = SaveOAuthToken(existingOTok)
-				if err != nil {
-					return nil, err
-				}
-				existingOTok = nil
-			}
-		}
-
-		tokenId := uuid.NewString()
-		if existingOTok != nil {
-			tokenId = existingOTok.Id
-		}
-
-		tok = KitsuTokenSourceConfig.PrepareToken(tok, tokenId, userId, userName)
-
-		otok := &OAuthToken{}
-		otok = otok.FromToken(tok)
-		err = SaveOAuthToken(otok)
-		if err != nil {
-			return nil, err
-		}
-
-		return tok, nil
+		return kitsuProvider.SaveToken(tok)
 	},
 }
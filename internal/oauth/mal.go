@@ -0,0 +1,52 @@
+package oauth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/request"
+	"golang.org/x/oauth2"
+)
+
+var MALTokenSourceConfig = TokenSourceConfig{
+	Provider: ProviderMAL,
+	GetUser: func(client *http.Client, oauthConfig *oauth2.Config) (userId, userName string, err error) {
+		req, err := http.NewRequest("GET", "https://api.myanimelist.net/v2/users/@me", nil)
+		if err != nil {
+			return "", "", err
+		}
+		res, err := client.Do(req)
+		var response struct {
+			responseError
+			Id   int    `json:"id"`
+			Name string `json:"name"`
+		}
+		err = request.ProcessResponseBody(res, err, &response)
+		if err != nil {
+			return "", "", err
+		}
+		return strconv.Itoa(response.Id), response.Name, nil
+	},
+	PrepareToken: prepareToken(ProviderMAL),
+}
+
+var malOAuthConfig = oauth2.Config{
+	ClientID:     config.Integration.MAL.ClientId,
+	ClientSecret: config.Integration.MAL.ClientSecret,
+	Endpoint: oauth2.Endpoint{
+		AuthURL:   "https://myanimelist.net/v1/oauth2/authorize",
+		TokenURL:  "https://myanimelist.net/v1/oauth2/token",
+		AuthStyle: oauth2.AuthStyleInParams,
+	},
+	RedirectURL: config.BaseURL.JoinPath("/auth/" + string(ProviderMAL) + "/callback").String(),
+}
+
+// MyAnimeList only supports the `plain` PKCE method.
+var _ = registerProvider(&ProviderConfig{
+	Name:              "MyAnimeList",
+	IsEnabled:         config.Integration.MAL.IsEnabled,
+	PKCEMethod:        PKCEMethodPlain,
+	OAuth:             &malOAuthConfig,
+	TokenSourceConfig: MALTokenSourceConfig,
+})
@@ -0,0 +1,231 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+type PKCEMethod string
+
+const (
+	PKCEMethodNone  PKCEMethod = ""
+	PKCEMethodPlain PKCEMethod = "plain"
+	PKCEMethodS256  PKCEMethod = "S256"
+)
+
+type ProviderConfig struct {
+	Name       string
+	IsEnabled  func() bool
+	PKCEMethod PKCEMethod
+	OAuth      *oauth2.Config
+	// for device authorization, if supported
+	DeviceOAuth *oauth2.Config
+	// name of the device code param for the token request, if non-standard
+	DeviceCodeParam string
+	TokenSourceConfig
+}
+
+var providers = map[Provider]*ProviderConfig{}
+
+func registerProvider(p *ProviderConfig) *ProviderConfig {
+	providers[p.Provider] = p
+	return p
+}
+
+func GetProvider(provider Provider) *ProviderConfig {
+	return providers[provider]
+}
+
+// pkce verifiers are kept by state, till the authorization code is exchanged
+var pkceVerifierCache = cache.NewCache[string](&cache.CacheConfig{
+	Lifetime: 15 * time.Minute,
+	Name:     "oauth:pkce_verifier",
+})
+
+func (p *ProviderConfig) AuthCodeURL(state string) string {
+	opts := []oauth2.AuthCodeOption{}
+	if p.PKCEMethod != PKCEMethodNone {
+		verifier := oauth2.GenerateVerifier()
+		if err := pkceVerifierCache.Add(state, verifier); err != nil {
+			log.Error("failed to cache pkce verifier", "error", err, "provider", p.Provider)
+		}
+		switch p.PKCEMethod {
+		case PKCEMethodPlain:
+			opts = append(opts,
+				oauth2.SetAuthURLParam("code_challenge_method", string(PKCEMethodPlain)),
+				oauth2.SetAuthURLParam("code_challenge", verifier),
+			)
+		case PKCEMethodS256:
+			opts = append(opts, oauth2.S256ChallengeOption(verifier))
+		}
+	}
+	return p.OAuth.AuthCodeURL(state, opts...)
+}
+
+func (p *ProviderConfig) Exchange(code, state string) (*oauth2.Token, error) {
+	opts := []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("state", state)}
+	if p.PKCEMethod != PKCEMethodNone {
+		verifier := ""
+		if !pkceVerifierCache.Get(state, &verifier) {
+			return nil, errors.New("invalid or expired state")
+		}
+		pkceVerifierCache.Remove(state)
+		opts = append(opts, oauth2.VerifierOption(verifier))
+	}
+
+	tok, err := p.OAuth.Exchange(context.Background(), code, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return p.SaveToken(tok)
+}
+
+// SaveToken saves the new token for the user, reusing the id of the existing
+// token of the user if it is still valid.
+func (p *ProviderConfig) SaveToken(tok *oauth2.Token) (*oauth2.Token, error) {
+	log.Debug("fetching user info for new token", "provider", p.Provider)
+	userId, userName, err := p.GetUser(
+		oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(tok)),
+		p.OAuth,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	existingOTok, err := GetOAuthTokenByUserId(p.Provider, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingOTok != nil {
+		client := oauth2.NewClient(
+			context.Background(),
+			DatabaseTokenSource(&DatabaseTokenSourceConfig{
+				OAuth:             p.OAuth,
+				TokenSourceConfig: p.TokenSourceConfig,
+			}, existingOTok.ToToken()),
+		)
+
+		log.Debug("fetching user info for existing token", "provider", p.Provider)
+		uId, _, err := p.GetUser(client, p.OAuth)
+		if err != nil || uId != userId {
+			existingOTok.AccessToken = ""
+			existingOTok.RefreshToken = ""
+			err = SaveOAuthToken(existingOTok)
+			if err != nil {
+				return nil, err
+			}
+			existingOTok = nil
+		}
+	}
+
+	tokenId := uuid.NewString()
+	if existingOTok != nil {
+		tokenId = existingOTok.Id
+	}
+
+	tok = p.PrepareToken(tok, tokenId, userId, userName)
+
+	otok := &OAuthToken{}
+	otok = otok.FromToken(tok)
+	err = SaveOAuthToken(otok)
+	if err != nil {
+		return nil, err
+	}
+
+	return tok, nil
+}
+
+// TokenSource returns the token source for the saved token, or nil if not
+// found.
+func (p *ProviderConfig) TokenSource(tokenId string) oauth2.TokenSource {
+	otok, err := GetOAuthTokenById(tokenId)
+	if err != nil {
+		log.Error("failed to get token", "error", err, "provider", p.Provider, "id", tokenId)
+		return nil
+	}
+	if otok == nil || otok.Provider != p.Provider {
+		return nil
+	}
+	return DatabaseTokenSource(&DatabaseTokenSourceConfig{
+		OAuth:             p.OAuth,
+		TokenSourceConfig: p.TokenSourceConfig,
+	}, otok.ToToken())
+}
+
+// prepareToken is the PrepareToken for providers not necessarily including
+// `created_at`, `scope` or `expires_in` in the token response.
+func prepareToken(provider Provider) func(tok *oauth2.Token, id, userId, userName string) *oauth2.Token {
+	return func(tok *oauth2.Token, id, userId, userName string) *oauth2.Token {
+		scope, _ := tok.Extra("scope").(string)
+		createdAt := time.Now()
+		if ts, ok := tok.Extra("created_at").(float64); ok {
+			createdAt = time.Unix(int64(ts), 0)
+		} else if ts, ok := tok.Extra("created_at").(time.Time); ok {
+			createdAt = ts
+		}
+		tok = tok.WithExtra(map[string]any{
+			"id":         id,
+			"provider":   provider,
+			"user_id":    userId,
+			"user_name":  userName,
+			"scope":      scope,
+			"created_at": createdAt,
+		})
+		if tok.Expiry.IsZero() {
+			// non-expiring token, saved with distant expiry as it is required
+			tok.Expiry = createdAt.AddDate(100, 0, 0)
+		}
+		return tok
+	}
+}
+
+type responseError struct {
+	Err     string `json:"error,omitempty"`
+	ErrDesc string `json:"error_description,omitempty"`
+	Message string `json:"message,omitempty"`
+	Errors  []struct {
+		Message string `json:"message"`
+	} `json:"errors,omitempty"`
+}
+
+func (e *responseError) Error() string {
+	ret, _ := json.Marshal(e)
+	return string(ret)
+}
+
+func (e *responseError) Unmarshal(res *http.Response, body []byte, v any) error {
+	contentType := res.Header.Get("Content-Type")
+	switch {
+	case strings.Contains(contentType, "application/json"):
+		return core.UnmarshalJSON(res.StatusCode, body, v)
+	case strings.Contains(contentType, "text/html"), strings.Contains(contentType, "text/plain"):
+		if res.StatusCode >= http.StatusBadRequest {
+			errMsg := strings.TrimSpace(string(body))
+			if errMsg == "" {
+				errMsg = res.Status
+			}
+			return errors.New(errMsg)
+		}
+		fallthrough
+	default:
+		return fmt.Errorf("unexpected content type: %s", contentType)
+	}
+}
+
+func (r *responseError) GetError(res *http.Response) error {
+	if r == nil || (r.Err == "" && len(r.Errors) == 0) {
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestProviderAuthCodeURL(t *testing.T) {
+	for _, test := range []struct {
+		method          PKCEMethod
+		challengeMethod string
+	}{
+		{PKCEMethodNone, ""},
+		{PKCEMethodPlain, "plain"},
+		{PKCEMethodS256, "S256"},
+	} {
+		t.Run(string(test.method), func(t *testing.T) {
+			p := &ProviderConfig{
+				PKCEMethod: test.method,
+				OAuth: &oauth2.Config{
+					ClientID: "client",
+					Endpoint: oauth2.Endpoint{AuthURL: "https://example.com/authorize"},
+				},
+			}
+			state := "state-" + string(test.method)
+			authUrl, err := url.Parse(p.AuthCodeURL(state))
+			assert.NoError(t, err)
+
+			q := authUrl.Query()
+			assert.Equal(t, state, q.Get("state"))
+			assert.Equal(t, test.challengeMethod, q.Get("code_challenge_method"))
+
+			verifier := ""
+			assert.Equal(t, test.method != PKCEMethodNone, pkceVerifierCache.Get(state, &verifier))
+			switch test.method {
+			case PKCEMethodPlain:
+				assert.Equal(t, verifier, q.Get("code_challenge"))
+			case PKCEMethodS256:
+				assert.Equal(t, oauth2.S256ChallengeFromVerifier(verifier), q.Get("code_challenge"))
+			}
+		})
+	}
+}
+
+func TestPrepareToken(t *testing.T) {
+	createdAt := time.Unix(1700000000, 0)
+
+	tok := (&oauth2.Token{AccessToken: "access"}).WithExtra(map[string]any{
+		"created_at": float64(createdAt.Unix()),
+	})
+	tok = prepareToken(ProviderSimkl)(tok, "id", "42", "name")
+	assert.Equal(t, ProviderSimkl, tok.Extra("provider"))
+	assert.Equal(t, "42", tok.Extra("user_id"))
+	assert.Equal(t, "", tok.Extra("scope"))
+	assert.Equal(t, createdAt, tok.Extra("created_at"))
+	assert.Equal(t, createdAt.AddDate(100, 0, 0), tok.Expiry)
+
+	expiry := time.Now().Add(time.Hour)
+	tok = (&oauth2.Token{AccessToken: "access", Expiry: expiry}).WithExtra(map[string]any{
+		"scope": "read write",
+	})
+	tok = prepareToken(ProviderMAL)(tok, "id", "42", "name")
+	assert.Equal(t, "read write", tok.Extra("scope"))
+	assert.Equal(t, expiry, tok.Expiry)
+}
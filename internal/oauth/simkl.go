@@ -0,0 +1,56 @@
+package oauth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/request"
+	"golang.org/x/oauth2"
+)
+
+var SimklTokenSourceConfig = TokenSourceConfig{
+	Provider: ProviderSimkl,
+	GetUser: func(client *http.Client, oauthConfig *oauth2.Config) (userId, userName string, err error) {
+		req, err := http.NewRequest("POST", "https://api.simkl.com/users/settings", nil)
+		if err != nil {
+			return "", "", err
+		}
+		req.Header.Set("simkl-api-key", oauthConfig.ClientID)
+		res, err := client.Do(req)
+		var response struct {
+			responseError
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+			Account struct {
+				Id int `json:"id"`
+			} `json:"account"`
+		}
+		err = request.ProcessResponseBody(res, err, &response)
+		if err != nil {
+			return "", "", err
+		}
+		return strconv.Itoa(response.Account.Id), response.User.Name, nil
+	},
+	PrepareToken: prepareToken(ProviderSimkl),
+}
+
+var simklOAuthConfig = oauth2.Config{
+	ClientID:     config.Integration.Simkl.ClientId,
+	ClientSecret: config.Integration.Simkl.ClientSecret,
+	Endpoint: oauth2.Endpoint{
+		AuthURL:   "https://simkl.com/oauth/authorize",
+		TokenURL:  "https://api.simkl.com/oauth/token",
+		AuthStyle: oauth2.AuthStyleInParams,
+	},
+	RedirectURL: config.BaseURL.JoinPath("/auth/" + string(ProviderSimkl) + "/callback").String(),
+}
+
+// Simkl tokens do not expire.
+var _ = registerProvider(&ProviderConfig{
+	Name:              "Simkl",
+	IsEnabled:         config.Integration.Simkl.IsEnabled,
+	OAuth:             &simklOAuthConfig,
+	TokenSourceConfig: SimklTokenSourceConfig,
+})
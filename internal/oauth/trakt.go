@@ -1,7 +1,6 @@
 package oauth
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,7 +11,6 @@ import (
 	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/request"
-	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 )
 
@@ -101,67 +99,29 @@ var traktOAuthConfig = oauth2.Config{
 	RedirectURL: config.BaseURL.JoinPath("/auth/trakt.tv/callback").String(),
 }
 
+// device token is issued from a separate endpoint
+var traktDeviceOAuthConfig = oauth2.Config{
+	ClientID:     config.Integration.Trakt.ClientId,
+	ClientSecret: config.Integration.Trakt.ClientSecret,
+	Endpoint: oauth2.Endpoint{
+		DeviceAuthURL: "https://api.trakt.tv/oauth/device/code",
+		TokenURL:      "https://api.trakt.tv/oauth/device/token",
+		AuthStyle:     oauth2.AuthStyleInParams,
+	},
+}
+
+var traktProvider = registerProvider(&ProviderConfig{
+	Name:              "Trakt.tv",
+	IsEnabled:         config.Integration.Trakt.IsEnabled,
+	OAuth:             &traktOAuthConfig,
+	DeviceOAuth:       &traktDeviceOAuthConfig,
+	DeviceCodeParam:   "code",
+	TokenSourceConfig: TraktTokenSourceConfig,
+})
+
 var TraktOAuthConfig = OAuthConfig{
 	Config: traktOAuthConfig,
 	Exchange: func(code, state string) (*oauth2.Token, error) {
-		tok, err := traktOAuthConfig.Exchange(context.Background(), code, oauth2.SetAuthURLParam("state", state))
-		if err != nil {
-			return nil, err
-		}
-
-		traktLog.Debug("fetching user info for new token")
-		userId, userName, err := TraktTokenSourceConfig.GetUser(
-			oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(tok)),
-			&traktOAuthConfig,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		existingOTok, err := GetOAuthTokenByUserId(TraktTokenSourceConfig.Provider, userId)
-		if err != nil {
-			return nil, err
-		}
-
-		if existingOTok != nil {
-			client := oauth2.NewClient(
-				context.Background(),
-				DatabaseTokenSource(&DatabaseTokenSourceConfig{
-					OAuth:             &traktOAuthConfig,
-					TokenSourceConfig: TraktTokenSourceConfig,
-				}, existingOTok.ToToken()),
-			)
-
-			traktLog.Debug("fetching user info for existing token")
-			uId, _, err := TraktTokenSourceConfig.GetUser(
-				client,
-				&traktOAuthConfig,
-			)
-			if err != nil || uId != userId {
-				existingOTok.AccessToken = ""
-				existingOTok.RefreshToken = ""
-				err = SaveOAuthToken(existingOTok)
-				if err != nil {
-					return nil, err
-				}
-				existingOTok = nil
-			}
-		}
-
-		tokenId := uuid.NewString()
-		if existingOTok != nil {
-			tokenId = existingOTok.Id
-		}
-
-		tok = TraktTokenSourceConfig.PrepareToken(tok, tokenId, userId, userName)
-
-		otok := &OAuthToken{}
-		otok = otok.FromToken(tok)
-		err = SaveOAuthToken(otok)
-		if err != nil {
-			return nil, err
-		}
-
-		return tok, nil
+		return traktProvider.Exchange(code, state)
 	},
 }
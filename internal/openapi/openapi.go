@@ -0,0 +1,298 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Operation struct {
+	OperationId string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+const ContentTypeJSON = "application/json"
+
+var timeType = reflect.TypeFor[time.Time]()
+
+var componentNameRegex = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// Generator builds the schemas from the types, as they are encoded by
+// `encoding/json`. Named struct types are added to the components.
+type Generator struct {
+	Doc   *Document
+	enums map[reflect.Type][]any
+}
+
+func NewGenerator(info Info) *Generator {
+	return &Generator{
+		Doc: &Document{
+			OpenAPI:    "3.0.3",
+			Info:       info,
+			Paths:      map[string]map[string]*Operation{},
+			Components: Components{Schemas: map[string]*Schema{}},
+		},
+		enums: map[reflect.Type][]any{},
+	}
+}
+
+// SetEnum sets the allowed values for the type.
+func SetEnum[T any](g *Generator, values ...T) {
+	enum := make([]any, len(values))
+	for i := range values {
+		enum[i] = values[i]
+	}
+	g.enums[reflect.TypeFor[T]()] = enum
+}
+
+func getComponentName(t reflect.Type) string {
+	pkgPath := t.PkgPath()
+	pkgName := pkgPath[strings.LastIndex(pkgPath, "/")+1:]
+	return componentNameRegex.ReplaceAllString(pkgName+"."+t.Name(), "_")
+}
+
+func (g *Generator) SchemaOf(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := g.SchemaOf(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	}
+
+	if enum, ok := g.enums[t]; ok {
+		schema := g.schemaOfKind(t)
+		schema.Enum = enum
+		return schema
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	if t.Kind() == reflect.Struct && t.Name() != "" {
+		name := getComponentName(t)
+		if _, ok := g.Doc.Components.Schemas[name]; !ok {
+			// placeholder for recursive types
+			g.Doc.Components.Schemas[name] = &Schema{}
+			g.Doc.Components.Schemas[name] = g.schemaOfStruct(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	return g.schemaOfKind(t)
+}
+
+func (g *Generator) schemaOfKind(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.SchemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.SchemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.schemaOfStruct(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *Generator) schemaOfStruct(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addStructFields(schema, t)
+	return schema
+}
+
+func (g *Generator) addStructFields(schema *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addStructFields(schema, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		var fieldSchema *Schema
+		if hasOption(opts, "string") {
+			fieldSchema = &Schema{Type: "string"}
+		} else {
+			fieldSchema = g.SchemaOf(field.Type)
+		}
+		schema.Properties[name] = fieldSchema
+		if !hasOption(opts, "omitempty") && !hasOption(opts, "omitzero") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for opt := range strings.SplitSeq(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Route describes an operation, with the types of the request body and the
+// responses by status code. The JSON responses are wrapped in `data` field.
+type Route struct {
+	Method      string
+	Path        string
+	OperationId string
+	Summary     string
+	Tag         string
+	Parameters  []Parameter
+	Request     reflect.Type
+	// content type by status code, defaults to JSON
+	ContentType map[int]string
+	Responses   map[int]reflect.Type
+}
+
+func (g *Generator) AddRoute(route Route, errorType reflect.Type) {
+	op := &Operation{
+		OperationId: route.OperationId,
+		Summary:     route.Summary,
+		Parameters:  route.Parameters,
+		Responses:   map[string]Response{},
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				ContentTypeJSON: {Schema: g.SchemaOf(route.Request)},
+			},
+		}
+	}
+	for statusCode, t := range route.Responses {
+		res := Response{Description: http.StatusText(statusCode)}
+		contentType := ContentTypeJSON
+		if ct, ok := route.ContentType[statusCode]; ok {
+			contentType = ct
+		}
+		switch {
+		case t == nil:
+		case contentType == ContentTypeJSON:
+			res.Content = map[string]MediaType{
+				contentType: {Schema: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"data": g.SchemaOf(t)},
+					Required:   []string{"data"},
+				}},
+			}
+		default:
+			res.Content = map[string]MediaType{
+				contentType: {Schema: g.SchemaOf(t)},
+			}
+		}
+		op.Responses[strconv.Itoa(statusCode)] = res
+	}
+	if errorType != nil {
+		op.Responses["default"] = Response{
+			Description: "Error",
+			Content: map[string]MediaType{
+				ContentTypeJSON: {Schema: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"error": g.SchemaOf(errorType)},
+					Required:   []string{"error"},
+				}},
+			},
+		}
+	}
+
+	if _, ok := g.Doc.Paths[route.Path]; !ok {
+		g.Doc.Paths[route.Path] = map[string]*Operation{}
+	}
+	g.Doc.Paths[route.Path][strings.ToLower(route.Method)] = op
+}
@@ -0,0 +1,94 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+func (doc *Document) resolve(schema *Schema) *Schema {
+	for schema.Ref != "" {
+		schema = doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		if schema == nil {
+			return &Schema{}
+		}
+	}
+	return schema
+}
+
+// Validate checks the value decoded from JSON against the schema. Unknown
+// fields in objects are reported, so that undocumented changes are caught.
+func (doc *Document) Validate(schema *Schema, value any) error {
+	return doc.validate(schema, value, "$")
+}
+
+func (doc *Document) validate(schema *Schema, value any, path string) error {
+	schema = doc.resolve(schema)
+	if value == nil {
+		if schema.Nullable || schema.Type == "" || schema.Type == "array" || schema.Type == "object" {
+			return nil
+		}
+		return fmt.Errorf("%s: unexpected null", path)
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(v any) bool {
+		return fmt.Sprint(v) == fmt.Sprint(value)
+	}) {
+		return fmt.Errorf("%s: unexpected value %v", path, value)
+	}
+
+	switch schema.Type {
+	case "":
+		return nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", path, value)
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%s: expected %s, got %T", path, schema.Type, value)
+		}
+		if schema.Type == "integer" && n != math.Trunc(n) {
+			return fmt.Errorf("%s: expected integer, got %v", path, n)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", path, value)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", path, value)
+		}
+		for i, item := range items {
+			if err := doc.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", path, value)
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing field %q", path, name)
+			}
+		}
+		for name, v := range obj {
+			propSchema, ok := schema.Properties[name]
+			if !ok {
+				propSchema = schema.AdditionalProperties
+			}
+			if propSchema == nil {
+				return fmt.Errorf("%s: unknown field %q", path, name)
+			}
+			if err := doc.validate(propSchema, v, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package p2p
+
+import (
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/bittorrent"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/torrent_meta"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	maxMetadataPeerCount = 10
+	metadataPeerTimeout  = 20 * time.Second
+)
+
+var errTorrentInfoNotFound = errors.New("torrent info not found")
+
+type engine struct {
+	mu       sync.Mutex
+	torrents map[string]*torrent
+	peerId   []byte
+	group    singleflight.Group
+	initOnce sync.Once
+}
+
+var e = &engine{
+	torrents: map[string]*torrent{},
+	peerId:   bittorrent.NewPeerId(),
+}
+
+func (e *engine) init() {
+	// torrent data does not outlive the process
+	removeStalePieceStorages(config.P2P.CacheDir)
+
+	go func() {
+		interval := max(config.P2P.IdleTimeout/2, 10*time.Second)
+		for range time.Tick(interval) {
+			e.evictIdle()
+		}
+	}()
+}
+
+func isHTTPTracker(tracker string) bool {
+	return strings.HasPrefix(tracker, "http://") || strings.HasPrefix(tracker, "https://")
+}
+
+func (e *engine) getTorrent(magnet *core.MagnetLink) (*torrent, error) {
+	e.initOnce.Do(e.init)
+
+	hash := magnet.Hash
+
+	e.mu.Lock()
+	t, ok := e.torrents[hash]
+	e.mu.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	result, err, _ := e.group.Do(hash, func() (any, error) {
+		e.mu.Lock()
+		t, ok := e.torrents[hash]
+		e.mu.Unlock()
+		if ok {
+			return t, nil
+		}
+
+		peers := slices.Clone(config.P2P.Peers)
+		trackers := slices.DeleteFunc(slices.Concat(config.P2P.Trackers, magnet.Trackers), func(tracker string) bool {
+			return !isHTTPTracker(tracker)
+		})
+		slices.Sort(trackers)
+		trackers = slices.Compact(trackers)
+
+		info, err := torrent_meta.Fetch(hash)
+		if err != nil {
+			log.Warn("failed to fetch torrent info", "error", err, "hash", hash)
+		}
+		if info == nil {
+			info, peers = fetchInfoFromPeers(hash, peers, trackers)
+		}
+		if info == nil {
+			return nil, errTorrentInfoNotFound
+		}
+
+		t, err = newTorrent(hash, info, peers, trackers)
+		if err != nil {
+			return nil, err
+		}
+
+		e.mu.Lock()
+		e.torrents[hash] = t
+		e.mu.Unlock()
+
+		log.Info("opened torrent", "hash", hash, "name", info.Name)
+
+		return t, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*torrent), nil
+}
+
+// fetchInfoFromPeers fetches the info dictionary from the peers, including
+// the ones announced by the trackers. It returns the info and all the peers.
+func fetchInfoFromPeers(hash string, peers, trackers []string) (*torrent_meta.Info, []string) {
+	infoHash, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, peers
+	}
+
+	for _, tracker := range trackers {
+		trackerPeers, err := bittorrent.Announce(tracker, &bittorrent.AnnounceParams{
+			InfoHash: infoHash,
+			PeerId:   e.peerId,
+			Port:     announcePort,
+			Left:     1,
+		})
+		if err != nil {
+			log.Debug("failed to announce", "error", err, "hash", hash, "tracker", tracker)
+			continue
+		}
+		peers = append(peers, trackerPeers...)
+	}
+	slices.Sort(peers)
+	peers = slices.Compact(peers)
+
+	for i, addr := range peers {
+		if i == maxMetadataPeerCount {
+			break
+		}
+		raw, err := torrent_meta.FetchFromPeer(addr, hash, metadataPeerTimeout)
+		if err != nil {
+			log.Debug("failed to fetch torrent info from peer", "error", err, "hash", hash, "peer", addr)
+			continue
+		}
+		info, err := torrent_meta.ParseInfo(raw)
+		if err != nil {
+			log.Debug("failed to parse torrent info from peer", "error", err, "hash", hash, "peer", addr)
+			continue
+		}
+		return info, peers
+	}
+	return nil, peers
+}
+
+// removeTorrent must be called with mu held, after the torrent is closed.
+func (e *engine) removeTorrent(t *torrent) {
+	delete(e.torrents, t.hash)
+	if err := t.storage.removeAll(); err != nil {
+		log.Warn("failed to remove torrent data", "error", err, "hash", t.hash)
+	}
+	log.Info("closed torrent", "hash", t.hash)
+}
+
+func (e *engine) evictIdle() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, t := range e.torrents {
+		if t.closeIfIdle(config.P2P.IdleTimeout) {
+			e.removeTorrent(t)
+		}
+	}
+}
+
+// enforceCacheLimit evicts inactive torrents, least recently accessed first,
+// and then the pieces outside the read window of the active torrents.
+func (e *engine) enforceCacheLimit() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	limit := config.P2P.CacheSize
+
+	total := int64(0)
+	torrents := make([]*torrent, 0, len(e.torrents))
+	for _, t := range e.torrents {
+		total += t.getHaveSize()
+		torrents = append(torrents, t)
+	}
+	if total <= limit {
+		return
+	}
+
+	slices.SortFunc(torrents, func(a, b *torrent) int {
+		return a.getLastAccess().Compare(b.getLastAccess())
+	})
+
+	for _, t := range torrents {
+		if total <= limit {
+			break
+		}
+		size := t.getHaveSize()
+		if t.closeIfIdle(0) {
+			e.removeTorrent(t)
+			total -= size
+		}
+	}
+
+	for _, t := range torrents {
+		if total <= limit {
+			break
+		}
+		if !t.isClosed() {
+			total -= t.evictPieces(total - limit)
+		}
+	}
+
+	if total > limit {
+		log.Warn("cache size exceeds limit", "size", total, "limit", limit)
+	}
+}
@@ -0,0 +1,7 @@
+package p2p
+
+import (
+	"github.com/MunifTanjim/stremthru/internal/logger"
+)
+
+var log = logger.Scoped("p2p")
@@ -0,0 +1,173 @@
+package p2p
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/bittorrent"
+)
+
+const (
+	maxPendingRequests = 32
+	keepAliveInterval  = 1 * time.Minute
+	// peer is dropped if no block is received for this long
+	peerSnubTimeout = 1 * time.Minute
+)
+
+var errPeerSnubbed = errors.New("peer snubbed")
+
+type pieceDownload struct {
+	piece     int
+	data      []byte
+	blocks    []bool
+	received  int
+	nextBegin int
+	pending   int
+}
+
+func (pd *pieceDownload) isComplete() bool {
+	return pd.received == len(pd.blocks)
+}
+
+// runPeer downloads the picked pieces from the peer, until the torrent is
+// closed or the connection fails. Only downloading is supported, the peer
+// is never unchoked.
+func (t *torrent) runPeer(addr string) error {
+	c, err := bittorrent.Dial(addr, t.infoHash, e.peerId, peerDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	connDone := make(chan struct{})
+	defer close(connDone)
+
+	msgs := make(chan *bittorrent.Message, 64)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := c.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			if msg == nil {
+				continue
+			}
+			select {
+			case msgs <- msg:
+			case <-connDone:
+				return
+			}
+		}
+	}()
+
+	if err := c.WriteMessage(&bittorrent.Message{Id: bittorrent.MsgInterested}); err != nil {
+		return err
+	}
+
+	peerHas := make([]bool, t.pieceCount())
+	choked := true
+
+	var download *pieceDownload
+	defer func() {
+		if download != nil {
+			t.releasePiece(download.piece)
+		}
+	}()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	lastBlockAt := time.Now()
+
+	for {
+		t.mu.Lock()
+		changed := t.changed
+		t.mu.Unlock()
+
+		if !choked && download == nil {
+			if piece := t.pickPiece(peerHas); piece != -1 {
+				size := int(t.pieceSize(piece))
+				download = &pieceDownload{
+					piece:  piece,
+					data:   make([]byte, size),
+					blocks: make([]bool, (size+bittorrent.BlockSize-1)/bittorrent.BlockSize),
+				}
+				lastBlockAt = time.Now()
+			}
+		}
+
+		if !choked && download != nil {
+			for download.pending < maxPendingRequests && download.nextBegin < len(download.data) {
+				length := min(bittorrent.BlockSize, len(download.data)-download.nextBegin)
+				if err := c.WriteMessage(bittorrent.NewRequestMessage(download.piece, download.nextBegin, length)); err != nil {
+					return err
+				}
+				download.nextBegin += length
+				download.pending++
+			}
+		}
+
+		select {
+		case <-t.done:
+			return nil
+		case err := <-readErr:
+			return err
+		case <-changed:
+		case <-keepAlive.C:
+			if download != nil && time.Since(lastBlockAt) > peerSnubTimeout {
+				return errPeerSnubbed
+			}
+			if err := c.WriteMessage(nil); err != nil {
+				return err
+			}
+		case msg := <-msgs:
+			switch msg.Id {
+			case bittorrent.MsgChoke:
+				choked = true
+				if download != nil {
+					t.releasePiece(download.piece)
+					download = nil
+				}
+			case bittorrent.MsgUnchoke:
+				choked = false
+			case bittorrent.MsgHave:
+				if piece, err := bittorrent.ParseHaveMessage(msg); err == nil && piece < len(peerHas) {
+					peerHas[piece] = true
+				}
+			case bittorrent.MsgBitfield:
+				for piece := range peerHas {
+					if piece/8 < len(msg.Payload) && msg.Payload[piece/8]&(0x80>>(piece%8)) != 0 {
+						peerHas[piece] = true
+					}
+				}
+			case bittorrent.MsgPiece:
+				piece, begin, data, err := bittorrent.ParsePieceMessage(msg)
+				if err != nil {
+					return err
+				}
+				if download == nil || piece != download.piece || begin%bittorrent.BlockSize != 0 || begin+len(data) > len(download.data) {
+					continue
+				}
+				block := begin / bittorrent.BlockSize
+				if download.blocks[block] {
+					continue
+				}
+				copy(download.data[begin:], data)
+				download.blocks[block] = true
+				download.received++
+				download.pending--
+				lastBlockAt = time.Now()
+
+				if download.isComplete() {
+					piece := download.piece
+					data := download.data
+					download = nil
+					if err := t.completePiece(piece, data); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var errFileNotFound = errors.New("file not found in torrent")
+
+// Reader reads a file of the torrent, waiting for the pieces to be
+// downloaded. The read position drives the piece selection.
+type Reader struct {
+	t      *torrent
+	ctx    context.Context
+	name   string
+	offset int64 // of the file in the torrent
+	length int64
+	// read position in the file, written with t.mu held
+	pos int64
+}
+
+func (t *torrent) openReader(ctx context.Context, fileIdx int) (*Reader, error) {
+	files := t.info.Files
+	if fileIdx < 0 {
+		for idx, f := range files {
+			if !f.IsPadding && (fileIdx < 0 || f.Length > files[fileIdx].Length) {
+				fileIdx = idx
+			}
+		}
+	}
+	if fileIdx < 0 || fileIdx >= len(files) || files[fileIdx].IsPadding {
+		return nil, errFileNotFound
+	}
+
+	offset := int64(0)
+	for _, f := range files[:fileIdx] {
+		offset += f.Length
+	}
+
+	r := &Reader{
+		t:      t,
+		ctx:    ctx,
+		name:   files[fileIdx].Name(),
+		offset: offset,
+		length: files[fileIdx].Length,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, errTorrentClosed
+	}
+	t.readers[r] = struct{}{}
+	t.lastAccess = time.Now()
+	t.notify()
+
+	return r, nil
+}
+
+// readWindow returns the range of the torrent to download ahead of the
+// read position. It must be called with t.mu held.
+func (r *Reader) readWindow() (int64, int64) {
+	start := r.offset + min(r.pos, r.length)
+	end := min(start+r.t.readAheadSize(), r.offset+r.length)
+	return start, end
+}
+
+func (r *Reader) Name() string {
+	return r.name
+}
+
+func (r *Reader) Size() int64 {
+	return r.length
+}
+
+func (r *Reader) setPos(pos int64) {
+	t := r.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prevPiece := (r.offset + r.pos) / t.info.PieceLength
+	r.pos = pos
+	t.lastAccess = time.Now()
+	if (r.offset+r.pos)/t.info.PieceLength != prevPiece {
+		t.notify()
+	}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.pos >= r.length {
+		return 0, io.EOF
+	}
+
+	t := r.t
+	pos := r.offset + r.pos
+	piece := int(pos / t.info.PieceLength)
+	if err := t.waitPiece(r.ctx, piece); err != nil {
+		return 0, err
+	}
+
+	pieceOffset := pos - int64(piece)*t.info.PieceLength
+	size := min(int64(len(p)), t.pieceSize(piece)-pieceOffset, r.length-r.pos)
+	n, err := t.storage.readAt(piece, p[:size], pieceOffset)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	r.setPos(r.pos + int64(n))
+	return n, err
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	pos := offset
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		pos += r.pos
+	case io.SeekEnd:
+		pos += r.length
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.setPos(pos)
+	return pos, nil
+}
+
+func (r *Reader) Close() error {
+	t := r.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.readers, r)
+	t.lastAccess = time.Now()
+	t.notify()
+	return nil
+}
@@ -0,0 +1,118 @@
+package p2p
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
+
+func toAPIError(err error) error {
+	var apiErr *core.APIError
+	switch {
+	case errors.Is(err, errTorrentInfoNotFound), errors.Is(err, errFileNotFound):
+		apiErr = core.NewAPIError(err.Error())
+		apiErr.StatusCode = http.StatusNotFound
+	case errors.Is(err, errTorrentClosed):
+		apiErr = core.NewAPIError(err.Error())
+		apiErr.StatusCode = http.StatusServiceUnavailable
+	default:
+		return err
+	}
+	apiErr.Cause = err
+	return apiErr
+}
+
+func IsMagnetLink(link string) bool {
+	return strings.HasPrefix(link, "magnet:")
+}
+
+// getSelectedFileIdx returns the first file index from the `so` parameter
+// of the magnet link, as in BEP-53. It returns -1 if not present.
+func getSelectedFileIdx(link string) int {
+	u, err := url.Parse(link)
+	if err != nil {
+		return -1
+	}
+	so := u.Query().Get("so")
+	so, _, _ = strings.Cut(so, ",")
+	so, _, _ = strings.Cut(so, "-")
+	idx, err := strconv.Atoi(so)
+	if err != nil {
+		return -1
+	}
+	return idx
+}
+
+// CreateMagnetLink returns the magnet link for the file of the torrent.
+func CreateMagnetLink(hash string, fileIdx int) string {
+	link := "magnet:?xt=urn:btih:" + strings.ToLower(hash)
+	if fileIdx >= 0 {
+		link += "&so=" + strconv.Itoa(fileIdx)
+	}
+	return link
+}
+
+// ServeFile serves the file selected in the magnet link, or the largest
+// file if none is selected, supporting range requests.
+func ServeFile(w http.ResponseWriter, r *http.Request, link string) (int64, error) {
+	if !config.P2P.IsEnabled() {
+		err := core.NewAPIError("p2p stream is disabled")
+		err.StatusCode = http.StatusForbidden
+		return 0, err
+	}
+
+	magnet, err := core.ParseMagnetLink(link)
+	if err != nil {
+		return 0, err
+	}
+	fileIdx := getSelectedFileIdx(link)
+
+	var reader *Reader
+	for range 2 {
+		t, err := e.getTorrent(&magnet)
+		if err != nil {
+			return 0, toAPIError(err)
+		}
+		reader, err = t.openReader(r.Context(), fileIdx)
+		if errors.Is(err, errTorrentClosed) {
+			continue
+		}
+		if err != nil {
+			return 0, toAPIError(err)
+		}
+		break
+	}
+	if reader == nil {
+		return 0, toAPIError(errTorrentClosed)
+	}
+	defer reader.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(reader.Name()))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	cw := &countingResponseWriter{ResponseWriter: w}
+	http.ServeContent(cw, r, reader.Name(), time.Time{}, reader)
+	return cw.written, nil
+}
+
+type countingResponseWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
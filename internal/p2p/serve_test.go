@@ -0,0 +1,27 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSelectedFileIdx(t *testing.T) {
+	hash := "0123456789abcdef0123456789abcdef01234567"
+	for _, tc := range []struct {
+		link     string
+		expected int
+	}{
+		{"magnet:?xt=urn:btih:" + hash, -1},
+		{"magnet:?xt=urn:btih:" + hash + "&so=3", 3},
+		{"magnet:?xt=urn:btih:" + hash + "&so=2,4,6", 2},
+		{"magnet:?xt=urn:btih:" + hash + "&so=5-8", 5},
+		{"magnet:?xt=urn:btih:" + hash + "&so=invalid", -1},
+		{CreateMagnetLink(hash, 7), 7},
+		{CreateMagnetLink(hash, -1), -1},
+	} {
+		t.Run(tc.link, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getSelectedFileIdx(tc.link))
+		})
+	}
+}
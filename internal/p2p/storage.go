@@ -0,0 +1,99 @@
+package p2p
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// pieceStorageMarker is created in every piece storage directory, so that
+// only the directories created by the engine are ever removed.
+const pieceStorageMarker = ".stremthru-p2p"
+
+var errNotPieceStorage = errors.New("directory exists and is not a piece storage")
+
+func isPieceStorage(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, pieceStorageMarker))
+	return err == nil
+}
+
+// removeStalePieceStorages removes the piece storage directories left by a
+// previous process.
+func removeStalePieceStorages(cacheDir string) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		dir := filepath.Join(cacheDir, entry.Name())
+		if entry.IsDir() && isPieceStorage(dir) {
+			if err := os.RemoveAll(dir); err != nil {
+				log.Warn("failed to remove stale piece storage", "error", err, "dir", dir)
+			}
+		}
+	}
+}
+
+// pieceStore keeps the downloaded pieces of a torrent.
+type pieceStore interface {
+	write(piece int, data []byte) error
+	readAt(piece int, p []byte, off int64) (int, error)
+	remove(piece int) error
+	removeAll() error
+}
+
+// pieceStorage keeps each piece in a separate file, so that pieces can be
+// evicted individually.
+type pieceStorage struct {
+	dir string
+}
+
+func newPieceStorage(dir string) (*pieceStorage, error) {
+	if _, err := os.Stat(dir); err == nil {
+		if !isPieceStorage(dir) {
+			return nil, errNotPieceStorage
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, pieceStorageMarker), nil, 0644); err != nil {
+		return nil, err
+	}
+	return &pieceStorage{dir: dir}, nil
+}
+
+func (s *pieceStorage) path(piece int) string {
+	return filepath.Join(s.dir, strconv.Itoa(piece))
+}
+
+func (s *pieceStorage) write(piece int, data []byte) error {
+	tmpPath := s.path(piece) + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path(piece))
+}
+
+func (s *pieceStorage) readAt(piece int, p []byte, off int64) (int, error) {
+	file, err := os.Open(s.path(piece))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return file.ReadAt(p, off)
+}
+
+func (s *pieceStorage) remove(piece int) error {
+	return os.Remove(s.path(piece))
+}
+
+func (s *pieceStorage) removeAll() error {
+	return os.RemoveAll(s.dir)
+}
@@ -0,0 +1,31 @@
+package p2p
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveStalePieceStorages(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	hash := "0123456789abcdef0123456789abcdef01234567"
+	storage, err := newPieceStorage(filepath.Join(cacheDir, hash))
+	assert.NoError(t, err)
+	assert.NoError(t, storage.write(0, []byte("piece")))
+
+	otherDir := filepath.Join(cacheDir, "fedcba9876543210fedcba9876543210fedcba98")
+	assert.NoError(t, os.MkdirAll(otherDir, 0755))
+
+	_, err = newPieceStorage(otherDir)
+	assert.ErrorIs(t, err, errNotPieceStorage)
+
+	removeStalePieceStorages(cacheDir)
+
+	_, err = os.Stat(storage.dir)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(otherDir)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,398 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/bittorrent"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/torrent_meta"
+)
+
+const (
+	// pieces ahead of the read head are downloaded sequentially
+	readAheadSize      = 32 * 1024 * 1024
+	minReadAheadPieces = 2
+
+	maxPeerCount      = 30
+	peerDialTimeout   = 10 * time.Second
+	peerRetryInterval = 1 * time.Minute
+	announceInterval  = 5 * time.Minute
+	maintainInterval  = 10 * time.Second
+
+	// the port is announced to the trackers, incoming connections are not
+	// accepted.
+	announcePort = 6881
+
+	pieceWaitTimeout = 2 * time.Minute
+)
+
+var (
+	errTorrentClosed      = errors.New("torrent closed")
+	errPieceHashMismatch  = errors.New("piece hash mismatch")
+	errPieceWaitTimeout   = errors.New("timed out waiting for piece")
+	errInvalidTorrentInfo = errors.New("invalid torrent info")
+)
+
+type peerState struct {
+	connected bool
+	retryAt   time.Time
+}
+
+type torrent struct {
+	hash     string
+	infoHash []byte
+	info     *torrent_meta.Info
+	size     int64
+	trackers []string
+	storage  pieceStore
+
+	mu         sync.Mutex
+	changed    chan struct{}
+	have       []bool
+	haveSize   int64
+	inProgress map[int]struct{}
+	readers    map[*Reader]struct{}
+	peers      map[string]*peerState
+	lastAccess time.Time
+	closed     bool
+	done       chan struct{}
+}
+
+func newTorrent(hash string, info *torrent_meta.Info, peers, trackers []string) (*torrent, error) {
+	infoHash, err := hex.DecodeString(hash)
+	if err != nil || len(infoHash) != 20 {
+		return nil, errors.New("invalid info hash: " + hash)
+	}
+
+	size := info.Size()
+	if info.PieceLength <= 0 || int64(info.PieceCount()) != (size+info.PieceLength-1)/info.PieceLength {
+		return nil, errInvalidTorrentInfo
+	}
+
+	storage, err := newPieceStorage(filepath.Join(config.P2P.CacheDir, hash))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &torrent{
+		hash:       hash,
+		infoHash:   infoHash,
+		info:       info,
+		size:       size,
+		trackers:   trackers,
+		storage:    storage,
+		changed:    make(chan struct{}),
+		have:       make([]bool, info.PieceCount()),
+		inProgress: map[int]struct{}{},
+		readers:    map[*Reader]struct{}{},
+		peers:      map[string]*peerState{},
+		lastAccess: time.Now(),
+		done:       make(chan struct{}),
+	}
+	t.addPeers(peers)
+
+	go t.maintain()
+
+	return t, nil
+}
+
+func (t *torrent) pieceCount() int {
+	return len(t.have)
+}
+
+func (t *torrent) pieceSize(piece int) int64 {
+	if piece == t.pieceCount()-1 {
+		return t.size - int64(piece)*t.info.PieceLength
+	}
+	return t.info.PieceLength
+}
+
+func (t *torrent) readAheadSize() int64 {
+	return max(readAheadSize, minReadAheadPieces*t.info.PieceLength)
+}
+
+// notify wakes up everyone waiting for a change. It must be called with
+// mu held.
+func (t *torrent) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// close must be called with mu held.
+func (t *torrent) close() {
+	if t.closed {
+		return
+	}
+	t.closed = true
+	close(t.done)
+	t.notify()
+}
+
+// closeIfIdle closes the torrent if it has no readers, and was not accessed
+// within the timeout.
+func (t *torrent) closeIfIdle(timeout time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.readers) > 0 || time.Since(t.lastAccess) < timeout {
+		return false
+	}
+	t.close()
+	return true
+}
+
+func (t *torrent) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.closed
+}
+
+func (t *torrent) getHaveSize() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.haveSize
+}
+
+func (t *torrent) getLastAccess() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.lastAccess
+}
+
+// isInReadWindow must be called with mu held.
+func (t *torrent) isInReadWindow(piece int) bool {
+	start := int64(piece) * t.info.PieceLength
+	end := start + t.pieceSize(piece)
+	for r := range t.readers {
+		wStart, wEnd := r.readWindow()
+		if start < wEnd && end > wStart {
+			return true
+		}
+	}
+	return false
+}
+
+// pickPiece returns the missing piece closest to the read head of any of
+// the readers, which the peer has and is not being downloaded already. It
+// returns -1 if there is none.
+func (t *torrent) pickPiece(peerHas []bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return -1
+	}
+
+	best, bestDistance := -1, int64(-1)
+	for r := range t.readers {
+		start, end := r.readWindow()
+		if start >= end {
+			continue
+		}
+		first := int(start / t.info.PieceLength)
+		last := int((end - 1) / t.info.PieceLength)
+		for piece := first; piece <= last; piece++ {
+			if _, ok := t.inProgress[piece]; ok || t.have[piece] || !peerHas[piece] {
+				continue
+			}
+			distance := max(int64(piece)*t.info.PieceLength-start, 0)
+			if best == -1 || distance < bestDistance {
+				best, bestDistance = piece, distance
+			}
+			break
+		}
+	}
+	if best != -1 {
+		t.inProgress[best] = struct{}{}
+	}
+	return best
+}
+
+func (t *torrent) releasePiece(piece int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.inProgress, piece)
+	t.notify()
+}
+
+func (t *torrent) completePiece(piece int, data []byte) error {
+	sum := sha1.Sum(data)
+	if !bytes.Equal(sum[:], t.info.PieceHash(piece)) {
+		t.releasePiece(piece)
+		return errPieceHashMismatch
+	}
+
+	t.mu.Lock()
+	delete(t.inProgress, piece)
+	if t.closed {
+		t.mu.Unlock()
+		return errTorrentClosed
+	}
+	if err := t.storage.write(piece, data); err != nil {
+		t.notify()
+		t.mu.Unlock()
+		return err
+	}
+	t.have[piece] = true
+	t.haveSize += int64(len(data))
+	t.notify()
+	t.mu.Unlock()
+
+	e.enforceCacheLimit()
+	return nil
+}
+
+// evictPieces removes pieces outside the read window of the readers, until
+// the target size is freed. It returns the freed size.
+func (t *torrent) evictPieces(target int64) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	freed := int64(0)
+	for piece := range t.have {
+		if freed >= target {
+			break
+		}
+		if !t.have[piece] || t.isInReadWindow(piece) {
+			continue
+		}
+		if err := t.storage.remove(piece); err != nil {
+			log.Warn("failed to remove piece", "error", err, "hash", t.hash, "piece", piece)
+			continue
+		}
+		size := t.pieceSize(piece)
+		t.have[piece] = false
+		t.haveSize -= size
+		freed += size
+	}
+	return freed
+}
+
+// waitPiece blocks until the piece is available.
+func (t *torrent) waitPiece(ctx context.Context, piece int) error {
+	timer := time.NewTimer(pieceWaitTimeout)
+	defer timer.Stop()
+
+	for {
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			return errTorrentClosed
+		}
+		if t.have[piece] {
+			t.mu.Unlock()
+			return nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return errPieceWaitTimeout
+		}
+	}
+}
+
+func (t *torrent) addPeers(addrs []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, addr := range addrs {
+		if _, ok := t.peers[addr]; !ok {
+			t.peers[addr] = &peerState{}
+		}
+	}
+}
+
+func (t *torrent) announce() {
+	t.mu.Lock()
+	left := t.size - t.haveSize
+	t.mu.Unlock()
+
+	for _, tracker := range t.trackers {
+		peers, err := bittorrent.Announce(tracker, &bittorrent.AnnounceParams{
+			InfoHash: t.infoHash,
+			PeerId:   e.peerId,
+			Port:     announcePort,
+			Left:     left,
+		})
+		if err != nil {
+			log.Debug("failed to announce", "error", err, "hash", t.hash, "tracker", tracker)
+			continue
+		}
+		t.addPeers(peers)
+	}
+}
+
+func (t *torrent) connectPeers() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	connectedCount := 0
+	for _, ps := range t.peers {
+		if ps.connected {
+			connectedCount++
+		}
+	}
+	for addr, ps := range t.peers {
+		if connectedCount >= maxPeerCount {
+			break
+		}
+		if ps.connected || now.Before(ps.retryAt) {
+			continue
+		}
+		ps.connected = true
+		connectedCount++
+		go t.runPeerConn(addr)
+	}
+}
+
+func (t *torrent) runPeerConn(addr string) {
+	err := t.runPeer(addr)
+
+	t.mu.Lock()
+	ps := t.peers[addr]
+	ps.connected = false
+	ps.retryAt = time.Now().Add(peerRetryInterval)
+	closed := t.closed
+	t.mu.Unlock()
+
+	if err != nil && !closed {
+		log.Debug("peer disconnected", "error", err, "hash", t.hash, "peer", addr)
+	}
+}
+
+func (t *torrent) maintain() {
+	ticker := time.NewTicker(maintainInterval)
+	defer ticker.Stop()
+
+	announceAt := time.Time{}
+	for {
+		if len(t.trackers) > 0 && time.Now().After(announceAt) {
+			go t.announce()
+			announceAt = time.Now().Add(announceInterval)
+		}
+		t.connectPeers()
+
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
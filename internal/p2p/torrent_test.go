@@ -0,0 +1,334 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/torrent_meta"
+	"github.com/stretchr/testify/assert"
+)
+
+// memPieceStore keeps the pieces in memory.
+type memPieceStore struct {
+	mu     sync.Mutex
+	pieces map[int][]byte
+}
+
+func (s *memPieceStore) write(piece int, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pieces[piece] = data
+	return nil
+}
+
+func (s *memPieceStore) readAt(piece int, p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.pieces[piece]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	if off >= int64(len(data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (s *memPieceStore) remove(piece int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pieces[piece]; !ok {
+		return os.ErrNotExist
+	}
+	delete(s.pieces, piece)
+	return nil
+}
+
+func (s *memPieceStore) removeAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.pieces)
+	return nil
+}
+
+func newTestTorrent(t *testing.T, pieceLength int64, fileLengths ...int64) (*torrent, []byte) {
+	t.Helper()
+
+	info := &torrent_meta.Info{Name: "test", PieceLength: pieceLength}
+	for i, length := range fileLengths {
+		info.Files = append(info.Files, torrent_meta.InfoFile{
+			Path:   []string{"file-" + strconv.Itoa(i)},
+			Length: length,
+		})
+	}
+	size := info.Size()
+	data := make([]byte, size)
+	rand.Read(data)
+	for start := int64(0); start < size; start += pieceLength {
+		sum := sha1.Sum(data[start:min(start+pieceLength, size)])
+		info.Pieces = append(info.Pieces, sum[:]...)
+	}
+
+	return &torrent{
+		hash:       "0123456789abcdef0123456789abcdef01234567",
+		info:       info,
+		size:       size,
+		storage:    &memPieceStore{pieces: map[int][]byte{}},
+		changed:    make(chan struct{}),
+		have:       make([]bool, info.PieceCount()),
+		inProgress: map[int]struct{}{},
+		readers:    map[*Reader]struct{}{},
+		peers:      map[string]*peerState{},
+		lastAccess: time.Now(),
+		done:       make(chan struct{}),
+	}, data
+}
+
+func completeTestPieces(t *testing.T, tor *torrent, data []byte, pieces ...int) {
+	t.Helper()
+	for _, piece := range pieces {
+		start := int64(piece) * tor.info.PieceLength
+		end := start + tor.pieceSize(piece)
+		assert.NoError(t, tor.completePiece(piece, data[start:end]))
+	}
+}
+
+func allPieces(tor *torrent) []int {
+	pieces := make([]int, tor.pieceCount())
+	for i := range pieces {
+		pieces[i] = i
+	}
+	return pieces
+}
+
+func TestTorrentPickPiece(t *testing.T) {
+	tor, data := newTestTorrent(t, 4, 40)
+	peerHas := make([]bool, tor.pieceCount())
+	for i := range peerHas {
+		peerHas[i] = true
+	}
+
+	assert.Equal(t, -1, tor.pickPiece(peerHas), "no reader")
+
+	r := &Reader{t: tor, length: 40, pos: 10}
+	tor.readers[r] = struct{}{}
+
+	assert.Equal(t, 2, tor.pickPiece(peerHas), "piece at read head")
+	assert.Equal(t, 3, tor.pickPiece(peerHas), "in progress piece is skipped")
+
+	completeTestPieces(t, tor, data, 4)
+	peerHas[5] = false
+	assert.Equal(t, 6, tor.pickPiece(peerHas), "have piece and missing piece of peer are skipped")
+
+	tor.releasePiece(2)
+	assert.Equal(t, 2, tor.pickPiece(peerHas), "released piece is picked again")
+
+	t.Run("closest to read head", func(t *testing.T) {
+		tor, data := newTestTorrent(t, 4, 40)
+		completeTestPieces(t, tor, data, 0, 1, 2, 3)
+		tor.readers[&Reader{t: tor, length: 40, pos: 0}] = struct{}{}
+		tor.readers[&Reader{t: tor, length: 40, pos: 30}] = struct{}{}
+		assert.Equal(t, 7, tor.pickPiece(peerHas))
+		assert.Equal(t, 8, tor.pickPiece(peerHas), "next piece of the closer reader")
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		tor, _ := newTestTorrent(t, 4, 40)
+		tor.readers[&Reader{t: tor, length: 40}] = struct{}{}
+		tor.mu.Lock()
+		tor.close()
+		tor.mu.Unlock()
+		assert.Equal(t, -1, tor.pickPiece(peerHas))
+	})
+}
+
+func TestTorrentCompletePiece(t *testing.T) {
+	tor, data := newTestTorrent(t, 4, 10)
+
+	tor.inProgress[0] = struct{}{}
+	assert.ErrorIs(t, tor.completePiece(0, make([]byte, 4)), errPieceHashMismatch)
+	assert.False(t, tor.have[0])
+	assert.NotContains(t, tor.inProgress, 0)
+
+	completeTestPieces(t, tor, data, 0, 2)
+	assert.Equal(t, []bool{true, false, true}, tor.have)
+	assert.Equal(t, int64(6), tor.haveSize, "last piece is shorter")
+}
+
+func TestReaderReadWindow(t *testing.T) {
+	tor, _ := newTestTorrent(t, 4, 100, 50)
+
+	r := &Reader{t: tor, offset: 100, length: 50, pos: 10}
+	start, end := r.readWindow()
+	assert.Equal(t, int64(110), start)
+	assert.Equal(t, int64(150), end, "window ends at the end of the file")
+
+	r.pos = 60
+	start, end = r.readWindow()
+	assert.Equal(t, start, end, "empty window after the end of the file")
+
+	tor.info.PieceLength = 32 * 1024 * 1024
+	r = &Reader{t: tor, offset: 0, length: 200 * 1024 * 1024}
+	start, end = r.readWindow()
+	assert.Equal(t, int64(0), start)
+	assert.Equal(t, int64(minReadAheadPieces)*tor.info.PieceLength, end, "at least the minimum pieces ahead")
+}
+
+func TestTorrentEvictPieces(t *testing.T) {
+	tor, data := newTestTorrent(t, 4, 40)
+	completeTestPieces(t, tor, data, allPieces(tor)...)
+	tor.readers[&Reader{t: tor, length: 40, pos: 20}] = struct{}{}
+
+	assert.Equal(t, int64(8), tor.evictPieces(6))
+	assert.Equal(t, []bool{false, false, true, true, true, true, true, true, true, true}, tor.have)
+	assert.Equal(t, int64(32), tor.haveSize)
+
+	assert.Equal(t, int64(12), tor.evictPieces(100), "pieces in read window are kept")
+	assert.Equal(t, int64(20), tor.haveSize)
+	for piece := range 5 {
+		_, err := tor.storage.readAt(piece, make([]byte, 1), 0)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	}
+}
+
+func TestEngineEnforceCacheLimit(t *testing.T) {
+	cacheSize := config.P2P.CacheSize
+	t.Cleanup(func() { config.P2P.CacheSize = cacheSize })
+
+	setup := func(t *testing.T) (*engine, *torrent, *torrent) {
+		idle, idleData := newTestTorrent(t, 4, 40)
+		idle.hash = "1111111111111111111111111111111111111111"
+		completeTestPieces(t, idle, idleData, allPieces(idle)...)
+		idle.lastAccess = time.Now().Add(-time.Hour)
+
+		active, activeData := newTestTorrent(t, 4, 40)
+		active.hash = "2222222222222222222222222222222222222222"
+		completeTestPieces(t, active, activeData, allPieces(active)...)
+		active.readers[&Reader{t: active, length: 40, pos: 20}] = struct{}{}
+
+		return &engine{torrents: map[string]*torrent{
+			idle.hash:   idle,
+			active.hash: active,
+		}}, idle, active
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+		eng, idle, active := setup(t)
+		config.P2P.CacheSize = 80
+		eng.enforceCacheLimit()
+		assert.Len(t, eng.torrents, 2)
+		assert.Equal(t, int64(40), idle.haveSize)
+		assert.Equal(t, int64(40), active.haveSize)
+	})
+
+	t.Run("inactive torrent first", func(t *testing.T) {
+		eng, idle, active := setup(t)
+		config.P2P.CacheSize = 50
+		eng.enforceCacheLimit()
+		assert.NotContains(t, eng.torrents, idle.hash)
+		assert.True(t, idle.isClosed())
+		assert.Equal(t, int64(40), active.haveSize)
+	})
+
+	t.Run("then pieces of active torrent", func(t *testing.T) {
+		eng, idle, active := setup(t)
+		config.P2P.CacheSize = 30
+		eng.enforceCacheLimit()
+		assert.NotContains(t, eng.torrents, idle.hash)
+		assert.False(t, active.isClosed())
+		assert.Equal(t, int64(28), active.haveSize)
+	})
+}
+
+func TestReaderReadSeek(t *testing.T) {
+	tor, data := newTestTorrent(t, 8, 10, 30)
+	completeTestPieces(t, tor, data, allPieces(tor)...)
+
+	r, err := tor.openReader(t.Context(), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "file-1", r.Name(), "largest file is selected")
+	assert.Equal(t, int64(30), r.Size())
+
+	content, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, data[10:40], content)
+
+	read := func(n int) []byte {
+		t.Helper()
+		p := make([]byte, n)
+		_, err := io.ReadFull(r, p)
+		assert.NoError(t, err)
+		return p
+	}
+
+	pos, err := r.Seek(5, io.SeekStart)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), pos)
+	assert.Equal(t, data[15:18], read(3))
+
+	pos, err = r.Seek(-2, io.SeekCurrent)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), pos)
+	assert.Equal(t, data[16:26], read(10), "read across pieces")
+
+	pos, err = r.Seek(-4, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(26), pos)
+	assert.Equal(t, data[36:40], read(4))
+
+	n, err := r.Read(make([]byte, 1))
+	assert.Equal(t, 0, n)
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, err = r.Seek(-1, io.SeekStart)
+	assert.Error(t, err)
+	_, err = r.Seek(0, 42)
+	assert.Error(t, err)
+
+	assert.NoError(t, r.Close())
+	assert.Empty(t, tor.readers)
+
+	_, err = tor.openReader(t.Context(), 2)
+	assert.ErrorIs(t, err, errFileNotFound)
+}
+
+func TestReaderReadWaitsForPiece(t *testing.T) {
+	tor, data := newTestTorrent(t, 8, 16)
+
+	r, err := tor.openReader(t.Context(), 0)
+	assert.NoError(t, err)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		completeTestPieces(t, tor, data, 0)
+	}()
+
+	p := make([]byte, 8)
+	n, err := r.Read(p)
+	assert.NoError(t, err)
+	assert.Equal(t, data[:n], p[:n])
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		r, err := tor.openReader(ctx, 0)
+		assert.NoError(t, err)
+		_, err = r.Seek(8, io.SeekStart)
+		assert.NoError(t, err)
+		cancel()
+		_, err = r.Read(p)
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
@@ -66,6 +66,18 @@ var ErrorBadRequest = func(r *http.Request, msg string) *core.APIError {
 	return err
 }
 
+var ErrorConflict = func(r *http.Request, msg string) *core.APIError {
+	if msg == "" {
+		msg = "conflict"
+	}
+
+	err := core.NewAPIError(msg)
+	err.InjectReq(r)
+	err.Code = core.ErrorCodeConflict
+	err.StatusCode = http.StatusConflict
+	return err
+}
+
 var ErrorInternalServerError = func(r *http.Request, msg string) *core.APIError {
 	if msg == "" {
 		msg = "internal server error"
@@ -141,8 +141,15 @@ func CreateProxyLink(r *http.Request, link string, headers map[string]string, tu
 		var encLink string
 		var encFormat string
 
+		secret := config.ProxyAuthPassword.GetSecret(user)
+		if secret == "" {
+			err := core.NewAPIError("unauthorized")
+			err.StatusCode = http.StatusUnauthorized
+			return "", err
+		}
+
 		if shouldEncrypt {
-			encryptedLink, err := core.Encrypt(password, linkBlob)
+			encryptedLink, err := core.Encrypt(secret, linkBlob)
 			if err != nil {
 				return "", err
 			}
@@ -167,7 +174,7 @@ func CreateProxyLink(r *http.Request, link string, headers map[string]string, tu
 		if expiresIn != 0 {
 			claims.RegisteredClaims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(expiresIn))
 		}
-		token, err := core.CreateJWT(password, claims)
+		token, err := core.CreateJWT(secret, claims)
 		if err != nil {
 			return "", err
 		}
@@ -222,13 +229,13 @@ var proxyLinkTokenCache = func() cache.Cache[proxyLinkData] {
 	})
 }()
 
-func getUserCredsFromJWT(t *jwt.Token) (user, password string, err error) {
+func getUserSecretFromJWT(t *jwt.Token) (user, secret string, err error) {
 	user, err = t.Claims.GetSubject()
 	if err != nil {
 		return "", "", err
 	}
-	password = config.ProxyAuthPassword.GetPassword(user)
-	return user, password, nil
+	secret = config.ProxyAuthPassword.GetSecret(user)
+	return user, secret, nil
 }
 
 func UnwrapProxyLinkToken(encodedToken string) (user string, link string, headers map[string]string, tunnelType config.TunnelType, err error) {
@@ -246,7 +253,7 @@ func UnwrapProxyLinkToken(encodedToken string) (user string, link string, header
 			return "", "", nil, "", err
 		}
 		user, pass, _ := strings.Cut(proxyLink.User, ":")
-		if pass != config.ProxyAuthPassword.GetPassword(user) {
+		if !config.ProxyAuthPassword.VerifyPassword(user, pass) {
 			err := core.NewAPIError("unauthorized")
 			err.StatusCode = http.StatusUnauthorized
 			return "", "", nil, "", err
@@ -254,14 +261,17 @@ func UnwrapProxyLinkToken(encodedToken string) (user string, link string, header
 		proxyLink.User = user
 	} else {
 		claims := &core.JWTClaims[proxyLinkTokenData]{}
-		password := ""
+		secret := ""
 		_, err = core.ParseJWT(func(t *jwt.Token) (any, error) {
-			user, password, err = getUserCredsFromJWT(t)
-			return []byte(password), err
+			user, secret, err = getUserSecretFromJWT(t)
+			if err == nil && secret == "" {
+				err = errors.New("unknown user")
+			}
+			return []byte(secret), err
 		}, encodedToken, claims)
 
 		if err != nil {
-			if errors.Is(err, jwt.ErrTokenInvalidClaims) {
+			if errors.Is(err, jwt.ErrTokenInvalidClaims) || errors.Is(err, jwt.ErrTokenUnverifiable) {
 				rerr := core.NewAPIError("unauthorized")
 				rerr.StatusCode = http.StatusUnauthorized
 				rerr.Cause = err
@@ -279,7 +289,7 @@ func UnwrapProxyLinkToken(encodedToken string) (user string, link string, header
 			}
 			linkBlob = blob
 		} else {
-			blob, err := core.Decrypt(password, claims.Data.EncLink)
+			blob, err := core.Decrypt(secret, claims.Data.EncLink)
 			if err != nil {
 				return "", "", nil, "", err
 			}
@@ -6,8 +6,15 @@ import (
 	"io"
 	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 )
@@ -15,12 +22,109 @@ import (
 //go:embed *.mp4
 var videoFS embed.FS
 
+// clips in this directory override the embedded ones, localized clips are
+// kept in sub-directory named after the language, e.g. `pt-br/500.mp4`
+var overrideDir = filepath.Join(config.DataDir, "store_video")
+
+var langRegex = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// parseAcceptLanguage returns the languages in the order of preference,
+// followed by their base language.
+func parseAcceptLanguage(header string) []string {
+	type weightedLang struct {
+		lang string
+		q    float64
+	}
+	wLangs := []weightedLang{}
+	for part := range strings.SplitSeq(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if !langRegex.MatchString(lang) {
+			continue
+		}
+		q := 1.0
+		if qValue, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(qValue, 64); err == nil {
+				q = v
+			}
+		}
+		if q > 0 {
+			wLangs = append(wLangs, weightedLang{lang: lang, q: q})
+		}
+	}
+	slices.SortStableFunc(wLangs, func(a, b weightedLang) int {
+		if a.q > b.q {
+			return -1
+		}
+		if a.q < b.q {
+			return 1
+		}
+		return 0
+	})
+
+	langs := []string{}
+	for _, wLang := range wLangs {
+		if !slices.Contains(langs, wLang.lang) {
+			langs = append(langs, wLang.lang)
+		}
+	}
+	for _, wLang := range wLangs {
+		if base, _, ok := strings.Cut(wLang.lang, "-"); ok && !slices.Contains(langs, base) {
+			langs = append(langs, base)
+		}
+	}
+	return langs
+}
+
+func getLangs(r *http.Request) []string {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return parseAcceptLanguage(lang)
+	}
+	return parseAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+func openOverride(name string, langs []string) (*os.File, error) {
+	for _, lang := range langs {
+		file, err := os.Open(filepath.Join(overrideDir, lang, name))
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return file, err
+		}
+	}
+	return os.Open(filepath.Join(overrideDir, name))
+}
+
+func open(name string, langs []string) (fs.File, time.Time, error) {
+	file, err := openOverride(name, langs)
+	if err == nil {
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, time.Time{}, err
+		}
+		return file, stat.ModTime(), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, time.Time{}, err
+	}
+
+	embedded, err := videoFS.Open(name)
+	return embedded, config.ServerStartTime, err
+}
+
 func Serve(name string, w http.ResponseWriter, r *http.Request) error {
-	if !strings.HasSuffix(name, ".mp4") {
-		name += ".mp4"
+	name = strings.TrimSuffix(name, ".mp4")
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		w.WriteHeader(404)
+		return nil
 	}
 
-	file, err := videoFS.Open(name)
+	langs := getLangs(r)
+	file, modTime, err := open(name+".mp4", langs)
+	if errors.Is(err, fs.ErrNotExist) {
+		if fallbackName, ok := fallbackNameByName[name]; ok {
+			file, modTime, err = open(fallbackName+".mp4", langs)
+		}
+	}
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
 			w.WriteHeader(404)
@@ -32,7 +136,8 @@ func Serve(name string, w http.ResponseWriter, r *http.Request) error {
 
 	if f, ok := file.(io.ReadSeeker); ok {
 		w.Header().Set("Content-Type", "video/mp4")
-		http.ServeContent(w, r, name, config.ServerStartTime, f)
+		w.Header().Add("Vary", "Accept-Language")
+		http.ServeContent(w, r, name+".mp4", modTime, f)
 		return nil
 	}
 
@@ -50,10 +155,53 @@ const (
 	StoreVideoNameDownloadFailed           StoreVideoName = "download_failed"
 	StoreVideoNameDownloading              StoreVideoName = "downloading"
 	StoreVideoNameNoMatchingFile           StoreVideoName = "no_matching_file"
+
+	StoreVideoNameStoreIPBlocked       StoreVideoName = "store_ip_blocked"
+	StoreVideoNameStoreLimitExceeded   StoreVideoName = "store_limit_exceeded"
+	StoreVideoNameStoreMagnetTooLarge  StoreVideoName = "store_magnet_too_large"
+	StoreVideoNameStorePaymentRequired StoreVideoName = "store_payment_required"
+	StoreVideoNameStoreRateLimited     StoreVideoName = "store_rate_limited"
 )
 
+// clips served for the names without embedded clip, unless overridden
+var fallbackNameByName = map[StoreVideoName]StoreVideoName{
+	StoreVideoNameStoreIPBlocked:       StoreVideoName403,
+	StoreVideoNameStoreLimitExceeded:   StoreVideoName500,
+	StoreVideoNameStoreMagnetTooLarge:  StoreVideoNameDownloadFailed,
+	StoreVideoNameStorePaymentRequired: StoreVideoName403,
+	StoreVideoNameStoreRateLimited:     StoreVideoName500,
+}
+
+var nameByErrorCode = map[core.ErrorCode]StoreVideoName{
+	core.ErrorCodeForbidden:           StoreVideoName403,
+	core.ErrorCodePaymentRequired:     StoreVideoNameStorePaymentRequired,
+	core.ErrorCodeStoreIPBlocked:      StoreVideoNameStoreIPBlocked,
+	core.ErrorCodeStoreLimitExceeded:  StoreVideoNameStoreLimitExceeded,
+	core.ErrorCodeStoreMagnetInvalid:  StoreVideoNameDownloadFailed,
+	core.ErrorCodeStoreMagnetTooLarge: StoreVideoNameStoreMagnetTooLarge,
+	core.ErrorCodeTooManyRequests:     StoreVideoNameStoreRateLimited,
+	core.ErrorCodeUnauthorized:        StoreVideoName401,
+}
+
+// GetNameForError returns the name of the clip for the error code of the
+// error, or the given default name.
+func GetNameForError(err error, defaultName StoreVideoName) StoreVideoName {
+	var sterr core.StremThruError
+	if errors.As(err, &sterr) {
+		if name, ok := nameByErrorCode[sterr.GetError().Code]; ok {
+			return name
+		}
+	}
+	return defaultName
+}
+
 func GetLink(name StoreVideoName, r *http.Request) string {
-	return shared.ExtractRequestBaseURL(r).JoinPath("/v0/store/_/static/" + name + ".mp4").String()
+	link := shared.ExtractRequestBaseURL(r).JoinPath("/v0/store/_/static/" + name + ".mp4")
+	// the player fetching the clip may not have the language of the client
+	if langs := parseAcceptLanguage(r.Header.Get("Accept-Language")); len(langs) > 0 {
+		link.RawQuery = "lang=" + langs[0]
+	}
+	return link.String()
 }
 
 func Redirect(name StoreVideoName, w http.ResponseWriter, r *http.Request) (url string) {
@@ -0,0 +1,49 @@
+package store_video
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	for _, test := range []struct {
+		header   string
+		expected []string
+	}{
+		{"", []string{}},
+		{"en", []string{"en"}},
+		{"pt-BR,pt;q=0.9,en;q=0.8", []string{"pt-br", "pt", "en"}},
+		{"en;q=0.5, fr-CA, de;q=0", []string{"fr-ca", "en", "fr"}},
+		{"*, ../etc", []string{}},
+	} {
+		t.Run(test.header, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseAcceptLanguage(test.header))
+		})
+	}
+}
+
+func TestGetNameForError(t *testing.T) {
+	rateLimitedErr := core.NewUpstreamError("slow down")
+	rateLimitedErr.Code = core.ErrorCodeTooManyRequests
+
+	unknownErr := core.NewStoreError("unknown")
+	unknownErr.Code = core.ErrorCodeUnknown
+
+	for _, test := range []struct {
+		name     string
+		err      error
+		expected StoreVideoName
+	}{
+		{"rate limited", rateLimitedErr, StoreVideoNameStoreRateLimited},
+		{"unknown code", unknownErr, StoreVideoName500},
+		{"not stremthru error", errors.New("oops"), StoreVideoName500},
+		{"nil", nil, StoreVideoName500},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, GetNameForError(test.err, StoreVideoName500))
+		})
+	}
+}
@@ -1,8 +1,18 @@
 package configure
 
-import "html/template"
+import (
+	"html/template"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
 
 func GetScriptStoreTokenDescription(nameSelector, tokenSelector string) template.JS {
+	p2pDescription := "⚠️ Peer-to-Peer (🧪 Experimental)"
+	p2pTokenDisabled := "true"
+	if config.P2P.IsEnabled() {
+		p2pDescription += ", optional StremThru Basic Auth Token (base64 encoded) to stream through StremThru"
+		p2pTokenDisabled = "false"
+	}
 	if nameSelector == "" {
 		nameSelector = "'[data-field-store-token]'"
 	}
@@ -28,7 +38,7 @@ function onStoreNameChangeUpdateStoreTokenDescription(nameField) {
 			pp: "PikPak <a href='https://mypikpak.com/drive/account/basic' target='_blank'>credential</a> in <code>email:password</code> format, e.g. <code>john.doe@example.com:secret-password</code>",
 			rd: "RealDebrid <a href='https://real-debrid.com/apitoken' target='_blank'>API Token</a>",
 			tb: "TorBox <a href='https://torbox.app/settings' target='_blank'>API Key</a>",
-			p2p: "⚠️ Peer-to-Peer (🧪 Experimental)",
+			p2p: "` + p2pDescription + `",
 		};
 		const storeFallback = {
 			alldebrid: "ad",
@@ -42,8 +52,8 @@ function onStoreNameChangeUpdateStoreTokenDescription(nameField) {
 		  p2p: "p2p",
 		};
     tokenDescElem.innerHTML = descByStore[nameField.value] || descByStore[storeFallback[nameField.value]] || descByStore["*"] || "";
-    tokenField.disabled = nameField.value === "p2p";
-    if (nameField.value === "p2p") {
+    tokenField.disabled = ` + p2pTokenDisabled + ` && nameField.value === "p2p";
+    if (tokenField.disabled) {
       tokenField.value = "";
     }
   }
@@ -98,6 +98,7 @@ func getIMDBMetaFromMDBList(imdbIds []string, mdblistAPIKey string) (map[string]
 		newMetas = append(newMetas, meta)
 		newMappings = append(newMappings, imdb_title.BulkRecordMappingInputItem{
 			IMDBId:  mInfo.Ids.IMDB,
+			Type:    mInfo.Type,
 			TMDBId:  strconv.Itoa(mInfo.Ids.TMDB),
 			TVDBId:  strconv.Itoa(mInfo.Ids.TVDB),
 			TraktId: strconv.Itoa(mInfo.Ids.Trakt),
@@ -113,6 +114,16 @@ func getIMDBMetaFromMDBList(imdbIds []string, mdblistAPIKey string) (map[string]
 	return byId, nil
 }
 
+// getIMDBRatings uses the synced IMDb ratings, for lists without MDBList.
+func getIMDBRatings(r *http.Request, imdbIds []string) map[string]imdb_title.IMDBTitleRating {
+	ratingById, err := imdb_title.GetRatingsByIds(imdbIds)
+	if err != nil {
+		LogError(r, "failed to get imdb ratings", err)
+		return map[string]imdb_title.IMDBTitleRating{}
+	}
+	return ratingById
+}
+
 type catalogItem struct {
 	stremio.MetaPreview
 	item any
@@ -268,6 +279,14 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		imdbIds := []string{}
+		for i := range medias {
+			if idMap := medias[i].IdMap; idMap != nil && idMap.IMDB != "" {
+				imdbIds = append(imdbIds, idMap.IMDB)
+			}
+		}
+		ratingById := getIMDBRatings(r, imdbIds)
+
 		for i := range catalogItems {
 			item := &catalogItems[i]
 			media := medias[i]
@@ -280,6 +299,9 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 			if rpdbPosterBaseUrl != "" && media.IdMap.IMDB != "" {
 				item.Poster = rpdbPosterBaseUrl + media.IdMap.IMDB + ".jpg?fallback=true"
 			}
+			if rating, ok := ratingById[media.IdMap.IMDB]; ok {
+				item.IMDBRating = rating.String()
+			}
 
 			items = append(items, item.MetaPreview)
 		}
@@ -327,6 +349,12 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		imdbIds := make([]string, 0, len(imdbIdByTraktId))
+		for _, imdbId := range imdbIdByTraktId {
+			imdbIds = append(imdbIds, imdbId)
+		}
+		ratingById := getIMDBRatings(r, imdbIds)
+
 		for i := range catalogItems {
 			item := &catalogItems[i]
 			traktId := traktIds[i]
@@ -336,6 +364,9 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 				if rpdbPosterBaseUrl != "" {
 					item.MetaPreview.Poster = rpdbPosterBaseUrl + imdbId + ".jpg?fallback=true"
 				}
+				if rating, ok := ratingById[imdbId]; ok {
+					item.MetaPreview.IMDBRating = rating.String()
+				}
 			} else {
 				continue
 			}
@@ -18,7 +18,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 
 	isAuthed := false
 	if cookie, err := stremio_shared.GetAdminCookieValue(w, r); err == nil && !cookie.IsExpired {
-		isAuthed = config.ProxyAuthPassword.GetPassword(cookie.User()) == cookie.Pass()
+		isAuthed = config.ProxyAuthPassword.VerifyPassword(cookie.User(), cookie.Pass())
 	}
 
 	ud, err := getUserData(r, isAuthed)
@@ -40,7 +40,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 			if !IsPublicInstance {
 				user := r.Form.Get("user")
 				pass := r.Form.Get("pass")
-				if pass == "" || config.ProxyAuthPassword.GetPassword(user) != pass {
+				if !config.ProxyAuthPassword.VerifyPassword(user, pass) {
 					td.AuthError = "Wrong Credential!"
 				} else if !config.AuthAdmin.IsAdmin(user) {
 					td.AuthError = "Not Authorized!"
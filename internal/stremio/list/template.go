@@ -15,7 +15,6 @@ import (
 	stremio_template "github.com/MunifTanjim/stremthru/internal/stremio/template"
 	stremio_userdata "github.com/MunifTanjim/stremthru/internal/stremio/userdata"
 	"github.com/MunifTanjim/stremthru/internal/trakt"
-	"github.com/google/uuid"
 )
 
 var IsPublicInstance = config.IsPublicInstance
@@ -147,7 +146,7 @@ func getTemplateData(ud *UserData, udError userDataError, isAuthed bool, r *http
 			Action: configure.ConfigAction{
 				Visible: ud.TraktTokenId == "" || udError.trakt_token_id != "",
 				Label:   "Authorize",
-				OnClick: template.JS(`window.open("` + oauth.TraktOAuthConfig.AuthCodeURL(uuid.NewString()) + `", "_blank")`),
+				OnClick: template.JS(`window.open("` + config.BaseURL.JoinPath("/auth", string(oauth.ProviderTraktTv), "authorize").String() + `", "_blank")`),
 			},
 		},
 		Shuffle: configure.Config{
@@ -0,0 +1,109 @@
+package stremio_meta
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+type ExtraData struct {
+	Search string
+	Skip   int
+}
+
+func getExtra(r *http.Request) *ExtraData {
+	extra := &ExtraData{}
+	if extraParams := GetPathValue(r, "extra"); extraParams != "" {
+		if q, err := url.ParseQuery(extraParams); err == nil {
+			extra.Search = q.Get("search")
+			if skipStr := q.Get("skip"); skipStr != "" {
+				if skip, err := strconv.Atoi(skipStr); err == nil {
+					extra.Skip = skip
+				}
+			}
+		}
+	}
+	return extra
+}
+
+const searchLimit = 50
+
+func handleCatalog(w http.ResponseWriter, r *http.Request) {
+	if !IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	if GetPathValue(r, "id") != searchCatalogId {
+		shared.ErrorNotFound(r).Send(w, r)
+		return
+	}
+
+	res := stremio.CatalogHandlerResponse{
+		Metas: []stremio.MetaPreview{},
+	}
+
+	extra := getExtra(r)
+	// search results are not paginated
+	if extra.Search == "" || extra.Skip > 0 {
+		SendResponse(w, r, 200, res)
+		return
+	}
+
+	titleType := imdb_title.SearchTitleTypeMovie
+	if r.PathValue("contentType") == string(stremio.ContentTypeSeries) {
+		titleType = imdb_title.SearchTitleTypeShow
+	}
+
+	ids, err := imdb_title.SearchIds(extra.Search, titleType, 0, false, searchLimit)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if len(ids) == 0 {
+		SendResponse(w, r, 200, res)
+		return
+	}
+
+	titles, err := imdb_title.ListByIds(ids)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	titleById := make(map[string]*imdb_title.IMDBTitle, len(titles))
+	for i := range titles {
+		titleById[titles[i].TId] = &titles[i]
+	}
+
+	metas, err := imdb_title.GetMetasByIds(ids)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	metaById := make(map[string]*imdb_title.IMDBTitleMeta, len(metas))
+	for i := range metas {
+		metaById[metas[i].TId] = &metas[i]
+	}
+
+	ratingById, err := imdb_title.GetRatingsByIds(ids)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	for _, id := range ids {
+		if title, ok := titleById[id]; ok {
+			preview := toMetaPreview(title, metaById[id])
+			if rating, ok := ratingById[id]; ok {
+				preview.IMDBRating = rating.String()
+			}
+			res.Metas = append(res.Metas, preview)
+		}
+	}
+
+	SendResponse(w, r, 200, res)
+}
@@ -0,0 +1,66 @@
+package stremio_meta
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/server"
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCatalogRequest(contentType, id, extra string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/catalog/"+contentType+"/"+id+"/"+extra, nil)
+	r.SetPathValue("contentType", contentType)
+	r.SetPathValue("id", id)
+	r.SetPathValue("extraJson", extra)
+	return server.SetReqCtx(r, &server.ReqCtx{StartTime: time.Now(), Log: slog.Default()})
+}
+
+func TestGetExtra(t *testing.T) {
+	extra := getExtra(newCatalogRequest("movie", searchCatalogId, "search=example&skip=10.json"))
+	assert.Equal(t, &ExtraData{Search: "example", Skip: 10}, extra)
+
+	extra = getExtra(newCatalogRequest("movie", searchCatalogId, "skip=invalid.json"))
+	assert.Equal(t, &ExtraData{}, extra)
+}
+
+func TestHandleCatalog(t *testing.T) {
+	setupMetaTest(t)
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		id          string
+		extra       string
+		status      int
+		ids         []string
+	}{
+		{"unknown catalog", "movie", "unknown", "search=example.json", 404, nil},
+		{"without search", "movie", searchCatalogId, "skip=0.json", 200, []string{}},
+		{"skipped", "movie", searchCatalogId, "search=example&skip=10.json", 200, []string{}},
+		{"movie", "movie", searchCatalogId, "search=example%20movie.json", 200, []string{"tt9200001"}},
+		{"series", "series", searchCatalogId, "search=example%20series.json", 200, []string{"tt9200002", "tt9200003"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handleCatalog(w, newCatalogRequest(tc.contentType, tc.id, tc.extra))
+			assert.Equal(t, tc.status, w.Code)
+			if tc.ids == nil {
+				return
+			}
+
+			var res stremio.CatalogHandlerResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			ids := []string{}
+			for _, meta := range res.Metas {
+				ids = append(ids, meta.Id)
+			}
+			assert.Equal(t, tc.ids, ids)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package stremio_meta
+
+import (
+	"net/http"
+
+	"github.com/MunifTanjim/stremthru/internal/anime"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+const searchCatalogId = "st.meta.search"
+
+func GetManifest(r *http.Request) *stremio.Manifest {
+	idPrefixes := append([]string{"tt", "tmdb:", "tvdb:"}, anime.StremIdPrefixes...)
+
+	manifest := &stremio.Manifest{
+		ID:          shared.GetReversedHostname(r) + ".meta",
+		Name:        "StremThru Meta",
+		Description: "Stremio Addon for Metadata from IMDb Datasets",
+		Version:     config.Version,
+		Resources: []stremio.Resource{
+			{
+				Name: stremio.ResourceNameCatalog,
+				Types: []stremio.ContentType{
+					stremio.ContentTypeMovie,
+					stremio.ContentTypeSeries,
+				},
+			},
+			{
+				Name: stremio.ResourceNameMeta,
+				Types: []stremio.ContentType{
+					stremio.ContentTypeMovie,
+					stremio.ContentTypeSeries,
+				},
+				IDPrefixes: idPrefixes,
+			},
+		},
+		Types: []stremio.ContentType{
+			stremio.ContentTypeMovie,
+			stremio.ContentTypeSeries,
+		},
+		Catalogs: []stremio.Catalog{
+			{
+				Type: string(stremio.ContentTypeMovie),
+				Id:   searchCatalogId,
+				Name: "Search",
+				Extra: []stremio.CatalogExtra{
+					{Name: "search", IsRequired: true},
+					{Name: "skip"},
+				},
+			},
+			{
+				Type: string(stremio.ContentTypeSeries),
+				Id:   searchCatalogId,
+				Name: "Search",
+				Extra: []stremio.CatalogExtra{
+					{Name: "search", IsRequired: true},
+					{Name: "skip"},
+				},
+			},
+		},
+		Logo: "https://emojiapi.dev/api/v1/sparkles/256.png",
+	}
+
+	return manifest
+}
+
+func handleManifest(w http.ResponseWriter, r *http.Request) {
+	if !IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	SendResponse(w, r, 200, GetManifest(r))
+}
@@ -0,0 +1,30 @@
+package stremio_meta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetManifest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://stremthru.example.com/stremio/meta/manifest.json", nil)
+	manifest := GetManifest(r)
+
+	assert.Equal(t, "com.example.stremthru.meta", manifest.ID)
+
+	catalogTypes := []string{}
+	for _, catalog := range manifest.Catalogs {
+		assert.Equal(t, searchCatalogId, catalog.Id)
+		catalogTypes = append(catalogTypes, catalog.Type)
+	}
+	assert.Equal(t, []string{string(stremio.ContentTypeMovie), string(stremio.ContentTypeSeries)}, catalogTypes)
+
+	for _, resource := range manifest.Resources {
+		if resource.Name == stremio.ResourceNameMeta {
+			assert.Subset(t, resource.IDPrefixes, []string{"tt", "tmdb:", "tvdb:"})
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package stremio_meta
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+// resolveIMDBId returns the IMDb id for `tt…`, `tmdb:`, `tvdb:` and anime
+// ids. It returns empty string if there is none.
+func resolveIMDBId(contentType stremio.ContentType, id string) (string, error) {
+	stremId, err := imdb_title.ResolveStremId(contentType, id)
+	if err != nil {
+		return "", err
+	}
+	imdbId, _, _ := strings.Cut(stremId, ":")
+	return imdbId, nil
+}
+
+func getContentType(titleType string) stremio.ContentType {
+	switch imdb_title.IMDBTitleType(titleType) {
+	case imdb_title.IMDBTitleTypeTvSeries, imdb_title.IMDBTitleTypeTvMiniSeries:
+		return stremio.ContentTypeSeries
+	default:
+		return stremio.ContentTypeMovie
+	}
+}
+
+func getPosterUrl(imdbId string) string {
+	return "https://images.metahub.space/poster/small/" + imdbId + "/img"
+}
+
+func getBackgroundUrl(imdbId string) string {
+	return "https://images.metahub.space/background/medium/" + imdbId + "/img"
+}
+
+func getLogoUrl(imdbId string) string {
+	return "https://images.metahub.space/logo/medium/" + imdbId + "/img"
+}
+
+func toMetaPreview(title *imdb_title.IMDBTitle, m *imdb_title.IMDBTitleMeta) stremio.MetaPreview {
+	preview := stremio.MetaPreview{
+		Id:          title.TId,
+		Type:        getContentType(title.Type),
+		Name:        title.Title,
+		Poster:      getPosterUrl(title.TId),
+		PosterShape: stremio.MetaPosterShapePoster,
+	}
+	if title.Year != 0 {
+		preview.ReleaseInfo = strconv.Itoa(title.Year)
+	}
+	if m != nil {
+		if m.Poster != "" {
+			preview.Poster = m.Poster
+		}
+		preview.Description = m.Description
+		preview.Genres = m.Genres
+		if m.Rating != 0 {
+			preview.IMDBRating = strconv.FormatFloat(float64(m.Rating)/10, 'f', 1, 32)
+		}
+		if trailer, err := url.Parse(m.Trailer); err == nil && trailer.Host == "youtube.com" {
+			preview.Trailers = append(preview.Trailers, stremio.MetaTrailer{
+				Source: trailer.Query().Get("v"),
+				Type:   "Trailer",
+			})
+		}
+	}
+	return preview
+}
+
+func buildMeta(imdbId string) (*stremio.Meta, error) {
+	title, err := imdb_title.Get(imdbId)
+	if err != nil || title == nil {
+		return nil, err
+	}
+
+	var m *imdb_title.IMDBTitleMeta
+	if metas, err := imdb_title.GetMetasByIds([]string{imdbId}); err != nil {
+		return nil, err
+	} else if len(metas) > 0 {
+		m = &metas[0]
+	}
+
+	preview := toMetaPreview(title, m)
+	if ratingById, err := imdb_title.GetRatingsByIds([]string{imdbId}); err != nil {
+		return nil, err
+	} else if rating, ok := ratingById[imdbId]; ok {
+		preview.IMDBRating = rating.String()
+	}
+	meta := &stremio.Meta{
+		Id:          preview.Id,
+		Type:        preview.Type,
+		Name:        preview.Name,
+		Genres:      preview.Genres,
+		Poster:      stremio.MetaPosterShape(preview.Poster),
+		PosterShape: string(preview.PosterShape),
+		Background:  getBackgroundUrl(imdbId),
+		Logo:        getLogoUrl(imdbId),
+		Description: preview.Description,
+		ReleaseInfo: preview.ReleaseInfo,
+		IMDBRating:  preview.IMDBRating,
+		Trailers:    preview.Trailers,
+		IMDBId:      imdbId,
+		Year:        preview.ReleaseInfo,
+	}
+	if meta.IMDBRating != "" {
+		meta.Links = append(meta.Links, stremio.MetaLink{
+			Name:     meta.IMDBRating,
+			Category: stremio.MetaLinkCategoryIMDB,
+			URL:      "https://imdb.com/title/" + imdbId,
+		})
+	}
+	if m != nil {
+		if m.Backdrop != "" {
+			meta.Background = m.Backdrop
+		}
+		if m.Runtime != 0 {
+			meta.Runtime = strconv.Itoa(m.Runtime) + " min"
+		}
+	}
+
+	// the datasets do not have the release date of the episodes, so the start
+	// of the series is used, without which the videos are left out.
+	if meta.Type == stremio.ContentTypeSeries && title.Year != 0 {
+		episodes, err := imdb_title.ListEpisodesByParentId(imdbId)
+		if err != nil {
+			return nil, err
+		}
+		released := time.Date(title.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		meta.Videos = make([]stremio.MetaVideo, len(episodes))
+		for i := range episodes {
+			e := &episodes[i]
+			meta.Videos[i] = stremio.MetaVideo{
+				Id:       imdbId + ":" + strconv.Itoa(e.Season) + ":" + strconv.Itoa(e.Episode),
+				Released: released,
+				Season:   stremio.ZeroIndexedInt(e.Season),
+				Episode:  stremio.ZeroIndexedInt(e.Episode),
+			}
+		}
+	}
+
+	return meta, nil
+}
+
+var metaCache = cache.NewCache[stremio.Meta](&cache.CacheConfig{
+	Lifetime: 6 * time.Hour,
+	Name:     "stremio:meta:meta",
+})
+
+// GetMeta returns the meta for `tt…` or anime ids, built from the synced
+// IMDb datasets. It returns nil if the title is not found.
+func GetMeta(contentType stremio.ContentType, id string) (*stremio.Meta, error) {
+	imdbId, err := resolveIMDBId(contentType, id)
+	if err != nil || imdbId == "" {
+		return nil, err
+	}
+
+	var meta stremio.Meta
+	if !metaCache.Get(imdbId, &meta) {
+		m, err := buildMeta(imdbId)
+		if err != nil || m == nil {
+			return nil, err
+		}
+		meta = *m
+		if err := metaCache.Add(imdbId, meta); err != nil {
+			log.Warn("failed to cache meta", "error", err, "id", imdbId)
+		}
+	}
+	meta.Id = id
+	return &meta, nil
+}
+
+func handleMeta(w http.ResponseWriter, r *http.Request) {
+	if !IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	meta, err := GetMeta(stremio.ContentType(r.PathValue("contentType")), GetPathValue(r, "id"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if meta == nil {
+		shared.ErrorNotFound(r).Send(w, r)
+		return
+	}
+
+	SendResponse(w, r, 200, stremio.MetaHandlerResponse{Meta: *meta})
+}
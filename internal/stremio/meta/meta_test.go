@@ -0,0 +1,97 @@
+package stremio_meta
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMetaTest(t *testing.T) {
+	t.Helper()
+	dbtest.Setup(t)
+	assert.NoError(t, imdb_title.Upsert([]imdb_title.IMDBTitle{
+		{TId: "tt9200001", Title: "Example Movie", Year: 2020, Type: string(imdb_title.IMDBTitleTypeMovie)},
+		{TId: "tt9200002", Title: "Example Series", Year: 2021, Type: string(imdb_title.IMDBTitleTypeTvSeries)},
+		{TId: "tt9200003", Title: "Example Undated Series", Type: string(imdb_title.IMDBTitleTypeTvSeries)},
+	}))
+	assert.NoError(t, imdb_title.UpsertEpisodes([]imdb_title.IMDBTitleEpisode{
+		{TId: "tt9200021", ParentTId: "tt9200002", Season: 1, Episode: 1},
+		{TId: "tt9200022", ParentTId: "tt9200002", Season: 1, Episode: 2},
+		{TId: "tt9200031", ParentTId: "tt9200003", Season: 1, Episode: 1},
+	}))
+	assert.NoError(t, imdb_title.UpsertRatings([]imdb_title.IMDBTitleRating{
+		{TId: "tt9200001", Rating: 7.5, Votes: 100},
+	}))
+	assert.NoError(t, imdb_title.RebuildFTS())
+}
+
+func TestToMetaPreview(t *testing.T) {
+	title := &imdb_title.IMDBTitle{TId: "tt9200001", Title: "Example Movie", Year: 2020, Type: string(imdb_title.IMDBTitleTypeMovie)}
+
+	preview := toMetaPreview(title, nil)
+	assert.Equal(t, stremio.ContentTypeMovie, preview.Type)
+	assert.Equal(t, "2020", preview.ReleaseInfo)
+	assert.Equal(t, getPosterUrl("tt9200001"), preview.Poster)
+
+	preview = toMetaPreview(title, &imdb_title.IMDBTitleMeta{
+		TId:     "tt9200001",
+		Poster:  "https://example.com/poster.jpg",
+		Rating:  75,
+		Trailer: "https://youtube.com/watch?v=abc",
+	})
+	assert.Equal(t, "https://example.com/poster.jpg", preview.Poster)
+	assert.Equal(t, "7.5", preview.IMDBRating)
+	assert.Equal(t, []stremio.MetaTrailer{{Source: "abc", Type: "Trailer"}}, preview.Trailers)
+}
+
+func TestGetMeta(t *testing.T) {
+	setupMetaTest(t)
+
+	t.Run("movie", func(t *testing.T) {
+		meta, err := GetMeta(stremio.ContentTypeMovie, "tt9200001")
+		assert.NoError(t, err)
+		if assert.NotNil(t, meta) {
+			assert.Equal(t, stremio.ContentTypeMovie, meta.Type)
+			assert.Equal(t, "Example Movie", meta.Name)
+			assert.Equal(t, "7.5", meta.IMDBRating)
+			assert.Len(t, meta.Links, 1)
+			assert.Empty(t, meta.Videos)
+		}
+	})
+
+	t.Run("series", func(t *testing.T) {
+		meta, err := GetMeta(stremio.ContentTypeSeries, "tt9200002")
+		assert.NoError(t, err)
+		if assert.NotNil(t, meta) && assert.Len(t, meta.Videos, 2) {
+			released := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+			for i, video := range meta.Videos {
+				assert.Equal(t, "tt9200002:1:"+video.Episode.String(), video.Id)
+				assert.Equal(t, i+1, int(video.Episode))
+				assert.Equal(t, released, video.Released)
+			}
+
+			blob, err := json.Marshal(meta.Videos[0])
+			assert.NoError(t, err)
+			assert.Contains(t, string(blob), `"released":"2021-01-01T00:00:00Z"`)
+		}
+	})
+
+	t.Run("series without year", func(t *testing.T) {
+		meta, err := GetMeta(stremio.ContentTypeSeries, "tt9200003")
+		assert.NoError(t, err)
+		if assert.NotNil(t, meta) {
+			assert.Empty(t, meta.Videos)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		meta, err := GetMeta(stremio.ContentTypeMovie, "tt9299999")
+		assert.NoError(t, err)
+		assert.Nil(t, meta)
+	})
+}
@@ -0,0 +1,31 @@
+package stremio_meta
+
+import (
+	"net/http"
+
+	"github.com/MunifTanjim/stremthru/internal/server"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+)
+
+func commonMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := server.GetReqCtx(r)
+		ctx.Log = log.With("request_id", ctx.RequestId)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func AddStremioMetaEndpoints(mux *http.ServeMux) {
+	withCors := shared.Middleware(shared.EnableCORS)
+
+	router := http.NewServeMux()
+
+	router.HandleFunc("/manifest.json", withCors(handleManifest))
+
+	router.HandleFunc("/catalog/{contentType}/{idJson}", withCors(handleCatalog))
+	router.HandleFunc("/catalog/{contentType}/{id}/{extraJson}", withCors(handleCatalog))
+
+	router.HandleFunc("/meta/{contentType}/{idJson}", withCors(handleMeta))
+
+	mux.Handle("/stremio/meta/", http.StripPrefix("/stremio/meta", commonMiddleware(router)))
+}
@@ -0,0 +1,12 @@
+package stremio_meta
+
+import (
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
+)
+
+var IsMethod = shared.IsMethod
+var SendError = shared.SendError
+
+var SendResponse = stremio_shared.SendResponse
+var GetPathValue = stremio_shared.GetPathValue
@@ -8,6 +8,7 @@ import (
 	"github.com/MunifTanjim/go-ptt"
 	"github.com/MunifTanjim/stremthru/internal/anidb"
 	"github.com/MunifTanjim/stremthru/internal/anime"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
 	"github.com/MunifTanjim/stremthru/internal/torrent_info"
 	"github.com/MunifTanjim/stremthru/internal/torrent_stream"
 	"github.com/MunifTanjim/stremthru/internal/util"
@@ -111,11 +112,10 @@ func MatchFileByStremId(files []store.MagnetFile, sid string, magnetHash string,
 			return file
 		}
 	}
-	if strings.HasPrefix(sid, "kitsu:") {
-		kitsuId, episode, _ := strings.Cut(strings.TrimPrefix(sid, "kitsu:"), ":")
-		anidbId, season, err := anime.GetAniDBIdByKitsuId(kitsuId)
+	if column, animeId, episode, ok := anime.ParseStremId(sid); ok {
+		anidbId, season, err := anime.GetAniDBIdById(column, animeId)
 		if err != nil {
-			log.Error("failed to get anidb id by kitsu id", "error", err, "kitsu_id", kitsuId)
+			log.Error("failed to get anidb id", "error", err, "sid", sid)
 			return nil
 		}
 		tInfo, err := torrent_info.GetByHash(magnetHash)
@@ -188,6 +188,49 @@ func MatchFileByStremId(files []store.MagnetFile, sid string, magnetHash string,
 				return f
 			}
 		}
+		return matchFileByAniDBEpisode(files, parts[0], expectedSeason, expectedEpisode, magnetHash, storeCode)
+	}
+	return nil
+}
+
+// matchFileByAniDBEpisode matches files of anime torrents, that are numbered
+// differently than IMDb, e.g. by absolute episode.
+func matchFileByAniDBEpisode(files []store.MagnetFile, imdbId string, season, episode int, magnetHash string, storeCode store.StoreCode) *store.MagnetFile {
+	e, err := imdb_title.GetAniDBEpisode(imdbId, season, episode)
+	if err != nil {
+		log.Error("failed to get anidb episode", "error", err, "imdb_id", imdbId, "season", season, "episode", episode)
+		return nil
+	}
+	if e == nil {
+		return nil
+	}
+
+	if f, err := torrent_stream.GetFile(magnetHash, "anidb:"+e.StremId()); err != nil {
+		log.Error("failed to get file by anidb episode", "hash", magnetHash, "asid", e.StremId(), "error", err)
+	} else if f != nil {
+		if file := MatchFileByIdx(files, f.Idx, storeCode); file != nil {
+			return file
+		}
+		if file := MatchFileByName(files, f.Name); file != nil {
+			return file
+		}
+	}
+
+	anidbSeason := util.SafeParseInt(e.Season, -1)
+	for i := range files {
+		f := &files[i]
+		d := getSeasonEpisode(f.Name, true)
+		if d.releaseType != "" || d.episode == -1 {
+			continue
+		}
+		if e.AbsoluteEpisode != -1 && d.episode == e.AbsoluteEpisode && (d.season == -1 || d.season == 1) {
+			log.Debug("matched file by absolute episode", "hash", magnetHash, "filename", f.Name, "episode", e.AbsoluteEpisode)
+			return f
+		}
+		if d.episode == e.Episode && anidbSeason != -1 && d.season == anidbSeason {
+			log.Debug("matched file by anidb episode", "hash", magnetHash, "filename", f.Name, "asid", e.StremId())
+			return f
+		}
 	}
 	return nil
 }
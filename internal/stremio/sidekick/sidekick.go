@@ -113,7 +113,7 @@ func handleRoot(w http.ResponseWriter, r *http.Request) {
 			if !IsPublicInstance {
 				user := r.FormValue("user")
 				pass := r.FormValue("pass")
-				if pass == "" || config.ProxyAuthPassword.GetPassword(user) != pass {
+				if !config.ProxyAuthPassword.VerifyPassword(user, pass) {
 					td.AuthAdminError = "Wrong Credential!"
 				} else if !config.AuthAdmin.IsAdmin(user) {
 					td.AuthAdminError = "Not Authorized!"
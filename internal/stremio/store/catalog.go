@@ -13,7 +13,6 @@ import (
 	stremio_store_usenet "github.com/MunifTanjim/stremthru/internal/stremio/store/usenet"
 	stremio_store_webdl "github.com/MunifTanjim/stremthru/internal/stremio/store/webdl"
 	"github.com/MunifTanjim/stremthru/internal/torrent_info"
-	"github.com/MunifTanjim/stremthru/internal/torrent_stream"
 	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
 	"github.com/MunifTanjim/stremthru/store"
 	"github.com/MunifTanjim/stremthru/stremio"
@@ -172,7 +171,7 @@ func getCatalogItems(s store.Store, storeToken string, clientIp string, idPrefix
 			if hasMore && offset >= max_fetch_list_items {
 				worker_queue.StoreCrawlerQueue.Queue(worker_queue.StoreCrawlerQueueItem{
 					StoreCode:  string(s.GetName().Code()),
-					StoreToken: storeToken,
+					StoreToken: worker_queue.Secret(storeToken),
 				})
 				break
 			}
@@ -315,15 +314,9 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 	totalItems := len(items)
 	items = items[min(extra.Skip, totalItems):min(extra.Skip+limit, totalItems)]
 
-	hashes := make([]string, len(items))
-	for i := range items {
-		item := &items[i]
-		hashes[i] = item.hash
-	}
-
 	includeWebDLsMetaPreview := ud.EnableWebDL && (idr.storeCode == store.StoreCodeRealDebrid || idr.storeCode == store.StoreCodePremiumize || idr.storeCode == store.StoreCodeAllDebrid)
 
-	count := len(hashes)
+	count := len(items)
 	if includeWebDLsMetaPreview {
 		count += 1
 	}
@@ -340,15 +333,20 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	stremIdByHash, err := torrent_stream.GetStremIdByHashes(hashes)
-	if err != nil {
-		log.Error("failed to get strem id by hashes", "error", err)
+	nameByHash := make(map[string]string, len(items))
+	if !idr.isUsenet && !idr.isWebDL {
+		for i := range items {
+			if item := &items[i]; item.hash != "" {
+				nameByHash[item.hash] = item.Name
+			}
+		}
 	}
+	matchByHash := matchTitles(getTitleMatchUserKey(string(ctx.Store.GetName()), ctx.StoreAuthToken), nameByHash, true)
 	for i := range items {
 		item := &items[i]
-		if stremId := stremIdByHash.Get(item.hash); stremId != "" {
-			stremId, _, _ = strings.Cut(stremId, ":")
-			item.Poster = getPosterUrl(stremId)
+		if match, ok := matchByHash[item.hash]; ok && match.IsMatched() {
+			item.Type = match.Type
+			item.Poster = getPosterUrl(match.IMDBId)
 		}
 		res.Metas = append(res.Metas, item.MetaPreview)
 	}
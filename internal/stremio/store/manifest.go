@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/anime"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	"github.com/MunifTanjim/stremthru/store"
@@ -65,7 +66,7 @@ func GetManifest(r *http.Request, ud *UserData) *stremio.Manifest {
 		case "":
 			names := []string{}
 			if user, err := core.ParseBasicAuth(ud.StoreToken); err == nil {
-				if password := config.ProxyAuthPassword.GetPassword(user.Username); password != "" && password == user.Password {
+				if config.ProxyAuthPassword.VerifyPassword(user.Username, user.Password) {
 					for _, name := range config.StoreAuthToken.ListStores(user.Username) {
 						storeName := store.StoreName(name)
 						storeCode := storeName.Code()
@@ -122,12 +123,18 @@ func GetManifest(r *http.Request, ud *UserData) *stremio.Manifest {
 
 	streamResource := stremio.Resource{
 		Name:       stremio.ResourceNameStream,
-		Types:      []stremio.ContentType{ContentTypeOther},
+		Types:      []stremio.ContentType{ContentTypeOther, stremio.ContentTypeMovie, stremio.ContentTypeSeries},
 		IDPrefixes: idPrefixes,
 	}
 	if !ud.HideStream {
 		streamResource.IDPrefixes = append([]string{"tt"}, idPrefixes...)
-		streamResource.Types = append(streamResource.Types, stremio.ContentTypeMovie, stremio.ContentTypeSeries)
+		if config.Feature.IsEnabled(config.FeatureAnime) {
+			streamResource.Types = append(streamResource.Types, "anime")
+			streamResource.IDPrefixes = append(streamResource.IDPrefixes, anime.StremIdPrefixes...)
+		}
+		if config.Feature.IsEnabled(config.FeatureIMDBTitle) {
+			streamResource.IDPrefixes = append(streamResource.IDPrefixes, "tmdb:", "tvdb:")
+		}
 	}
 
 	manifest := &stremio.Manifest{
@@ -139,7 +146,7 @@ func GetManifest(r *http.Request, ud *UserData) *stremio.Manifest {
 		Resources: []stremio.Resource{
 			{
 				Name:       stremio.ResourceNameMeta,
-				Types:      []stremio.ContentType{ContentTypeOther},
+				Types:      []stremio.ContentType{ContentTypeOther, stremio.ContentTypeMovie, stremio.ContentTypeSeries},
 				IDPrefixes: idPrefixes,
 			},
 			streamResource,
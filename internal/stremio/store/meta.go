@@ -10,16 +10,16 @@ import (
 	"github.com/MunifTanjim/go-ptt"
 	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	stremio_addon "github.com/MunifTanjim/stremthru/internal/stremio/addon"
+	stremio_meta "github.com/MunifTanjim/stremthru/internal/stremio/meta"
 	stremio_store_usenet "github.com/MunifTanjim/stremthru/internal/stremio/store/usenet"
 	stremio_store_webdl "github.com/MunifTanjim/stremthru/internal/stremio/store/webdl"
 	"github.com/MunifTanjim/stremthru/internal/torrent_info"
-	"github.com/MunifTanjim/stremthru/internal/torrent_stream"
 	"github.com/MunifTanjim/stremthru/internal/util"
 	"github.com/MunifTanjim/stremthru/store"
 	"github.com/MunifTanjim/stremthru/stremio"
-	"golang.org/x/sync/singleflight"
 )
 
 var client = func() *stremio_addon.Client {
@@ -35,34 +35,38 @@ var cinemetaBaseUrl = func() *url.URL {
 }()
 
 var metaCache = cache.NewCache[stremio.MetaHandlerResponse](&cache.CacheConfig{
-	Lifetime: 2 * time.Hour,
-	Name:     "stremio:store:catalog",
+	Lifetime:  2 * time.Hour,
+	Name:      "stremio:store:catalog",
+	StaleTime: 24 * time.Hour,
 })
 
-var fetchMetaGroup singleflight.Group
-
+// fetchMeta uses the self-hosted meta addon, and falls back to Cinemeta only
+// for titles missing from the synced IMDb datasets.
 func fetchMeta(sType, imdbId, clientIp string) (stremio.MetaHandlerResponse, error) {
 	var meta stremio.MetaHandlerResponse
 
 	cacheKey := sType + ":" + imdbId
-	if !metaCache.Get(cacheKey, &meta) {
-		m, err, _ := fetchMetaGroup.Do(cacheKey, func() (any, error) {
-			r, err := client.FetchMeta(&stremio_addon.FetchMetaParams{
-				BaseURL:  cinemetaBaseUrl,
-				Type:     sType,
-				Id:       imdbId + ".json",
-				ClientIP: clientIp,
-			})
-			return r.Data, err
-		})
-		if err != nil {
-			return meta, err
+	err := metaCache.Fetch(cacheKey, &meta, func() (stremio.MetaHandlerResponse, error) {
+		if config.Feature.IsEnabled(config.FeatureIMDBTitle) {
+			m, err := stremio_meta.GetMeta(stremio.ContentType(sType), imdbId)
+			if err != nil {
+				return stremio.MetaHandlerResponse{}, err
+			}
+			if m != nil {
+				return stremio.MetaHandlerResponse{Meta: *m}, nil
+			}
 		}
-		meta = m.(stremio.MetaHandlerResponse)
-		metaCache.Add(cacheKey, meta)
-	}
 
-	return meta, nil
+		r, err := client.FetchMeta(&stremio_addon.FetchMetaParams{
+			BaseURL:  cinemetaBaseUrl,
+			Type:     sType,
+			Id:       imdbId + ".json",
+			ClientIP: clientIp,
+		})
+		return r.Data, err
+	})
+
+	return meta, err
 }
 
 func getPosterUrl(imdbId string) string {
@@ -354,7 +358,7 @@ func handleMeta(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := getContentType(r); err != nil {
+	if _, err := getItemContentType(r); err != nil {
 		err.Send(w, r)
 		return
 	}
@@ -448,18 +452,9 @@ func handleMeta(w http.ResponseWriter, r *http.Request) {
 	} else {
 		meta.Description = getMetaPreviewDescriptionForTorrent(cInfo.Hash, cInfo.Name)
 
-		if stremIdByHashes, err := torrent_stream.GetStremIdByHashes([]string{cInfo.Hash}); err != nil {
-			log.Error("failed to get strem id by hashes", "error", err)
-		} else {
-			if sid := stremIdByHashes.Get(cInfo.Hash); sid != "" {
-				sid, _, isSeries := strings.Cut(sid, ":")
-				sId = sid
-				if isSeries {
-					sType = "series"
-				} else {
-					sType = "movie"
-				}
-			}
+		if match := matchTitles(getTitleMatchUserKey(string(ctx.Store.GetName()), ctx.StoreAuthToken), map[string]string{cInfo.Hash: cInfo.Name}, false)[cInfo.Hash]; match.IsMatched() {
+			sType, sId = string(match.Type), match.IMDBId
+			meta.Type = match.Type
 		}
 	}
 
@@ -469,6 +464,10 @@ func handleMeta(w http.ResponseWriter, r *http.Request) {
 			log.Error("failed to fetch meta", "error", err)
 		} else {
 			m := r.Meta
+			if m.Name != "" {
+				meta.Name = m.Name
+				meta.Description = cInfo.Name + " " + meta.Description
+			}
 			meta.Description += " " + m.Description
 			meta.Poster = m.Poster
 			meta.Background = m.Background
@@ -499,6 +498,7 @@ func handleMeta(w http.ResponseWriter, r *http.Request) {
 		pttLog.Warn("failed to parse", "error", err, "title", cInfo.Name)
 	}
 
+	videoFiles := []store.MagnetFile{}
 	for _, f := range cInfo.Files {
 		if !core.HasVideoExtension(f.Name) {
 			continue
@@ -548,6 +548,7 @@ func handleMeta(w http.ResponseWriter, r *http.Request) {
 		}
 
 		meta.Videos = append(meta.Videos, video)
+		videoFiles = append(videoFiles, f)
 
 		tInfo.Files = append(tInfo.Files, torrent_info.TorrentInfoInsertDataFile{
 			Name: f.Name,
@@ -556,6 +557,19 @@ func handleMeta(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	// plays the largest video file directly for movies
+	if sType == "movie" && len(meta.Videos) > 0 {
+		var largestSize int64 = -1
+		for i, f := range videoFiles {
+			if f.Size > largestSize {
+				largestSize = f.Size
+				meta.BehaviorHints = &stremio.MetaBehaviorHints{
+					DefaultVideoId: meta.Videos[i].Id,
+				}
+			}
+		}
+	}
+
 	if !idr.isUsenet && !idr.isWebDL {
 		go torrent_info.Upsert([]torrent_info.TorrentInfoInsertData{tInfo}, "", ctx.Store.GetName().Code() != store.StoreCodeRealDebrid)
 	}
@@ -82,7 +82,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		}
 		if lerr != nil {
 			LogError(r, "failed to generate stremthru link", lerr)
-			store_video.Redirect("500", w, r)
+			store_video.Redirect(store_video.GetNameForError(lerr, store_video.StoreVideoName500), w, r)
 			return
 		}
 
@@ -116,7 +116,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		}
 		if lerr != nil {
 			LogError(r, "failed to generate stremthru link", lerr)
-			store_video.Redirect("500", w, r)
+			store_video.Redirect(store_video.GetNameForError(lerr, store_video.StoreVideoName500), w, r)
 			return
 		}
 
@@ -125,7 +125,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		stLink, err := shared.GenerateStremThruLink(r, ctx, url)
 		if err != nil {
 			LogError(r, "failed to generate stremthru link", err)
-			store_video.Redirect("500", w, r)
+			store_video.Redirect(store_video.GetNameForError(err, store_video.StoreVideoName500), w, r)
 			return
 		}
 
@@ -9,7 +9,9 @@ import (
 
 	"github.com/MunifTanjim/go-ptt"
 	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/anime"
 	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
 	"github.com/MunifTanjim/stremthru/internal/torrent_stream"
@@ -19,12 +21,24 @@ import (
 	fuzzy "github.com/paul-mannino/go-fuzzywuzzy"
 )
 
-var streamTemplate = func() *stremio_transformer.StreamTemplate {
-	tmplBlob := stremio_transformer.StreamTemplateDefault.Blob
-	tmplBlob.Description = "✏️ {{.Title}}\n" + tmplBlob.Description
-	tmpl := tmplBlob.MustParse()
+var defaultStreamTemplate = stremio_transformer.StreamTemplateStore
+
+// getStreamTemplate returns the selected template, or the default one if it
+// is missing or invalid.
+func getStreamTemplate(templateId string) *stremio_transformer.StreamTemplate {
+	if templateId == "" {
+		return defaultStreamTemplate
+	}
+	tmpl, err := stremio_transformer.GetParsedTemplate(templateId)
+	if err != nil {
+		log.Error("failed to get template", "error", err, "template_id", templateId)
+		return defaultStreamTemplate
+	}
+	if tmpl == nil {
+		return defaultStreamTemplate
+	}
 	return tmpl
-}()
+}
 
 type StreamFileMatcher struct {
 	MagnetId       string
@@ -33,6 +47,8 @@ type StreamFileMatcher struct {
 	UseLargestFile bool
 	Episode        int
 	Season         int
+	// for anime numbered by absolute episode, 0 if unknown
+	AbsoluteEpisode int
 
 	IdR        *ParsedId
 	IdPrefix   string
@@ -56,9 +72,25 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 	videoIdWithLink := getId(r)
 	contentType := r.PathValue("contentType")
 	isStremThruStoreId := isStoreId(videoIdWithLink)
+	if !isStremThruStoreId && !strings.HasPrefix(videoIdWithLink, "tt") {
+		if _, _, _, isAnimeId := anime.ParseStremId(videoIdWithLink); isAnimeId && contentType == "anime" {
+			contentType = string(stremio.ContentTypeSeries)
+		}
+		imdbId, err := imdb_title.ResolveStremId(stremio.ContentType(contentType), videoIdWithLink)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+		if imdbId != "" {
+			if contentType == string(stremio.ContentTypeSeries) && !strings.Contains(imdbId, ":") {
+				contentType = string(stremio.ContentTypeMovie)
+			}
+			videoIdWithLink = imdbId
+		}
+	}
 	isImdbId := strings.HasPrefix(videoIdWithLink, "tt")
 	if isStremThruStoreId {
-		if contentType != ContentTypeOther {
+		if !isItemContentType(contentType) {
 			shared.ErrorBadRequest(r, "unsupported type: "+contentType).Send(w, r)
 			return
 		}
@@ -140,6 +172,15 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		}
 		meta = &mres.Meta
 
+		absoluteEpisode := 0
+		if sType == "series" {
+			if e, err := imdb_title.GetAniDBEpisode(sId, season, episode); err != nil {
+				LogError(r, "failed to get anidb episode", err)
+			} else if e != nil && e.AbsoluteEpisode > 0 {
+				absoluteEpisode = e.AbsoluteEpisode
+			}
+		}
+
 		var wg sync.WaitGroup
 
 		idPrefixes := ud.getIdPrefixes()
@@ -183,9 +224,10 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 					id := strings.TrimPrefix(item.Id, idPrefix)
 					if sType == "series" {
 						matcherResults[idx] = append(matcherResults[idx], StreamFileMatcher{
-							MagnetId: id,
-							Season:   season,
-							Episode:  episode,
+							MagnetId:        id,
+							Season:          season,
+							Episode:         episode,
+							AbsoluteEpisode: absoluteEpisode,
 
 							IdPrefix:   idPrefix,
 							IdR:        idr,
@@ -222,6 +264,8 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 
 	var wg sync.WaitGroup
 	streamBaseUrl := ExtractRequestBaseURL(r).JoinPath("/stremio/store/" + eud + "/_/strem/")
+	streamTemplate := getStreamTemplate(ud.TemplateId)
+
 	errs := make([]error, len(matchers))
 	streams := make([]*stremio.Stream, len(matchers))
 	for i, matcher := range matchers {
@@ -299,6 +343,11 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 							season, episode = fSeason, fEpisode
 							break
 						}
+						if matcher.AbsoluteEpisode > 0 && fEpisode == matcher.AbsoluteEpisode && (fSeason == -1 || fSeason == 1) {
+							file = f
+							season, episode = matcher.Season, matcher.Episode
+							break
+						}
 					} else {
 						pttLog.Warn("failed to parse", "error", err, "title", f.Name)
 					}
@@ -370,7 +419,9 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 					for i := range meta.Videos {
 						video := &meta.Videos[i]
 						if video.Season.Equal(season) && video.Episode.Equal(episode) {
-							pttr.Title = video.Name
+							if video.Name != "" {
+								pttr.Title = video.Name
+							}
 							break
 						}
 					}
@@ -6,6 +6,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/stremio/configure"
 	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
+	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
 )
 
 func getStoreNameConfig(defaultValue string) configure.Config {
@@ -35,6 +36,27 @@ func getStoreNameConfig(defaultValue string) configure.Config {
 	return config
 }
 
+func getTemplateConfig(defaultValue string) configure.Config {
+	options := []configure.ConfigOption{
+		{Value: "", Label: "Default"},
+	}
+	if templateIds, err := stremio_transformer.GetTemplateIds(); err != nil {
+		log.Error("failed to list templates", "error", err)
+	} else {
+		for _, id := range templateIds {
+			options = append(options, configure.ConfigOption{Value: id, Label: id})
+		}
+	}
+	return configure.Config{
+		Key:         "template",
+		Type:        "select",
+		Default:     defaultValue,
+		Title:       "Stream Template",
+		Description: `Templates are managed in <a href="/stremio/wrap/configure" target="_blank">Wrap</a>`,
+		Options:     options,
+	}
+}
+
 func getTemplateData(ud *UserData) *configure.TemplateData {
 	hideCatalogConfig := configure.Config{
 		Key:   "hide_catalog",
@@ -79,6 +101,7 @@ func getTemplateData(ud *UserData) *configure.TemplateData {
 			hideCatalogConfig,
 			hideStreamConfig,
 			enableWebDLConfig,
+			getTemplateConfig(ud.TemplateId),
 		},
 		Script: configure.GetScriptStoreTokenDescription("'#store_name'", "'#store_token'"),
 	}
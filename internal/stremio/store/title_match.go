@@ -0,0 +1,252 @@
+package stremio_store
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/anidb"
+	"github.com/MunifTanjim/stremthru/internal/anime"
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+	"github.com/MunifTanjim/stremthru/internal/imdb_torrent"
+	"github.com/MunifTanjim/stremthru/internal/torrent_info"
+	"github.com/MunifTanjim/stremthru/internal/torrent_stream"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+type titleMatch struct {
+	IMDBId string              `json:"id"`
+	Type   stremio.ContentType `json:"type"`
+}
+
+func (m titleMatch) IsMatched() bool {
+	return m.IMDBId != ""
+}
+
+// unmatched torrents are also cached, to avoid searching them repeatedly.
+// The matches found by searching the torrent name are only cached per user,
+// as the name of a torrent in the store can be changed by the user.
+var titleMatchCache = cache.NewCache[titleMatch](&cache.CacheConfig{
+	Lifetime: 6 * time.Hour,
+	Name:     "stremio:store:title_match",
+})
+
+// cache keys of the torrents being searched in background
+var titleSearchInFlight sync.Map
+
+func getTitleMatchUserKey(storeName, storeToken string) string {
+	return util.HashString(storeName + ":" + storeToken)
+}
+
+func getTitleMatchCacheKey(userKey, hash string) string {
+	return userKey + ":" + hash
+}
+
+func getContentTypeForIMDBTitleType(titleType imdb_title.IMDBTitleType) stremio.ContentType {
+	switch titleType {
+	case imdb_title.IMDBTitleTypeTvSeries, imdb_title.IMDBTitleTypeTvMiniSeries:
+		return stremio.ContentTypeSeries
+	case "":
+		return ""
+	default:
+		return stremio.ContentTypeMovie
+	}
+}
+
+func isSeriesTorrent(tInfo *torrent_info.TorrentInfo) bool {
+	return tInfo.Category == torrent_info.TorrentInfoCategorySeries || len(tInfo.Seasons) > 0 || len(tInfo.Episodes) > 0
+}
+
+// searchTitles searches the parsed title and year of the torrents. The name
+// is used if the torrent info is missing.
+func searchTitles(nameByHash map[string]string) map[string]titleMatch {
+	matchByHash := make(map[string]titleMatch, len(nameByHash))
+
+	hashes := make([]string, 0, len(nameByHash))
+	for hash := range nameByHash {
+		hashes = append(hashes, hash)
+	}
+	tInfoByHash, err := torrent_info.GetByHashes(hashes)
+	if err != nil {
+		log.Error("failed to get torrent info", "error", err)
+		tInfoByHash = map[string]torrent_info.TorrentInfo{}
+	}
+	for _, hash := range hashes {
+		tInfo, ok := tInfoByHash[hash]
+		if !ok {
+			tInfo = torrent_info.TorrentInfo{Hash: hash, TorrentTitle: nameByHash[hash]}
+		}
+		if err := tInfo.Parse(); err != nil || tInfo.Title == "" {
+			continue
+		}
+
+		titleType := imdb_title.SearchTitleTypeMovie
+		contentType := stremio.ContentTypeMovie
+		if isSeriesTorrent(&tInfo) {
+			titleType = imdb_title.SearchTitleTypeShow
+			contentType = stremio.ContentTypeSeries
+		}
+		imdbTitle, err := imdb_title.SearchOne(tInfo.Title, titleType, tInfo.Year, false)
+		if err != nil {
+			log.Error("failed to search imdb title", "error", err, "title", tInfo.Title, "year", tInfo.Year)
+			continue
+		}
+		if imdbTitle == nil {
+			continue
+		}
+		matchByHash[hash] = titleMatch{IMDBId: imdbTitle.TId, Type: contentType}
+	}
+	return matchByHash
+}
+
+// searchTitlesInBackground searches the torrents not already being searched,
+// and caches the matches for the user.
+func searchTitlesInBackground(userKey string, nameByHash map[string]string) {
+	pending := make(map[string]string, len(nameByHash))
+	for hash, name := range nameByHash {
+		if _, loaded := titleSearchInFlight.LoadOrStore(getTitleMatchCacheKey(userKey, hash), struct{}{}); !loaded {
+			pending[hash] = name
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	go func() {
+		matchByHash := searchTitles(pending)
+		for hash := range pending {
+			cacheKey := getTitleMatchCacheKey(userKey, hash)
+			titleMatchCache.Add(cacheKey, matchByHash[hash])
+			titleSearchInFlight.Delete(cacheKey)
+		}
+	}()
+}
+
+// matchTitles matches the torrents to IMDb titles, using the strem ids of
+// the files, the mapped IMDb ids, the anime id maps, and finally searching
+// the parsed title and year. With searchInBackground, the search does not
+// block the request, and the torrents are matched on later requests.
+func matchTitles(userKey string, nameByHash map[string]string, searchInBackground bool) map[string]titleMatch {
+	matchByHash := make(map[string]titleMatch, len(nameByHash))
+
+	hashes := []string{}
+	for hash := range nameByHash {
+		var match titleMatch
+		if titleMatchCache.Get(getTitleMatchCacheKey(userKey, hash), &match) {
+			matchByHash[hash] = match
+		} else {
+			hashes = append(hashes, hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return matchByHash
+	}
+
+	unmatchedHashes := func() []string {
+		unmatched := []string{}
+		for _, hash := range hashes {
+			if !matchByHash[hash].IsMatched() {
+				unmatched = append(unmatched, hash)
+			}
+		}
+		return unmatched
+	}
+
+	if stremIdByHash, err := torrent_stream.GetStremIdByHashes(hashes); err != nil {
+		log.Error("failed to get strem id by hashes", "error", err)
+	} else {
+		for _, hash := range hashes {
+			if sid := stremIdByHash.Get(hash); sid != "" {
+				sid, _, isSeries := strings.Cut(sid, ":")
+				match := titleMatch{IMDBId: sid, Type: stremio.ContentTypeMovie}
+				if isSeries {
+					match.Type = stremio.ContentTypeSeries
+				}
+				matchByHash[hash] = match
+			}
+		}
+	}
+
+	if remaining := unmatchedHashes(); len(remaining) > 0 {
+		if imdbIdByHash, err := imdb_torrent.GetIMDBIdByHashes(remaining); err != nil {
+			log.Error("failed to get imdb id by hashes", "error", err)
+		} else {
+			for hash, imdbId := range imdbIdByHash {
+				matchByHash[hash] = titleMatch{IMDBId: imdbId}
+			}
+		}
+	}
+
+	if remaining := unmatchedHashes(); len(remaining) > 0 && config.Feature.IsEnabled(config.FeatureAnime) {
+		if anidbIdByHash, err := anidb.GetAniDBIdByHashes(remaining); err != nil {
+			log.Error("failed to get anidb id by hashes", "error", err)
+		} else {
+			for hash, anidbId := range anidbIdByHash {
+				idMap, err := anime.GetIdMap(anime.IdMapColumn.AniDB, anidbId)
+				if err != nil {
+					log.Error("failed to get anime id map", "error", err, "anidb_id", anidbId)
+					continue
+				}
+				if idMap != nil && idMap.IMDB != "" {
+					matchByHash[hash] = titleMatch{IMDBId: idMap.IMDB}
+				}
+			}
+		}
+	}
+
+	searchingHashes := map[string]struct{}{}
+	if remaining := unmatchedHashes(); len(remaining) > 0 && config.Feature.IsEnabled(config.FeatureIMDBTitle) {
+		remainingNameByHash := make(map[string]string, len(remaining))
+		for _, hash := range remaining {
+			remainingNameByHash[hash] = nameByHash[hash]
+		}
+		if searchInBackground {
+			searchTitlesInBackground(userKey, remainingNameByHash)
+			for hash := range remainingNameByHash {
+				searchingHashes[hash] = struct{}{}
+			}
+		} else {
+			for hash, match := range searchTitles(remainingNameByHash) {
+				matchByHash[hash] = match
+			}
+		}
+	}
+
+	untypedIds := []string{}
+	for _, hash := range hashes {
+		if match := matchByHash[hash]; match.IsMatched() && match.Type == "" {
+			untypedIds = append(untypedIds, match.IMDBId)
+		}
+	}
+	if len(untypedIds) > 0 {
+		typeById, err := imdb_title.GetTypeByIds(untypedIds)
+		if err != nil {
+			log.Error("failed to get imdb title type", "error", err)
+		}
+		for _, hash := range hashes {
+			if match := matchByHash[hash]; match.IsMatched() && match.Type == "" {
+				match.Type = getContentTypeForIMDBTitleType(typeById[match.IMDBId])
+				if match.Type == "" {
+					match.Type = stremio.ContentTypeMovie
+					tInfo := torrent_info.TorrentInfo{TorrentTitle: nameByHash[hash]}
+					if err := tInfo.Parse(); err == nil && isSeriesTorrent(&tInfo) {
+						match.Type = stremio.ContentTypeSeries
+					}
+				}
+				matchByHash[hash] = match
+			}
+		}
+	}
+
+	for _, hash := range hashes {
+		if _, ok := searchingHashes[hash]; ok {
+			continue
+		}
+		titleMatchCache.Add(getTitleMatchCacheKey(userKey, hash), matchByHash[hash])
+	}
+
+	return matchByHash
+}
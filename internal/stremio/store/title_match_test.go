@@ -0,0 +1,56 @@
+package stremio_store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+	"github.com/MunifTanjim/stremthru/internal/imdb_torrent"
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTitleMatchTest(t *testing.T) {
+	t.Helper()
+	dbtest.Setup(t)
+	assert.NoError(t, imdb_title.Upsert([]imdb_title.IMDBTitle{
+		{TId: "tt0000001", Title: "Example Movie", OrigTitle: "Example Movie", Year: 2020, Type: string(imdb_title.IMDBTitleTypeMovie)},
+	}))
+	assert.NoError(t, imdb_title.RebuildFTS())
+}
+
+func TestMatchTitles(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	nameByHash := map[string]string{hash: "Example.Movie.2020.1080p.WEB-DL.x264-GROUP"}
+	expected := titleMatch{IMDBId: "tt0000001", Type: stremio.ContentTypeMovie}
+
+	t.Run("search", func(t *testing.T) {
+		setupTitleMatchTest(t)
+
+		userKey := getTitleMatchUserKey("realdebrid", "token-a")
+		assert.Equal(t, expected, matchTitles(userKey, nameByHash, false)[hash])
+
+		var match titleMatch
+		assert.True(t, titleMatchCache.Get(getTitleMatchCacheKey(userKey, hash), &match))
+		assert.Equal(t, expected, match)
+		assert.False(t, titleMatchCache.Get(getTitleMatchCacheKey(getTitleMatchUserKey("realdebrid", "token-b"), hash), &match), "not cached for other users")
+
+		imdbIdByHash, err := imdb_torrent.GetIMDBIdByHashes([]string{hash})
+		assert.NoError(t, err)
+		assert.Empty(t, imdbIdByHash, "not recorded globally")
+	})
+
+	t.Run("search in background", func(t *testing.T) {
+		setupTitleMatchTest(t)
+
+		userKey := getTitleMatchUserKey("realdebrid", "token-c")
+		assert.False(t, matchTitles(userKey, nameByHash, true)[hash].IsMatched())
+
+		assert.Eventually(t, func() bool {
+			_, inFlight := titleSearchInFlight.Load(getTitleMatchCacheKey(userKey, hash))
+			return !inFlight
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, expected, matchTitles(userKey, nameByHash, true)[hash])
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/context"
 	"github.com/MunifTanjim/stremthru/internal/server"
 	"github.com/MunifTanjim/stremthru/internal/shared"
+	stremio_userdata "github.com/MunifTanjim/stremthru/internal/stremio/userdata"
 	"github.com/MunifTanjim/stremthru/store"
 )
 
@@ -19,6 +20,7 @@ type UserData struct {
 	HideCatalog bool   `json:"hide_catalog,omitempty"`
 	HideStream  bool   `json:"hide_stream,omitempty"`
 	EnableWebDL bool   `json:"webdl,omitempty"`
+	TemplateId  string `json:"template,omitempty"`
 	encoded     string `json:"-"`
 
 	idPrefixes []string `json:"-"`
@@ -37,14 +39,14 @@ func (ud UserData) GetEncoded() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return core.Base64Encode(string(blob)), nil
+	return stremio_userdata.Encode(blob)
 }
 
 func (ud *UserData) getIdPrefixes() []string {
 	if len(ud.idPrefixes) == 0 {
 		if ud.StoreName == "" {
 			if user, err := core.ParseBasicAuth(ud.StoreToken); err == nil {
-				if password := config.ProxyAuthPassword.GetPassword(user.Username); password != "" && password == user.Password {
+				if config.ProxyAuthPassword.VerifyPassword(user.Username, user.Password) {
 					for _, name := range config.StoreAuthToken.ListStores(user.Username) {
 						storeName := store.StoreName(name)
 						storeCode := "st-" + string(storeName.Code())
@@ -114,8 +116,7 @@ func (ud UserData) GetRequestContext(r *http.Request, idr *ParsedId) (*context.S
 		if err != nil {
 			return ctx, &userDataError{storeToken: err.Error()}
 		}
-		password := config.ProxyAuthPassword.GetPassword(user.Username)
-		if password != "" && password == user.Password {
+		if config.ProxyAuthPassword.VerifyPassword(user.Username, user.Password) {
 			ctx.IsProxyAuthorized = true
 			ctx.ProxyAuthUser = user.Username
 			ctx.ProxyAuthPassword = user.Password
@@ -145,7 +146,7 @@ func getUserData(r *http.Request) (*UserData, error) {
 		if data.encoded == "" {
 			return data, nil
 		}
-		blob, err := core.Base64DecodeToByte(data.encoded)
+		blob, err := stremio_userdata.Decode(data.encoded)
 		if err != nil {
 			return nil, err
 		}
@@ -159,6 +160,7 @@ func getUserData(r *http.Request) (*UserData, error) {
 		data.HideCatalog = r.FormValue("hide_catalog") == "on"
 		data.HideStream = r.FormValue("hide_stream") == "on"
 		data.EnableWebDL = r.FormValue("enable_webdl") == "on"
+		data.TemplateId = r.FormValue("template")
 		encoded, err := data.GetEncoded()
 		if err != nil {
 			return nil, err
@@ -8,6 +8,7 @@ import (
 	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
+	"github.com/MunifTanjim/stremthru/stremio"
 )
 
 var IsMethod = shared.IsMethod
@@ -49,3 +50,16 @@ func getContentType(r *http.Request) (string, *core.APIError) {
 	}
 	return contentType, nil
 }
+
+// items matched to IMDb titles are listed as movie or series
+func isItemContentType(contentType string) bool {
+	return contentType == ContentTypeOther || contentType == string(stremio.ContentTypeMovie) || contentType == string(stremio.ContentTypeSeries)
+}
+
+func getItemContentType(r *http.Request) (string, *core.APIError) {
+	contentType := r.PathValue("contentType")
+	if !isItemContentType(contentType) {
+		return "", shared.ErrorBadRequest(r, "unsupported type: "+contentType)
+	}
+	return contentType, nil
+}
@@ -2,10 +2,14 @@ package stremio_torz
 
 import (
 	"net/http"
+	"net/url"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
+	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
 )
 
 func handleConfigure(w http.ResponseWriter, r *http.Request) {
@@ -28,6 +32,41 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 			if ud.CachedOnly {
 				conf.Default = "checked"
 			}
+		case "profile.res":
+			conf.Default = strings.Join(ud.Profile.Resolutions, ", ")
+		case "profile.size":
+			conf.Default = formatSizeLimits(ud.Profile.SizeLimits)
+			if IsMethod(r, http.MethodPost) {
+				value := r.Form.Get(conf.Key)
+				if _, err := parseSizeLimits(value); err != nil {
+					conf.Default = value
+					conf.Error = err.Error()
+				}
+			}
+		case "profile.group_pref":
+			conf.Default = strings.Join(ud.Profile.PreferredGroups, ", ")
+		case "profile.group_block":
+			conf.Default = strings.Join(ud.Profile.BlockedGroups, ", ")
+		case "profile.source_block":
+			conf.Default = strings.Join(ud.Profile.BlockedSources, ", ")
+		case "profile.lang":
+			conf.Default = strings.Join(ud.Profile.Languages, ", ")
+		case "profile.max_per_res":
+			if ud.Profile.MaxPerResolution > 0 {
+				conf.Default = strconv.Itoa(ud.Profile.MaxPerResolution)
+			}
+		case "profile.no_3d":
+			if ud.Profile.Exclude3D {
+				conf.Default = "checked"
+			}
+		case "profile.no_upscaled":
+			if ud.Profile.ExcludeUpscaled {
+				conf.Default = "checked"
+			}
+		case "profile.no_hardcoded":
+			if ud.Profile.ExcludeHardcoded {
+				conf.Default = "checked"
+			}
 		}
 	}
 
@@ -43,6 +82,14 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 				end = 1
 			}
 			td.Stores = slices.Clone(td.Stores[0:end])
+		case "add-indexer":
+			if td.IsAuthed || len(td.Indexers) < MaxPublicInstanceIndexerCount {
+				td.Indexers = append(td.Indexers, IndexerConfig{})
+			}
+		case "remove-indexer":
+			if len(td.Indexers) > 0 {
+				td.Indexers = slices.Clone(td.Indexers[0 : len(td.Indexers)-1])
+			}
 		}
 
 		page, err := getPage(td)
@@ -94,6 +141,20 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := stremio_transformer.ValidateSortConfig(ud.Sort); err != nil {
+		td.SortConfig.Default = ud.Sort
+		td.SortConfig.Error = err.Error()
+	}
+
+	for i := range td.Indexers {
+		indexer := &td.Indexers[i]
+		if u, err := url.Parse(indexer.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			indexer.Error.URL = "Invalid URL"
+		} else if !isIndexerHostAllowed(u.Hostname()) {
+			indexer.Error.URL = "Private address not allowed"
+		}
+	}
+
 	hasError := td.HasFieldError()
 
 	if IsMethod(r, http.MethodPost) && !hasError {
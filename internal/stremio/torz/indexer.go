@@ -0,0 +1,221 @@
+package stremio_torz
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/torrent_info"
+	"github.com/MunifTanjim/stremthru/internal/torznab"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+type UserDataIndexer struct {
+	URL    string `json:"url"`
+	APIKey string `json:"apikey,omitempty"`
+}
+
+// items without info hash are resolved by downloading the .torrent file,
+// upto this many per indexer
+const maxIndexerResolveCount = 10
+
+// total time for searching the indexers, slower indexers are skipped
+const indexerSearchTimeout = 10 * time.Second
+
+var errIndexerAddressNotAllowed = errors.New("indexer address not allowed")
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// isIndexerHostAllowed is a quick check of the host for the configure page,
+// the address is checked again on every connection.
+func isIndexerHostAllowed(host string) bool {
+	if !IsPublicInstance {
+		return true
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return isPublicAddr(addr)
+	}
+	return true
+}
+
+// newIndexerHTTPClient returns the client for the indexers. With
+// blockPrivateAddr, it connects directly and refuses loopback, private and
+// link-local addresses, including ones reached by redirects.
+func newIndexerHTTPClient(blockPrivateAddr bool) *http.Client {
+	if !blockPrivateAddr {
+		client := config.GetHTTPClient(config.TUNNEL_TYPE_AUTO)
+		client.Timeout = indexerSearchTimeout
+		return client
+	}
+
+	dialer := &net.Dialer{
+		Timeout: indexerSearchTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !isPublicAddr(addrPort.Addr()) {
+				return errIndexerAddressNotAllowed
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   indexerSearchTimeout,
+	}
+}
+
+var indexerHTTPClient = newIndexerHTTPClient(IsPublicInstance)
+
+var indexerResultCache = cache.NewCache[[]torrent_info.TorrentInfoInsertData](&cache.CacheConfig{
+	Name:     "stremio:torz:indexer",
+	Lifetime: 30 * time.Minute,
+})
+
+func getIndexerQuery(sid string) torznab.Query {
+	parts := strings.SplitN(sid, ":", 3)
+	query := torznab.Query{
+		IMDBId: parts[0],
+	}
+	if len(parts) == 3 {
+		query.Type = "tvsearch"
+		query.Season = parts[1]
+		query.Ep = parts[2]
+		query.Categories = []int{torznab.CategoryTV.ID}
+	} else {
+		query.Type = "movie"
+		query.Categories = []int{torznab.CategoryMovies.ID}
+	}
+	return query
+}
+
+// getIndexerResultCacheKey identifies the indexer by the url and api key,
+// without including the api key.
+func getIndexerResultCacheKey(indexer UserDataIndexer, sid string) string {
+	return util.HashString(indexer.URL+"\n"+indexer.APIKey) + ":" + sid
+}
+
+func searchIndexer(ctx context.Context, indexer UserDataIndexer, query torznab.Query, category torrent_info.TorrentInfoCategory) ([]torrent_info.TorrentInfoInsertData, error) {
+	client := torznab.NewClient(&torznab.ClientConfig{
+		URL:        indexer.URL,
+		APIKey:     indexer.APIKey,
+		HTTPClient: indexerHTTPClient,
+	})
+
+	resultItems, err := client.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	resolveCount := 0
+	for i := range resultItems {
+		ri := &resultItems[i]
+		if ri.InfoHash != "" || resolveCount == maxIndexerResolveCount {
+			continue
+		}
+		resolveCount++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.ResolveInfoHash(ctx, ri); err != nil {
+				log.Debug("failed to resolve info hash", "error", err, "indexer", indexer.URL, "title", ri.Title)
+			}
+		}()
+	}
+	wg.Wait()
+
+	items := []torrent_info.TorrentInfoInsertData{}
+	for i := range resultItems {
+		ri := &resultItems[i]
+		if len(ri.InfoHash) != 40 || ri.Title == "" {
+			continue
+		}
+		items = append(items, torrent_info.TorrentInfoInsertData{
+			Hash:         ri.InfoHash,
+			TorrentTitle: ri.Title,
+			Size:         ri.Size,
+			Source:       torrent_info.TorrentInfoSourceTorznab,
+			Category:     category,
+		})
+	}
+	return items, nil
+}
+
+// searchIndexers queries the indexers in parallel for the IMDb strem id,
+// within indexerSearchTimeout. The results are not recorded, as the
+// indexers are configured by the user, so they are only used for the
+// request.
+func searchIndexers(ctx context.Context, indexers []UserDataIndexer, sid string) []torrent_info.TorrentInfo {
+	ctx, cancel := context.WithTimeout(ctx, indexerSearchTimeout)
+	defer cancel()
+
+	query := getIndexerQuery(sid)
+	category := torrent_info.GetCategoryFromStremId(sid)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	items := []torrent_info.TorrentInfoInsertData{}
+	for _, indexer := range indexers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			cacheKey := getIndexerResultCacheKey(indexer, sid)
+			var result []torrent_info.TorrentInfoInsertData
+			if !indexerResultCache.Get(cacheKey, &result) {
+				var err error
+				result, err = searchIndexer(ctx, indexer, query, category)
+				if err != nil {
+					log.Warn("failed to search indexer", "error", err, "indexer", indexer.URL, "sid", sid)
+					return
+				}
+				indexerResultCache.Add(cacheKey, result)
+			}
+
+			mu.Lock()
+			items = append(items, result...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	tInfos := make([]torrent_info.TorrentInfo, 0, len(items))
+	seen := map[string]struct{}{}
+	for i := range items {
+		item := &items[i]
+		if _, ok := seen[item.Hash]; ok {
+			continue
+		}
+		seen[item.Hash] = struct{}{}
+		tInfo := torrent_info.TorrentInfo{
+			Hash:         item.Hash,
+			TorrentTitle: item.TorrentTitle,
+			Size:         item.Size,
+			Source:       string(item.Source),
+			Category:     item.Category,
+		}
+		if err := tInfo.Parse(); err != nil {
+			log.Debug("failed to parse indexer result", "error", err, "title", item.TorrentTitle)
+			continue
+		}
+		tInfos = append(tInfos, tInfo)
+	}
+	return tInfos
+}
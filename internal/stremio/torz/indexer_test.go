@@ -0,0 +1,156 @@
+package stremio_torz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/bencode"
+	"github.com/MunifTanjim/stremthru/internal/torznab"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIndexer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	client := indexerHTTPClient
+	indexerHTTPClient = newIndexerHTTPClient(false)
+	t.Cleanup(func() { indexerHTTPClient = client })
+
+	var searchCount atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		searchCount.Add(1)
+		handler(w, r)
+	})
+	mux.HandleFunc("/dl/", func(w http.ResponseWriter, r *http.Request) {
+		info := map[string]any{"name": strings.TrimPrefix(r.URL.Path, "/dl/"), "length": 1000, "piece length": 16384, "pieces": string(make([]byte, 20))}
+		torrent, _ := bencode.Encode(map[string]any{"info": info})
+		w.Write(torrent)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &searchCount
+}
+
+func writeTestSearchResponse(w http.ResponseWriter, serverURL string, items ...string) {
+	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed"><channel>`)
+	for _, item := range items {
+		title, hash, _ := strings.Cut(item, "|")
+		if hash != "" {
+			fmt.Fprintf(w, `<item><title>%s</title><size>1000</size><torznab:attr name="infohash" value="%s" /></item>`, title, hash)
+		} else {
+			fmt.Fprintf(w, `<item><title>%s</title><link>%s/dl/%s</link></item>`, title, serverURL, title)
+		}
+	}
+	fmt.Fprint(w, `</channel></rss>`)
+}
+
+func TestGetIndexerQuery(t *testing.T) {
+	assert.Equal(t, torznab.Query{
+		Type:       "movie",
+		IMDBId:     "tt1234567",
+		Categories: []int{torznab.CategoryMovies.ID},
+	}, getIndexerQuery("tt1234567"))
+	assert.Equal(t, torznab.Query{
+		Type:       "tvsearch",
+		IMDBId:     "tt1234567",
+		Season:     "1",
+		Ep:         "2",
+		Categories: []int{torznab.CategoryTV.ID},
+	}, getIndexerQuery("tt1234567:1:2"))
+}
+
+func TestSearchIndexers(t *testing.T) {
+	var server *httptest.Server
+	server, searchCount := newTestIndexer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.URL.Query().Get("apikey"))
+		writeTestSearchResponse(w, server.URL,
+			"Movie.2020.1080p.WEB-DL|abcdef0123456789abcdef0123456789abcdef01",
+			"Movie.2020.720p.WEB-DL",
+			"Movie.2020.2160p.WEB-DL|abcdef0123456789abcdef0123456789abcdef01",
+		)
+	})
+	failing, _ := newTestIndexer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	indexers := []UserDataIndexer{
+		{URL: server.URL + "/api", APIKey: "secret"},
+		{URL: failing.URL + "/api", APIKey: "secret"},
+	}
+	sid := "tt9300001"
+
+	tInfos := searchIndexers(t.Context(), indexers, sid)
+	if assert.Len(t, tInfos, 2, "duplicate hash is skipped") {
+		assert.Equal(t, "abcdef0123456789abcdef0123456789abcdef01", tInfos[0].Hash)
+		assert.Equal(t, "1080p", tInfos[0].Resolution, "result is parsed")
+		assert.Equal(t, "Movie.2020.720p.WEB-DL", tInfos[1].TorrentTitle)
+		assert.Len(t, tInfos[1].Hash, 40, "info hash is resolved from .torrent file")
+	}
+
+	assert.Len(t, searchIndexers(t.Context(), indexers, sid), 2)
+	assert.Equal(t, int32(1), searchCount.Load(), "results are cached")
+
+	cacheKey := getIndexerResultCacheKey(indexers[0], sid)
+	assert.NotContains(t, cacheKey, "secret")
+	assert.NotEqual(t, cacheKey, getIndexerResultCacheKey(UserDataIndexer{URL: indexers[0].URL, APIKey: "other"}, sid))
+}
+
+func TestSearchIndexersTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var server *httptest.Server
+	server, _ = newTestIndexer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	tInfos := searchIndexers(ctx, []UserDataIndexer{{URL: server.URL + "/api"}}, "tt9300002")
+	assert.Empty(t, tInfos)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestIndexerPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("private address should not be reached")
+	}))
+	defer server.Close()
+
+	client := torznab.NewClient(&torznab.ClientConfig{
+		URL:        server.URL + "/api",
+		HTTPClient: newIndexerHTTPClient(true),
+	})
+	_, err := client.Search(t.Context(), torznab.Query{Q: "movie"})
+	assert.ErrorIs(t, err, errIndexerAddressNotAllowed)
+
+	for _, tc := range []struct {
+		addr     string
+		expected bool
+	}{
+		{"127.0.0.1", false},
+		{"10.0.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"1.1.1.1", true},
+		{"2606:4700::1111", true},
+	} {
+		assert.Equal(t, tc.expected, isPublicAddr(netip.MustParseAddr(tc.addr)), tc.addr)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/MunifTanjim/stremthru/internal/anime"
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	"github.com/MunifTanjim/stremthru/stremio"
@@ -46,7 +47,11 @@ func GetManifest(r *http.Request, ud *UserData) *stremio.Manifest {
 
 	if config.Feature.IsEnabled(config.FeatureAnime) {
 		streamResource.Types = append(streamResource.Types, "anime")
-		streamResource.IDPrefixes = append(streamResource.IDPrefixes, "kitsu:")
+		streamResource.IDPrefixes = append(streamResource.IDPrefixes, anime.StremIdPrefixes...)
+	}
+
+	if config.Feature.IsEnabled(config.FeatureIMDBTitle) {
+		streamResource.IDPrefixes = append(streamResource.IDPrefixes, "tmdb:", "tvdb:")
 	}
 
 	manifest := &stremio.Manifest{
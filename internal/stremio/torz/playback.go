@@ -7,8 +7,12 @@ import (
 	"time"
 
 	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/anime"
 	"github.com/MunifTanjim/stremthru/internal/buddy"
 	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/p2p"
 	"github.com/MunifTanjim/stremthru/internal/server"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	store_video "github.com/MunifTanjim/stremthru/internal/store/video"
@@ -31,6 +35,24 @@ func redirectToStaticVideo(w http.ResponseWriter, r *http.Request, cacheKey stri
 
 var stremGroup singleflight.Group
 
+// handleP2PStrem redirects to the proxy link for the file, served by the p2p
+// engine.
+func handleP2PStrem(w http.ResponseWriter, r *http.Request, ctx *context.StoreContext, magnetHash string, fileIdx int, fileName string) {
+	if !ctx.IsProxyAuthorized || !config.P2P.IsEnabled() {
+		shared.ErrorForbidden(r).Send(w, r)
+		return
+	}
+
+	link, err := shared.CreateProxyLink(r, p2p.CreateMagnetLink(magnetHash, fileIdx), nil, config.TUNNEL_TYPE_NONE, 12*time.Hour, ctx.ProxyAuthUser, ctx.ProxyAuthPassword, true, fileName)
+	if err != nil {
+		LogError(r, "failed to create proxy link", err)
+		store_video.Redirect("500", w, r)
+		return
+	}
+
+	http.Redirect(w, r, link, http.StatusFound)
+}
+
 type stremResult struct {
 	link        string
 	error_log   string
@@ -67,6 +89,11 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 
 	sid := r.PathValue("stremId")
 
+	if ud.IsP2P() {
+		handleP2PStrem(w, r, ctx, magnetHash, fileIdx, fileName)
+		return
+	}
+
 	s := ud.GetStoreByCode(r.PathValue("storeCode"))
 	ctx.Store, ctx.StoreAuthToken = s.Store, s.AuthToken
 	storeCode := s.Store.GetName().Code()
@@ -91,7 +118,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			return &stremResult{
 				error_log:   "failed to add magnet",
-				error_video: "download_failed",
+				error_video: store_video.GetNameForError(err, store_video.StoreVideoNameDownloadFailed),
 			}, err
 		}
 
@@ -105,14 +132,14 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		}
 
 		isIMDBId := strings.HasPrefix(sid, "tt")
-		isKitsuId := strings.HasPrefix(sid, "kitsu:")
-		shouldTagStream := isIMDBId || isKitsuId
+		_, _, _, isAnimeId := anime.ParseStremId(sid)
+		shouldTagStream := isIMDBId || isAnimeId
 
 		magnet, err = stremio_shared.WaitForMagnetStatus(ctx, magnet, store.MagnetStatusDownloaded, 3, 5*time.Second)
 		if err != nil {
 			strem := &stremResult{
 				error_log:   "failed wait for magnet status",
-				error_video: "500",
+				error_video: store_video.GetNameForError(err, store_video.StoreVideoName500),
 			}
 			if magnet.Status == store.MagnetStatusQueued || magnet.Status == store.MagnetStatusDownloading || magnet.Status == store.MagnetStatusProcessing {
 				strem.error_video = "downloading"
@@ -169,7 +196,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		if shouldTagStream {
 			if isIMDBId {
 				torrent_stream.TagStremId(magnet.Hash, file.Name, sid)
-			} else if isKitsuId {
+			} else if isAnimeId {
 				go torrent_stream.TagAnimeStremId(magnet.Hash, file.Name, sid)
 			}
 		}
@@ -178,7 +205,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			return &stremResult{
 				error_log:   "failed to generate stremthru link",
-				error_video: "500",
+				error_video: store_video.GetNameForError(err, store_video.StoreVideoName500),
 			}, err
 		}
 
@@ -0,0 +1,198 @@
+package stremio_torz
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/torrent_info"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+// used for torrents without parsed resolution
+const unknownResolution = "unknown"
+
+// used in size limits for any resolution
+const anyResolution = "*"
+
+type UserDataSizeLimit struct {
+	Min int64 `json:"min,omitempty"`
+	Max int64 `json:"max,omitempty"`
+}
+
+func (l UserDataSizeLimit) allows(size int64) bool {
+	if l.Min > 0 && size < l.Min {
+		return false
+	}
+	if l.Max > 0 && size > l.Max {
+		return false
+	}
+	return true
+}
+
+type UserDataProfile struct {
+	Resolutions      []string                     `json:"res,omitempty"`
+	SizeLimits       map[string]UserDataSizeLimit `json:"size,omitempty"`
+	PreferredGroups  []string                     `json:"group_pref,omitempty"`
+	BlockedGroups    []string                     `json:"group_block,omitempty"`
+	BlockedSources   []string                     `json:"source_block,omitempty"`
+	Languages        []string                     `json:"lang,omitempty"`
+	Exclude3D        bool                         `json:"no_3d,omitempty"`
+	ExcludeUpscaled  bool                         `json:"no_upscaled,omitempty"`
+	ExcludeHardcoded bool                         `json:"no_hardcoded,omitempty"`
+	MaxPerResolution int                          `json:"max_per_res,omitempty"`
+}
+
+func getResolution(tInfo *torrent_info.TorrentInfo) string {
+	if tInfo.Resolution == "" {
+		return unknownResolution
+	}
+	return strings.ToLower(tInfo.Resolution)
+}
+
+func containsFold(list []string, value string) bool {
+	return slices.ContainsFunc(list, func(item string) bool {
+		return strings.EqualFold(item, value)
+	})
+}
+
+// isAllowed checks the stored fields of the torrent against the profile.
+// The size is of the video file, per episode for series, or 0 if unknown.
+func (p *UserDataProfile) isAllowed(tInfo *torrent_info.TorrentInfo, size int64) bool {
+	resolution := getResolution(tInfo)
+	if len(p.Resolutions) > 0 && !containsFold(p.Resolutions, resolution) {
+		return false
+	}
+	if size > 0 {
+		limit, ok := p.SizeLimits[resolution]
+		if !ok {
+			limit = p.SizeLimits[anyResolution]
+		}
+		if !limit.allows(size) {
+			return false
+		}
+	}
+	if tInfo.Group != "" && containsFold(p.BlockedGroups, tInfo.Group) {
+		return false
+	}
+	if tInfo.Quality != "" && containsFold(p.BlockedSources, tInfo.Quality) {
+		return false
+	}
+	// torrents without language tags are kept
+	if len(p.Languages) > 0 && len(tInfo.Languages) > 0 && !slices.ContainsFunc(tInfo.Languages, func(lang string) bool {
+		return containsFold(p.Languages, lang)
+	}) {
+		return false
+	}
+	if p.Exclude3D && tInfo.ThreeD != "" {
+		return false
+	}
+	if p.ExcludeUpscaled && tInfo.Upscaled {
+		return false
+	}
+	if p.ExcludeHardcoded && tInfo.Hardcoded {
+		return false
+	}
+	return true
+}
+
+func (p *UserDataProfile) isPreferredGroup(group string) bool {
+	return group != "" && containsFold(p.PreferredGroups, group)
+}
+
+// apply moves the streams from preferred groups to the top, keeping the
+// order otherwise, and caps the streams per resolution.
+func (p *UserDataProfile) apply(streams []wrappedStream) []wrappedStream {
+	if len(p.PreferredGroups) > 0 {
+		slices.SortStableFunc(streams, func(a, b wrappedStream) int {
+			aPref, bPref := p.isPreferredGroup(a.r.Group), p.isPreferredGroup(b.r.Group)
+			if aPref == bPref {
+				return 0
+			}
+			if aPref {
+				return -1
+			}
+			return 1
+		})
+	}
+
+	if p.MaxPerResolution > 0 {
+		countByResolution := map[string]int{}
+		streams = slices.DeleteFunc(streams, func(s wrappedStream) bool {
+			resolution := strings.ToLower(s.r.Resolution)
+			if resolution == "" {
+				resolution = unknownResolution
+			}
+			countByResolution[resolution]++
+			return countByResolution[resolution] > p.MaxPerResolution
+		})
+	}
+
+	return streams
+}
+
+func parseList(value string) []string {
+	list := []string{}
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// parseSizeLimits parses size limits in the format
+// `2160p:5GB-40GB, 1080p:-15GB, *:500MB-`.
+func parseSizeLimits(value string) (map[string]UserDataSizeLimit, error) {
+	limits := map[string]UserDataSizeLimit{}
+	for _, item := range parseList(value) {
+		resolution, sizeRange, ok := strings.Cut(item, ":")
+		if !ok {
+			resolution, sizeRange = anyResolution, item
+		}
+		resolution = strings.ToLower(strings.TrimSpace(resolution))
+		minSize, maxSize, ok := strings.Cut(sizeRange, "-")
+		if !ok {
+			return nil, errors.New("invalid size range: " + item)
+		}
+		limit := UserDataSizeLimit{}
+		if minSize = strings.TrimSpace(minSize); minSize != "" {
+			if limit.Min = util.ToBytes(minSize); limit.Min < 0 {
+				return nil, errors.New("invalid size: " + minSize)
+			}
+		}
+		if maxSize = strings.TrimSpace(maxSize); maxSize != "" {
+			if limit.Max = util.ToBytes(maxSize); limit.Max < 0 {
+				return nil, errors.New("invalid size: " + maxSize)
+			}
+		}
+		if limit.Max > 0 && limit.Min > limit.Max {
+			return nil, errors.New("invalid size range: " + item)
+		}
+		limits[resolution] = limit
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	return limits, nil
+}
+
+func formatSizeLimits(limits map[string]UserDataSizeLimit) string {
+	items := make([]string, 0, len(limits))
+	for resolution, limit := range limits {
+		item := resolution + ":"
+		if limit.Min > 0 {
+			item += util.ToSize(limit.Min)
+		}
+		item += "-"
+		if limit.Max > 0 {
+			item += util.ToSize(limit.Max)
+		}
+		items = append(items, item)
+	}
+	slices.Sort(items)
+	return strings.Join(items, ", ")
+}
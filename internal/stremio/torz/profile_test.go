@@ -0,0 +1,87 @@
+package stremio_torz
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/torrent_info"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSizeLimits(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected map[string]UserDataSizeLimit
+		isErr    bool
+	}{
+		{"", nil, false},
+		{
+			"2160p:5GB-40GB, 1080P:-15GB, *:500MB-",
+			map[string]UserDataSizeLimit{
+				"2160p": {Min: 5 << 30, Max: 40 << 30},
+				"1080p": {Max: 15 << 30},
+				"*":     {Min: 500 << 20},
+			},
+			false,
+		},
+		{"1GB-2GB", map[string]UserDataSizeLimit{"*": {Min: 1 << 30, Max: 2 << 30}}, false},
+		{"1080p:2GB-1GB", nil, true},
+		{"1080p:2GB", nil, true},
+		{"1080p:xyz-", nil, true},
+	} {
+		t.Run(test.input, func(t *testing.T) {
+			limits, err := parseSizeLimits(test.input)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, limits)
+			if limits != nil {
+				reparsed, err := parseSizeLimits(formatSizeLimits(limits))
+				assert.NoError(t, err)
+				assert.Equal(t, limits, reparsed)
+			}
+		})
+	}
+}
+
+func TestProfileIsAllowed(t *testing.T) {
+	profile := UserDataProfile{
+		Resolutions: []string{"2160p", "1080p", "unknown"},
+		SizeLimits: map[string]UserDataSizeLimit{
+			"2160p": {Min: 10 << 30},
+			"*":     {Max: 5 << 30},
+		},
+		BlockedGroups:    []string{"YIFY"},
+		BlockedSources:   []string{"CAM"},
+		Languages:        []string{"en"},
+		Exclude3D:        true,
+		ExcludeHardcoded: true,
+	}
+
+	for _, test := range []struct {
+		name     string
+		tInfo    torrent_info.TorrentInfo
+		size     int64
+		expected bool
+	}{
+		{"allowed", torrent_info.TorrentInfo{Resolution: "1080p"}, 2 << 30, true},
+		{"unknown size", torrent_info.TorrentInfo{Resolution: "1080p"}, 0, true},
+		{"unknown resolution", torrent_info.TorrentInfo{}, 2 << 30, true},
+		{"resolution", torrent_info.TorrentInfo{Resolution: "720p"}, 2 << 30, false},
+		{"resolution min size", torrent_info.TorrentInfo{Resolution: "2160p"}, 8 << 30, false},
+		{"resolution size", torrent_info.TorrentInfo{Resolution: "2160p"}, 20 << 30, true},
+		{"any max size", torrent_info.TorrentInfo{Resolution: "1080p"}, 8 << 30, false},
+		{"group", torrent_info.TorrentInfo{Resolution: "1080p", Group: "yify"}, 2 << 30, false},
+		{"source", torrent_info.TorrentInfo{Resolution: "1080p", Quality: "CAM"}, 2 << 30, false},
+		{"language", torrent_info.TorrentInfo{Resolution: "1080p", Languages: []string{"fr"}}, 2 << 30, false},
+		{"languages", torrent_info.TorrentInfo{Resolution: "1080p", Languages: []string{"fr", "en"}}, 2 << 30, true},
+		{"3d", torrent_info.TorrentInfo{Resolution: "1080p", ThreeD: "3D SBS"}, 2 << 30, false},
+		{"hardcoded", torrent_info.TorrentInfo{Resolution: "1080p", Hardcoded: true}, 2 << 30, false},
+		{"upscaled", torrent_info.TorrentInfo{Resolution: "1080p", Upscaled: true}, 2 << 30, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, profile.isAllowed(&test.tInfo, test.size))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package stremio_torz
 import (
 	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/anime"
 	"github.com/MunifTanjim/stremthru/internal/buddy"
 	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
 	"github.com/MunifTanjim/stremthru/internal/shared"
 	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
 	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
@@ -20,7 +22,7 @@ import (
 	"github.com/MunifTanjim/stremthru/stremio"
 )
 
-var streamTemplate = stremio_transformer.StreamTemplateDefault
+var defaultStreamTemplate = stremio_transformer.StreamTemplateDefault
 var lazyPull = config.Stremio.Torz.LazyPull
 
 type wrappedStream struct {
@@ -45,6 +47,42 @@ func (s wrappedStream) GetSize() string {
 	return s.r.Size
 }
 
+// getStreamTemplate returns the selected template, or the default one if it
+// is missing or invalid.
+func getStreamTemplate(templateId string) *stremio_transformer.StreamTemplate {
+	if templateId == "" {
+		return defaultStreamTemplate
+	}
+	tmpl, err := stremio_transformer.GetParsedTemplate(templateId)
+	if err != nil {
+		log.Error("failed to get template", "error", err, "template_id", templateId)
+		return defaultStreamTemplate
+	}
+	if tmpl == nil {
+		return defaultStreamTemplate
+	}
+	return tmpl
+}
+
+// getVideoSize returns the size of the video file, or the torrent size per
+// episode for series. It returns 0 if unknown.
+func getVideoSize(tInfo *torrent_info.TorrentInfo, fileSize int64, contentType string) int64 {
+	if fileSize > 0 {
+		return fileSize
+	}
+	if tInfo.Size <= 0 {
+		return 0
+	}
+	if contentType != string(stremio.ContentTypeSeries) {
+		return tInfo.Size
+	}
+	if len(tInfo.Episodes) > 0 {
+		return tInfo.Size / int64(len(tInfo.Episodes))
+	}
+	// season pack without episode list
+	return 0
+}
+
 func handleStream(w http.ResponseWriter, r *http.Request) {
 	if !IsMethod(r, http.MethodGet) {
 		shared.ErrorMethodNotAllowed(r).Send(w, r)
@@ -66,15 +104,30 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 	contentType := r.PathValue("contentType")
 	id := stremio_shared.GetPathValue(r, "id")
 
+	if strings.HasPrefix(id, "tmdb:") || strings.HasPrefix(id, "tvdb:") {
+		imdbId, err := imdb_title.ResolveStremId(stremio.ContentType(contentType), id)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+		if imdbId == "" {
+			SendResponse(w, r, 200, &stremio.StreamHandlerResponse{
+				Streams: []stremio.Stream{},
+			})
+			return
+		}
+		id = imdbId
+	}
+
 	isImdbId := strings.HasPrefix(id, "tt")
-	isKitsuId := strings.HasPrefix(id, "kitsu:")
+	_, _, _, isAnimeId := anime.ParseStremId(id)
 	if isImdbId {
 		if contentType != string(stremio.ContentTypeMovie) && contentType != string(stremio.ContentTypeSeries) {
 			shared.ErrorBadRequest(r, "unsupported type: "+contentType).Send(w, r)
 			return
 		}
-	} else if isKitsuId {
-		if contentType != "anime" && contentType != string(stremio.ContentTypeSeries) {
+	} else if isAnimeId {
+		if contentType != "anime" && contentType != string(stremio.ContentTypeSeries) && contentType != string(stremio.ContentTypeMovie) {
 			shared.ErrorBadRequest(r, "unsupported type: "+contentType).Send(w, r)
 			return
 		}
@@ -83,9 +136,19 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// new episodes are not synced yet, so it is only used to skip pulling
+	shouldPull := isImdbId
+	if parts := strings.Split(id, ":"); isImdbId && contentType == string(stremio.ContentTypeSeries) && len(parts) == 3 {
+		if exists, err := imdb_title.CheckEpisode(parts[0], parts[1], parts[2]); err != nil {
+			LogError(r, "failed to check episode", err)
+		} else if !exists {
+			shouldPull = false
+		}
+	}
+
 	eud := ud.GetEncoded()
 
-	if isImdbId {
+	if shouldPull {
 		if lazyPull {
 			go buddy.PullTorrentsByStremId(id, "")
 		} else {
@@ -99,6 +162,16 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var indexerTInfos []torrent_info.TorrentInfo
+	if isImdbId && len(ud.Indexers) > 0 {
+		indexerTInfos = searchIndexers(r.Context(), ud.Indexers, id)
+		for i := range indexerTInfos {
+			if hash := indexerTInfos[i].Hash; !slices.Contains(hashes, hash) {
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+
 	magnetByHash := map[string]core.MagnetLink{}
 	for _, hash := range hashes {
 		magnet, err := core.ParseMagnetLink(hash)
@@ -132,6 +205,12 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		SendError(w, r, err)
 		return
 	}
+	for i := range indexerTInfos {
+		tInfo := &indexerTInfos[i]
+		if _, ok := tInfoByHash[tInfo.Hash]; !ok {
+			tInfoByHash[tInfo.Hash] = *tInfo
+		}
+	}
 
 	filesByHashes, err := torrent_stream.GetFilesByHashes(hashes)
 	if err != nil {
@@ -139,6 +218,17 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// series ids for anime, that are numbered differently on AniDB
+	var anidbEpisode *imdb_title.AniDBEpisode
+	if isImdbId && contentType == string(stremio.ContentTypeSeries) {
+		if parts := strings.SplitN(id, ":", 3); len(parts) == 3 {
+			season, episode := util.SafeParseInt(parts[1], -1), util.SafeParseInt(parts[2], -1)
+			if anidbEpisode, err = imdb_title.GetAniDBEpisode(parts[0], season, episode); err != nil {
+				LogError(r, "failed to get anidb episode", err)
+			}
+		}
+	}
+
 	hashSeen := map[string]struct{}{}
 	wrappedStreams := []wrappedStream{}
 	for _, hash := range hashes {
@@ -156,12 +246,11 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		var file *torrent_stream.File
 		if files, ok := filesByHashes[hash]; ok {
 			idToMatch := id
-			if isKitsuId {
-				kitsuId, episode, _ := strings.Cut(strings.TrimPrefix(id, "kitsu:"), ":")
-				anidbId, _, err := anime.GetAniDBIdByKitsuId(kitsuId)
+			if column, animeId, episode, ok := anime.ParseStremId(id); ok {
+				anidbId, _, err := anime.GetAniDBIdById(column, animeId)
 				if err != nil || anidbId == "" {
 					if err != nil {
-						log.Error("failed to get anidb id for kitsu id", "kitsu_id", kitsuId, "error", err)
+						log.Error("failed to get anidb id", "sid", id, "error", err)
 					}
 					idToMatch = ""
 				} else {
@@ -174,10 +263,21 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 					if core.HasVideoExtension(f.Name) {
 						if f.SId == idToMatch || f.ASId == idToMatch {
 							file = f
+							break
 						}
 					}
 				}
 			}
+			if file == nil && anidbEpisode != nil {
+				asid := anidbEpisode.StremId()
+				for i := range files {
+					f := &files[i]
+					if f.ASId == asid && core.HasVideoExtension(f.Name) {
+						file = f
+						break
+					}
+				}
+			}
 		}
 		fName := ""
 		fIdx := -1
@@ -192,6 +292,10 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 			fName = tInfo.TorrentTitle
 		}
 
+		if !ud.Profile.isAllowed(&tInfo, getVideoSize(&tInfo, fSize, contentType)) {
+			continue
+		}
+
 		pttr, err := tInfo.ToParsedResult()
 		if err != nil {
 			SendError(w, r, err)
@@ -226,7 +330,10 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	stremio_transformer.SortStreams(wrappedStreams, "")
+	stremio_transformer.SortStreams(wrappedStreams, ud.Sort)
+	wrappedStreams = ud.Profile.apply(wrappedStreams)
+
+	streamTemplate := getStreamTemplate(ud.TemplateId)
 
 	cachedStreams := []stremio.Stream{}
 	uncachedStreams := []stremio.Stream{}
@@ -244,8 +351,16 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 				SendError(w, r, err)
 				return
 			}
-			stream.InfoHash = wStream.hash
-			stream.FileIndex = fIdx
+			if ctx.IsProxyAuthorized && config.P2P.IsEnabled() {
+				streamUrl := streamBaseUrl.JoinPath("p2p", wStream.hash, strconv.Itoa(fIdx), "/")
+				if wStream.r.File.Name != "" {
+					streamUrl = streamUrl.JoinPath(wStream.r.File.Name)
+				}
+				stream.URL = streamUrl.String()
+			} else {
+				stream.InfoHash = wStream.hash
+				stream.FileIndex = fIdx
+			}
 			uncachedStreams = append(uncachedStreams, *stream)
 		} else if storeCode, isCached := isCachedByHash[wStream.hash]; isCached && storeCode != "" {
 			storeName := store.StoreCode(strings.ToLower(storeCode)).Name()
@@ -9,6 +9,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/stremio/configure"
 	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
 	stremio_template "github.com/MunifTanjim/stremthru/internal/stremio/template"
+	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
 	stremio_userdata "github.com/MunifTanjim/stremthru/internal/stremio/userdata"
 )
 
@@ -23,12 +24,28 @@ type StoreConfig struct {
 	}
 }
 
+type IndexerConfig struct {
+	URL    string
+	APIKey string
+	Error  struct {
+		URL string
+	}
+}
+
+type TemplatePreview struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Error       string `json:"error"`
+}
+
 type TemplateData struct {
 	Base
 
 	Stores           []StoreConfig
 	StoreCodeOptions []configure.ConfigOption
 
+	Indexers []IndexerConfig
+
 	Configs     []configure.Config
 	Error       string
 	ManifestURL string
@@ -37,6 +54,15 @@ type TemplateData struct {
 	CanAddStore    bool
 	CanRemoveStore bool
 
+	CanAddIndexer    bool
+	CanRemoveIndexer bool
+
+	TemplateIds      []string
+	TemplateId       string
+	TemplateError    string
+	TemplatePreviews map[string]TemplatePreview
+	SortConfig       configure.Config
+
 	CanAuthorize bool
 	IsAuthed     bool
 	AuthError    string
@@ -55,6 +81,14 @@ func (td *TemplateData) HasFieldError() bool {
 	if td.HasStoreError() {
 		return true
 	}
+	for i := range td.Indexers {
+		if td.Indexers[i].Error.URL != "" {
+			return true
+		}
+	}
+	if td.TemplateError != "" || td.SortConfig.Error != "" {
+		return true
+	}
 	for i := range td.Configs {
 		if td.Configs[i].Error != "" {
 			return true
@@ -71,6 +105,7 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 			NavTitle:    "Torz",
 		},
 		Stores:           []StoreConfig{},
+		Indexers:         []IndexerConfig{},
 		StoreCodeOptions: stremio_shared.GetStoreCodeOptions(true),
 		Configs: []configure.Config{
 			{
@@ -78,12 +113,79 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 				Type:  configure.ConfigTypeCheckbox,
 				Title: "Only Show Cached Content",
 			},
+			{
+				Key:         "profile.res",
+				Type:        configure.ConfigTypeText,
+				Title:       "Resolutions",
+				Description: "Comma separated list of allowed resolutions, e.g. <code>2160p, 1080p, unknown</code>",
+			},
+			{
+				Key:         "profile.size",
+				Type:        configure.ConfigTypeText,
+				Title:       "Size Limits",
+				Description: "Min/max size of the video file (per episode for series) by resolution, e.g. <code>2160p:5GB-40GB, 1080p:-15GB, *:500MB-</code>",
+			},
+			{
+				Key:         "profile.group_pref",
+				Type:        configure.ConfigTypeText,
+				Title:       "Preferred Release Groups",
+				Description: "Comma separated list, shown first",
+			},
+			{
+				Key:         "profile.group_block",
+				Type:        configure.ConfigTypeText,
+				Title:       "Blocked Release Groups",
+				Description: "Comma separated list",
+			},
+			{
+				Key:         "profile.source_block",
+				Type:        configure.ConfigTypeText,
+				Title:       "Blocked Sources",
+				Description: "Comma separated list, e.g. <code>CAM, TeleSync</code>",
+			},
+			{
+				Key:         "profile.lang",
+				Type:        configure.ConfigTypeText,
+				Title:       "Required Languages",
+				Description: "Comma separated list of language codes, e.g. <code>en, multi</code>. Torrents without language tags are kept.",
+			},
+			{
+				Key:         "profile.max_per_res",
+				Type:        configure.ConfigTypeNumber,
+				Title:       "Max Results per Resolution",
+				Description: "<code>0</code> for no limit",
+			},
+			{
+				Key:   "profile.no_3d",
+				Type:  configure.ConfigTypeCheckbox,
+				Title: "Exclude 3D",
+			},
+			{
+				Key:   "profile.no_upscaled",
+				Type:  configure.ConfigTypeCheckbox,
+				Title: "Exclude Upscaled",
+			},
+			{
+				Key:   "profile.no_hardcoded",
+				Type:  configure.ConfigTypeCheckbox,
+				Title: "Exclude Hardcoded Subtitles",
+			},
 		},
 		Script: configure.GetScriptStoreTokenDescription("", ""),
+
+		TemplateIds:      []string{},
+		TemplatePreviews: map[string]TemplatePreview{},
+		SortConfig: configure.Config{
+			Key:         "sort",
+			Type:        configure.ConfigTypeText,
+			Default:     ud.Sort,
+			Title:       "Stream Sort",
+			Description: "Comma separated fields: <code>resolution</code>, <code>quality</code>, <code>size</code>. Prefix with <code>-</code> for reverse sort. Default: <code>" + stremio_transformer.StreamDefaultSortConfig + "</code>",
+		},
 	}
 
 	if cookie, err := stremio_shared.GetAdminCookieValue(w, r); err == nil && !cookie.IsExpired {
-		td.IsAuthed = config.ProxyAuthPassword.GetPassword(cookie.User()) == cookie.Pass()
+		td.IsAuthed = config.ProxyAuthPassword.VerifyPassword(cookie.User(), cookie.Pass())
 	}
 
 	for i := range ud.Stores {
@@ -98,9 +200,57 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 		td.Stores = append(td.Stores, StoreConfig{})
 	}
 
+	if templateIds, err := stremio_transformer.GetTemplateIds(); err != nil {
+		LogError(r, "failed to list templates", err)
+	} else {
+		td.TemplateIds = templateIds
+	}
+	td.setTemplate(ud.TemplateId)
+
+	for i := range ud.Indexers {
+		indexer := &ud.Indexers[i]
+		td.Indexers = append(td.Indexers, IndexerConfig{
+			URL:    indexer.URL,
+			APIKey: indexer.APIKey,
+		})
+	}
+
 	return td
 }
 
+// getTemplatePreview returns the preview of the streams using the template.
+func getTemplatePreview(templateId string) TemplatePreview {
+	tmpl := defaultStreamTemplate
+	if templateId != "" {
+		t, err := stremio_transformer.GetParsedTemplate(templateId)
+		if err != nil {
+			return TemplatePreview{Error: "Failed to load template"}
+		}
+		if t == nil {
+			return TemplatePreview{Error: "Template is not saved"}
+		}
+		tmpl = t
+	}
+	stream, err := tmpl.Preview("Torz")
+	if err != nil {
+		return TemplatePreview{Error: err.Error()}
+	}
+	return TemplatePreview{Name: stream.Name, Description: stream.Description}
+}
+
+// setTemplate sets the selected template, with the previews of all the
+// templates, so that the preview is updated on the page as the selection
+// changes.
+func (td *TemplateData) setTemplate(templateId string) {
+	td.TemplateId = templateId
+	for _, id := range append([]string{"", templateId}, td.TemplateIds...) {
+		if _, ok := td.TemplatePreviews[id]; !ok {
+			td.TemplatePreviews[id] = getTemplatePreview(id)
+		}
+	}
+	td.TemplateError = td.TemplatePreviews[templateId].Error
+}
+
 var executeTemplate = func() stremio_template.Executor[TemplateData] {
 	return stremio_template.GetExecutor("stremio/torz", func(td *TemplateData) *TemplateData {
 		td.StremThruAddons = stremio_shared.GetStremThruAddons()
@@ -122,6 +272,8 @@ var executeTemplate = func() stremio_template.Executor[TemplateData] {
 			}
 		}
 		td.CanRemoveStore = len(td.Stores) > 1
+		td.CanAddIndexer = td.IsAuthed || len(td.Indexers) < MaxPublicInstanceIndexerCount
+		td.CanRemoveIndexer = len(td.Indexers) > 0
 
 		return td
 	}, template.FuncMap{}, "configure_config.html", "torz.html")
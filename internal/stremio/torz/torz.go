@@ -10,6 +10,7 @@ import (
 
 var IsPublicInstance = config.IsPublicInstance
 var MaxPublicInstanceStoreCount = 3
+var MaxPublicInstanceIndexerCount = 2
 
 func handleRoot(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/stremio/torz/configure", http.StatusFound)
@@ -20,7 +20,12 @@ type UserDataStore struct {
 
 type UserData struct {
 	stremio_userdata.UserDataStores
-	CachedOnly bool `json:"cached,omitempty"`
+	CachedOnly bool              `json:"cached,omitempty"`
+	Indexers   []UserDataIndexer `json:"indexers,omitempty"`
+	Profile    UserDataProfile   `json:"profile,omitzero"`
+
+	TemplateId string `json:"template,omitempty"`
+	Sort       string `json:"sort,omitempty"`
 
 	encoded string `json:"-"` // correctly configured
 }
@@ -160,12 +165,55 @@ func getUserData(r *http.Request) (*UserData, error) {
 			}
 		}
 
+		indexers_length := 0
+		if v := r.Form.Get("indexers_length"); v != "" {
+			indexers_length, err = strconv.Atoi(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for idx := range indexers_length {
+			url := strings.TrimSpace(r.Form.Get("indexers[" + strconv.Itoa(idx) + "].url"))
+			apiKey := r.Form.Get("indexers[" + strconv.Itoa(idx) + "].apikey")
+			if url == "" {
+				continue
+			}
+			data.Indexers = append(data.Indexers, UserDataIndexer{
+				URL:    url,
+				APIKey: apiKey,
+			})
+		}
+
 		data.CachedOnly = r.Form.Get("cached") == "on"
+
+		data.TemplateId = r.Form.Get("template_id")
+		data.Sort = strings.TrimSpace(r.Form.Get("sort"))
+
+		data.Profile = UserDataProfile{
+			Resolutions:      parseList(strings.ToLower(r.Form.Get("profile.res"))),
+			PreferredGroups:  parseList(r.Form.Get("profile.group_pref")),
+			BlockedGroups:    parseList(r.Form.Get("profile.group_block")),
+			BlockedSources:   parseList(r.Form.Get("profile.source_block")),
+			Languages:        parseList(r.Form.Get("profile.lang")),
+			Exclude3D:        r.Form.Get("profile.no_3d") == "on",
+			ExcludeUpscaled:  r.Form.Get("profile.no_upscaled") == "on",
+			ExcludeHardcoded: r.Form.Get("profile.no_hardcoded") == "on",
+		}
+		// invalid value is reported on the configure page
+		data.Profile.SizeLimits, _ = parseSizeLimits(r.Form.Get("profile.size"))
+		if v := r.Form.Get("profile.max_per_res"); v != "" {
+			data.Profile.MaxPerResolution, _ = strconv.Atoi(v)
+		}
 	}
 
 	if IsPublicInstance && len(data.Stores) > MaxPublicInstanceStoreCount {
 		data.Stores = data.Stores[0:MaxPublicInstanceStoreCount]
 	}
 
+	if IsPublicInstance && len(data.Indexers) > MaxPublicInstanceIndexerCount {
+		data.Indexers = data.Indexers[0:MaxPublicInstanceIndexerCount]
+	}
+
 	return data, nil
 }
@@ -116,6 +116,8 @@ type StreamExtractorResultStore struct {
 
 type StreamExtractorResultAddon struct {
 	Name string
+	// names of the addons reporting the stream, when merged across addons
+	Names []string
 }
 
 type StreamExtractorResultRaw struct {
@@ -234,6 +236,47 @@ var language_to_code = map[string]string{
 	"🇹🇭":         "th",
 }
 
+func getStreamFieldValue(stream *stremio.Stream, field string) string {
+	switch field {
+	case "name":
+		return stream.Name
+	case "description":
+		if stream.Description == "" {
+			return stream.Title
+		}
+		return stream.Description
+	case "bingeGroup":
+		if stream.BehaviorHints != nil {
+			return stream.BehaviorHints.BingeGroup
+		}
+	case "filename":
+		if stream.BehaviorHints != nil {
+			return stream.BehaviorHints.Filename
+		}
+	case "url":
+		return stream.URL
+	}
+	return ""
+}
+
+// Match returns the number of patterns matching the stream, which tells
+// how well the extractor fits the shape of the stream.
+func (se StreamExtractor) Match(stream *stremio.Stream) int {
+	count := 0
+	lastField := ""
+	for _, pattern := range se.items {
+		field := pattern.Field
+		if field == "" {
+			field = lastField
+		}
+		lastField = field
+		if fieldValue := getStreamFieldValue(stream, field); fieldValue != "" && pattern.Regex.MatchString(fieldValue) {
+			count++
+		}
+	}
+	return count
+}
+
 func (se StreamExtractor) Parse(stream *stremio.Stream, sType string) *StreamExtractorResult {
 	r := &StreamExtractorResult{
 		Result: &ptt.Result{},
@@ -267,26 +310,7 @@ func (se StreamExtractor) Parse(stream *stremio.Stream, sType string) *StreamExt
 			lastField = field
 		}
 
-		fieldValue := ""
-		switch field {
-		case "name":
-			fieldValue = stream.Name
-		case "description":
-			fieldValue = stream.Description
-			if fieldValue == "" {
-				fieldValue = stream.Title
-			}
-		case "bingeGroup":
-			if stream.BehaviorHints != nil {
-				fieldValue = stream.BehaviorHints.BingeGroup
-			}
-		case "filename":
-			if stream.BehaviorHints != nil {
-				fieldValue = stream.BehaviorHints.Filename
-			}
-		case "url":
-			fieldValue = stream.URL
-		}
+		fieldValue := getStreamFieldValue(stream, field)
 		if fieldValue == "" {
 			continue
 		}
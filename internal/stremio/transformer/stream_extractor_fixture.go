@@ -0,0 +1,120 @@
+package stremio_transformer
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+// Fields returns the extracted values keyed by the names of the fields,
+// with the same names used as named groups in the extractor.
+func (r *StreamExtractorResult) Fields() map[string]string {
+	fields := map[string]string{}
+	set := func(field, value string) {
+		if value != "" {
+			fields[field] = value
+		}
+	}
+	setInt := func(field string, value int) {
+		if value != -1 {
+			fields[field] = strconv.Itoa(value)
+		}
+	}
+
+	set(StreamExtractorFieldAddonName, r.Addon.Name)
+	set(StreamExtractorFieldBitDepth, r.BitDepth)
+	set(StreamExtractorFieldChannel, strings.Join(r.Channels, ","))
+	set(StreamExtractorFieldCodec, r.Codec)
+	setInt(StreamExtractorFieldEpisode, r.Episode)
+	setInt(StreamExtractorFieldFileIdx, r.File.Idx)
+	set(StreamExtractorFieldFileName, r.File.Name)
+	set(StreamExtractorFieldFileSize, r.File.Size)
+	set(StreamExtractorFieldHash, r.Hash)
+	set(StreamExtractorFieldHDR, strings.Join(r.HDR, ","))
+	set(StreamExtractorFieldLanguage, strings.Join(r.Languages, ","))
+	set(StreamExtractorFieldQuality, r.Quality)
+	set(StreamExtractorFieldResolution, r.Resolution)
+	setInt(StreamExtractorFieldSeason, r.Season)
+	set(StreamExtractorFieldSite, r.Site)
+	set(StreamExtractorFieldSize, r.Size)
+	set(StreamExtractorFieldStoreCode, r.Store.Code)
+	if r.Store.IsCached {
+		fields[StreamExtractorFieldStoreIsCached] = "true"
+	}
+	set(StreamExtractorFieldStoreName, r.Store.Name)
+	set(StreamExtractorFieldTTitle, r.TTitle)
+
+	return fields
+}
+
+// StreamExtractorFixture is a sample stream with the field values an
+// extractor is expected to produce for it. Fields not present in Expected
+// are not checked, and an empty value expects the field to be missing.
+type StreamExtractorFixture struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Stream   stremio.Stream    `json:"stream"`
+	Expected map[string]string `json:"expected"`
+}
+
+type StreamExtractorFixtureMismatch struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type StreamExtractorFixtureResult struct {
+	Name       string                           `json:"name"`
+	Passed     bool                             `json:"passed"`
+	Mismatches []StreamExtractorFixtureMismatch `json:"mismatches,omitempty"`
+}
+
+func (r StreamExtractorFixtureResult) String() string {
+	var sb strings.Builder
+	sb.WriteString(r.Name)
+	for i, m := range r.Mismatches {
+		if i == 0 {
+			sb.WriteString(": ")
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(m.Field + " expected " + strconv.Quote(m.Expected) + " got " + strconv.Quote(m.Actual))
+	}
+	return sb.String()
+}
+
+func (se StreamExtractor) TestFixture(fixture *StreamExtractorFixture) StreamExtractorFixtureResult {
+	stream := fixture.Stream
+	fields := se.Parse(&stream, fixture.Type).Fields()
+
+	result := StreamExtractorFixtureResult{Name: fixture.Name, Passed: true}
+	for field, expected := range fixture.Expected {
+		if actual := fields[field]; actual != expected {
+			result.Passed = false
+			result.Mismatches = append(result.Mismatches, StreamExtractorFixtureMismatch{
+				Field:    field,
+				Expected: expected,
+				Actual:   actual,
+			})
+		}
+	}
+	slices.SortFunc(result.Mismatches, func(a, b StreamExtractorFixtureMismatch) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return result
+}
+
+// Test runs the fixtures, and returns the results along with the failed
+// ones.
+func (se StreamExtractor) Test(fixtures []StreamExtractorFixture) (results []StreamExtractorFixtureResult, failed []StreamExtractorFixtureResult) {
+	results = make([]StreamExtractorFixtureResult, len(fixtures))
+	for i := range fixtures {
+		results[i] = se.TestFixture(&fixtures[i])
+		if !results[i].Passed {
+			failed = append(failed, results[i])
+		}
+	}
+	return results, failed
+}
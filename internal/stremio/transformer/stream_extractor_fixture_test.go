@@ -0,0 +1,59 @@
+package stremio_transformer
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamExtractorFixture(t *testing.T) {
+	stream := stremio.Stream{
+		Name:        "[PM⚡] Comet 2160p",
+		Description: "Deadpool (2016) 2160p [4K] BluRay SDR [HINDI-ENG-5.1] 10bit HEVC - PeruGuy.mkv\n💿 BluRay|SDR|hevc|AC3|5.1|10bit|PeruGuy\n💾 8.14 GB 🔎 MediaFusion|Knightcrawler\n🇬🇧/🇮🇳",
+		URL:         "https://comet.elfhosted.com/xxxxxxx/playback/74315dd5d8a0a4e2b229914ad729887acedc396f/0/deadpool/n/n/Deadpool (2016) 2160p [4K] BluRay SDR [HINDI-ENG-5.1] 10bit HEVC - PeruGuy.mkv",
+	}
+
+	for _, tc := range []struct {
+		name       string
+		expected   map[string]string
+		mismatches []StreamExtractorFixtureMismatch
+	}{
+		{
+			"pass",
+			map[string]string{
+				StreamExtractorFieldAddonName:     "Comet",
+				StreamExtractorFieldEpisode:       "",
+				StreamExtractorFieldFileIdx:       "0",
+				StreamExtractorFieldHash:          "74315dd5d8a0a4e2b229914ad729887acedc396f",
+				StreamExtractorFieldLanguage:      "en,hi",
+				StreamExtractorFieldResolution:    "4k",
+				StreamExtractorFieldStoreCode:     "PM",
+				StreamExtractorFieldStoreIsCached: "true",
+			},
+			nil,
+		},
+		{
+			"fail",
+			map[string]string{
+				StreamExtractorFieldResolution: "1080p",
+				StreamExtractorFieldSeason:     "1",
+				StreamExtractorFieldSize:       "8.14 GB",
+			},
+			[]StreamExtractorFixtureMismatch{
+				{Field: StreamExtractorFieldResolution, Expected: "1080p", Actual: "4k"},
+				{Field: StreamExtractorFieldSeason, Expected: "1", Actual: ""},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			results, failed := StreamExtractorComet.Test([]StreamExtractorFixture{
+				{Name: tc.name, Type: "movie", Stream: stream, Expected: tc.expected},
+			})
+			assert.Len(t, results, 1)
+			assert.Equal(t, tc.mismatches == nil, results[0].Passed)
+			assert.Equal(t, tc.mismatches, results[0].Mismatches)
+			assert.Equal(t, len(tc.mismatches) > 0, len(failed) == 1)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package stremio_transformer
 
 import (
+	"errors"
 	"sort"
 	"strconv"
 	"strings"
@@ -139,6 +140,31 @@ func parseSortConfig(config string) []StreamSorterConfig {
 	return sortConfigs
 }
 
+// ValidateSortConfig checks that the config only has supported fields, each
+// used once.
+func ValidateSortConfig(config string) error {
+	if strings.TrimSpace(config) == "" {
+		return nil
+	}
+	seen := map[StreamSortableField]struct{}{}
+	for part := range strings.SplitSeq(config, ",") {
+		part = strings.TrimSpace(part)
+		field := StreamSortableField(strings.TrimPrefix(part, "-"))
+		switch field {
+		case StreamSortableFieldResolution, StreamSortableFieldQuality, StreamSortableFieldSize:
+		case "":
+			return errors.New("empty sort field")
+		default:
+			return errors.New("unsupported sort field: " + part)
+		}
+		if _, ok := seen[field]; ok {
+			return errors.New("duplicate sort field: " + string(field))
+		}
+		seen[field] = struct{}{}
+	}
+	return nil
+}
+
 type streamSorter[T StreamSortable] struct {
 	items  []T
 	config []StreamSorterConfig
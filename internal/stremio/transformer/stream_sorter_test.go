@@ -0,0 +1,30 @@
+package stremio_transformer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSortConfig(t *testing.T) {
+	for _, tc := range []struct {
+		config string
+		err    string
+	}{
+		{"", ""},
+		{StreamDefaultSortConfig, ""},
+		{"size, -resolution", ""},
+		{"-seeders", "unsupported sort field: -seeders"},
+		{"size,,quality", "empty sort field"},
+		{"size,-size", "duplicate sort field: size"},
+	} {
+		t.Run(tc.config, func(t *testing.T) {
+			err := ValidateSortConfig(tc.config)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/MunifTanjim/go-ptt"
 	"github.com/MunifTanjim/stremthru/internal/util"
 	"github.com/MunifTanjim/stremthru/stremio"
 )
@@ -95,3 +96,46 @@ func (t StreamTemplate) Execute(stream *stremio.Stream, data *StreamExtractorRes
 
 	return stream, nil
 }
+
+var streamTemplatePreviewResult = StreamExtractorResult{
+	Result: &ptt.Result{
+		Audio:      []string{"DDP", "Atmos"},
+		Channels:   []string{"5.1"},
+		Codec:      "hevc",
+		Group:      "GROUP",
+		HDR:        []string{"DV", "HDR10"},
+		Languages:  []string{"en", "ja"},
+		Quality:    "WEB-DL",
+		Resolution: "2160p",
+		Size:       "18.4 GB",
+		Title:      "Example Show",
+		Year:       "2024",
+	},
+	Category: "series",
+	Episode:  1,
+	File: StreamExtractorResultFile{
+		Idx:  0,
+		Name: "Example.Show.S01E01.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR10.HEVC-GROUP.mkv",
+		Size: "6.1 GB",
+	},
+	Hash:   "0123456789abcdef0123456789abcdef01234567",
+	Season: 1,
+	Store: StreamExtractorResultStore{
+		Name:     "realdebrid",
+		Code:     "RD",
+		IsCached: true,
+	},
+	TTitle: "Example.Show.S01.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR10.HEVC-GROUP",
+	Raw: StreamExtractorResultRaw{
+		Name:        "Example Addon\n2160p",
+		Description: "Example.Show.S01E01.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR10.HEVC-GROUP.mkv",
+	},
+}
+
+// Preview executes the template against a sample stream of the addon.
+func (t StreamTemplate) Preview(addonName string) (*stremio.Stream, error) {
+	data := streamTemplatePreviewResult
+	data.Addon.Name = addonName
+	data.Addon.Names = []string{addonName}
+	return t.Execute(&stremio.Stream{}, &data)
+}
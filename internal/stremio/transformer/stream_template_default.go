@@ -17,6 +17,8 @@ var StreamTemplateDefault = StreamTemplateBlob{
 {{- end}}{{if ne .File.Name ""}}
 📄 {{.File.Name}}{{else if ne .TTitle ""}}
 📁 {{.TTitle}}
-{{end}}
+{{end}}{{if gt (len .Addon.Names) 1}}
+🔍 via {{str_join .Addon.Names ", "}}
+{{- end}}
 `),
 }.MustParse()
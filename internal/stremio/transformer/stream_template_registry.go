@@ -0,0 +1,129 @@
+package stremio_transformer
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/kv"
+)
+
+const BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI = "✨"
+const BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX = BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI + " "
+
+func IsBuiltInEntityId(id string) bool {
+	return strings.HasPrefix(id, BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI)
+}
+
+var builtInTemplates = func() map[string]StreamTemplateBlob {
+	templates := map[string]StreamTemplateBlob{}
+
+	templates[BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX+"Default"] = StreamTemplateDefault.Blob
+	templates[BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX+"Raw"] = StreamTemplateRaw.Blob
+	templates[BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX+"Store"] = StreamTemplateStore.Blob
+
+	return templates
+}()
+
+var templateStore = kv.NewKVStore[StreamTemplateBlob](&kv.KVStoreConfig{
+	Type: "st:wrap:transformer:template",
+	GetKey: func(key string) string {
+		return key
+	},
+})
+
+// GetTemplate returns the built-in or saved template. It returns an empty
+// template if not saved.
+func GetTemplate(templateId string) (StreamTemplateBlob, error) {
+	if IsBuiltInEntityId(templateId) {
+		if template, ok := builtInTemplates[templateId]; ok {
+			return template, nil
+		}
+		return StreamTemplateBlob{}, errors.New("built-in template not found")
+	}
+
+	var template StreamTemplateBlob
+	if err := templateStore.Get(templateId, &template); err != nil {
+		return StreamTemplateBlob{}, err
+	}
+	return template, nil
+}
+
+var parsedTemplateCache = cache.NewLRUCache[*StreamTemplate](&cache.CacheConfig{
+	Lifetime:      5 * time.Minute,
+	Name:          "stremio:transformer:template",
+	LocalCapacity: 1024,
+})
+
+// GetParsedTemplate returns the parsed built-in or saved template, cached to
+// avoid parsing it on every request. It returns nil if not saved.
+func GetParsedTemplate(templateId string) (*StreamTemplate, error) {
+	var tmpl *StreamTemplate
+	if parsedTemplateCache.Get(templateId, &tmpl) {
+		return tmpl, nil
+	}
+	blob, err := GetTemplate(templateId)
+	if err != nil {
+		return nil, err
+	}
+	if !blob.IsEmpty() {
+		tmpl, err = blob.Parse()
+		if err != nil {
+			return nil, err
+		}
+	}
+	parsedTemplateCache.Add(templateId, tmpl)
+	return tmpl, nil
+}
+
+// GetTemplateIds returns the ids of the built-in templates, followed by the
+// saved ones.
+func GetTemplateIds() ([]string, error) {
+	templates, err := templateStore.List()
+	if err != nil {
+		return nil, err
+	}
+	templateIds := make([]string, 0, len(builtInTemplates)+len(templates))
+	for id := range builtInTemplates {
+		templateIds = append(templateIds, id)
+	}
+	slices.Sort(templateIds)
+	for _, template := range templates {
+		templateIds = append(templateIds, template.Key)
+	}
+	return templateIds, nil
+}
+
+func SaveTemplate(templateId string, template StreamTemplateBlob) error {
+	if IsBuiltInEntityId(templateId) {
+		return errors.New("✨-prefixed ids are reserved")
+	}
+	defer parsedTemplateCache.Remove(templateId)
+	return templateStore.Set(templateId, template)
+}
+
+func DeleteTemplate(templateId string) error {
+	if IsBuiltInEntityId(templateId) {
+		return errors.New("✨-prefixed ids are reserved")
+	}
+	defer parsedTemplateCache.Remove(templateId)
+	return templateStore.Del(templateId)
+}
+
+// CleanupSeedTemplates removes the built-in templates seeded into the store
+// by older versions.
+func CleanupSeedTemplates(isPublicInstance bool) {
+	for key := range builtInTemplates {
+		if err := templateStore.Del(key); err != nil {
+			log.Warn("Failed to cleanup seed template: " + key)
+		}
+		if isPublicInstance {
+			key = strings.TrimPrefix(key, BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX)
+			if err := templateStore.Del(key); err != nil {
+				log.Warn("Failed to cleanup seed template: " + key)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package stremio_transformer
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetParsedTemplate(t *testing.T) {
+	t.Run("built-in", func(t *testing.T) {
+		tmpl, err := GetParsedTemplate(BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "Store")
+		assert.NoError(t, err)
+		assert.Equal(t, StreamTemplateStore.Blob, tmpl.Blob)
+
+		_, err = GetParsedTemplate(BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "Unknown")
+		assert.Error(t, err)
+	})
+
+	t.Run("saved", func(t *testing.T) {
+		dbtest.Setup(t)
+
+		tmpl, err := GetParsedTemplate("custom")
+		assert.NoError(t, err)
+		assert.Nil(t, tmpl, "not saved")
+
+		blob := StreamTemplateBlob{Name: "{{.Addon.Name}}", Description: "{{.File.Name}}"}
+		assert.NoError(t, SaveTemplate("custom", blob))
+
+		tmpl, err = GetParsedTemplate("custom")
+		assert.NoError(t, err)
+		if assert.NotNil(t, tmpl) {
+			assert.Equal(t, blob, tmpl.Blob)
+		}
+
+		cached, err := GetParsedTemplate("custom")
+		assert.NoError(t, err)
+		assert.Same(t, tmpl, cached)
+
+		blob.Name = "{{.Store.Code}}"
+		assert.NoError(t, SaveTemplate("custom", blob))
+		tmpl, err = GetParsedTemplate("custom")
+		assert.NoError(t, err)
+		if assert.NotNil(t, tmpl) {
+			assert.Equal(t, blob, tmpl.Blob)
+		}
+
+		assert.NoError(t, DeleteTemplate("custom"))
+		tmpl, err = GetParsedTemplate("custom")
+		assert.NoError(t, err)
+		assert.Nil(t, tmpl)
+	})
+}
@@ -0,0 +1,6 @@
+package stremio_transformer
+
+var StreamTemplateStore = StreamTemplateBlob{
+	Name:        StreamTemplateDefault.Blob.Name,
+	Description: "✏️ {{.Title}}\n" + StreamTemplateDefault.Blob.Description,
+}.MustParse()
@@ -2,6 +2,9 @@ package stremio_userdata
 
 import (
 	"encoding/json"
+	"fmt"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
 
 	"github.com/MunifTanjim/stremthru/internal/db"
 	"github.com/MunifTanjim/stremthru/internal/stremio/configure"
@@ -19,6 +22,22 @@ type StremioUserData[T any] struct {
 	UAt      db.Timestamp
 }
 
+func marshalValue(value any) (string, error) {
+	blob, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return encryptValue(config.EncryptionKey, blob)
+}
+
+func unmarshalValue(value string, v any) error {
+	blob, err := decryptValue(config.EncryptionKey, value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(blob, v)
+}
+
 func List[T any](addon string) ([]StremioUserData[T], error) {
 	query := "SELECT addon, key, value, name, cat, uat FROM " + TableName + " WHERE addon = ? AND disabled = " + db.BooleanFalse
 	rows, err := db.Query(query, addon)
@@ -34,7 +53,7 @@ func List[T any](addon string) ([]StremioUserData[T], error) {
 		if err := rows.Scan(&sud.Addon, &sud.Key, &value, &sud.Name, &sud.CAt, &sud.UAt); err != nil {
 			return nil, err
 		}
-		if err := json.Unmarshal([]byte(value), &sud.Value); err != nil {
+		if err := unmarshalValue(value, &sud.Value); err != nil {
 			return nil, err
 		}
 		suds = append(suds, sud)
@@ -75,19 +94,19 @@ func Get[T any](addon string, key string) (*StremioUserData[T], error) {
 	if err := row.Scan(&sud.Addon, &sud.Key, &value, &sud.Name, &sud.CAt, &sud.UAt); err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal([]byte(value), &sud.Value); err != nil {
+	if err := unmarshalValue(value, &sud.Value); err != nil {
 		return nil, err
 	}
 	return &sud, nil
 }
 
 func Update[T any](addon, key string, value T) error {
-	blob, err := json.Marshal(value)
+	blob, err := marshalValue(value)
 	if err != nil {
 		return err
 	}
 	query := "UPDATE " + TableName + " SET value = ?, uat = " + db.CurrentTimestamp + " WHERE addon = ? AND key = ? AND disabled = " + db.BooleanFalse
-	_, err = db.Exec(query, string(blob), addon, key)
+	_, err = db.Exec(query, blob, addon, key)
 	return err
 }
 
@@ -98,11 +117,56 @@ func Delete(addon, key string) error {
 }
 
 func Create[T any](addon, key, name string, value T) error {
-	blob, err := json.Marshal(value)
+	blob, err := marshalValue(value)
 	if err != nil {
 		return err
 	}
 	query := "INSERT INTO " + TableName + " (addon, key, value, name) VALUES (?, ?, ?, ?)"
-	_, err = db.Exec(query, addon, key, string(blob), name)
+	_, err = db.Exec(query, addon, key, blob, name)
 	return err
 }
+
+// EncryptAll encrypts the saved values not encrypted with the active key,
+// i.e. the ones saved before enabling encryption or rotating the key. It
+// returns the count of the updated rows.
+func EncryptAll() (int, error) {
+	if !config.EncryptionKey.IsEnabled() {
+		return 0, nil
+	}
+
+	rows, err := db.Query("SELECT addon, key, value FROM " + TableName)
+	if err != nil {
+		return 0, err
+	}
+	suds := []StremioUserData[string]{}
+	for rows.Next() {
+		sud := StremioUserData[string]{}
+		if err := rows.Scan(&sud.Addon, &sud.Key, &sud.Value); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		suds = append(suds, sud)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	query := "UPDATE " + TableName + " SET value = ? WHERE addon = ? AND key = ?"
+	for i := range suds {
+		sud := &suds[i]
+		value, changed, err := reencryptValue(config.EncryptionKey, sud.Value)
+		if err != nil {
+			return count, fmt.Errorf("failed to encrypt %s/%s: %w", sud.Addon, sud.Key, err)
+		}
+		if !changed {
+			continue
+		}
+		if _, err := db.Exec(query, value, sud.Addon, sud.Key); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
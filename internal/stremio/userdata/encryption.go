@@ -0,0 +1,152 @@
+package stremio_userdata
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
+
+// encryptedValue is the saved value, encrypted with its own data key. The
+// data key is encrypted with the encryption key, so rotating the key only
+// needs re-encrypting the data key.
+type encryptedValue struct {
+	KeyId   string `json:"kid"`
+	DataKey string `json:"dek"`
+	Data    string `json:"data"`
+}
+
+type savedValue struct {
+	Encrypted *encryptedValue `json:"__enc,omitempty"`
+}
+
+// parseSavedValue returns the encrypted value, or nil if not encrypted.
+func parseSavedValue(value string) (*encryptedValue, error) {
+	sv := savedValue{}
+	if err := json.Unmarshal([]byte(value), &sv); err != nil {
+		return nil, err
+	}
+	return sv.Encrypted, nil
+}
+
+func (ev *encryptedValue) getDataKey(keys config.EncryptionKeyList) (string, error) {
+	key := keys.Get(ev.KeyId)
+	if key == "" {
+		return "", fmt.Errorf("missing encryption key: %s", ev.KeyId)
+	}
+	return core.Decrypt(key, ev.DataKey)
+}
+
+func (ev *encryptedValue) setDataKey(keys config.EncryptionKeyList, dataKey string) error {
+	keyId, key := keys.GetActive()
+	encDataKey, err := core.Encrypt(key, dataKey)
+	if err != nil {
+		return err
+	}
+	ev.KeyId = keyId
+	ev.DataKey = encDataKey
+	return nil
+}
+
+func marshalEncryptedValue(ev *encryptedValue) (string, error) {
+	blob, err := json.Marshal(savedValue{Encrypted: ev})
+	if err != nil {
+		return "", err
+	}
+	return string(blob), nil
+}
+
+// encryptValue encrypts the value to be saved, if encryption is enabled.
+func encryptValue(keys config.EncryptionKeyList, blob []byte) (string, error) {
+	if !keys.IsEnabled() {
+		return string(blob), nil
+	}
+
+	dataKey := rand.Text()
+	data, err := core.Encrypt(dataKey, string(blob))
+	if err != nil {
+		return "", err
+	}
+	ev := &encryptedValue{Data: data}
+	if err := ev.setDataKey(keys, dataKey); err != nil {
+		return "", err
+	}
+	return marshalEncryptedValue(ev)
+}
+
+// decryptValue decrypts the saved value, if encrypted.
+func decryptValue(keys config.EncryptionKeyList, value string) ([]byte, error) {
+	ev, err := parseSavedValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if ev == nil {
+		return []byte(value), nil
+	}
+
+	dataKey, err := ev.getDataKey(keys)
+	if err != nil {
+		return nil, err
+	}
+	data, err := core.Decrypt(dataKey, ev.Data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+// reencryptValue encrypts the saved value with the active key, if not
+// already. It returns false if unchanged.
+func reencryptValue(keys config.EncryptionKeyList, value string) (string, bool, error) {
+	if !keys.IsEnabled() {
+		return value, false, nil
+	}
+
+	ev, err := parseSavedValue(value)
+	if err != nil {
+		return "", false, err
+	}
+	if ev == nil {
+		value, err := encryptValue(keys, []byte(value))
+		return value, err == nil, err
+	}
+
+	if activeKeyId, _ := keys.GetActive(); ev.KeyId == activeKeyId {
+		return value, false, nil
+	}
+	dataKey, err := ev.getDataKey(keys)
+	if err != nil {
+		return "", false, err
+	}
+	if err := ev.setDataKey(keys, dataKey); err != nil {
+		return "", false, err
+	}
+	value, err = marshalEncryptedValue(ev)
+	return value, err == nil, err
+}
+
+const encryptedEncodedPrefix = "e."
+
+func isEncryptedEncoded(encoded string) bool {
+	return strings.HasPrefix(encoded, encryptedEncodedPrefix)
+}
+
+// encryptEncoded encrypts the userdata for the url, as `e.<key id>.<blob>`.
+func encryptEncoded(keys config.EncryptionKeyList, blob []byte) (string, error) {
+	encrypted, err := keys.Encrypt(string(blob))
+	if err != nil {
+		return "", err
+	}
+	return encryptedEncodedPrefix + encrypted, nil
+}
+
+func decryptEncoded(keys config.EncryptionKeyList, encoded string) ([]byte, error) {
+	value, err := keys.Decrypt(strings.TrimPrefix(encoded, encryptedEncodedPrefix))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
@@ -0,0 +1,120 @@
+package stremio_userdata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedValueEncryption(t *testing.T) {
+	plain := `{"stores":[{"c":"rd","t":"secret-token"}]}`
+	oldKeys := config.EncryptionKeyList{"old-key"}
+	keys := config.EncryptionKeyList{"new-key", "old-key"}
+
+	t.Run("disabled", func(t *testing.T) {
+		value, err := encryptValue(config.EncryptionKeyList{}, []byte(plain))
+		assert.NoError(t, err)
+		assert.Equal(t, plain, value)
+
+		value, changed, err := reencryptValue(config.EncryptionKeyList{}, plain)
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, plain, value)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		value, err := encryptValue(keys, []byte(plain))
+		assert.NoError(t, err)
+		assert.NotContains(t, value, "secret-token")
+
+		blob, err := decryptValue(keys, value)
+		assert.NoError(t, err)
+		assert.Equal(t, plain, string(blob))
+	})
+
+	t.Run("plain value", func(t *testing.T) {
+		blob, err := decryptValue(keys, plain)
+		assert.NoError(t, err)
+		assert.Equal(t, plain, string(blob))
+
+		value, changed, err := reencryptValue(keys, plain)
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		blob, err = decryptValue(keys, value)
+		assert.NoError(t, err)
+		assert.Equal(t, plain, string(blob))
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		value, err := encryptValue(oldKeys, []byte(plain))
+		assert.NoError(t, err)
+
+		rotated, changed, err := reencryptValue(keys, value)
+		assert.NoError(t, err)
+		assert.True(t, changed)
+
+		ev, err := parseSavedValue(rotated)
+		assert.NoError(t, err)
+		activeKeyId, _ := keys.GetActive()
+		assert.Equal(t, activeKeyId, ev.KeyId)
+
+		_, changed, err = reencryptValue(keys, rotated)
+		assert.NoError(t, err)
+		assert.False(t, changed)
+
+		blob, err := decryptValue(config.EncryptionKeyList{"new-key"}, rotated)
+		assert.NoError(t, err)
+		assert.Equal(t, plain, string(blob))
+
+		_, err = decryptValue(config.EncryptionKeyList{"other-key"}, rotated)
+		assert.ErrorContains(t, err, "missing encryption key")
+	})
+}
+
+func TestEncodedEncryption(t *testing.T) {
+	plain := `{"stores":[{"c":"rd","t":"secret-token"}]}`
+	keys := config.EncryptionKeyList{"new-key", "old-key"}
+
+	encoded, err := encryptEncoded(config.EncryptionKeyList{"old-key"}, []byte(plain))
+	assert.NoError(t, err)
+	assert.True(t, isEncryptedEncoded(encoded))
+	assert.NotContains(t, encoded, "/")
+
+	blob, err := decryptEncoded(keys, encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, plain, string(blob))
+
+	_, err = decryptEncoded(config.EncryptionKeyList{"new-key"}, encoded)
+	assert.ErrorContains(t, err, "missing encryption key")
+
+	keyId, _ := keys.GetActive()
+	_, err = decryptEncoded(keys, "e."+keyId+".AAAA")
+	assert.ErrorContains(t, err, "malformed")
+
+	_, err = decryptEncoded(keys, "e."+strings.Repeat("A", 8))
+	assert.ErrorContains(t, err, "malformed")
+}
+
+func TestEncode(t *testing.T) {
+	plain := `{"store_name":"realdebrid","store_token":"secret-token"}`
+	keys := config.EncryptionKey
+	t.Cleanup(func() { config.EncryptionKey = keys })
+
+	config.EncryptionKey = config.EncryptionKeyList{}
+	base64Encoded, err := Encode([]byte(plain))
+	assert.NoError(t, err)
+	assert.False(t, isEncryptedEncoded(base64Encoded))
+
+	config.EncryptionKey = config.EncryptionKeyList{"key"}
+	encoded, err := Encode([]byte(plain))
+	assert.NoError(t, err)
+	assert.True(t, isEncryptedEncoded(encoded))
+
+	for _, encoded := range []string{base64Encoded, encoded} {
+		blob, err := Decode(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, plain, string(blob))
+	}
+}
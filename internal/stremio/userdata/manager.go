@@ -8,6 +8,7 @@ import (
 
 	"github.com/MunifTanjim/stremthru/core"
 	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/google/uuid"
 )
 
@@ -30,18 +31,39 @@ type iManager[T any] struct {
 	cache cache.Cache[StremioUserData[T]]
 }
 
+// Encode encodes the userdata for the url, encrypted if encryption is
+// enabled.
+func Encode(blob []byte) (string, error) {
+	if !config.EncryptionKey.IsEnabled() {
+		return core.Base64EncodeByte(blob), nil
+	}
+	return encryptEncoded(config.EncryptionKey, blob)
+}
+
+// Decode decodes the userdata from the url, encoded with Encode.
+func Decode(encoded string) ([]byte, error) {
+	if isEncryptedEncoded(encoded) {
+		return decryptEncoded(config.EncryptionKey, encoded)
+	}
+	return core.Base64DecodeToByte(encoded)
+}
+
 func (m iManager[T]) encode(ud UserData[T]) error {
 	blob, err := json.Marshal(ud)
 	if err != nil {
 		return err
 	}
-	ud.SetEncoded(core.Base64Encode(string(blob)))
+	encoded, err := Encode(blob)
+	if err != nil {
+		return err
+	}
+	ud.SetEncoded(encoded)
 	return nil
 }
 
 func (m iManager[T]) decode(ud UserData[T]) error {
 	encoded := ud.GetEncoded()
-	blob, err := core.Base64DecodeToByte(encoded)
+	blob, err := Decode(encoded)
 	if err != nil {
 		return err
 	}
@@ -45,27 +45,31 @@ func (ud *UserDataStores) IsP2P() bool {
 	return ud.isP2P
 }
 
+func authorizeProxy(ctx *context.StoreContext, token string) error {
+	auth, err := core.ParseBasicAuth(token)
+	if err != nil {
+		return err
+	}
+	if !config.ProxyAuthPassword.VerifyPassword(auth.Username, auth.Password) {
+		return errors.New("invalid token")
+	}
+	ctx.IsProxyAuthorized = true
+	ctx.ProxyAuthUser = auth.Username
+	ctx.ProxyAuthPassword = auth.Password
+	return nil
+}
+
 func (ud *UserDataStores) Prepare(ctx *context.StoreContext) (err error, errField string) {
 	storeCount := len(ud.Stores)
 	if storeCount == 0 {
 		return errors.New("missing store"), "store"
 	}
 	if storeCount == 1 && ud.Stores[0].Code.IsStremThru() {
-		token := ud.Stores[0].Token
-		auth, err := core.ParseBasicAuth(token)
-		if err != nil {
+		if err := authorizeProxy(ctx, ud.Stores[0].Token); err != nil {
 			return err, "token"
 		}
-		password := config.ProxyAuthPassword.GetPassword(auth.Username)
-		if password == "" || password != auth.Password {
-			return errors.New("invalid token"), "token"
-		} else {
-			ctx.IsProxyAuthorized = true
-			ctx.ProxyAuthUser = auth.Username
-			ctx.ProxyAuthPassword = auth.Password
-		}
 
-		storeNames := config.StoreAuthToken.ListStores(auth.Username)
+		storeNames := config.StoreAuthToken.ListStores(ctx.ProxyAuthUser)
 		stores := make([]resolvedStore, len(storeNames))
 		for i, storeName := range storeNames {
 			stores[i] = resolvedStore{
@@ -76,6 +80,12 @@ func (ud *UserDataStores) Prepare(ctx *context.StoreContext) (err error, errFiel
 		ud.stores = stores
 		ud.isStremThruStore = true
 	} else if storeCount == 1 && ud.Stores[0].Code.IsP2P() {
+		// token is optional, to stream through the p2p engine
+		if token := ud.Stores[0].Token; token != "" {
+			if err := authorizeProxy(ctx, token); err != nil {
+				return err, "token"
+			}
+		}
 		ud.stores = nil
 		ud.isP2P = true
 		return nil, ""
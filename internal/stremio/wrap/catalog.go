@@ -46,6 +46,15 @@ func (ud UserData) fetchAddonCatalog(ctx *context.StoreContext, w http.ResponseW
 }
 
 func (ud UserData) fetchCatalog(ctx *context.StoreContext, w http.ResponseWriter, r *http.Request, rType, id, extra string) (*stremio.CatalogHandlerResponse, error) {
+	if id == mergedCatalogId && len(ud.Upstreams) > 1 {
+		res, err := ud.fetchMergedCatalog(ctx, rType, extra)
+		if err != nil {
+			return nil, err
+		}
+		ud.setRPDBPosters(res.Metas)
+		return res, nil
+	}
+
 	idx, catalogId, err := parseCatalogId(id, &ud)
 	if err != nil {
 		SendError(w, r, err)
@@ -63,17 +72,21 @@ func (ud UserData) fetchCatalog(ctx *context.StoreContext, w http.ResponseWriter
 		return nil, err
 	}
 
-	rpdbPosterBaseUrl := ""
-	if ud.RPDBAPIKey != "" {
-		rpdbPosterBaseUrl = "https://api.ratingposterdb.com/" + ud.RPDBAPIKey + "/imdb/poster-default/"
+	ud.setRPDBPosters(res.Data.Metas)
+
+	return &res.Data, nil
+}
+
+func (ud UserData) setRPDBPosters(items []stremio.MetaPreview) {
+	if ud.RPDBAPIKey == "" {
+		return
 	}
 
-	for i := range res.Data.Metas {
-		item := &res.Data.Metas[i]
-		if rpdbPosterBaseUrl != "" && strings.HasPrefix(item.Id, "tt") {
+	rpdbPosterBaseUrl := "https://api.ratingposterdb.com/" + ud.RPDBAPIKey + "/imdb/poster-default/"
+	for i := range items {
+		item := &items[i]
+		if strings.HasPrefix(item.Id, "tt") {
 			item.Poster = rpdbPosterBaseUrl + item.Id + ".jpg?fallback=true"
 		}
 	}
-
-	return &res.Data, nil
 }
@@ -0,0 +1,294 @@
+package stremio_wrap
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+const mergedCatalogId = "st.merged"
+const mergedCatalogPageSize = 100
+
+func isCatalogMergeable(c *stremio.Catalog) bool {
+	if len(c.ExtraRequired) > 0 {
+		return false
+	}
+	return !slices.ContainsFunc(c.Extra, func(extra stremio.CatalogExtra) bool {
+		return extra.IsRequired
+	})
+}
+
+// getMergedCatalogs returns a merged catalog for each type having catalogs
+// in multiple upstreams.
+func getMergedCatalogs(upstreamManifests []stremio.Manifest) []stremio.Catalog {
+	upstreamIdxsByType := map[string][]int{}
+	types := []string{}
+	for mIdx := range upstreamManifests {
+		m := &upstreamManifests[mIdx]
+		for i := range m.Catalogs {
+			c := &m.Catalogs[i]
+			if !isCatalogMergeable(c) {
+				continue
+			}
+			if _, ok := upstreamIdxsByType[c.Type]; !ok {
+				types = append(types, c.Type)
+			}
+			if !slices.Contains(upstreamIdxsByType[c.Type], mIdx) {
+				upstreamIdxsByType[c.Type] = append(upstreamIdxsByType[c.Type], mIdx)
+			}
+		}
+	}
+
+	catalogs := []stremio.Catalog{}
+	for _, cType := range types {
+		if len(upstreamIdxsByType[cType]) < 2 {
+			continue
+		}
+		catalogs = append(catalogs, stremio.Catalog{
+			Type: cType,
+			Id:   mergedCatalogId,
+			Name: "Merged",
+			Extra: []stremio.CatalogExtra{
+				{Name: "skip"},
+			},
+		})
+	}
+	return catalogs
+}
+
+type mergedCatalogSource struct {
+	Idx   int                   `json:"idx"`
+	Id    string                `json:"id"`
+	Items []stremio.MetaPreview `json:"items"`
+	Done  bool                  `json:"done"`
+
+	// failed to fetch in this request, retried in the next one
+	failed bool
+}
+
+func (source mergedCatalogSource) isExhausted(position int) bool {
+	return position >= len(source.Items) && (source.Done || source.failed)
+}
+
+// fetched pages of the sources are kept, to continue from for the next page
+var mergedCatalogSourcesCache = cache.NewCache[[]mergedCatalogSource](&cache.CacheConfig{
+	Lifetime: 15 * time.Minute,
+	Name:     "stremio:wrap:catalog:merged",
+})
+
+func getMergedCatalogSourcesCacheKey(ud *UserData, rType string) string {
+	return util.HashString(ud.GetEncoded()) + ":" + rType
+}
+
+func (ud UserData) getMergedCatalogSources(ctx *context.StoreContext, rType string) ([]mergedCatalogSource, error) {
+	manifests, errs := ud.getUpstreamManifests(ctx)
+	if errs != nil {
+		return nil, errors.Join(errs...)
+	}
+
+	sources := []mergedCatalogSource{}
+	for mIdx := range manifests {
+		m := &manifests[mIdx]
+		for i := range m.Catalogs {
+			if c := &m.Catalogs[i]; c.Type == rType && isCatalogMergeable(c) {
+				sources = append(sources, mergedCatalogSource{Idx: mIdx, Id: c.Id})
+			}
+		}
+	}
+	return sources, nil
+}
+
+type fetchCatalogPage func(source *mergedCatalogSource, skip int) ([]stremio.MetaPreview, error)
+
+func (ud UserData) getCatalogPageFetcher(ctx *context.StoreContext, rType string) fetchCatalogPage {
+	return func(source *mergedCatalogSource, skip int) ([]stremio.MetaPreview, error) {
+		up := &ud.Upstreams[source.Idx]
+		extra := ""
+		if skip > 0 {
+			extra = "skip=" + strconv.Itoa(skip)
+		}
+		res, err := up.fetchCatalog(rType, source.Id, extra, ctx.ClientIP)
+		if err != nil {
+			ctx.Log.Error("failed to fetch catalog", "error", err, "hostname", up.baseUrl.Hostname(), "id", source.Id)
+			return nil, err
+		}
+		return res.Data.Metas, nil
+	}
+}
+
+// fetchNextPage fetches the next page of the source. The source is done if
+// the page has no new item, i.e. it is empty or the upstream ignores skip.
+// If the fetch fails, the source is only skipped for the current request.
+func fetchNextPage(fetch fetchCatalogPage, source *mergedCatalogSource) {
+	metas, err := fetch(source, len(source.Items))
+	if err != nil {
+		source.failed = true
+		return
+	}
+
+	hasNewItem := false
+	for _, item := range metas {
+		if !slices.ContainsFunc(source.Items, func(i stremio.MetaPreview) bool {
+			return i.Id == item.Id
+		}) {
+			source.Items = append(source.Items, item)
+			hasNewItem = true
+		}
+	}
+	if !hasNewItem {
+		source.Done = true
+	}
+}
+
+// fetchNextPages fetches the next page of the sources concurrently.
+func fetchNextPages(fetch fetchCatalogPage, sources []*mergedCatalogSource) {
+	var wg sync.WaitGroup
+	for _, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetchNextPage(fetch, source)
+		}()
+	}
+	wg.Wait()
+}
+
+// getCatalogItemKeys returns the keys for the ids of the items, the IMDb id
+// if resolved, to identify the same title across id schemes.
+func getCatalogItemKeys(rType string, ids []string) map[string]string {
+	keyById := make(map[string]string, len(ids))
+	unresolved := []string{}
+	for _, id := range ids {
+		if strings.HasPrefix(id, "tt") {
+			keyById[id] = id
+		} else {
+			unresolved = append(unresolved, id)
+		}
+	}
+	if len(unresolved) == 0 {
+		return keyById
+	}
+
+	imdbIdById, err := imdb_title.ResolveStremIds(stremio.ContentType(rType), unresolved)
+	if err != nil {
+		log.Error("failed to resolve strem ids", "error", err)
+	}
+	for _, id := range unresolved {
+		if imdbId := imdbIdById[id]; imdbId != "" {
+			keyById[id] = imdbId
+		} else {
+			keyById[id] = id
+		}
+	}
+	return keyById
+}
+
+type getCatalogItemKeysFunc func(rType string, ids []string) map[string]string
+
+// interleaveCatalogSources interleaves the items of the sources, skipping the
+// titles already seen, until limit items. It also returns the sources that
+// ran out of fetched items before that.
+func interleaveCatalogSources(sources []mergedCatalogSource, keyById map[string]string, limit int) ([]stremio.MetaPreview, []*mergedCatalogSource) {
+	positions := make([]int, len(sources))
+	seen := map[string]struct{}{}
+	items := []stremio.MetaPreview{}
+	for len(items) < limit {
+		hasMore := false
+		for i := range sources {
+			source := &sources[i]
+			if source.isExhausted(positions[i]) {
+				continue
+			}
+			if positions[i] >= len(source.Items) {
+				pending := []*mergedCatalogSource{}
+				for j := range sources {
+					if !sources[j].isExhausted(positions[j]) && positions[j] >= len(sources[j].Items) {
+						pending = append(pending, &sources[j])
+					}
+				}
+				return items, pending
+			}
+			hasMore = true
+			item := source.Items[positions[i]]
+			positions[i]++
+			key := keyById[item.Id]
+			if key == "" {
+				key = item.Id
+			}
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				items = append(items, item)
+			}
+		}
+		if !hasMore {
+			break
+		}
+	}
+	return items, nil
+}
+
+// mergeCatalogSources returns the interleaved items upto limit, fetching the
+// next pages of the sources as needed.
+func mergeCatalogSources(rType string, sources []mergedCatalogSource, limit int, fetch fetchCatalogPage, getKeys getCatalogItemKeysFunc) []stremio.MetaPreview {
+	keyById := map[string]string{}
+	for {
+		ids := []string{}
+		for i := range sources {
+			for _, item := range sources[i].Items {
+				if _, ok := keyById[item.Id]; !ok {
+					ids = append(ids, item.Id)
+				}
+			}
+		}
+		if len(ids) > 0 {
+			maps.Copy(keyById, getKeys(rType, ids))
+		}
+
+		items, pending := interleaveCatalogSources(sources, keyById, limit)
+		if len(pending) == 0 {
+			return items
+		}
+		fetchNextPages(fetch, pending)
+	}
+}
+
+// fetchMergedCatalog interleaves the items of the catalogs of the type from
+// the upstreams, skipping the titles already seen, across id schemes.
+func (ud UserData) fetchMergedCatalog(ctx *context.StoreContext, rType, extra string) (*stremio.CatalogHandlerResponse, error) {
+	skip := 0
+	if q, err := url.ParseQuery(extra); err == nil {
+		if skip, err = strconv.Atoi(q.Get("skip")); err != nil || skip < 0 {
+			skip = 0
+		}
+	}
+
+	cacheKey := getMergedCatalogSourcesCacheKey(&ud, rType)
+	sources := []mergedCatalogSource{}
+	if !mergedCatalogSourcesCache.Get(cacheKey, &sources) {
+		var err error
+		if sources, err = ud.getMergedCatalogSources(ctx, rType); err != nil {
+			return nil, err
+		}
+	}
+
+	items := mergeCatalogSources(rType, sources, skip+mergedCatalogPageSize, ud.getCatalogPageFetcher(ctx, rType), getCatalogItemKeys)
+
+	if err := mergedCatalogSourcesCache.Add(cacheKey, sources); err != nil {
+		log.Warn("failed to cache merged catalog sources", "error", err)
+	}
+
+	return &stremio.CatalogHandlerResponse{
+		Metas: items[min(skip, len(items)):min(skip+mergedCatalogPageSize, len(items))],
+	}, nil
+}
@@ -0,0 +1,150 @@
+package stremio_wrap
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMergedCatalogs(t *testing.T) {
+	manifests := []stremio.Manifest{
+		{Catalogs: []stremio.Catalog{
+			{Type: "movie", Id: "a.movie.top"},
+			{Type: "movie", Id: "a.movie.new"},
+			{Type: "series", Id: "a.series.top"},
+			{Type: "movie", Id: "a.movie.search", Extra: []stremio.CatalogExtra{{Name: "search", IsRequired: true}}},
+		}},
+		{Catalogs: []stremio.Catalog{
+			{Type: "movie", Id: "b.movie.top", Extra: []stremio.CatalogExtra{{Name: "skip"}, {Name: "genre"}}},
+			{Type: "series", Id: "b.series.search", ExtraRequired: []string{"search"}},
+			{Type: "anime", Id: "b.anime.top"},
+		}},
+		{Catalogs: []stremio.Catalog{
+			{Type: "anime", Id: "c.anime.top"},
+		}},
+	}
+
+	catalogs := getMergedCatalogs(manifests)
+	types := []string{}
+	for _, c := range catalogs {
+		assert.Equal(t, mergedCatalogId, c.Id)
+		types = append(types, c.Type)
+	}
+	assert.Equal(t, []string{"movie", "anime"}, types)
+}
+
+func getTestMetas(ids ...string) []stremio.MetaPreview {
+	metas := make([]stremio.MetaPreview, len(ids))
+	for i, id := range ids {
+		metas[i] = stremio.MetaPreview{Id: id, Type: stremio.ContentTypeMovie}
+	}
+	return metas
+}
+
+type testCatalogUpstream struct {
+	pageSize int
+	items    []stremio.MetaPreview
+	err      error
+}
+
+func newTestCatalogPageFetcher(upstreams map[string]*testCatalogUpstream) (fetchCatalogPage, func() map[string]int) {
+	var mu sync.Mutex
+	fetchCount := map[string]int{}
+	fetch := func(source *mergedCatalogSource, skip int) ([]stremio.MetaPreview, error) {
+		mu.Lock()
+		fetchCount[source.Id]++
+		mu.Unlock()
+		up := upstreams[source.Id]
+		if up.err != nil {
+			return nil, up.err
+		}
+		return up.items[min(skip, len(up.items)):min(skip+up.pageSize, len(up.items))], nil
+	}
+	return fetch, func() map[string]int {
+		return fetchCount
+	}
+}
+
+func getTestCatalogItemKeys(rType string, ids []string) map[string]string {
+	keyById := map[string]string{}
+	for _, id := range ids {
+		switch id {
+		case "tmdb:1":
+			keyById[id] = "tt1"
+		case "tmdb:3":
+			keyById[id] = "tt3"
+		default:
+			keyById[id] = id
+		}
+	}
+	return keyById
+}
+
+func getMetaIds(metas []stremio.MetaPreview) []string {
+	ids := make([]string, len(metas))
+	for i := range metas {
+		ids[i] = metas[i].Id
+	}
+	return ids
+}
+
+func TestMergeCatalogSources(t *testing.T) {
+	t.Run("interleave and dedupe", func(t *testing.T) {
+		fetch, getFetchCount := newTestCatalogPageFetcher(map[string]*testCatalogUpstream{
+			"a": {pageSize: 2, items: getTestMetas("tt1", "tt2", "tt3", "tt4")},
+			"b": {pageSize: 3, items: getTestMetas("tmdb:1", "tmdb:5", "tmdb:3", "tmdb:6")},
+		})
+		sources := []mergedCatalogSource{{Idx: 0, Id: "a"}, {Idx: 1, Id: "b"}}
+
+		items := mergeCatalogSources("movie", sources, 100, fetch, getTestCatalogItemKeys)
+		assert.Equal(t, []string{"tt1", "tt2", "tmdb:5", "tt3", "tt4", "tmdb:6"}, getMetaIds(items))
+		assert.True(t, sources[0].Done)
+		assert.True(t, sources[1].Done)
+		assert.Equal(t, map[string]int{"a": 3, "b": 3}, getFetchCount())
+	})
+
+	t.Run("fetches only needed pages", func(t *testing.T) {
+		fetch, getFetchCount := newTestCatalogPageFetcher(map[string]*testCatalogUpstream{
+			"a": {pageSize: 2, items: getTestMetas("tt1", "tt2", "tt3", "tt4", "tt5", "tt6")},
+			"b": {pageSize: 2, items: getTestMetas("tt11", "tt12", "tt13", "tt14", "tt15", "tt16")},
+		})
+		sources := []mergedCatalogSource{{Idx: 0, Id: "a"}, {Idx: 1, Id: "b"}}
+
+		items := mergeCatalogSources("movie", sources, 4, fetch, getTestCatalogItemKeys)
+		assert.Equal(t, []string{"tt1", "tt11", "tt2", "tt12"}, getMetaIds(items))
+		assert.Equal(t, map[string]int{"a": 1, "b": 1}, getFetchCount())
+		assert.False(t, sources[0].Done)
+
+		items = mergeCatalogSources("movie", sources, 6, fetch, getTestCatalogItemKeys)
+		assert.Equal(t, []string{"tt1", "tt11", "tt2", "tt12", "tt3", "tt13"}, getMetaIds(items))
+		assert.Equal(t, map[string]int{"a": 2, "b": 2}, getFetchCount())
+	})
+
+	t.Run("failed source is not done", func(t *testing.T) {
+		upstreams := map[string]*testCatalogUpstream{
+			"a": {pageSize: 2, items: getTestMetas("tt1", "tt2")},
+			"b": {pageSize: 2, err: errors.New("timeout")},
+		}
+		fetch, _ := newTestCatalogPageFetcher(upstreams)
+		sources := []mergedCatalogSource{{Idx: 0, Id: "a"}, {Idx: 1, Id: "b"}}
+
+		items := mergeCatalogSources("movie", sources, 100, fetch, getTestCatalogItemKeys)
+		assert.Equal(t, []string{"tt1", "tt2"}, getMetaIds(items))
+		assert.True(t, sources[0].Done)
+		assert.False(t, sources[1].Done)
+
+		// sources are cached without the failure
+		sources = []mergedCatalogSource{{Idx: 0, Id: "a", Items: sources[0].Items, Done: true}, {Idx: 1, Id: "b"}}
+		upstreams["b"] = &testCatalogUpstream{pageSize: 2, items: getTestMetas("tt3")}
+		items = mergeCatalogSources("movie", sources, 100, fetch, getTestCatalogItemKeys)
+		assert.Equal(t, []string{"tt1", "tt3", "tt2"}, getMetaIds(items))
+	})
+}
+
+func TestGetMergedCatalogSourcesCacheKey(t *testing.T) {
+	ud := &UserData{encoded: "secret-token"}
+	assert.NotContains(t, getMergedCatalogSourcesCacheKey(ud, "movie"), "secret-token")
+}
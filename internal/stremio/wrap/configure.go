@@ -32,6 +32,14 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 			if ud.CachedOnly {
 				conf.Default = "checked"
 			}
+		case "merge_catalogs":
+			if ud.MergeCatalogs {
+				conf.Default = "checked"
+			}
+		case "merge_meta":
+			if ud.MergeMeta {
+				conf.Default = "checked"
+			}
 		}
 	}
 
@@ -41,7 +49,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 			if !IsPublicInstance {
 				user := r.Form.Get("user")
 				pass := r.Form.Get("pass")
-				if pass == "" || config.ProxyAuthPassword.GetPassword(user) != pass {
+				if !config.ProxyAuthPassword.VerifyPassword(user, pass) {
 					td.AuthError = "Wrong Credential!"
 				} else if !config.AuthAdmin.IsAdmin(user) {
 					td.AuthError = "Not Authorized!"
@@ -113,9 +121,11 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 					up.ExtractorError = "✨-prefixed ids are reserved"
 				}
 				if up.ExtractorError == "" {
-					if _, err := value.Parse(); err != nil {
+					if extractor, err := value.Parse(); err != nil {
 						LogError(r, "failed to parse extractor", err)
 						up.ExtractorError = err.Error()
+					} else if err := testExtractor(id, extractor); value != "" && err != nil {
+						up.ExtractorError = err.Error()
 					} else {
 						up.ExtractorId = id
 						up.Extractor = value
@@ -126,6 +136,8 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 						if err := extractorStore.Del(id); err != nil {
 							LogError(r, "failed to delete extractor", err)
 							up.ExtractorError = "Failed to delete extractor"
+						} else if err := extractorFixtureStore.Del(id); err != nil {
+							LogError(r, "failed to delete extractor fixtures", err)
 						}
 						extractorIds := []string{}
 						for _, extractorId := range td.ExtractorIds {
@@ -161,7 +173,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 		case "set-template":
 			id := ud.TemplateId
 			if id != "" {
-				value, err := getTemplate(id)
+				value, err := stremio_transformer.GetTemplate(id)
 				if err != nil {
 					LogError(r, "failed to fetch template", err)
 					td.TemplateError.Name = "Failed to fetch template"
@@ -200,7 +212,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 				}
 				if td.TemplateError.IsEmpty() {
 					if value.Name == "" && value.Description == "" {
-						if err := templateStore.Del(id); err != nil {
+						if err := stremio_transformer.DeleteTemplate(id); err != nil {
 							LogError(r, "failed to delete template", err)
 							td.TemplateError.Name = "Failed to delete template"
 							td.TemplateError.Description = "Failed to delete template"
@@ -215,7 +227,7 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 						td.TemplateId = ""
 						td.Template = stremio_transformer.StreamTemplateBlob{}
 					} else {
-						if err := templateStore.Set(id, value); err != nil {
+						if err := stremio_transformer.SaveTemplate(id, value); err != nil {
 							LogError(r, "failed to save template", err)
 							td.TemplateError.Name = "Failed to save template"
 							td.TemplateError.Description = "Failed to save template"
@@ -342,6 +354,16 @@ func handleConfigure(w http.ResponseWriter, r *http.Request) {
 							continue
 						}
 					}
+
+					if up := &ud.Upstreams[i]; !up.hasExtractor() {
+						if id := detectExtractorId(ctx, up, &manifest, nil); id != "" {
+							tup.ExtractorHint = "Auto-detected: " + id
+						} else if _, ok := getDetectedExtractorId(up); ok {
+							tup.ExtractorHint = "Auto-detected: none, using fallback heuristics"
+						} else {
+							tup.ExtractorHint = "Auto-detected from streams on first use"
+						}
+					}
 				}
 			}
 
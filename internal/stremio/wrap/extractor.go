@@ -0,0 +1,375 @@
+package stremio_wrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/shared"
+	stremio_addon "github.com/MunifTanjim/stremthru/internal/stremio/addon"
+	stremio_shared "github.com/MunifTanjim/stremthru/internal/stremio/shared"
+	stremio_template "github.com/MunifTanjim/stremthru/internal/stremio/template"
+	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+type ExtractorTemplateData struct {
+	Base
+	CanAuthorize bool
+	IsAuthed     bool
+	ExtractorIds []string
+	TemplateIds  []string
+}
+
+var executeExtractorTemplate = func() stremio_template.Executor[ExtractorTemplateData] {
+	return stremio_template.GetExecutor("stremio/wrap/extractor", func(td *ExtractorTemplateData) *ExtractorTemplateData {
+		td.StremThruAddons = stremio_shared.GetStremThruAddons()
+		td.Version = config.Version
+		td.CanAuthorize = !IsPublicInstance
+		return td
+	}, template.FuncMap{}, "wrap_extractor.html")
+}()
+
+func isAdminAuthed(w http.ResponseWriter, r *http.Request) bool {
+	cookie, err := stremio_shared.GetAdminCookieValue(w, r)
+	return err == nil && !cookie.IsExpired && config.ProxyAuthPassword.VerifyPassword(cookie.User(), cookie.Pass())
+}
+
+func handleExtractorConsole(w http.ResponseWriter, r *http.Request) {
+	if !IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	td := &ExtractorTemplateData{
+		Base: Base{
+			Title:       "StremThru Wrap - Extractor Console",
+			Description: "Try Stream Extractors and Templates against Addon Streams",
+			NavTitle:    "Wrap",
+		},
+		IsAuthed: isAdminAuthed(w, r),
+	}
+
+	if extractorIds, err := getExtractorIds(); err != nil {
+		LogError(r, "failed to list extractors", err)
+	} else {
+		td.ExtractorIds = extractorIds
+	}
+
+	if templateIds, err := stremio_transformer.GetTemplateIds(); err != nil {
+		LogError(r, "failed to list templates", err)
+	} else {
+		td.TemplateIds = templateIds
+	}
+
+	page, err := executeExtractorTemplate(td, "wrap_extractor.html")
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	SendHTML(w, 200, page)
+}
+
+var streamURLRegex = regexp.MustCompile(`^(.+)/stream/([^/]+)/([^/]+?)(?:\.json)?$`)
+
+func fetchStreamsForExtractorTest(r *http.Request, streamUrl string) ([]stremio.Stream, string, error) {
+	match := streamURLRegex.FindStringSubmatch(streamUrl)
+	if match == nil {
+		return nil, "", errors.New("invalid stream url, expected: <addon>/stream/<type>/<id>.json")
+	}
+	baseUrl, err := url.Parse(match[1])
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := url.PathUnescape(match[3])
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := addon.FetchStream(&stremio_addon.FetchStreamParams{
+		BaseURL:  baseUrl,
+		Type:     match[2],
+		Id:       id,
+		ClientIP: core.GetClientIP(r),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return res.Data.Streams, match[2], nil
+}
+
+// Accepts a single stream, a list of streams or a stream handler response.
+func parseStreamsForExtractorTest(raw json.RawMessage) ([]stremio.Stream, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		raw = bytes.TrimSpace([]byte(s))
+	}
+	if len(raw) > 0 && raw[0] == '[' {
+		streams := []stremio.Stream{}
+		err := json.Unmarshal(raw, &streams)
+		return streams, err
+	}
+	res := struct {
+		stremio.Stream
+		Streams []stremio.Stream `json:"streams"`
+	}{}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	if res.Streams != nil {
+		return res.Streams, nil
+	}
+	return []stremio.Stream{res.Stream}, nil
+}
+
+type ExtractorTestPayload struct {
+	ExtractorId string                                       `json:"extractor_id"`
+	Extractor   stremio_transformer.StreamExtractorBlob      `json:"extractor"`
+	TemplateId  string                                       `json:"template_id"`
+	Template    stremio_transformer.StreamTemplateBlob       `json:"template"`
+	Type        string                                       `json:"type"`
+	StreamURL   string                                       `json:"stream_url"`
+	Streams     json.RawMessage                              `json:"streams"`
+	Fixtures    []stremio_transformer.StreamExtractorFixture `json:"fixtures"`
+}
+
+type ExtractorTestResultOutput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ExtractorTestResultItem struct {
+	Stream stremio.Stream             `json:"stream"`
+	Fields map[string]string          `json:"fields"`
+	Output *ExtractorTestResultOutput `json:"output,omitempty"`
+	Error  string                     `json:"error,omitempty"`
+}
+
+type ExtractorTestResult struct {
+	Type     string                                             `json:"type"`
+	Items    []ExtractorTestResultItem                          `json:"items"`
+	Fixtures []stremio_transformer.StreamExtractorFixtureResult `json:"fixtures"`
+}
+
+func (p *ExtractorTestPayload) resolve() (stremio_transformer.StreamExtractor, *stremio_transformer.StreamTemplate, error) {
+	if p.Extractor == "" && p.ExtractorId != "" {
+		extractor, err := getExtractor(p.ExtractorId)
+		if err != nil {
+			return stremio_transformer.StreamExtractor{}, nil, err
+		}
+		p.Extractor = extractor
+	}
+	extractor, err := p.Extractor.Parse()
+	if err != nil {
+		return extractor, nil, errors.New("invalid extractor: " + err.Error())
+	}
+
+	if p.Template.IsEmpty() && p.TemplateId != "" {
+		tmpl, err := stremio_transformer.GetTemplate(p.TemplateId)
+		if err != nil {
+			return extractor, nil, err
+		}
+		p.Template = tmpl
+	}
+	tmpl, err := p.Template.Parse()
+	if err != nil {
+		return extractor, nil, errors.New("invalid template: " + err.Error())
+	}
+
+	return extractor, tmpl, nil
+}
+
+func handleExtractorTest(w http.ResponseWriter, r *http.Request) {
+	if !IsMethod(r, http.MethodPost) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	payload := &ExtractorTestPayload{}
+	if err := shared.ReadRequestBodyJSON(r, payload); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	extractor, tmpl, err := payload.resolve()
+	if err != nil {
+		shared.ErrorBadRequest(r, err.Error()).Send(w, r)
+		return
+	}
+
+	result := &ExtractorTestResult{
+		Type:  payload.Type,
+		Items: []ExtractorTestResultItem{},
+	}
+
+	streams, err := parseStreamsForExtractorTest(payload.Streams)
+	if err != nil {
+		shared.ErrorBadRequest(r, "invalid streams: "+err.Error()).Send(w, r)
+		return
+	}
+	if payload.StreamURL != "" {
+		// fetching arbitrary url is only allowed for admin
+		if !isAdminAuthed(w, r) {
+			shared.ErrorUnauthorized(r).Send(w, r)
+			return
+		}
+		fetchedStreams, sType, err := fetchStreamsForExtractorTest(r, payload.StreamURL)
+		if err != nil {
+			shared.ErrorBadRequest(r, "failed to fetch streams: "+err.Error()).Send(w, r)
+			return
+		}
+		streams = append(streams, fetchedStreams...)
+		if result.Type == "" {
+			result.Type = sType
+		}
+	}
+	if result.Type == "" {
+		result.Type = "movie"
+	}
+
+	for i := range streams {
+		item := ExtractorTestResultItem{Stream: streams[i]}
+		stream := streams[i]
+		data := extractor.Parse(&stream, result.Type)
+		item.Fields = data.Fields()
+		if !tmpl.IsEmpty() {
+			if out, err := tmpl.Execute(&stream, data); err != nil {
+				item.Error = err.Error()
+			} else {
+				item.Output = &ExtractorTestResultOutput{
+					Name:        out.Name,
+					Description: out.Description,
+				}
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	fixtures := payload.Fixtures
+	if fixtures == nil && payload.ExtractorId != "" {
+		if fixtures, err = getExtractorFixtures(payload.ExtractorId); err != nil {
+			LogError(r, "failed to fetch extractor fixtures", err)
+		}
+	}
+	result.Fixtures, _ = extractor.Test(fixtures)
+
+	SendResponse(w, r, 200, result)
+}
+
+type ExtractorDefinition struct {
+	Id        string                                       `json:"id"`
+	Extractor stremio_transformer.StreamExtractorBlob      `json:"extractor"`
+	Fixtures  []stremio_transformer.StreamExtractorFixture `json:"fixtures"`
+}
+
+func handleExtractorDefinition(w http.ResponseWriter, r *http.Request) {
+	if !IsMethod(r, http.MethodGet) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		shared.ErrorBadRequest(r, "missing id").Send(w, r)
+		return
+	}
+
+	extractor, err := getExtractor(id)
+	if err != nil && !strings.HasPrefix(id, BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI) {
+		SendError(w, r, err)
+		return
+	}
+	if extractor == "" {
+		shared.ErrorNotFound(r).Send(w, r)
+		return
+	}
+
+	fixtures, err := getExtractorFixtures(id)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendResponse(w, r, 200, &ExtractorDefinition{
+		Id:        id,
+		Extractor: extractor,
+		Fixtures:  fixtures,
+	})
+}
+
+type ExtractorSavePayload struct {
+	Id        string                                       `json:"id"`
+	Extractor stremio_transformer.StreamExtractorBlob      `json:"extractor"`
+	Fixtures  []stremio_transformer.StreamExtractorFixture `json:"fixtures"`
+}
+
+func handleExtractorSave(w http.ResponseWriter, r *http.Request) {
+	if !IsMethod(r, http.MethodPost) {
+		shared.ErrorMethodNotAllowed(r).Send(w, r)
+		return
+	}
+
+	if !isAdminAuthed(w, r) {
+		shared.ErrorUnauthorized(r).Send(w, r)
+		return
+	}
+
+	payload := &ExtractorSavePayload{}
+	if err := shared.ReadRequestBodyJSON(r, payload); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	id := strings.TrimSpace(payload.Id)
+	if id == "" {
+		shared.ErrorBadRequest(r, "missing id").Send(w, r)
+		return
+	}
+	if strings.HasPrefix(id, BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI) {
+		shared.ErrorBadRequest(r, "✨-prefixed ids are reserved").Send(w, r)
+		return
+	}
+
+	extractor, err := payload.Extractor.Parse()
+	if err != nil {
+		shared.ErrorBadRequest(r, "invalid extractor: "+err.Error()).Send(w, r)
+		return
+	}
+
+	if payload.Fixtures == nil {
+		payload.Fixtures = []stremio_transformer.StreamExtractorFixture{}
+	}
+	results, failed := extractor.Test(payload.Fixtures)
+	if len(failed) > 0 {
+		msgs := make([]string, len(failed))
+		for i := range failed {
+			msgs[i] = failed[i].String()
+		}
+		shared.ErrorBadRequest(r, "fixtures failed: "+strings.Join(msgs, "; ")).Send(w, r)
+		return
+	}
+
+	if err := extractorStore.Set(id, payload.Extractor); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	if err := extractorFixtureStore.Set(id, payload.Fixtures); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendResponse(w, r, 200, &ExtractorTestResult{Items: []ExtractorTestResultItem{}, Fixtures: results})
+}
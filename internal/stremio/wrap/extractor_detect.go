@@ -0,0 +1,161 @@
+package stremio_wrap
+
+import (
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/context"
+	stremio_addon "github.com/MunifTanjim/stremthru/internal/stremio/addon"
+	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+// Matched against the manifest id and name of the upstream addon.
+var builtInExtractorManifestPatterns = map[string]*regexp.Regexp{
+	BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "Comet":       regexp.MustCompile(`(?i)\bcomet\b`),
+	BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "Debridio":    regexp.MustCompile(`(?i)debridio`),
+	BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "MediaFusion": regexp.MustCompile(`(?i)media\s*fusion`),
+	BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "Orion":       regexp.MustCompile(`(?i)\borion\b`),
+	BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "Peerflix":    regexp.MustCompile(`(?i)peerflix`),
+	BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "Torrentio":   regexp.MustCompile(`(?i)torrentio`),
+}
+
+func detectExtractorIdFromManifest(manifest *stremio.Manifest) string {
+	ids := make([]string, 0, len(builtInExtractorManifestPatterns))
+	for id := range builtInExtractorManifestPatterns {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		pattern := builtInExtractorManifestPatterns[id]
+		if pattern.MatchString(manifest.ID) || pattern.MatchString(manifest.Name) {
+			return id
+		}
+	}
+	return ""
+}
+
+const extractorDetectionSampleSize = 10
+
+// An extractor fits a stream if at least two of its patterns match it, and
+// it is picked if it fits at least half of the sampled streams.
+func detectExtractorIdFromStreams(streams []stremio.Stream) string {
+	sample := streams[:min(len(streams), extractorDetectionSampleSize)]
+	if len(sample) == 0 {
+		return ""
+	}
+
+	ids := make([]string, 0, len(builtInExtractors))
+	for id := range builtInExtractors {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	bestId, bestFitCount, bestMatchCount := "", 0, 0
+	for _, id := range ids {
+		extractor, err := builtInExtractors[id].Parse()
+		if err != nil {
+			continue
+		}
+		fitCount, matchCount := 0, 0
+		for i := range sample {
+			count := extractor.Match(&sample[i])
+			if count >= 2 {
+				fitCount++
+			}
+			matchCount += count
+		}
+		if fitCount > bestFitCount || (fitCount == bestFitCount && matchCount > bestMatchCount) {
+			bestId, bestFitCount, bestMatchCount = id, fitCount, matchCount
+		}
+	}
+
+	if bestFitCount*2 < len(sample) {
+		return ""
+	}
+	return bestId
+}
+
+type extractorDetection struct {
+	ExtractorId string
+}
+
+var extractorDetectionCache = cache.NewCache[extractorDetection](&cache.CacheConfig{
+	Name:     "stremio:wrap:extractorDetection",
+	Lifetime: 24 * time.Hour,
+})
+
+// Nothing detected from the streams is cached for shorter, so that it is
+// tried again with the streams or manifest not available earlier.
+const extractorDetectionNegativeLifetime = 1 * time.Hour
+
+// The base url of the upstream can contain tokens.
+func getDetectedExtractorCacheKey(up *UserDataUpstream) string {
+	return util.HashString(up.baseUrl.String())
+}
+
+func getDetectedExtractorId(up *UserDataUpstream) (string, bool) {
+	var detection extractorDetection
+	if up.baseUrl == nil || !extractorDetectionCache.Get(getDetectedExtractorCacheKey(up), &detection) {
+		return "", false
+	}
+	return detection.ExtractorId, true
+}
+
+// detectExtractorId picks a built-in extractor for the upstream using its
+// manifest, or the shape of its streams. An empty id means the fallback
+// heuristic extractor is used.
+func detectExtractorId(ctx *context.StoreContext, up *UserDataUpstream, manifest *stremio.Manifest, streams []stremio.Stream) string {
+	if id, ok := getDetectedExtractorId(up); ok {
+		return id
+	}
+
+	if manifest == nil {
+		res, err := addon.GetManifest(&stremio_addon.GetManifestParams{BaseURL: up.baseUrl, ClientIP: ctx.ClientIP})
+		if err != nil {
+			ctx.Log.Warn("failed to fetch manifest for extractor detection", "error", err, "hostname", up.baseUrl.Hostname())
+		} else {
+			manifest = &res.Data
+		}
+	}
+
+	id := ""
+	if manifest != nil {
+		id = detectExtractorIdFromManifest(manifest)
+	}
+	if id == "" {
+		id = detectExtractorIdFromStreams(streams)
+	}
+
+	lifetime := 24 * time.Hour
+	if id == "" {
+		if len(streams) == 0 {
+			// not cached, so that it is detected from the streams later
+			return id
+		}
+		lifetime = extractorDetectionNegativeLifetime
+	}
+	if err := extractorDetectionCache.AddWithLifetime(getDetectedExtractorCacheKey(up), extractorDetection{ExtractorId: id}, lifetime); err != nil {
+		ctx.Log.Warn("failed to cache extractor detection", "error", err)
+	}
+	ctx.Log.Debug("detected extractor", "hostname", up.baseUrl.Hostname(), "extractor_id", id)
+
+	return id
+}
+
+func (up *UserDataUpstream) hasExtractor() bool {
+	return up.ExtractorId != "" || up.extractor != ""
+}
+
+func (up *UserDataUpstream) getExtractor(ctx *context.StoreContext, streams []stremio.Stream) (stremio_transformer.StreamExtractor, error) {
+	if up.hasExtractor() {
+		return up.extractor.Parse()
+	}
+	if id := detectExtractorId(ctx, up, nil, streams); id != "" {
+		return builtInExtractors[id].Parse()
+	}
+	return stremio_transformer.StreamExtractor{}, nil
+}
@@ -0,0 +1,130 @@
+package stremio_wrap
+
+import (
+	"log/slog"
+	"net/url"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/context"
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectExtractorIdFromManifest(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		manifest stremio.Manifest
+		expected string
+	}{
+		{"by id", stremio.Manifest{ID: "com.stremio.torrentio.addon", Name: "Torrentio RD"}, "Torrentio"},
+		{"by name", stremio.Manifest{ID: "community.example", Name: "Comet | ElfHosted"}, "Comet"},
+		{"name with space", stremio.Manifest{ID: "stremio.addons.mf", Name: "Media Fusion"}, "MediaFusion"},
+		{"word boundary", stremio.Manifest{ID: "community.cometary", Name: "Cometary"}, ""},
+		{"unknown", stremio.Manifest{ID: "community.example", Name: "Example"}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := ""
+			if tc.expected != "" {
+				expected = BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + tc.expected
+			}
+			assert.Equal(t, expected, detectExtractorIdFromManifest(&tc.manifest))
+		})
+	}
+}
+
+func TestDetectExtractorIdFromStreams(t *testing.T) {
+	torrentio := stremio.Stream{
+		Name:      "Torrentio\n4k DV",
+		Title:     "Deadpool.2016.UHD.BluRay.2160p.TrueHD.Atmos.7.1.DV.HEVC.HYBRiD.REMUX-FraMeSToR\n👤 47 💾 40.33 GB ⚙️ TorrentGalaxy",
+		InfoHash:  "e4f5d7a2f3dd6b7b1826bd77e316b6b5ba31eb72",
+		FileIndex: 0,
+		BehaviorHints: &stremio.StreamBehaviorHints{
+			BingeGroup: "torrentio|4k|BluRay REMUX|hevc|DV",
+			Filename:   "Deadpool.2016.UHD.BluRay.2160p.TrueHD.Atmos.7.1.DV.HEVC.HYBRiD.REMUX-FraMeSToR.mkv",
+		},
+	}
+	comet := stremio.Stream{
+		Name:        "[TORRENT🧲] Comet 1080p",
+		Description: "Deadpool 2016 BluRay 1080p DTS-ES AC3 x264-3Li.mkv\n💿 BluRay|avc|DTS Lossy|AC3|3Li\n💾 7.29 GB 🔎 DMM\n🇪🇸",
+		InfoHash:    "c359566eed1264fbe0482aae479cbe51c966d468",
+		FileIndex:   0,
+		BehaviorHints: &stremio.StreamBehaviorHints{
+			BingeGroup: "comet|c359566eed1264fbe0482aae479cbe51c966d468",
+			VideoSize:  7826122416,
+			Filename:   "Deadpool 2016 BluRay 1080p DTS-ES AC3 x264-3Li.mkv",
+		},
+	}
+	unknown := stremio.Stream{
+		Name:  "Example",
+		Title: "Something",
+		URL:   "https://example.com/video.mp4",
+	}
+
+	for _, tc := range []struct {
+		name     string
+		streams  []stremio.Stream
+		expected string
+	}{
+		{"empty", nil, ""},
+		{"torrentio", []stremio.Stream{torrentio, torrentio}, "Torrentio"},
+		{"comet", []stremio.Stream{comet}, "Comet"},
+		{"half fit", []stremio.Stream{torrentio, unknown}, "Torrentio"},
+		{"less than half fit", []stremio.Stream{torrentio, unknown, unknown}, ""},
+		{"unknown", []stremio.Stream{unknown}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := ""
+			if tc.expected != "" {
+				expected = BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + tc.expected
+			}
+			assert.Equal(t, expected, detectExtractorIdFromStreams(tc.streams))
+		})
+	}
+}
+
+func TestGetDetectedExtractorCacheKey(t *testing.T) {
+	baseUrl, err := url.Parse("https://addon.example.com/secret-token")
+	assert.NoError(t, err)
+	up := &UserDataUpstream{baseUrl: baseUrl}
+	assert.NotContains(t, getDetectedExtractorCacheKey(up), "secret-token")
+}
+
+func TestDetectExtractorId(t *testing.T) {
+	ctx := &context.StoreContext{Log: slog.Default()}
+
+	baseUrl, err := url.Parse("https://addon.example.com/" + t.Name())
+	assert.NoError(t, err)
+	up := &UserDataUpstream{baseUrl: baseUrl}
+
+	manifest := &stremio.Manifest{ID: "community.example", Name: "Example"}
+	torrentio := stremio.Stream{
+		Name:     "Torrentio\n4k DV",
+		Title:    "Deadpool.2016.UHD.BluRay.2160p.TrueHD.Atmos.7.1.DV.HEVC.HYBRiD.REMUX-FraMeSToR\n👤 47 💾 40.33 GB ⚙️ TorrentGalaxy",
+		InfoHash: "e4f5d7a2f3dd6b7b1826bd77e316b6b5ba31eb72",
+		BehaviorHints: &stremio.StreamBehaviorHints{
+			BingeGroup: "torrentio|4k|BluRay REMUX|hevc|DV",
+			Filename:   "Deadpool.2016.UHD.BluRay.2160p.TrueHD.Atmos.7.1.DV.HEVC.HYBRiD.REMUX-FraMeSToR.mkv",
+		},
+	}
+	unknown := stremio.Stream{Name: "Example", Title: "Something", URL: "https://example.com/video.mp4"}
+
+	// configure page, without streams
+	assert.Equal(t, "", detectExtractorId(ctx, up, manifest, nil))
+	_, ok := getDetectedExtractorId(up)
+	assert.False(t, ok)
+
+	// first use, with streams
+	assert.Equal(t, BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX+"Torrentio", detectExtractorId(ctx, up, manifest, []stremio.Stream{torrentio}))
+	id, ok := getDetectedExtractorId(up)
+	assert.True(t, ok)
+	assert.Equal(t, BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX+"Torrentio", id)
+
+	baseUrl, err = url.Parse("https://addon.example.com/" + t.Name() + "/unknown")
+	assert.NoError(t, err)
+	up = &UserDataUpstream{baseUrl: baseUrl}
+
+	assert.Equal(t, "", detectExtractorId(ctx, up, manifest, []stremio.Stream{unknown}))
+	id, ok = getDetectedExtractorId(up)
+	assert.True(t, ok)
+	assert.Equal(t, "", id)
+}
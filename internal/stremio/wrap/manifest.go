@@ -155,6 +155,10 @@ func GetManifest(r *http.Request, upstreamManifests []stremio.Manifest, ud *User
 		}
 	}
 
+	if ud.MergeCatalogs {
+		manifest.Catalogs = append(manifest.Catalogs, getMergedCatalogs(upstreamManifests)...)
+	}
+
 	for rName := range resourceByName {
 		r := resourceByName[rName]
 
@@ -0,0 +1,150 @@
+package stremio_wrap
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+var nonAlphaNumericRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+func normalizeFilename(name string) string {
+	return nonAlphaNumericRegex.ReplaceAllLiteralString(strings.ToLower(name), "")
+}
+
+func (ws WrappedStream) getFileSize() int64 {
+	size := ws.r.File.Size
+	if size == "" {
+		size = ws.r.Size
+	}
+	if size == "" {
+		return -1
+	}
+	return util.ToBytes(size)
+}
+
+// sizes are formatted differently by the addons, so they are considered
+// same within 2% of each other
+func isSameSize(a, b int64) bool {
+	if a <= 0 || b <= 0 {
+		return false
+	}
+	return max(a, b)-min(a, b) <= max(a, b)/50
+}
+
+// file index 0 is also used by addons when the file is unknown
+func hasFileIdx(ws *WrappedStream) bool {
+	return ws.r.File.Idx > 0
+}
+
+// isDuplicateOf checks if the streams are for the same torrent file, or the
+// same file with URL, reported by different upstreams.
+func (ws WrappedStream) isDuplicateOf(other WrappedStream) bool {
+	if ws.r.Hash != "" || other.r.Hash != "" {
+		if !strings.EqualFold(ws.r.Hash, other.r.Hash) {
+			return false
+		}
+		if hasFileIdx(&ws) && hasFileIdx(&other) && ws.r.File.Idx != other.r.File.Idx {
+			return false
+		}
+		if ws.r.File.Name != "" && other.r.File.Name != "" && normalizeFilename(ws.r.File.Name) != normalizeFilename(other.r.File.Name) {
+			return false
+		}
+		return true
+	}
+
+	if ws.r.File.Name == "" || normalizeFilename(ws.r.File.Name) != normalizeFilename(other.r.File.Name) {
+		return false
+	}
+	return isSameSize(ws.getFileSize(), other.getFileSize())
+}
+
+// merge keeps the richest metadata from the duplicate stream. The cached
+// stream is preferred, for the URL to be playable without waiting.
+func (ws *WrappedStream) merge(other *WrappedStream) {
+	r, or := ws.r, other.r
+
+	if or.Store.IsCached && !r.Store.IsCached {
+		ws.Stream = other.Stream
+		ws.noContentProxy = other.noContentProxy
+		r.Store = or.Store
+	}
+	if r.Hash == "" {
+		r.Hash = or.Hash
+	}
+	if !hasFileIdx(ws) && hasFileIdx(other) {
+		r.File.Idx = or.File.Idx
+	}
+	if r.File.Name == "" {
+		r.File.Name = or.File.Name
+	}
+	if r.File.Size == "" {
+		r.File.Size = or.File.Size
+	}
+	if r.Size == "" {
+		r.Size = or.Size
+	}
+	for _, lang := range or.Languages {
+		if !slices.Contains(r.Languages, lang) {
+			r.Languages = append(r.Languages, lang)
+		}
+	}
+	for _, name := range or.Addon.Names {
+		if !slices.Contains(r.Addon.Names, name) {
+			r.Addon.Names = append(r.Addon.Names, name)
+		}
+	}
+
+	if ws.URL == "" && ws.InfoHash != "" && hasFileIdx(ws) {
+		ws.FileIndex = r.File.Idx
+	}
+	if r.File.Name != "" {
+		if ws.BehaviorHints == nil {
+			ws.BehaviorHints = &stremio.StreamBehaviorHints{}
+		}
+		if ws.BehaviorHints.Filename == "" {
+			ws.BehaviorHints.Filename = r.File.Name
+		}
+	}
+}
+
+// mergeStreams unifies the duplicate streams across upstreams, keeping the
+// position of the first one. Streams without extracted data are kept as is.
+func mergeStreams(allStreams []WrappedStream) []WrappedStream {
+	streams := []WrappedStream{}
+	indicesByKey := map[string][]int{}
+	for i := range allStreams {
+		s := allStreams[i]
+		if s.r == nil {
+			streams = append(streams, s)
+			continue
+		}
+
+		key := ""
+		if s.r.Hash != "" {
+			key = "h:" + strings.ToLower(s.r.Hash)
+		} else if s.r.File.Name != "" {
+			key = "f:" + normalizeFilename(s.r.File.Name)
+		} else {
+			streams = append(streams, s)
+			continue
+		}
+
+		isMerged := false
+		for _, idx := range indicesByKey[key] {
+			if streams[idx].isDuplicateOf(s) {
+				streams[idx].merge(&s)
+				isMerged = true
+				break
+			}
+		}
+		if !isMerged {
+			indicesByKey[key] = append(indicesByKey[key], len(streams))
+			streams = append(streams, s)
+		}
+	}
+	return streams
+}
@@ -0,0 +1,103 @@
+package stremio_wrap
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/go-ptt"
+	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStream struct {
+	addon     string
+	url       string
+	hash      string
+	fileIdx   int
+	fileName  string
+	size      string
+	languages []string
+	isCached  bool
+}
+
+func (ts testStream) toWrappedStream() WrappedStream {
+	stream := &stremio.Stream{URL: ts.url}
+	if ts.url == "" {
+		stream.InfoHash = ts.hash
+		stream.FileIndex = ts.fileIdx
+	}
+	return WrappedStream{
+		Stream: stream,
+		r: &stremio_transformer.StreamExtractorResult{
+			Result: &ptt.Result{Languages: ts.languages},
+			Addon:  stremio_transformer.StreamExtractorResultAddon{Name: ts.addon, Names: []string{ts.addon}},
+			Hash:   ts.hash,
+			File: stremio_transformer.StreamExtractorResultFile{
+				Idx:  ts.fileIdx,
+				Name: ts.fileName,
+				Size: ts.size,
+			},
+			Store: stremio_transformer.StreamExtractorResultStore{IsCached: ts.isCached},
+		},
+	}
+}
+
+func TestMergeStreams(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		streams  []testStream
+		expected []testStream
+		addons   [][]string
+	}{
+		{
+			"hash with file",
+			[]testStream{
+				{addon: "A", hash: "abc"},
+				{addon: "B", hash: "ABC", fileIdx: 3, fileName: "Show.S01E02.mkv", size: "1 GB", languages: []string{"en"}},
+				{addon: "C", hash: "abc", fileIdx: 4, fileName: "Show.S01E03.mkv"},
+			},
+			[]testStream{
+				{addon: "A", hash: "abc", fileIdx: 3, fileName: "Show.S01E02.mkv", size: "1 GB", languages: []string{"en"}},
+				{addon: "C", hash: "abc", fileIdx: 4, fileName: "Show.S01E03.mkv"},
+			},
+			[][]string{{"A", "B"}, {"C"}},
+		},
+		{
+			"url with filename and size",
+			[]testStream{
+				{addon: "A", url: "http://a/1", fileName: "Movie.2020.1080p.mkv", size: "4.2 GB"},
+				{addon: "B", url: "http://b/1", fileName: "movie 2020 1080p.mkv", size: "4.21 GB", isCached: true},
+				{addon: "C", url: "http://c/1", fileName: "Movie.2020.1080p.mkv", size: "8 GB"},
+				{addon: "D", url: "http://d/1", fileName: "Movie.2020.1080p.mkv"},
+			},
+			[]testStream{
+				{addon: "B", url: "http://b/1", fileName: "Movie.2020.1080p.mkv", size: "4.2 GB", isCached: true},
+				{addon: "C", url: "http://c/1", fileName: "Movie.2020.1080p.mkv", size: "8 GB"},
+				{addon: "D", url: "http://d/1", fileName: "Movie.2020.1080p.mkv"},
+			},
+			[][]string{{"A", "B"}, {"C"}, {"D"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			streams := make([]WrappedStream, len(test.streams))
+			for i := range test.streams {
+				streams[i] = test.streams[i].toWrappedStream()
+			}
+			streams = mergeStreams(streams)
+			assert.Len(t, streams, len(test.expected))
+			for i, s := range streams {
+				expected := test.expected[i]
+				assert.Equal(t, expected.url, s.URL)
+				assert.Equal(t, expected.fileIdx, s.r.File.Idx)
+				assert.Equal(t, expected.fileName, s.r.File.Name)
+				assert.Equal(t, expected.size, s.r.File.Size)
+				assert.Equal(t, expected.languages, s.r.Languages)
+				assert.Equal(t, expected.isCached, s.r.Store.IsCached)
+				assert.Equal(t, test.addons[i], s.r.Addon.Names)
+				if s.URL == "" {
+					assert.Equal(t, expected.fileIdx, s.FileIndex)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package stremio_wrap
 
 import (
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/MunifTanjim/stremthru/internal/context"
 	"github.com/MunifTanjim/stremthru/internal/shared"
@@ -10,6 +13,19 @@ import (
 )
 
 func (ud UserData) fetchMeta(ctx *context.StoreContext, w http.ResponseWriter, r *http.Request, rType, id, extra string) error {
+	if ud.MergeMeta && len(ud.Upstreams) > 1 && !IsPublicInstance {
+		res, err := ud.fetchMergedMeta(ctx, rType, id)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			shared.ErrorNotFound(r).Send(w, r)
+			return nil
+		}
+		SendResponse(w, r, 200, res)
+		return nil
+	}
+
 	upstreams, err := ud.getUpstreams(ctx, stremio.ResourceNameMeta, rType, id)
 	if err != nil {
 		return err
@@ -32,3 +48,155 @@ func (ud UserData) fetchMeta(ctx *context.StoreContext, w http.ResponseWriter, r
 	})
 	return nil
 }
+
+type metaUpstream struct {
+	idx int
+	id  string
+}
+
+// getMetaUpstreams returns the upstreams supporting meta for the type, with
+// the id translated into the id schemes they support. The upstreams
+// supporting the id as is come first.
+func (ud UserData) getMetaUpstreams(ctx *context.StoreContext, rType, id string) ([]metaUpstream, error) {
+	resolver, err := ud.getUpstreamsResolver(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idPrefixesByIdx := map[int][]string{}
+	for _, entry := range resolver[string(stremio.ResourceNameMeta)+":"+rType] {
+		for _, idx := range entry.Indices {
+			idPrefixesByIdx[idx] = append(idPrefixesByIdx[idx], entry.Prefix)
+		}
+	}
+
+	var ids titleIds
+	direct, translated := []metaUpstream{}, []metaUpstream{}
+	for idx := range ud.Upstreams {
+		idPrefixes, ok := idPrefixesByIdx[idx]
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(idPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(id, prefix)
+		}) {
+			direct = append(direct, metaUpstream{idx: idx, id: id})
+			continue
+		}
+		if ids == nil {
+			if ids, err = getTitleIds(rType, id); err != nil {
+				log.Error("failed to get title ids", "error", err, "id", id)
+				ids = titleIds{}
+			}
+		}
+		if upId := ids.translate(idPrefixes); upId != "" {
+			translated = append(translated, metaUpstream{idx: idx, id: upId})
+		}
+	}
+	return append(direct, translated...), nil
+}
+
+// fetchMergedMeta fetches the meta from the upstreams, and fills the missing
+// fields of the first one using the others. It returns nil if not found.
+func (ud UserData) fetchMergedMeta(ctx *context.StoreContext, rType, id string) (*stremio.MetaHandlerResponse, error) {
+	upstreams, err := ud.getMetaUpstreams(ctx, rType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	metas := make([]*stremio.Meta, len(upstreams))
+	var wg sync.WaitGroup
+	for i := range upstreams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			up := &ud.Upstreams[upstreams[i].idx]
+			res, err := up.fetchMeta(rType, upstreams[i].id, ctx.ClientIP)
+			if err != nil {
+				ctx.Log.Error("failed to fetch meta", "error", err, "hostname", up.baseUrl.Hostname())
+				return
+			}
+			if res.Data.Meta.Id != "" {
+				metas[i] = &res.Data.Meta
+			}
+		}()
+	}
+	wg.Wait()
+
+	var meta *stremio.Meta
+	for _, m := range metas {
+		if m == nil {
+			continue
+		}
+		if meta == nil {
+			meta = m
+			meta.Id = id
+		} else {
+			mergeMeta(meta, m)
+		}
+	}
+	if meta == nil {
+		return nil, nil
+	}
+	return &stremio.MetaHandlerResponse{Meta: *meta}, nil
+}
+
+// mergeMeta fills the missing fields of the meta from the other one. Videos
+// are only taken if missing, as their ids are specific to the addon.
+func mergeMeta(meta, other *stremio.Meta) {
+	fillString := func(value *string, otherValue string) {
+		if *value == "" {
+			*value = otherValue
+		}
+	}
+	fillString(&meta.Name, other.Name)
+	fillString(&meta.Background, other.Background)
+	fillString(&meta.Logo, other.Logo)
+	fillString(&meta.Description, other.Description)
+	fillString(&meta.ReleaseInfo, other.ReleaseInfo)
+	fillString(&meta.IMDBRating, other.IMDBRating)
+	fillString(&meta.Runtime, other.Runtime)
+	fillString(&meta.Language, other.Language)
+	fillString(&meta.Country, other.Country)
+	fillString(&meta.Awards, other.Awards)
+	fillString(&meta.Website, other.Website)
+	if meta.Poster == "" {
+		meta.Poster = other.Poster
+		meta.PosterShape = other.PosterShape
+	}
+	if meta.Released == nil {
+		meta.Released = other.Released
+	}
+	if len(meta.Genres) == 0 {
+		meta.Genres = other.Genres
+	}
+	if len(meta.Director) == 0 {
+		meta.Director = other.Director
+	}
+	if len(meta.Cast) == 0 {
+		meta.Cast = other.Cast
+	}
+	for _, trailer := range other.Trailers {
+		if !slices.ContainsFunc(meta.Trailers, func(t stremio.MetaTrailer) bool {
+			return t.Source == trailer.Source
+		}) {
+			meta.Trailers = append(meta.Trailers, trailer)
+		}
+	}
+	for _, link := range other.Links {
+		if !slices.ContainsFunc(meta.Links, func(l stremio.MetaLink) bool {
+			return l.Category == link.Category && strings.EqualFold(l.Name, link.Name)
+		}) {
+			meta.Links = append(meta.Links, link)
+		}
+	}
+	if len(meta.TrailerStreams) == 0 {
+		meta.TrailerStreams = other.TrailerStreams
+	}
+	if len(meta.Videos) == 0 && len(other.Videos) > 0 {
+		meta.Videos = other.Videos
+		if meta.BehaviorHints == nil {
+			meta.BehaviorHints = other.BehaviorHints
+		}
+	}
+}
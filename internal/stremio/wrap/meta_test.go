@@ -0,0 +1,38 @@
+package stremio_wrap
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/stremio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeMeta(t *testing.T) {
+	meta := &stremio.Meta{
+		Id:       "tt1234567",
+		Name:     "Title",
+		Trailers: []stremio.MetaTrailer{{Source: "abc", Type: "Trailer"}},
+		Links:    []stremio.MetaLink{{Name: "Drama", Category: "Genres"}},
+	}
+	mergeMeta(meta, &stremio.Meta{
+		Id:          "kitsu:1",
+		Name:        "Other Title",
+		Poster:      "https://poster",
+		Description: "Description",
+		Trailers:    []stremio.MetaTrailer{{Source: "abc", Type: "Trailer"}, {Source: "def", Type: "Clip"}},
+		Links:       []stremio.MetaLink{{Name: "drama", Category: "Genres"}, {Name: "Action", Category: "Genres"}},
+		Videos:      []stremio.MetaVideo{{Id: "kitsu:1:1"}},
+	})
+	assert.Equal(t, &stremio.Meta{
+		Id:          "tt1234567",
+		Name:        "Title",
+		Poster:      "https://poster",
+		Description: "Description",
+		Trailers:    []stremio.MetaTrailer{{Source: "abc", Type: "Trailer"}, {Source: "def", Type: "Clip"}},
+		Links:       []stremio.MetaLink{{Name: "Drama", Category: "Genres"}, {Name: "Action", Category: "Genres"}},
+		Videos:      []stremio.MetaVideo{{Id: "kitsu:1:1"}},
+	}, meta)
+
+	mergeMeta(meta, &stremio.Meta{Videos: []stremio.MetaVideo{{Id: "tt1234567:1:1"}}})
+	assert.Equal(t, []stremio.MetaVideo{{Id: "kitsu:1:1"}}, meta.Videos)
+}
@@ -92,7 +92,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			return &stremResult{
 				error_log:   "failed to add magnet",
-				error_video: "download_failed",
+				error_video: store_video.GetNameForError(err, store_video.StoreVideoNameDownloadFailed),
 			}, err
 		}
 
@@ -109,7 +109,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			strem := &stremResult{
 				error_log:   "failed wait for magnet status",
-				error_video: "500",
+				error_video: store_video.GetNameForError(err, store_video.StoreVideoName500),
 			}
 			if magnet.Status == store.MagnetStatusQueued || magnet.Status == store.MagnetStatusDownloading || magnet.Status == store.MagnetStatusProcessing {
 				strem.error_video = "downloading"
@@ -190,7 +190,7 @@ func handleStrem(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			return &stremResult{
 				error_log:   "failed to generate stremthru link",
-				error_video: "500",
+				error_video: store_video.GetNameForError(err, store_video.StoreVideoName500),
 			}, err
 		}
 
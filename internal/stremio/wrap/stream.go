@@ -14,7 +14,6 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/context"
 	"github.com/MunifTanjim/stremthru/internal/shared"
-	stremio_addon "github.com/MunifTanjim/stremthru/internal/stremio/addon"
 	stremio_transformer "github.com/MunifTanjim/stremthru/internal/stremio/transformer"
 	"github.com/MunifTanjim/stremthru/internal/torrent_info"
 	"github.com/MunifTanjim/stremthru/internal/worker"
@@ -57,18 +56,13 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 		go func() {
 			defer wg.Done()
 			up := &upstreams[i]
-			res, err := addon.FetchStream(&stremio_addon.FetchStreamParams{
-				BaseURL:  up.baseUrl,
-				Type:     rType,
-				Id:       id,
-				ClientIP: ctx.ClientIP,
-			})
+			res, err := up.fetchStream(rType, id, ctx.ClientIP)
 			streams := res.Data.Streams
 			wstreams := make([]WrappedStream, len(streams))
 			errs[i] = err
 			tInfos := []torrent_info.TorrentInfoInsertData{}
 			if err == nil {
-				extractor, err := up.extractor.Parse()
+				extractor, err := up.getExtractor(ctx, streams)
 				if err != nil {
 					errs[i] = err
 				} else {
@@ -76,6 +70,7 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 					transformer := StreamTransformer{
 						Extractor: extractor,
 						Template:  template,
+						AddonName: addonHostname,
 					}
 					for i := range streams {
 						stream := streams[i]
@@ -110,7 +105,11 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 	for i := range chunks {
 		if errs[i] != nil {
 			hostname := upstreams[i].baseUrl.Hostname()
-			log.Error("failed to fetch streams", "error", errs[i], "hostname", hostname)
+			if errors.Is(errs[i], errUpstreamSkipped) {
+				log.Debug("skipped upstream", "hostname", hostname)
+			} else {
+				log.Error("failed to fetch streams", "error", errs[i], "hostname", hostname)
+			}
 		} else {
 			allStreams = append(allStreams, chunks[i]...)
 		}
@@ -121,9 +120,15 @@ func (ud UserData) fetchStream(ctx *context.StoreContext, r *http.Request, rType
 	}
 
 	totalStreams := len(allStreams)
-	allStreams = dedupeStreams(allStreams)
+	allStreams = mergeStreams(allStreams)
 	log.Debug("found streams", "total_count", totalStreams, "deduped_count", len(allStreams))
 
+	for i := range allStreams {
+		if err := allStreams[i].render(template); err != nil {
+			LogError(r, "failed to transform stream", err)
+		}
+	}
+
 	hashes := []string{}
 	magnetByHash := map[string]core.MagnetLink{}
 	for i := range allStreams {
@@ -31,6 +31,13 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 				Title:   "Only Show Cached Content",
 				Options: []configure.ConfigOption{},
 			},
+			{
+				Key:         "merge_catalogs",
+				Type:        configure.ConfigTypeCheckbox,
+				Title:       "Merge Catalogs",
+				Description: "Add catalogs combining the catalogs of same type across addons",
+				Options:     []configure.ConfigOption{},
+			},
 		},
 		Script: configure.GetScriptStoreTokenDescription("", ""),
 
@@ -55,8 +62,18 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 		TemplateIds:  []string{},
 	}
 
+	if !IsPublicInstance {
+		td.Configs = append(td.Configs, configure.Config{
+			Key:         "merge_meta",
+			Type:        configure.ConfigTypeCheckbox,
+			Title:       "Merge Meta",
+			Description: "Fill missing meta fields using other addons, translating the ids between IMDb, TMDB, TVDB and anime ids",
+			Options:     []configure.ConfigOption{},
+		})
+	}
+
 	if cookie, err := stremio_shared.GetAdminCookieValue(w, r); err == nil && !cookie.IsExpired {
-		td.IsAuthed = config.ProxyAuthPassword.GetPassword(cookie.User()) == cookie.Pass()
+		td.IsAuthed = config.ProxyAuthPassword.VerifyPassword(cookie.User(), cookie.Pass())
 	}
 
 	for i := range ud.Stores {
@@ -77,7 +94,7 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 	td.Template = ud.template
 	if !isExecutingAction {
 		if td.TemplateId != "" {
-			if storedBlob, err := getTemplate(td.TemplateId); err == nil {
+			if storedBlob, err := stremio_transformer.GetTemplate(td.TemplateId); err == nil {
 				if !storedBlob.IsEmpty() {
 					if storedBlob.Name != td.Template.Name {
 						td.TemplateError.Name = "Template is not updated"
@@ -108,6 +125,10 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 	hasExtractor := false
 
 	for _, up := range ud.Upstreams {
+		health := UpstreamHealth{}
+		if up.baseUrl != nil {
+			health = getUpstreamHealth(up.baseUrl.String())
+		}
 		extractorError := ""
 		if !isExecutingAction {
 			if up.ExtractorId != "" {
@@ -141,6 +162,8 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 			ExtractorError:   extractorError,
 			NoContentProxy:   up.NoContentProxy,
 			ReconfigureStore: up.ReconfigureStore,
+			Timeout:          up.Timeout,
+			Health:           health,
 		})
 	}
 
@@ -161,7 +184,7 @@ func getTemplateData(ud *UserData, w http.ResponseWriter, r *http.Request) *Temp
 		td.ExtractorIds = extractorIds
 	}
 
-	if templateIds, err := getTemplateIds(); err != nil {
+	if templateIds, err := stremio_transformer.GetTemplateIds(); err != nil {
 		LogError(r, "failed to list templates", err)
 	} else {
 		td.TemplateIds = templateIds
@@ -196,8 +219,11 @@ type UpstreamAddon struct {
 	ExtractorId      string
 	Extractor        stremio_transformer.StreamExtractorBlob
 	ExtractorError   string
+	ExtractorHint    string
 	NoContentProxy   bool
 	ReconfigureStore bool
+	Timeout          int
+	Health           UpstreamHealth
 }
 
 type StoreConfig struct {
@@ -305,6 +331,7 @@ var executeTemplate = func() stremio_template.Executor[TemplateData] {
 			for i := range td.Upstreams {
 				up := &td.Upstreams[i]
 				up.URL = upstreamUrlPattern.ReplaceAllString(up.URL, "${1}://${2}/"+redacted+"/${3}")
+				up.Health.LastError = ""
 			}
 			for i := range td.Stores {
 				s := &td.Stores[i]
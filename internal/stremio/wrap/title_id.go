@@ -0,0 +1,112 @@
+package stremio_wrap
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/anime"
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+// titleIds are the ids of a title by id prefix, e.g. `tt`, `kitsu:`.
+type titleIds map[string]string
+
+var titleIdsCache = cache.NewCache[titleIds](&cache.CacheConfig{
+	Lifetime: 6 * time.Hour,
+	Name:     "stremio:wrap:title_ids",
+})
+
+func getIdPrefix(id string) string {
+	if strings.HasPrefix(id, "tt") {
+		return "tt"
+	}
+	if prefix, _, ok := strings.Cut(id, ":"); ok {
+		return prefix + ":"
+	}
+	return ""
+}
+
+// getTitleIds translates the id of a title (without season or episode) into
+// the other id schemes, using the IMDb title map and the anime id map.
+func getTitleIds(rType, id string) (titleIds, error) {
+	cacheKey := rType + ":" + id
+	ids := titleIds{}
+	if titleIdsCache.Get(cacheKey, &ids) {
+		return ids, nil
+	}
+
+	ids = titleIds{}
+	if prefix := getIdPrefix(id); prefix != "" {
+		ids[prefix] = id
+	}
+
+	imdbId, err := imdb_title.ResolveStremId(stremio.ContentType(rType), id)
+	if err != nil {
+		return nil, err
+	}
+	if imdbId != "" {
+		ids["tt"] = imdbId
+		m, err := imdb_title.GetMapByIMDBId(imdbId)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			setTitleId(ids, "tmdb:", m.TMDBId)
+			setTitleId(ids, "tvdb:", m.TVDBId)
+			setTitleId(ids, "mal:", m.MALId)
+		}
+	}
+
+	var idMap *anime.AnimeIdMap
+	if column, animeId, _, ok := anime.ParseStremId(id); ok {
+		idMap, err = anime.GetIdMap(column, animeId)
+	} else if imdbId != "" {
+		idMap, err = anime.GetIdMap(anime.IdMapColumn.IMDB, imdbId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if idMap != nil {
+		setTitleId(ids, "anidb:", idMap.AniDB)
+		setTitleId(ids, "anilist:", idMap.AniList)
+		setTitleId(ids, "kitsu:", idMap.Kitsu)
+		setTitleId(ids, "mal:", idMap.MAL)
+		setTitleId(ids, "tmdb:", idMap.TMDB)
+		setTitleId(ids, "tvdb:", idMap.TVDB)
+	}
+
+	if err := titleIdsCache.Add(cacheKey, ids); err != nil {
+		log.Warn("failed to cache title ids", "error", err, "id", id)
+	}
+	return ids, nil
+}
+
+func setTitleId(ids titleIds, prefix, id string) {
+	if _, ok := ids[prefix]; !ok && id != "" {
+		ids[prefix] = prefix + id
+	}
+}
+
+// translate returns the id in the first supported id prefix, or empty
+// string if there is none.
+func (ids titleIds) translate(idPrefixes []string) string {
+	for _, prefix := range idPrefixes {
+		for _, id := range ids {
+			if strings.HasPrefix(id, prefix) {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// getKey returns the IMDb id if available, to identify the same title across
+// id schemes.
+func (ids titleIds) getKey(id string) string {
+	if imdbId := ids["tt"]; imdbId != "" {
+		return imdbId
+	}
+	return id
+}
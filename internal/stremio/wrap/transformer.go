@@ -13,6 +13,8 @@ import (
 type StreamTransformer struct {
 	Extractor stremio_transformer.StreamExtractor
 	Template  *stremio_transformer.StreamTemplate
+	// used if the extractor does not find the addon name
+	AddonName string
 }
 
 type WrappedStream struct {
@@ -68,19 +70,31 @@ func (st StreamTransformer) Do(stream *stremio.Stream, sType string, tryReconfig
 		}
 	}
 
+	if data.Addon.Name != "" {
+		data.Addon.Names = []string{data.Addon.Name}
+	} else if st.AddonName != "" {
+		data.Addon.Names = []string{st.AddonName}
+	}
+
 	s.r = data
 
-	var err error
-	s.Stream, err = st.Template.Execute(s.Stream, data)
-	if err != nil {
-		return s, err
+	return s, nil
+}
+
+// render executes the template for the stream. It is done after the
+// duplicate streams from other upstreams are merged into it.
+func (ws *WrappedStream) render(template *stremio_transformer.StreamTemplate) error {
+	if ws.r == nil || template == nil || template.IsEmpty() {
+		return nil
 	}
 
-	return s, nil
+	var err error
+	ws.Stream, err = template.Execute(ws.Stream, ws.r)
+	return err
 }
 
-const BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI = "✨"
-const BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX = BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI + " "
+const BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI = stremio_transformer.BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI
+const BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX = stremio_transformer.BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX
 
 var newTransformerExtractorIdMap = map[string]string{
 	"Debridio":    BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + "Debridio",
@@ -130,74 +144,57 @@ func getExtractor(extractorId string) (stremio_transformer.StreamExtractorBlob,
 	return extractor, nil
 }
 
-func getExtractorIds() ([]string, error) {
-	extractors, err := extractorStore.List()
-	if err != nil {
-		return nil, err
-	}
-	builtInExtractorsCount := len(builtInExtractors)
-	extractorIds := make([]string, builtInExtractorsCount+len(extractors))
-	idx := 0
-	for id := range builtInExtractors {
-		extractorIds[idx] = id
-		idx++
-	}
-	for _, extractor := range extractors {
-		extractorIds[idx] = extractor.Key
-		idx++
-	}
-	return extractorIds, nil
-
-}
-
-var builtInTemplates = func() map[string]stremio_transformer.StreamTemplateBlob {
-	templates := map[string]stremio_transformer.StreamTemplateBlob{}
-
-	templates[BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX+"Default"] = stremio_transformer.StreamTemplateDefault.Blob
-	templates[BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX+"Raw"] = stremio_transformer.StreamTemplateRaw.Blob
-
-	return templates
-}()
-
-var templateStore = kv.NewKVStore[stremio_transformer.StreamTemplateBlob](&kv.KVStoreConfig{
-	Type: "st:wrap:transformer:template",
+var extractorFixtureStore = kv.NewKVStore[[]stremio_transformer.StreamExtractorFixture](&kv.KVStoreConfig{
+	Type: "st:wrap:transformer:extractor_fixture",
 	GetKey: func(key string) string {
 		return key
 	},
 })
 
-func getTemplate(templateId string) (stremio_transformer.StreamTemplateBlob, error) {
-	if strings.HasPrefix(templateId, BUILTIN_TRANSFORMER_ENTITY_ID_EMOJI) {
-		if template, ok := builtInTemplates[templateId]; ok {
-			return template, nil
-		}
-		return stremio_transformer.StreamTemplateBlob{}, errors.New("built-in template not found")
+func getExtractorFixtures(extractorId string) ([]stremio_transformer.StreamExtractorFixture, error) {
+	fixtures := []stremio_transformer.StreamExtractorFixture{}
+	if err := extractorFixtureStore.Get(extractorId, &fixtures); err != nil {
+		return nil, err
 	}
+	return fixtures, nil
+}
 
-	var template stremio_transformer.StreamTemplateBlob
-	if err := templateStore.Get(templateId, &template); err != nil {
-		return stremio_transformer.StreamTemplateBlob{}, err
+// testExtractor runs the fixtures saved for the extractor, and returns an
+// error describing the failed ones.
+func testExtractor(extractorId string, extractor stremio_transformer.StreamExtractor) error {
+	fixtures, err := getExtractorFixtures(extractorId)
+	if err != nil {
+		return err
 	}
-	return template, nil
+	_, failed := extractor.Test(fixtures)
+	if len(failed) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(failed))
+	for i := range failed {
+		msgs[i] = failed[i].String()
+	}
+	return errors.New("fixtures failed: " + strings.Join(msgs, "; "))
 }
 
-func getTemplateIds() ([]string, error) {
-	templates, err := templateStore.List()
+func getExtractorIds() ([]string, error) {
+	extractors, err := extractorStore.List()
 	if err != nil {
 		return nil, err
 	}
-	builtInTemplatesCount := len(builtInTemplates)
-	templateIds := make([]string, builtInTemplatesCount+len(templates))
+	builtInExtractorsCount := len(builtInExtractors)
+	extractorIds := make([]string, builtInExtractorsCount+len(extractors))
 	idx := 0
-	for id := range builtInTemplates {
-		templateIds[idx] = id
+	for id := range builtInExtractors {
+		extractorIds[idx] = id
 		idx++
 	}
-	for _, template := range templates {
-		templateIds[idx] = template.Key
+	for _, extractor := range extractors {
+		extractorIds[idx] = extractor.Key
 		idx++
 	}
-	return templateIds, nil
+	return extractorIds, nil
+
 }
 
 func seedDefaultTransformerEntities() {
@@ -214,15 +211,5 @@ func seedDefaultTransformerEntities() {
 		}
 	}
 
-	for key := range builtInTemplates {
-		if err := templateStore.Del(key); err != nil {
-			log.Warn("Failed to cleanup seed template: " + key)
-		}
-		if config.IsPublicInstance {
-			key = strings.TrimPrefix(key, BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX)
-			if err := templateStore.Del(key); err != nil {
-				log.Warn("Failed to cleanup seed template: " + key)
-			}
-		}
-	}
+	stremio_transformer.CleanupSeedTemplates(config.IsPublicInstance)
 }
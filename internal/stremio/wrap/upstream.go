@@ -0,0 +1,191 @@
+package stremio_wrap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/request"
+	stremio_addon "github.com/MunifTanjim/stremthru/internal/stremio/addon"
+	"github.com/MunifTanjim/stremthru/stremio"
+)
+
+const defaultUpstreamTimeout = 10 * time.Second
+const minUpstreamTimeout = 1 * time.Second
+const maxUpstreamTimeout = 30 * time.Second
+
+// consecutive failures after which the upstream is skipped
+const upstreamBreakerThreshold = 5
+const upstreamBreakerCooldown = 1 * time.Minute
+const upstreamBreakerMaxCooldown = 10 * time.Minute
+
+var errUpstreamSkipped = errors.New("upstream skipped after repeated failures")
+
+var now = time.Now
+
+func (up UserDataUpstream) getTimeout() time.Duration {
+	if up.Timeout <= 0 {
+		return defaultUpstreamTimeout
+	}
+	return min(max(time.Duration(up.Timeout)*time.Millisecond, minUpstreamTimeout), maxUpstreamTimeout)
+}
+
+type UpstreamHealth struct {
+	Requests     int
+	Failures     int
+	Timeouts     int
+	Skipped      int
+	TotalLatency time.Duration
+	LastError    string
+	LastErrorAt  time.Time
+	SkipUntil    time.Time
+
+	consecutiveFailures int
+	cooldown            time.Duration
+}
+
+func (h UpstreamHealth) AvgLatency() time.Duration {
+	if h.Requests == 0 {
+		return 0
+	}
+	return (h.TotalLatency / time.Duration(h.Requests)).Round(time.Millisecond)
+}
+
+func (h UpstreamHealth) IsSkipped() bool {
+	return now().Before(h.SkipUntil)
+}
+
+var upstreamHealthMutex sync.Mutex
+
+// stats are kept in memory per base url, and reset after inactivity
+var upstreamHealthCache = cache.NewLRUCache[UpstreamHealth](&cache.CacheConfig{
+	Lifetime: 6 * time.Hour,
+	Name:     "stremio:wrap:upstream_health",
+})
+
+func getUpstreamHealth(baseUrl string) UpstreamHealth {
+	upstreamHealthMutex.Lock()
+	defer upstreamHealthMutex.Unlock()
+
+	health := UpstreamHealth{}
+	upstreamHealthCache.Get(baseUrl, &health)
+	return health
+}
+
+// acquireUpstream checks if the upstream is allowed to be requested. After
+// the cooldown, a single request is allowed to probe the upstream, while the
+// others are still skipped.
+func acquireUpstream(baseUrl string) bool {
+	upstreamHealthMutex.Lock()
+	defer upstreamHealthMutex.Unlock()
+
+	health := UpstreamHealth{}
+	upstreamHealthCache.Get(baseUrl, &health)
+	if health.IsSkipped() {
+		health.Skipped++
+		upstreamHealthCache.Add(baseUrl, health)
+		return false
+	}
+	if health.consecutiveFailures >= upstreamBreakerThreshold {
+		health.SkipUntil = now().Add(health.cooldown)
+		upstreamHealthCache.Add(baseUrl, health)
+	}
+	return true
+}
+
+func recordUpstreamResult(baseUrl string, latency time.Duration, err error) {
+	upstreamHealthMutex.Lock()
+	defer upstreamHealthMutex.Unlock()
+
+	health := UpstreamHealth{}
+	upstreamHealthCache.Get(baseUrl, &health)
+	health.Requests++
+	health.TotalLatency += latency
+	if err == nil {
+		health.consecutiveFailures = 0
+		health.cooldown = 0
+		health.SkipUntil = time.Time{}
+	} else {
+		health.Failures++
+		if errors.Is(err, context.DeadlineExceeded) {
+			health.Timeouts++
+		}
+		health.LastError = err.Error()
+		health.LastErrorAt = now()
+		health.consecutiveFailures++
+		if health.consecutiveFailures >= upstreamBreakerThreshold {
+			if health.cooldown == 0 {
+				health.cooldown = upstreamBreakerCooldown
+			} else {
+				health.cooldown = min(2*health.cooldown, upstreamBreakerMaxCooldown)
+			}
+			health.SkipUntil = now().Add(health.cooldown)
+		}
+	}
+	upstreamHealthCache.Add(baseUrl, health)
+}
+
+// do runs the request to the upstream, giving up after its timeout, so that
+// the responses from the other upstreams are not delayed.
+func (up UserDataUpstream) do(fn func(ctx context.Context) error) error {
+	baseUrl := up.baseUrl.String()
+	if !acquireUpstream(baseUrl) {
+		return errUpstreamSkipped
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), up.getTimeout())
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	recordUpstreamResult(baseUrl, time.Since(start), err)
+	return err
+}
+
+func (up UserDataUpstream) fetchStream(rType, id, clientIp string) (res request.APIResponse[stremio.StreamHandlerResponse], err error) {
+	err = up.do(func(ctx context.Context) error {
+		res, err = addon.FetchStream(&stremio_addon.FetchStreamParams{
+			Ctx:      request.Ctx{Context: ctx},
+			BaseURL:  up.baseUrl,
+			Type:     rType,
+			Id:       id,
+			ClientIP: clientIp,
+		})
+		return err
+	})
+	return res, err
+}
+
+func (up UserDataUpstream) fetchMeta(rType, id, clientIp string) (res request.APIResponse[stremio.MetaHandlerResponse], err error) {
+	err = up.do(func(ctx context.Context) error {
+		res, err = addon.FetchMeta(&stremio_addon.FetchMetaParams{
+			Ctx:      request.Ctx{Context: ctx},
+			BaseURL:  up.baseUrl,
+			Type:     rType,
+			Id:       id,
+			ClientIP: clientIp,
+		})
+		return err
+	})
+	return res, err
+}
+
+func (up UserDataUpstream) fetchCatalog(rType, id, extra, clientIp string) (res request.APIResponse[stremio.CatalogHandlerResponse], err error) {
+	err = up.do(func(ctx context.Context) error {
+		res, err = addon.FetchCatalog(&stremio_addon.FetchCatalogParams{
+			Ctx:      request.Ctx{Context: ctx},
+			BaseURL:  up.baseUrl,
+			Type:     rType,
+			Id:       id,
+			Extra:    extra,
+			ClientIP: clientIp,
+		})
+		return err
+	})
+	return res, err
+}
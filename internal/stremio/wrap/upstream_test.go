@@ -0,0 +1,90 @@
+package stremio_wrap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpstreamCircuitBreaker(t *testing.T) {
+	type step struct {
+		advance  time.Duration
+		fail     int
+		succeed  bool
+		acquired []bool
+		cooldown time.Duration
+	}
+
+	for _, tc := range []struct {
+		name  string
+		steps []step
+	}{
+		{"closed", []step{
+			{fail: upstreamBreakerThreshold - 1},
+			{acquired: []bool{true, true}},
+		}},
+		{"open", []step{
+			{fail: upstreamBreakerThreshold, cooldown: time.Minute},
+			{acquired: []bool{false, false}},
+			{advance: 59 * time.Second, acquired: []bool{false}},
+		}},
+		{"half-open probe", []step{
+			{fail: upstreamBreakerThreshold},
+			{advance: time.Minute, acquired: []bool{true, false}},
+		}},
+		{"half-open success", []step{
+			{fail: upstreamBreakerThreshold},
+			{advance: time.Minute, acquired: []bool{true}},
+			{succeed: true},
+			{acquired: []bool{true, true}},
+			{fail: upstreamBreakerThreshold - 1},
+			{acquired: []bool{true}},
+		}},
+		{"doubling cooldown", []step{
+			{fail: upstreamBreakerThreshold, cooldown: 1 * time.Minute},
+			{advance: 1 * time.Minute, acquired: []bool{true}, fail: 1, cooldown: 2 * time.Minute},
+			{advance: 1 * time.Minute, acquired: []bool{false}},
+			{advance: 1 * time.Minute, acquired: []bool{true}, fail: 1, cooldown: 4 * time.Minute},
+			{advance: 4 * time.Minute, acquired: []bool{true}, fail: 1, cooldown: 8 * time.Minute},
+			{advance: 8 * time.Minute, acquired: []bool{true}, fail: 1, cooldown: upstreamBreakerMaxCooldown},
+			{advance: upstreamBreakerMaxCooldown, acquired: []bool{true}, fail: 1, cooldown: upstreamBreakerMaxCooldown},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			at := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+			now = func() time.Time { return at }
+			t.Cleanup(func() { now = time.Now })
+
+			baseUrl := "https://upstream.example.com/" + tc.name
+			skipped := 0
+			for i, s := range tc.steps {
+				at = at.Add(s.advance)
+				for j, expected := range s.acquired {
+					acquired := acquireUpstream(baseUrl)
+					assert.Equal(t, expected, acquired, "step %d, acquire %d", i, j)
+					if !acquired {
+						skipped++
+					}
+				}
+				for range s.fail {
+					recordUpstreamResult(baseUrl, time.Millisecond, errors.New("failed"))
+				}
+				if s.succeed {
+					recordUpstreamResult(baseUrl, time.Millisecond, nil)
+				}
+				health := getUpstreamHealth(baseUrl)
+				if s.cooldown != 0 {
+					assert.Equal(t, s.cooldown, health.cooldown, "step %d", i)
+					assert.Equal(t, at.Add(s.cooldown), health.SkipUntil, "step %d", i)
+				}
+				if s.succeed {
+					assert.Zero(t, health.cooldown, "step %d", i)
+					assert.False(t, health.IsSkipped(), "step %d", i)
+				}
+			}
+			assert.Equal(t, skipped, getUpstreamHealth(baseUrl).Skipped)
+		})
+	}
+}
@@ -58,6 +58,7 @@ type UserDataUpstream struct {
 	extractor        stremio_transformer.StreamExtractorBlob `json:"-"`
 	NoContentProxy   bool                                    `json:"ncp,omitempty"`
 	ReconfigureStore bool                                    `json:"rs,omitempty"`
+	Timeout          int                                     `json:"tmo,omitempty"` // in milliseconds
 }
 
 type UserData struct {
@@ -70,6 +71,9 @@ type UserData struct {
 
 	CachedOnly bool `json:"cached,omitempty"`
 
+	MergeMeta     bool `json:"merge_meta,omitempty"`
+	MergeCatalogs bool `json:"merge_catalogs,omitempty"`
+
 	TemplateId string                                 `json:"template,omitempty"`
 	template   stremio_transformer.StreamTemplateBlob `json:"-"`
 
@@ -389,7 +393,7 @@ func getUserData(r *http.Request) (*UserData, error) {
 				data.TemplateId = BUILTIN_TRANSFORMER_ENTITY_ID_PREFIX + data.TemplateId
 			}
 
-			if template, err := getTemplate(data.TemplateId); err != nil {
+			if template, err := stremio_transformer.GetTemplate(data.TemplateId); err != nil {
 				LogError(r, fmt.Sprintf("failed to fetch template(%s)", data.TemplateId), err)
 			} else {
 				data.template = template
@@ -437,6 +441,11 @@ func getUserData(r *http.Request) (*UserData, error) {
 			if upURL != "" || extractorId != "" || extractor != "" {
 				up.NoContentProxy = r.Form.Get("upstreams["+strconv.Itoa(idx)+"].no_content_proxy") == "on"
 				up.ReconfigureStore = r.Form.Get("upstreams["+strconv.Itoa(idx)+"].reconfigure_store") == "on"
+				if timeout := r.Form.Get("upstreams[" + strconv.Itoa(idx) + "].timeout"); timeout != "" {
+					if up.Timeout, err = strconv.Atoi(timeout); err != nil {
+						return nil, err
+					}
+				}
 				data.Upstreams = append(data.Upstreams, up)
 			}
 		}
@@ -469,6 +478,8 @@ func getUserData(r *http.Request) (*UserData, error) {
 		}
 
 		data.CachedOnly = r.Form.Get("cached") == "on"
+		data.MergeMeta = r.Form.Get("merge_meta") == "on"
+		data.MergeCatalogs = r.Form.Get("merge_catalogs") == "on"
 
 		isStoreStremThru := false
 		for i := range data.Stores {
@@ -148,6 +148,11 @@ func AddStremioWrapEndpoints(mux *http.ServeMux) {
 	router.HandleFunc("/configure", handleConfigure)
 	router.HandleFunc("/{userData}/configure", handleConfigure)
 
+	router.HandleFunc("/extractor", handleExtractorConsole)
+	router.HandleFunc("/extractor/definition", handleExtractorDefinition)
+	router.HandleFunc("/extractor/test", handleExtractorTest)
+	router.HandleFunc("/extractor/save", handleExtractorSave)
+
 	router.HandleFunc("/{userData}/{resource}/{contentType}/{id}", withCors(handleResource))
 	router.HandleFunc("/{userData}/{resource}/{contentType}/{id}/{extra}", withCors(handleResource))
 
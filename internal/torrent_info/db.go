@@ -15,6 +15,7 @@ import (
 	"github.com/MunifTanjim/stremthru/internal/anidb"
 	"github.com/MunifTanjim/stremthru/internal/anime"
 	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
 	"github.com/MunifTanjim/stremthru/internal/imdb_torrent"
 	ts "github.com/MunifTanjim/stremthru/internal/torrent_stream"
 	"github.com/MunifTanjim/stremthru/internal/util"
@@ -80,6 +81,7 @@ const (
 	TorrentInfoSourceDMM         TorrentInfoSource = "dmm"
 	TorrentInfoSourceMediaFusion TorrentInfoSource = "mfn"
 	TorrentInfoSourceTorrentio   TorrentInfoSource = "tio"
+	TorrentInfoSourceTorznab     TorrentInfoSource = "tzn"
 	TorrentInfoSourceAllDebrid   TorrentInfoSource = "ad"
 	TorrentInfoSourceDebridLink  TorrentInfoSource = "dl"
 	TorrentInfoSourceEasyDebrid  TorrentInfoSource = "ed"
@@ -1039,14 +1041,20 @@ var query_list_hashes_by_stremid_from_imdb_torrent_for_series = fmt.Sprintf(
 
 func ListHashesByStremId(stremId string) ([]string, error) {
 	if !strings.HasPrefix(stremId, "tt") {
-		if strings.HasPrefix(stremId, "kitsu:") {
-			kitsuId, episode, _ := strings.Cut(strings.TrimPrefix(stremId, "kitsu:"), ":")
-			anidbId, season, err := anime.GetAniDBIdByKitsuId(kitsuId)
+		if column, animeId, episode, ok := anime.ParseStremId(stremId); ok {
+			anidbId, season, err := anime.GetAniDBIdById(column, animeId)
 			if err != nil || anidbId == "" {
 				return nil, err
 			}
 			return listHashesForAnimeByAniDBId(anidbId, season, episode)
 		}
+		if strings.HasPrefix(stremId, "tmdb:") || strings.HasPrefix(stremId, "tvdb:") {
+			imdbStremId, err := imdb_title.ResolveStremId("", stremId)
+			if err != nil || imdbStremId == "" {
+				return []string{}, err
+			}
+			return ListHashesByStremId(imdbStremId)
+		}
 		return nil, fmt.Errorf("unsupported strem id: %s", stremId)
 	}
 
@@ -1092,6 +1100,23 @@ func ListHashesByStremId(stremId string) ([]string, error) {
 		return nil, err
 	}
 
+	if parts := strings.SplitN(stremId, ":", 3); len(parts) == 3 {
+		season, episode := util.SafeParseInt(parts[1], -1), util.SafeParseInt(parts[2], -1)
+		if e, err := imdb_title.GetAniDBEpisode(parts[0], season, episode); err != nil {
+			log.Error("failed to get anidb episode", "error", err, "stremId", stremId)
+		} else if e != nil {
+			animeHashes, err := listHashesForAnimeByAniDBId(e.AniDBId, e.Season, strconv.Itoa(e.Episode))
+			if err != nil {
+				return nil, err
+			}
+			for _, hash := range animeHashes {
+				if !slices.Contains(hashes, hash) {
+					hashes = append(hashes, hash)
+				}
+			}
+		}
+	}
+
 	return hashes, nil
 }
 
@@ -1478,6 +1503,61 @@ func SetMissingCategory(hashesByCategory map[TorrentInfoCategory][]string) {
 	wg.Wait()
 }
 
+var query_get_hashes_with_missing_files = fmt.Sprintf(
+	"SELECT ti.%s FROM %s ti WHERE ti.%s > ? AND (ti.%s = -1 OR EXISTS (SELECT 1 FROM %s ts WHERE ts.%s = ti.%s AND (ts.%s = -1 OR ts.%s = -1))) ORDER BY ti.%s LIMIT ?",
+	Column.Hash,
+	TableName,
+	Column.Hash,
+	Column.Size,
+	ts.TableName,
+	ts.Column.Hash,
+	Column.Hash,
+	ts.Column.Idx,
+	ts.Column.Size,
+	Column.Hash,
+)
+
+// GetHashesWithMissingFiles returns the hashes after afterHash, having
+// missing size or files with missing index or size.
+func GetHashesWithMissingFiles(afterHash string, limit int) ([]string, error) {
+	hashes := []string{}
+	limit = max(1, min(limit, 20000))
+
+	rows, err := db.Query(query_get_hashes_with_missing_files, afterHash, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+var query_set_missing_size = fmt.Sprintf(
+	"UPDATE %s SET %s = ?, %s = %s WHERE %s = ? AND %s = -1",
+	TableName,
+	Column.Size,
+	Column.UpdatedAt,
+	db.CurrentTimestamp,
+	Column.Hash,
+	Column.Size,
+)
+
+func SetMissingSize(hash string, size int64) error {
+	_, err := db.Exec(query_set_missing_size, size, hash)
+	return err
+}
+
 var query_get_basic_info_by_hash = fmt.Sprintf(
 	"SELECT %s, %s, %s FROM %s WHERE %s IN ",
 	Column.Hash,
@@ -0,0 +1,31 @@
+package torrent_meta
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readFromCacheDir reads `<hash>.torrent` from the cache directory. It
+// returns nil if the file is missing.
+func readFromCacheDir(dir string, hash string) ([]byte, error) {
+	for _, name := range []string{strings.ToLower(hash), strings.ToUpper(hash)} {
+		torrent, err := os.ReadFile(filepath.Join(dir, name+".torrent"))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		raw, err := ExtractRawInfo(torrent)
+		if err != nil {
+			return nil, err
+		}
+		if !verifyInfoHash(raw, hash) {
+			return nil, errors.New("info hash mismatch for " + name + ".torrent")
+		}
+		return raw, nil
+	}
+	return nil, nil
+}
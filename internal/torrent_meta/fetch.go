@@ -0,0 +1,40 @@
+package torrent_meta
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
+
+// Fetch resolves the info hash to the info dictionary, using the cache
+// directory first and then the peer. It returns nil if none of them has it.
+func Fetch(hash string) (*Info, error) {
+	hash = strings.ToLower(hash)
+
+	var errs []error
+
+	if dir := config.TorrentMeta.CacheDir; dir != "" {
+		raw, err := readFromCacheDir(dir, hash)
+		if err != nil {
+			log.Warn("failed to read from cache dir", "error", err, "hash", hash)
+			errs = append(errs, err)
+		} else if raw != nil {
+			return ParseInfo(raw)
+		}
+	}
+
+	if addr := config.TorrentMeta.PeerAddr; addr != "" {
+		raw, err := FetchFromPeer(addr, hash, config.TorrentMeta.PeerTimeout)
+		if err != nil {
+			if !errors.Is(err, ErrMetadataRejected) {
+				log.Debug("failed to fetch from peer", "error", err, "hash", hash)
+				errs = append(errs, err)
+			}
+		} else {
+			return ParseInfo(raw)
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
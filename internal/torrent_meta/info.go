@@ -0,0 +1,153 @@
+package torrent_meta
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/MunifTanjim/stremthru/internal/bencode"
+	"github.com/MunifTanjim/stremthru/internal/torrent_stream"
+)
+
+// FileSource is the `torrent_stream` source of the files from torrent
+// metadata.
+const FileSource = "meta"
+
+type InfoFile struct {
+	Path      []string
+	Length    int64
+	IsPadding bool
+}
+
+// Name returns the last element of the path, matching the file name used
+// by the stores.
+func (f InfoFile) Name() string {
+	if len(f.Path) == 0 {
+		return ""
+	}
+	return f.Path[len(f.Path)-1]
+}
+
+// Info is the info dictionary of the torrent, as in BEP-3.
+type Info struct {
+	Name        string
+	Files       []InfoFile
+	PieceLength int64
+	// concatenated SHA-1 hashes of the pieces
+	Pieces []byte
+}
+
+func (info *Info) Size() int64 {
+	size := int64(0)
+	for _, f := range info.Files {
+		size += f.Length
+	}
+	return size
+}
+
+// ToFiles returns the files, indexed by their position in the torrent.
+// Padding files are counted for the index, but not included.
+func (info *Info) ToFiles() torrent_stream.Files {
+	files := torrent_stream.Files{}
+	for idx, f := range info.Files {
+		if f.IsPadding || f.Name() == "" {
+			continue
+		}
+		files = append(files, torrent_stream.File{
+			Idx:    idx,
+			Name:   f.Name(),
+			Size:   f.Length,
+			Source: FileSource,
+		})
+	}
+	return files
+}
+
+func getString(dict map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := dict[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func getPath(dict map[string]any, keys ...string) []string {
+	for _, key := range keys {
+		if list, ok := dict[key].([]any); ok {
+			path := make([]string, 0, len(list))
+			for _, item := range list {
+				if part, ok := item.(string); ok {
+					path = append(path, part)
+				}
+			}
+			return path
+		}
+	}
+	return nil
+}
+
+// ParseInfo parses the encoded info dictionary. UTF-8 variants of the name
+// and paths are preferred when present.
+func ParseInfo(raw []byte) (*Info, error) {
+	dict, err := bencode.DecodeDict(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{
+		Name:   getString(dict, "name.utf-8", "name"),
+		Pieces: []byte(getString(dict, "pieces")),
+	}
+	info.PieceLength, _ = dict["piece length"].(int64)
+
+	if list, ok := dict["files"].([]any); ok {
+		info.Files = make([]InfoFile, 0, len(list))
+		for _, item := range list {
+			fDict, ok := item.(map[string]any)
+			if !ok {
+				return nil, errors.New("invalid file entry")
+			}
+			length, _ := fDict["length"].(int64)
+			info.Files = append(info.Files, InfoFile{
+				Path:      getPath(fDict, "path.utf-8", "path"),
+				Length:    length,
+				IsPadding: strings.Contains(getString(fDict, "attr"), "p"),
+			})
+		}
+	} else if length, ok := dict["length"].(int64); ok {
+		info.Files = []InfoFile{{Path: []string{info.Name}, Length: length}}
+	} else {
+		return nil, errors.New("missing files in info dictionary")
+	}
+
+	return info, nil
+}
+
+// PieceCount returns the number of pieces, as per the piece hashes.
+func (info *Info) PieceCount() int {
+	return len(info.Pieces) / sha1.Size
+}
+
+// PieceHash returns the SHA-1 hash of the piece.
+func (info *Info) PieceHash(piece int) []byte {
+	return info.Pieces[piece*sha1.Size : (piece+1)*sha1.Size]
+}
+
+// ExtractRawInfo returns the encoded info dictionary of .torrent file.
+func ExtractRawInfo(torrent []byte) ([]byte, error) {
+	raw, err := bencode.GetRawValue(torrent, "info")
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, errors.New("missing info dictionary")
+	}
+	return raw, nil
+}
+
+func verifyInfoHash(raw []byte, hash string) bool {
+	sum := sha1.Sum(raw)
+	return strings.EqualFold(hex.EncodeToString(sum[:]), hash)
+}
@@ -0,0 +1,62 @@
+package torrent_meta
+
+import (
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/torrent_stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInfo(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+		files torrent_stream.Files
+		size  int64
+	}{
+		{
+			"single file",
+			"d6:lengthi100e4:name9:Movie.mkve",
+			torrent_stream.Files{
+				{Idx: 0, Name: "Movie.mkv", Size: 100, Source: FileSource},
+			},
+			100,
+		},
+		{
+			"multiple files",
+			"d5:filesld6:lengthi10e4:pathl6:Extras8:Info.txteed6:lengthi20e4:pathl10:S01E01.mkveed4:attr1:p6:lengthi5e4:pathl4:.pad1:0eed6:lengthi30e4:pathl10:S01E02.mkveee4:name4:Showe",
+			torrent_stream.Files{
+				{Idx: 0, Name: "Info.txt", Size: 10, Source: FileSource},
+				{Idx: 1, Name: "S01E01.mkv", Size: 20, Source: FileSource},
+				{Idx: 3, Name: "S01E02.mkv", Size: 30, Source: FileSource},
+			},
+			65,
+		},
+		{
+			"utf-8 path",
+			"d5:filesld6:lengthi1e4:pathl5:a.mkve10:path.utf-8l5:b.mkveee4:name1:xe",
+			torrent_stream.Files{
+				{Idx: 0, Name: "b.mkv", Size: 1, Source: FileSource},
+			},
+			1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := ParseInfo([]byte(tc.input))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.files, info.ToFiles())
+			assert.Equal(t, tc.size, info.Size())
+		})
+	}
+}
+
+func TestExtractRawInfo(t *testing.T) {
+	raw, err := ExtractRawInfo([]byte("d8:announce3:url4:infod6:lengthi1e4:name1:aee"))
+	assert.NoError(t, err)
+	assert.Equal(t, "d6:lengthi1e4:name1:ae", string(raw))
+	assert.True(t, verifyInfoHash(raw, "8AA9D3C65B0164D222D9B2527A70F125668575EF"))
+	assert.False(t, verifyInfoHash(raw, "0000000000000000000000000000000000000000"))
+
+	_, err = ExtractRawInfo([]byte("d8:announce3:urle"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,7 @@
+package torrent_meta
+
+import (
+	"github.com/MunifTanjim/stremthru/internal/logger"
+)
+
+var log = logger.Scoped("torrent_meta")
@@ -0,0 +1,145 @@
+package torrent_meta
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/bencode"
+	"github.com/MunifTanjim/stremthru/internal/bittorrent"
+)
+
+//
+// Metadata exchange with a peer
+// https://www.bittorrent.org/beps/bep_0009.html
+//
+
+const (
+	// extension message id for ut_metadata in our handshake
+	extUTMetadataId = 1
+
+	metadataPieceSize = 16 * 1024
+	maxMetadataSize   = 10 * 1024 * 1024
+)
+
+var errPeerNotSupported = errors.New("peer does not support metadata exchange")
+
+// ErrMetadataRejected is returned when the peer does not have the metadata.
+var ErrMetadataRejected = errors.New("peer rejected metadata request")
+
+func fetchMetadata(c *bittorrent.Conn) ([]byte, error) {
+	if !c.SupportsExtension {
+		return nil, errPeerNotSupported
+	}
+
+	payload, err := bencode.Encode(map[string]any{
+		"m": map[string]any{"ut_metadata": extUTMetadataId},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.WriteExtended(bittorrent.ExtHandshakeId, payload); err != nil {
+		return nil, err
+	}
+
+	var peerUTMetadataId int64
+	var metadataSize int64
+	for {
+		extId, payload, err := c.ReadExtended()
+		if err != nil {
+			return nil, err
+		}
+		if extId != bittorrent.ExtHandshakeId {
+			continue
+		}
+		dict, err := bencode.DecodeDict(payload)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := dict["m"].(map[string]any); ok {
+			peerUTMetadataId, _ = m["ut_metadata"].(int64)
+		}
+		metadataSize, _ = dict["metadata_size"].(int64)
+		break
+	}
+	if peerUTMetadataId == 0 || metadataSize <= 0 {
+		return nil, errPeerNotSupported
+	}
+	if metadataSize > maxMetadataSize {
+		return nil, fmt.Errorf("metadata too large: %d", metadataSize)
+	}
+
+	pieceCount := int((metadataSize + metadataPieceSize - 1) / metadataPieceSize)
+	for piece := range pieceCount {
+		payload, err := bencode.Encode(map[string]any{"msg_type": 0, "piece": piece})
+		if err != nil {
+			return nil, err
+		}
+		if err := c.WriteExtended(byte(peerUTMetadataId), payload); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata := make([]byte, metadataSize)
+	received := 0
+	for received < pieceCount {
+		extId, payload, err := c.ReadExtended()
+		if err != nil {
+			return nil, err
+		}
+		if extId != extUTMetadataId {
+			continue
+		}
+		value, n, err := bencode.DecodePrefix(payload)
+		if err != nil {
+			return nil, err
+		}
+		dict, ok := value.(map[string]any)
+		if !ok {
+			return nil, errors.New("invalid metadata message")
+		}
+		msgType, _ := dict["msg_type"].(int64)
+		piece, _ := dict["piece"].(int64)
+		switch msgType {
+		case 1:
+			start := piece * metadataPieceSize
+			data := payload[n:]
+			if piece < 0 || int(piece) >= pieceCount || start+int64(len(data)) > metadataSize {
+				return nil, errors.New("invalid metadata piece")
+			}
+			copy(metadata[start:], data)
+			received++
+		case 2:
+			return nil, ErrMetadataRejected
+		}
+	}
+
+	return metadata, nil
+}
+
+// FetchFromPeer fetches the encoded info dictionary from the peer at addr.
+func FetchFromPeer(addr string, hash string, timeout time.Duration) ([]byte, error) {
+	infoHash, err := hex.DecodeString(hash)
+	if err != nil || len(infoHash) != 20 {
+		return nil, errors.New("invalid info hash: " + hash)
+	}
+
+	c, err := bittorrent.Dial(addr, infoHash, bittorrent.NewPeerId(), timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	if err := c.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	raw, err := fetchMetadata(c)
+	if err != nil {
+		return nil, err
+	}
+	if !verifyInfoHash(raw, hash) {
+		return nil, errors.New("info hash mismatch for metadata from peer")
+	}
+	return raw, nil
+}
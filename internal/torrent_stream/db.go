@@ -105,20 +105,21 @@ var Columns = []string{
 	Column.UAt,
 }
 
-var query_get_anime_file_for_kitsu = fmt.Sprintf(
-	`SELECT %s, %s, %s FROM %s WHERE %s = ? AND %s = CONCAT((SELECT %s FROM %s WHERE %s = ?), ':', CAST(? AS varchar))`,
+var query_get_anime_file = fmt.Sprintf(
+	`SELECT %s, %s, %s FROM %s WHERE %s = ? AND %s = ?`,
 	Column.Name, Column.Idx, Column.Size,
 	TableName,
 	Column.Hash,
 	Column.ASId,
-	anime.IdMapColumn.AniDB,
-	anime.IdMapTableName,
-	anime.IdMapColumn.Kitsu,
 )
 
-func getAnimeFileForKitsu(hash string, asid string) (*File, error) {
-	kitsuId, episode, _ := strings.Cut(strings.TrimPrefix(asid, "kitsu:"), ":")
-	row := db.QueryRow(query_get_anime_file_for_kitsu, hash, kitsuId, episode)
+func getAnimeFile(hash string, sid string) (*File, error) {
+	column, animeId, episode, _ := anime.ParseStremId(sid)
+	anidbId, _, err := anime.GetAniDBIdById(column, animeId)
+	if err != nil || anidbId == "" {
+		return nil, err
+	}
+	row := db.QueryRow(query_get_anime_file, hash, anidbId+":"+episode)
 	var file File
 	if err := row.Scan(&file.Name, &file.Idx, &file.Size); err != nil {
 		if err == sql.ErrNoRows {
@@ -138,8 +139,8 @@ var query_get_file = fmt.Sprintf(
 )
 
 func GetFile(hash string, sid string) (*File, error) {
-	if strings.HasPrefix(sid, "kitsu:") {
-		return getAnimeFileForKitsu(hash, sid)
+	if _, _, _, ok := anime.ParseStremId(sid); ok {
+		return getAnimeFile(hash, sid)
 	}
 	row := db.QueryRow(query_get_file, hash, sid)
 	var file File
@@ -315,13 +316,16 @@ var query_tag_anime_strem_id = fmt.Sprintf(
 )
 
 func TagAnimeStremId(hash string, filename string, sid string) {
-	if !strings.HasPrefix(sid, "kitsu:") {
+	column, animeId, episode, ok := anime.ParseStremId(sid)
+	if !ok {
 		return
 	}
-	kitsuId, episode, _ := strings.Cut(strings.TrimPrefix(sid, "kitsu:"), ":")
-	anidbId, _, err := anime.GetAniDBIdByKitsuId(kitsuId)
+	anidbId, _, err := anime.GetAniDBIdById(column, animeId)
 	if err != nil {
-		log.Error("failed to get anidb id by kitsu id", "error", err, "sid", sid)
+		log.Error("failed to get anidb id", "error", err, "sid", sid)
+		return
+	}
+	if anidbId == "" {
 		return
 	}
 	asid := anidbId + ":" + episode
@@ -0,0 +1,224 @@
+package torznab
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/torrent_meta"
+)
+
+type xmlResultItemAttribute struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type xmlResultItem struct {
+	Title       string `xml:"title"`
+	GUID        string `xml:"guid"`
+	Link        string `xml:"link"`
+	Size        int64  `xml:"size"`
+	PublishDate string `xml:"pubDate"`
+	Enclosure   struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+	Attributes []xmlResultItemAttribute `xml:"attr"`
+}
+
+type xmlResult struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []xmlResultItem `xml:"item"`
+	} `xml:"channel"`
+	// error response
+	Code        int    `xml:"code,attr"`
+	Description string `xml:"description,attr"`
+}
+
+func (item xmlResultItem) toResultItem() ResultItem {
+	ri := ResultItem{
+		GUID:  item.GUID,
+		Link:  item.Link,
+		Size:  item.Size,
+		Title: item.Title,
+	}
+	if ri.Size == 0 {
+		ri.Size = item.Enclosure.Length
+	}
+	if ri.Link == "" {
+		ri.Link = item.Enclosure.URL
+	}
+	if date, err := time.Parse(rfc822, item.PublishDate); err == nil {
+		ri.PublishDate = date
+	}
+
+	magnetLink := ""
+	for _, attr := range item.Attributes {
+		switch attr.Name {
+		case "category":
+			if id, err := strconv.Atoi(attr.Value); err == nil && (ri.Category.ID == 0 || id < ri.Category.ID) {
+				ri.Category = Category{ID: id}
+			}
+		case "imdb", "imdbid":
+			if attr.Value != "" && attr.Value != "0" {
+				ri.IMDB = "tt" + strings.TrimPrefix(attr.Value, "tt")
+			}
+		case "infohash":
+			ri.InfoHash = strings.ToLower(attr.Value)
+		case "magneturl":
+			magnetLink = attr.Value
+		case "size":
+			if size, err := strconv.ParseInt(attr.Value, 10, 64); err == nil && size > 0 {
+				ri.Size = size
+			}
+		case "files":
+			ri.Files, _ = strconv.Atoi(attr.Value)
+		}
+	}
+
+	if ri.InfoHash == "" {
+		for _, link := range []string{magnetLink, ri.Link, item.GUID} {
+			if !strings.HasPrefix(link, "magnet:") {
+				continue
+			}
+			if magnet, err := core.ParseMagnetLink(link); err == nil && len(magnet.Hash) == 40 {
+				ri.InfoHash = magnet.Hash
+				break
+			}
+		}
+	}
+
+	return ri
+}
+
+type ClientConfig struct {
+	// Torznab API endpoint, e.g. `http://localhost:9117/api/v2.0/indexers/all/results/torznab`
+	URL        string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+type Client struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewClient(conf *ClientConfig) *Client {
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = config.GetHTTPClient(config.TUNNEL_TYPE_AUTO)
+	}
+	return &Client{
+		url:        strings.TrimRight(conf.URL, "?&"),
+		apiKey:     conf.APIKey,
+		httpClient: conf.HTTPClient,
+	}
+}
+
+// Search queries the indexer. Items without info hash are included, with
+// the link to the .torrent file.
+func (c *Client) Search(ctx context.Context, query Query) ([]ResultItem, error) {
+	if query.APIKey == "" {
+		query.APIKey = c.apiKey
+	}
+
+	sep := "?"
+	if strings.Contains(c.url, "?") {
+		sep = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+sep+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 10*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("torznab: " + res.Status)
+	}
+
+	result := xmlResult{}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.XMLName.Local == "error" {
+		return nil, Error{Code: result.Code, Description: result.Description}
+	}
+
+	items := make([]ResultItem, len(result.Channel.Items))
+	for i := range result.Channel.Items {
+		items[i] = result.Channel.Items[i].toResultItem()
+	}
+	return items, nil
+}
+
+// ResolveInfoHash sets the info hash for the item, using the magnet link
+// it redirects to or the .torrent file it links to.
+func (c *Client) ResolveInfoHash(ctx context.Context, item *ResultItem) error {
+	if item.InfoHash != "" {
+		return nil
+	}
+	if item.Link == "" {
+		return errors.New("missing link")
+	}
+
+	httpClient := *c.httpClient
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme == "magnet" {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, item.Link, nil)
+	if err != nil {
+		return err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if location := res.Header.Get("Location"); strings.HasPrefix(location, "magnet:") {
+		magnet, err := core.ParseMagnetLink(location)
+		if err != nil {
+			return err
+		}
+		item.InfoHash = magnet.Hash
+		return nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.New("torznab: " + res.Status)
+	}
+	torrent, err := io.ReadAll(io.LimitReader(res.Body, 10*1024*1024))
+	if err != nil {
+		return err
+	}
+	raw, err := torrent_meta.ExtractRawInfo(torrent)
+	if err != nil {
+		return err
+	}
+	sum := sha1.Sum(raw)
+	item.InfoHash = hex.EncodeToString(sum[:])
+	return nil
+}
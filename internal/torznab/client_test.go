@@ -0,0 +1,194 @@
+package torznab
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/bencode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultItemParse(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		input    string
+		expected ResultItem
+	}{
+		{
+			"infohash attr",
+			`<item>
+				<title>Movie.2020.1080p.WEB-DL</title>
+				<link>http://localhost/dl/1</link>
+				<size>1000</size>
+				<torznab:attr name="category" value="2040" />
+				<torznab:attr name="category" value="2000" />
+				<torznab:attr name="imdbid" value="1234567" />
+				<torznab:attr name="infohash" value="ABCDEF0123456789ABCDEF0123456789ABCDEF01" />
+			</item>`,
+			ResultItem{
+				Category: Category{ID: 2000},
+				IMDB:     "tt1234567",
+				InfoHash: "abcdef0123456789abcdef0123456789abcdef01",
+				Link:     "http://localhost/dl/1",
+				Size:     1000,
+				Title:    "Movie.2020.1080p.WEB-DL",
+			},
+		},
+		{
+			"magneturl attr",
+			`<item>
+				<title>Show.S01E01.720p</title>
+				<enclosure url="http://localhost/dl/2" length="2000" />
+				<torznab:attr name="magneturl" value="magnet:?xt=urn:btih:abcdef0123456789abcdef0123456789abcdef02" />
+				<torznab:attr name="files" value="3" />
+			</item>`,
+			ResultItem{
+				Files:    3,
+				InfoHash: "abcdef0123456789abcdef0123456789abcdef02",
+				Link:     "http://localhost/dl/2",
+				Size:     2000,
+				Title:    "Show.S01E01.720p",
+			},
+		},
+		{
+			"torrent link",
+			`<item>
+				<title>Show.S01.1080p</title>
+				<link>http://localhost/dl/3</link>
+				<torznab:attr name="size" value="3000" />
+				<torznab:attr name="imdb" value="0" />
+			</item>`,
+			ResultItem{
+				Link:  "http://localhost/dl/3",
+				Size:  3000,
+				Title: "Show.S01.1080p",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			item := xmlResultItem{}
+			err := xml.Unmarshal([]byte(test.input), &item)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, item.toResultItem())
+		})
+	}
+}
+
+const testSearchResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+  <channel>
+    <item>
+      <title>Movie.2020.1080p.WEB-DL</title>
+      <link>http://localhost/dl/1</link>
+      <size>1000</size>
+      <torznab:attr name="infohash" value="abcdef0123456789abcdef0123456789abcdef01" />
+    </item>
+    <item>
+      <title>Movie.2020.720p.WEB-DL</title>
+      <link>http://localhost/dl/2</link>
+      <size>500</size>
+    </item>
+  </channel>
+</rss>`
+
+func TestClientSearch(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		switch r.URL.Query().Get("apikey") {
+		case "secret":
+			w.Write([]byte(testSearchResponse))
+		case "down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Invalid API Key" />`))
+		}
+	}))
+	defer server.Close()
+
+	t.Run("results", func(t *testing.T) {
+		client := NewClient(&ClientConfig{URL: server.URL + "/api?", APIKey: "secret"})
+		items, err := client.Search(t.Context(), Query{Type: "movie", IMDBId: "tt1234567"})
+		assert.NoError(t, err)
+		assert.Equal(t, "apikey=secret&imdbid=1234567&t=movie", query, "imdb id is sent without prefix")
+		if assert.Len(t, items, 2) {
+			assert.Equal(t, "abcdef0123456789abcdef0123456789abcdef01", items[0].InfoHash)
+			assert.Equal(t, "", items[1].InfoHash)
+			assert.Equal(t, "http://localhost/dl/2", items[1].Link)
+		}
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		client := NewClient(&ClientConfig{URL: server.URL, APIKey: "invalid"})
+		_, err := client.Search(t.Context(), Query{Q: "movie"})
+		assert.Equal(t, Error{Code: 100, Description: "Invalid API Key"}, err)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		client := NewClient(&ClientConfig{URL: server.URL, APIKey: "down"})
+		_, err := client.Search(t.Context(), Query{Q: "movie"})
+		assert.ErrorContains(t, err, "502")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		client := NewClient(&ClientConfig{URL: server.URL, APIKey: "secret"})
+		_, err := client.Search(ctx, Query{Q: "movie"})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestClientResolveInfoHash(t *testing.T) {
+	info := map[string]any{"name": "Movie.mkv", "length": 1000, "piece length": 16384, "pieces": string(make([]byte, 20))}
+	rawInfo, err := bencode.Encode(info)
+	assert.NoError(t, err)
+	torrent, err := bencode.Encode(map[string]any{"announce": "http://localhost/announce", "info": info})
+	assert.NoError(t, err)
+	sum := sha1.Sum(rawInfo)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dl/torrent", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(torrent)
+	})
+	mux.HandleFunc("/dl/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/dl/magnet", http.StatusFound)
+	})
+	mux.HandleFunc("/dl/magnet", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "magnet:?xt=urn:btih:abcdef0123456789abcdef0123456789abcdef02", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(&ClientConfig{URL: server.URL})
+
+	for _, tc := range []struct {
+		name     string
+		link     string
+		expected string
+	}{
+		{"torrent file", server.URL + "/dl/torrent", hex.EncodeToString(sum[:])},
+		{"magnet redirect", server.URL + "/dl/redirect", "abcdef0123456789abcdef0123456789abcdef02"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			item := ResultItem{Link: tc.link}
+			assert.NoError(t, client.ResolveInfoHash(t.Context(), &item))
+			assert.Equal(t, tc.expected, item.InfoHash)
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		item := ResultItem{Link: server.URL + "/dl/missing"}
+		assert.Error(t, client.ResolveInfoHash(t.Context(), &item))
+		assert.Equal(t, "", item.InfoHash)
+	})
+
+	t.Run("missing link", func(t *testing.T) {
+		assert.Error(t, client.ResolveInfoHash(t.Context(), &ResultItem{}))
+	})
+}
@@ -48,8 +48,38 @@ var lastMappedIMDBIdCached struct {
 	staleAt time.Time
 }
 
+// resolveIMDBId sets the IMDb id for queries with TVDB or TMDB id, using the
+// IMDb title map. It returns false if the id can't be resolved.
+func resolveIMDBId(q *Query) (bool, error) {
+	if q.IMDBId != "" {
+		return true, nil
+	}
+	stremId := ""
+	if q.TVDBId != "" {
+		stremId = "tvdb:" + q.TVDBId
+	} else if q.TMDBId != "" {
+		stremId = "tmdb:" + q.TMDBId
+	} else {
+		return true, nil
+	}
+	imdbId, err := imdb_title.ResolveStremId(q.ContentType(), stremId)
+	if err != nil || imdbId == "" {
+		return false, err
+	}
+	q.IMDBId = imdbId
+	return true, nil
+}
+
 func (sti stremThruIndexer) Search(q Query) ([]ResultItem, error) {
 	imdbIds := []string{}
+	shouldPull := true
+
+	if ok, err := resolveIMDBId(&q); err != nil {
+		return nil, err
+	} else if !ok {
+		log.Debug("imdb id not found for query", "tvdbid", q.TVDBId, "tmdbid", q.TMDBId)
+		return []ResultItem{}, nil
+	}
 
 	if q.IMDBId == "" && q.Q == "" {
 		if lastMappedIMDBIdCached.staleAt.Before(time.Now()) {
@@ -80,6 +110,16 @@ func (sti stremThruIndexer) Search(q Query) ([]ResultItem, error) {
 		}
 		imdbIds = append(imdbIds, ids...)
 	} else {
+		if q.Season != "" {
+			// not synced yet for new episodes, so only used to skip pulling
+			exists, err := imdb_title.CheckEpisode(q.IMDBId, q.Season, q.Ep)
+			if err != nil {
+				log.Warn("failed to check episode", "error", err, "imdbid", q.IMDBId)
+			} else if !exists {
+				log.Debug("episode not found for query, skipping pull", "imdbid", q.IMDBId, "season", q.Season, "ep", q.Ep)
+				shouldPull = false
+			}
+		}
 		imdbIds = append(imdbIds, q.IMDBId)
 	}
 
@@ -87,15 +127,17 @@ func (sti stremThruIndexer) Search(q Query) ([]ResultItem, error) {
 		return []ResultItem{}, nil
 	}
 
-	var wg sync.WaitGroup
-	for _, imdbId := range imdbIds {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			buddy.PullTorrentsByStremId(imdbId, "")
-		}()
+	if shouldPull {
+		var wg sync.WaitGroup
+		for _, imdbId := range imdbIds {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				buddy.PullTorrentsByStremId(imdbId, "")
+			}()
+		}
+		wg.Wait()
 	}
-	wg.Wait()
 
 	args := []any{}
 	var query strings.Builder
@@ -297,12 +339,12 @@ var StremThruIndexer = stremThruIndexer{
 			{
 				Name:            "tv-search",
 				Available:       true,
-				SupportedParams: []string{"q,imdbid,season,ep"},
+				SupportedParams: []string{"q,imdbid,tvdbid,tmdbid,season,ep"},
 			},
 			{
 				Name:            "movie-search",
 				Available:       true,
-				SupportedParams: []string{"q,imdbid"},
+				SupportedParams: []string{"q,imdbid,tmdbid"},
 			},
 		},
 		Categories: []CapsCategory{
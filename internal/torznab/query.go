@@ -6,6 +6,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+
+	"github.com/MunifTanjim/stremthru/stremio"
 )
 
 type Query struct {
@@ -20,6 +22,7 @@ type Query struct {
 
 	// identifier types
 	TVDBId   string
+	TMDBId   string
 	TVRageId string
 	IMDBId   string
 	TVMazeId string
@@ -44,6 +47,25 @@ func (query Query) HasMovies() bool {
 	return false
 }
 
+// ContentType returns the type of the searched content, or empty string if
+// it is not known.
+func (query Query) ContentType() stremio.ContentType {
+	switch query.Type {
+	case "movie":
+		return stremio.ContentTypeMovie
+	case "tvsearch":
+		return stremio.ContentTypeSeries
+	}
+	hasTVShows, hasMovies := query.HasTVShows(), query.HasMovies()
+	if hasTVShows && !hasMovies {
+		return stremio.ContentTypeSeries
+	}
+	if hasMovies && !hasTVShows {
+		return stremio.ContentTypeMovie
+	}
+	return ""
+}
+
 func (query Query) Encode() string {
 	v := url.Values{}
 
@@ -107,6 +129,10 @@ func (query Query) Encode() string {
 		v.Set("tvdbid", query.TVDBId)
 	}
 
+	if query.TMDBId != "" {
+		v.Set("tmdbid", query.TMDBId)
+	}
+
 	if query.TVRageId != "" {
 		v.Set("rid", query.TVRageId)
 	}
@@ -216,6 +242,18 @@ func ParseQuery(q url.Values) (Query, error) {
 			if !strings.HasPrefix(query.IMDBId, "tt") {
 				query.IMDBId = "tt" + query.IMDBId
 			}
+
+		case "tvdbid":
+			if len(vals) > 1 {
+				return query, errors.New("Multiple tvdbid parameters not allowed")
+			}
+			query.TVDBId = vals[0]
+
+		case "tmdbid":
+			if len(vals) > 1 {
+				return query, errors.New("Multiple tmdbid parameters not allowed")
+			}
+			query.TMDBId = vals[0]
 		}
 	}
 
@@ -483,6 +483,7 @@ func upsertItems(tx db.Executor, items []TraktItem) error {
 			if item.Ids.IMDB != "" {
 				mappings = append(mappings, imdb_title.BulkRecordMappingInputItem{
 					IMDBId:  item.Ids.IMDB,
+					Type:    item.Type,
 					TMDBId:  strconv.Itoa(item.Ids.TMDB),
 					TVDBId:  strconv.Itoa(item.Ids.TVDB),
 					TraktId: strconv.Itoa(item.Ids.Trakt),
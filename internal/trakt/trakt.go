@@ -28,14 +28,7 @@ func GetAPIClient(tokenId string) *APIClient {
 	conf.OAuth = APIClientConfigOAuth{
 		Config: oauth.TraktOAuthConfig.Config,
 		GetTokenSource: func(oauthConfig oauth2.Config) oauth2.TokenSource {
-			otok, _ := oauth.GetOAuthTokenById(tokenId)
-			if otok == nil {
-				return nil
-			}
-			return oauth.DatabaseTokenSource(&oauth.DatabaseTokenSourceConfig{
-				OAuth:             &oauth.TraktOAuthConfig.Config,
-				TokenSourceConfig: oauth.TraktTokenSourceConfig,
-			}, otok.ToToken())
+			return oauth.GetProvider(oauth.ProviderTraktTv).TokenSource(tokenId)
 		},
 	}
 
@@ -0,0 +1,371 @@
+package user_account
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+const TableName = "user_account"
+
+type UserAccount struct {
+	Name string
+	// hashed with util.HashPassword
+	PasswordHash string
+	// for signing the proxy links, reset with the password
+	Secret     string
+	IsAdmin    bool
+	IsDisabled bool
+	// preferred store first
+	StoreNames  db.CommaSeperatedString
+	StoreTokens db.JSONStringMap
+	// negative for the default limit
+	ContentProxyConnectionLimit int
+	CreatedAt                   db.Timestamp
+	UpdatedAt                   db.Timestamp
+}
+
+var Column = struct {
+	Name                        string
+	PasswordHash                string
+	Secret                      string
+	IsAdmin                     string
+	IsDisabled                  string
+	StoreNames                  string
+	StoreTokens                 string
+	ContentProxyConnectionLimit string
+	CreatedAt                   string
+	UpdatedAt                   string
+}{
+	Name:                        "name",
+	PasswordHash:                "password_hash",
+	Secret:                      "secret",
+	IsAdmin:                     "is_admin",
+	IsDisabled:                  "is_disabled",
+	StoreNames:                  "stores",
+	StoreTokens:                 "store_tokens",
+	ContentProxyConnectionLimit: "cp_conn_limit",
+	CreatedAt:                   "cat",
+	UpdatedAt:                   "uat",
+}
+
+var columns = []string{
+	Column.Name,
+	Column.PasswordHash,
+	Column.Secret,
+	Column.IsAdmin,
+	Column.IsDisabled,
+	Column.StoreNames,
+	Column.StoreTokens,
+	Column.ContentProxyConnectionLimit,
+	Column.CreatedAt,
+	Column.UpdatedAt,
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// encryptStoreTokens encodes the store tokens to be saved, encrypted if
+// encryption is enabled.
+func encryptStoreTokens(tokens db.JSONStringMap) (string, error) {
+	if tokens == nil {
+		tokens = db.JSONStringMap{}
+	}
+	blob, err := json.Marshal(tokens)
+	if err != nil {
+		return "", err
+	}
+	if !config.EncryptionKey.IsEnabled() {
+		return string(blob), nil
+	}
+	return config.EncryptionKey.Encrypt(string(blob))
+}
+
+func isEncryptedStoreTokens(value string) bool {
+	return !strings.HasPrefix(value, "{")
+}
+
+// decryptStoreTokens decodes the saved store tokens, decrypting if
+// encrypted.
+func decryptStoreTokens(value string) (db.JSONStringMap, error) {
+	if isEncryptedStoreTokens(value) {
+		decrypted, err := config.EncryptionKey.Decrypt(value)
+		if err != nil {
+			return nil, err
+		}
+		value = decrypted
+	}
+	tokens := db.JSONStringMap{}
+	if err := json.Unmarshal([]byte(value), &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// encryptSecret encodes the secret to be saved, encrypted if encryption is
+// enabled.
+func encryptSecret(secret string) (string, error) {
+	if !config.EncryptionKey.IsEnabled() {
+		return secret, nil
+	}
+	return config.EncryptionKey.Encrypt(secret)
+}
+
+// the secret is generated with rand.Text, without any "."
+func isEncryptedSecret(value string) bool {
+	return strings.Contains(value, ".")
+}
+
+func decryptSecret(value string) (string, error) {
+	if isEncryptedSecret(value) {
+		return config.EncryptionKey.Decrypt(value)
+	}
+	return value, nil
+}
+
+func scan(row scanner) (*UserAccount, error) {
+	ua := UserAccount{}
+	secret, storeTokens := "", ""
+	if err := row.Scan(
+		&ua.Name,
+		&ua.PasswordHash,
+		&secret,
+		&ua.IsAdmin,
+		&ua.IsDisabled,
+		&ua.StoreNames,
+		&storeTokens,
+		&ua.ContentProxyConnectionLimit,
+		&ua.CreatedAt,
+		&ua.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	secret, err := decryptSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret for %s: %w", ua.Name, err)
+	}
+	ua.Secret = secret
+	tokens, err := decryptStoreTokens(storeTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt store tokens for %s: %w", ua.Name, err)
+	}
+	ua.StoreTokens = tokens
+	return &ua, nil
+}
+
+var query_get_by_name = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ?`,
+	strings.Join(columns, ", "),
+	TableName,
+	Column.Name,
+)
+
+func GetByName(name string) (*UserAccount, error) {
+	ua, err := scan(db.QueryRow(query_get_by_name, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ua, nil
+}
+
+var query_list = fmt.Sprintf(
+	`SELECT %s FROM %s ORDER BY %s`,
+	strings.Join(columns, ", "),
+	TableName,
+	Column.Name,
+)
+
+func List() ([]UserAccount, error) {
+	rows, err := db.Query(query_list)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []UserAccount{}
+	for rows.Next() {
+		ua, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *ua)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+var query_save = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s`,
+	TableName,
+	strings.Join(columns, ","),
+	util.RepeatJoin("?", len(columns), ","),
+	Column.Name,
+	strings.Join([]string{
+		fmt.Sprintf("%s = EXCLUDED.%s", Column.PasswordHash, Column.PasswordHash),
+		fmt.Sprintf("%s = EXCLUDED.%s", Column.Secret, Column.Secret),
+		fmt.Sprintf("%s = EXCLUDED.%s", Column.IsAdmin, Column.IsAdmin),
+		fmt.Sprintf("%s = EXCLUDED.%s", Column.IsDisabled, Column.IsDisabled),
+		fmt.Sprintf("%s = EXCLUDED.%s", Column.StoreNames, Column.StoreNames),
+		fmt.Sprintf("%s = EXCLUDED.%s", Column.StoreTokens, Column.StoreTokens),
+		fmt.Sprintf("%s = EXCLUDED.%s", Column.ContentProxyConnectionLimit, Column.ContentProxyConnectionLimit),
+		fmt.Sprintf("%s = EXCLUDED.%s", Column.UpdatedAt, Column.UpdatedAt),
+	}, ", "),
+)
+
+func Save(ua *UserAccount) error {
+	if ua.PasswordHash == "" && ua.IsFromEnv() {
+		// user configured with the env, saved for the first time
+		if password := config.ProxyAuthPassword[ua.Name]; password != "" {
+			if err := ua.SetPassword(password); err != nil {
+				return err
+			}
+		}
+	}
+	if ua.PasswordHash == "" {
+		return fmt.Errorf("missing password for %s", ua.Name)
+	}
+	if ua.Secret == "" {
+		ua.Secret = rand.Text()
+	}
+	secret, err := encryptSecret(ua.Secret)
+	if err != nil {
+		return err
+	}
+	storeTokens, err := encryptStoreTokens(ua.StoreTokens)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if ua.CreatedAt.IsZero() {
+		ua.CreatedAt = db.Timestamp{Time: now}
+	}
+	ua.UpdatedAt = db.Timestamp{Time: now}
+	_, err = db.Exec(
+		query_save,
+		ua.Name,
+		ua.PasswordHash,
+		secret,
+		ua.IsAdmin,
+		ua.IsDisabled,
+		ua.StoreNames,
+		storeTokens,
+		ua.ContentProxyConnectionLimit,
+		ua.CreatedAt,
+		ua.UpdatedAt,
+	)
+	if err == nil {
+		invalidate(ua.Name)
+	}
+	return err
+}
+
+var query_delete = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ?`,
+	TableName,
+	Column.Name,
+)
+
+func Delete(name string) error {
+	_, err := db.Exec(query_delete, name)
+	if err == nil {
+		invalidate(name)
+	}
+	return err
+}
+
+var query_list_encrypted = fmt.Sprintf(
+	`SELECT %s, %s, %s FROM %s`,
+	Column.Name,
+	Column.Secret,
+	Column.StoreTokens,
+	TableName,
+)
+
+var query_update_encrypted = fmt.Sprintf(
+	`UPDATE %s SET %s = ?, %s = ? WHERE %s = ?`,
+	TableName,
+	Column.Secret,
+	Column.StoreTokens,
+	Column.Name,
+)
+
+// EncryptAll encrypts the secrets and store tokens not encrypted with the
+// active key, i.e. the ones saved before enabling encryption or rotating the
+// key. It returns the count of the updated rows.
+func EncryptAll() (int, error) {
+	if !config.EncryptionKey.IsEnabled() {
+		return 0, nil
+	}
+
+	type savedValues struct {
+		secret      string
+		storeTokens string
+	}
+
+	rows, err := db.Query(query_list_encrypted)
+	if err != nil {
+		return 0, err
+	}
+	valuesByName := map[string]savedValues{}
+	for rows.Next() {
+		name, values := "", savedValues{}
+		if err := rows.Scan(&name, &values.secret, &values.storeTokens); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		valuesByName[name] = values
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	activeKeyId, _ := config.EncryptionKey.GetActive()
+	isEncryptedWithActiveKey := func(value string) bool {
+		return config.EncryptionKey.GetKeyId(value) == activeKeyId
+	}
+	count := 0
+	for name, values := range valuesByName {
+		isSecretEncrypted := values.secret == "" || isEncryptedSecret(values.secret) && isEncryptedWithActiveKey(values.secret)
+		isStoreTokensEncrypted := isEncryptedStoreTokens(values.storeTokens) && isEncryptedWithActiveKey(values.storeTokens)
+		if isSecretEncrypted && isStoreTokensEncrypted {
+			continue
+		}
+		secret, err := decryptSecret(values.secret)
+		if err != nil {
+			return count, fmt.Errorf("failed to encrypt %s: %w", name, err)
+		}
+		if secret != "" {
+			if secret, err = encryptSecret(secret); err != nil {
+				return count, fmt.Errorf("failed to encrypt %s: %w", name, err)
+			}
+		}
+		tokens, err := decryptStoreTokens(values.storeTokens)
+		if err != nil {
+			return count, fmt.Errorf("failed to encrypt %s: %w", name, err)
+		}
+		storeTokens, err := encryptStoreTokens(tokens)
+		if err != nil {
+			return count, fmt.Errorf("failed to encrypt %s: %w", name, err)
+		}
+		if _, err := db.Exec(query_update_encrypted, secret, storeTokens, name); err != nil {
+			return count, err
+		}
+		invalidate(name)
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,120 @@
+package user_account
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func getSaved(t *testing.T, name string) (passwordHash, secret, storeTokens string) {
+	t.Helper()
+	row := db.QueryRow("SELECT "+Column.PasswordHash+", "+Column.Secret+", "+Column.StoreTokens+" FROM "+TableName+" WHERE "+Column.Name+" = ?", name)
+	assert.NoError(t, row.Scan(&passwordHash, &secret, &storeTokens))
+	return passwordHash, secret, storeTokens
+}
+
+func TestSave(t *testing.T) {
+	dbtest.Setup(t)
+
+	for _, encrypted := range []bool{false, true} {
+		name := "test-plain"
+		if encrypted {
+			name = "test-encrypted"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			if encrypted {
+				keys := config.EncryptionKey
+				config.EncryptionKey = config.EncryptionKeyList{"test-key"}
+				t.Cleanup(func() { config.EncryptionKey = keys })
+			}
+
+			t.Cleanup(func() { Delete(name) })
+
+			ua := &UserAccount{
+				Name:                        name,
+				StoreNames:                  db.CommaSeperatedString{"realdebrid"},
+				StoreTokens:                 db.JSONStringMap{"realdebrid": "rd-token"},
+				ContentProxyConnectionLimit: -1,
+			}
+			assert.Error(t, Save(ua), "missing password")
+
+			assert.NoError(t, ua.SetPassword("password"))
+			assert.NoError(t, Save(ua))
+
+			passwordHash, secret, storeTokens := getSaved(t, name)
+			assert.NotContains(t, passwordHash, "password")
+			assert.True(t, util.VerifyPassword(passwordHash, "password"))
+			assert.NotEmpty(t, ua.Secret)
+			assert.Equal(t, encrypted, secret != ua.Secret)
+			assert.Equal(t, encrypted, !strings.Contains(storeTokens, "rd-token"))
+
+			saved, err := GetByName(name)
+			assert.NoError(t, err)
+			assert.Equal(t, ua.Secret, saved.Secret)
+			assert.Equal(t, db.JSONStringMap{"realdebrid": "rd-token"}, saved.StoreTokens)
+
+			account, err := getUserAccount(name)
+			assert.NoError(t, err)
+			assert.Equal(t, ua.Secret, account.Secret)
+			assert.NotEqual(t, account.PasswordHash, account.Secret)
+			assert.Equal(t, "rd-token", account.StoreTokens["realdebrid"])
+
+			oldSecret := ua.Secret
+			assert.NoError(t, ua.SetPassword("new-password"))
+			assert.NotEqual(t, oldSecret, ua.Secret)
+		})
+	}
+}
+
+func TestEncryptAll(t *testing.T) {
+	dbtest.Setup(t)
+
+	keys := config.EncryptionKey
+	t.Cleanup(func() { config.EncryptionKey = keys })
+
+	config.EncryptionKey = config.EncryptionKeyList{}
+	ua := &UserAccount{
+		Name:        "test-encrypt-all",
+		StoreTokens: db.JSONStringMap{"torbox": "tb-token"},
+	}
+	t.Cleanup(func() { Delete(ua.Name) })
+	assert.NoError(t, ua.SetPassword("password"))
+	assert.NoError(t, Save(ua))
+	_, secret, storeTokens := getSaved(t, ua.Name)
+	assert.Equal(t, ua.Secret, secret)
+	assert.Contains(t, storeTokens, "tb-token")
+
+	config.EncryptionKey = config.EncryptionKeyList{"old-key"}
+	count, err := EncryptAll()
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, count, 1)
+	_, secret, storeTokens = getSaved(t, ua.Name)
+	assert.NotEqual(t, ua.Secret, secret)
+	assert.Equal(t, config.GetEncryptionKeyId("old-key"), config.EncryptionKey.GetKeyId(secret))
+	assert.NotContains(t, storeTokens, "tb-token")
+	assert.Equal(t, config.GetEncryptionKeyId("old-key"), config.EncryptionKey.GetKeyId(storeTokens))
+
+	config.EncryptionKey = config.EncryptionKeyList{"new-key", "old-key"}
+	count, err = EncryptAll()
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, count, 1)
+	_, secret, storeTokens = getSaved(t, ua.Name)
+	assert.Equal(t, config.GetEncryptionKeyId("new-key"), config.EncryptionKey.GetKeyId(secret))
+	assert.Equal(t, config.GetEncryptionKeyId("new-key"), config.EncryptionKey.GetKeyId(storeTokens))
+
+	count, err = EncryptAll()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	config.EncryptionKey = config.EncryptionKeyList{"new-key"}
+	saved, err := GetByName(ua.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, ua.Secret, saved.Secret)
+	assert.Equal(t, db.JSONStringMap{"torbox": "tb-token"}, saved.StoreTokens)
+}
@@ -0,0 +1,7 @@
+package user_account
+
+import (
+	"github.com/MunifTanjim/stremthru/internal/logger"
+)
+
+var log = logger.Scoped("user_account")
@@ -0,0 +1,134 @@
+package user_account
+
+import (
+	"crypto/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+type cachedUserAccount struct {
+	Account *config.UserAccount
+}
+
+// kept in memory only, as it has the credentials
+var userAccountCache = cache.NewLRUCache[cachedUserAccount](&cache.CacheConfig{
+	Lifetime:      1 * time.Minute,
+	Name:          "user_account",
+	PublishRemove: true,
+})
+
+func invalidate(name string) {
+	userAccountCache.Remove(name)
+}
+
+func (ua *UserAccount) toConfig() *config.UserAccount {
+	return &config.UserAccount{
+		PasswordHash:                ua.PasswordHash,
+		Secret:                      ua.Secret,
+		IsAdmin:                     ua.IsAdmin,
+		IsDisabled:                  ua.IsDisabled,
+		StoreNames:                  ua.StoreNames,
+		StoreTokens:                 ua.StoreTokens,
+		ContentProxyConnectionLimit: ua.ContentProxyConnectionLimit,
+	}
+}
+
+func getUserAccount(name string) (*config.UserAccount, error) {
+	cached := cachedUserAccount{}
+	err := userAccountCache.Fetch(name, &cached, func() (cachedUserAccount, error) {
+		ua, err := GetByName(name)
+		if err != nil || ua == nil {
+			return cachedUserAccount{}, err
+		}
+		return cachedUserAccount{Account: ua.toConfig()}, nil
+	})
+	if err != nil {
+		log.Error("failed to get user account", "error", err, "name", name)
+		return nil, err
+	}
+	return cached.Account, nil
+}
+
+func init() {
+	config.SetUserAccountGetter(getUserAccount)
+}
+
+func (ua *UserAccount) IsFromEnv() bool {
+	return ua.CreatedAt.IsZero()
+}
+
+// SetPassword also resets the secret, so that the proxy links created with
+// the old password stop working.
+func (ua *UserAccount) SetPassword(password string) error {
+	hash, err := util.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	ua.PasswordHash = hash
+	ua.Secret = rand.Text()
+	return nil
+}
+
+// FromEnv returns the user configured with the env, or nil if not found. The
+// password is not included, it is hashed when the user is saved.
+func FromEnv(name string) *UserAccount {
+	if _, ok := config.ProxyAuthPassword[name]; !ok {
+		return nil
+	}
+	ua := &UserAccount{
+		Name:                        name,
+		IsAdmin:                     config.AuthAdmin[name],
+		StoreNames:                  db.CommaSeperatedString{},
+		StoreTokens:                 db.JSONStringMap{},
+		ContentProxyConnectionLimit: -1,
+	}
+	if tokenByStore, ok := config.StoreAuthToken[name]; ok {
+		for store, token := range tokenByStore {
+			if store == "*" {
+				ua.StoreNames = strings.Fields(token)
+			} else {
+				ua.StoreTokens[store] = token
+			}
+		}
+	}
+	if limit, ok := config.ContentProxyConnectionLimit[name]; ok {
+		ua.ContentProxyConnectionLimit = limit
+	}
+	return ua
+}
+
+// ListWithEnv lists the users, including the ones only configured with the
+// env.
+func ListWithEnv() ([]UserAccount, error) {
+	accounts, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for name := range config.ProxyAuthPassword {
+		if !slices.ContainsFunc(accounts, func(ua UserAccount) bool {
+			return ua.Name == name
+		}) {
+			accounts = append(accounts, *FromEnv(name))
+		}
+	}
+	slices.SortFunc(accounts, func(a, b UserAccount) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return accounts, nil
+}
+
+// GetWithEnv returns the user, falling back to the one configured with the
+// env.
+func GetWithEnv(name string) (*UserAccount, error) {
+	ua, err := GetByName(name)
+	if err != nil || ua != nil {
+		return ua, err
+	}
+	return FromEnv(name), nil
+}
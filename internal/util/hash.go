@@ -0,0 +1,13 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashString returns the hex encoded sha256 of the value, for using secrets
+// (e.g. tokens) in keys without storing them.
+func HashString(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	passwordHashScheme     = "pbkdf2-sha256"
+	passwordHashIterations = 600000
+	passwordHashSaltLength = 16
+	passwordHashKeyLength  = 32
+)
+
+// HashPassword returns the salted hash of the password, as
+// `pbkdf2-sha256$<iterations>$<salt>$<hash>`.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordHashSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHashIterations, passwordHashKeyLength)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		passwordHashScheme,
+		strconv.Itoa(passwordHashIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// the verified passwords are remembered in memory, as the hashing is slow by
+// design and the password is checked on every request
+var verifiedPasswords sync.Map
+
+func getVerifiedPasswordKey(hash, password string) string {
+	return HashString(hash + "\x00" + password)
+}
+
+// VerifyPassword checks the password against the hash from HashPassword.
+func VerifyPassword(hash, password string) bool {
+	if hash == "" || password == "" {
+		return false
+	}
+
+	verifiedKey := getVerifiedPasswordKey(hash, password)
+	if _, ok := verifiedPasswords.Load(verifiedKey); ok {
+		return true
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordHashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expectedKey, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(expectedKey) == 0 {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expectedKey))
+	if err != nil || subtle.ConstantTimeCompare(key, expectedKey) != 1 {
+		return false
+	}
+
+	verifiedPasswords.Store(verifiedKey, struct{}{})
+	return true
+}
@@ -1,31 +1,41 @@
 package worker
 
 import (
-	"sync"
 	"time"
 
 	"github.com/MunifTanjim/stremthru/internal/kv"
 	"github.com/MunifTanjim/stremthru/internal/logger"
+	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
 )
 
 var log = logger.Scoped("worker")
 
 type IdQueue struct {
-	m            sync.Map
-	debounceTime time.Duration
-	transform    func(id string) string
-	disabled     bool
+	*worker_queue.WorkerQueue[string]
 }
 
-func (q *IdQueue) Queue(sid string) {
-	if q.disabled {
-		return
-	}
-	q.m.Swap(q.transform(sid), time.Now().Add(q.debounceTime))
+type IdQueueConfig struct {
+	Name         string
+	DebounceTime time.Duration
+	Transform    func(id string) string
+	Disabled     bool
 }
 
-func (q *IdQueue) delete(sid string) {
-	q.m.Delete(q.transform(sid))
+func NewIdQueue(conf *IdQueueConfig) IdQueue {
+	return IdQueue{
+		worker_queue.NewWorkerQueue(&worker_queue.WorkerQueueConfig[string]{
+			Name:         conf.Name,
+			DebounceTime: conf.DebounceTime,
+			GetKey: func(id string) string {
+				return id
+			},
+			Transform: func(id *string) *string {
+				tid := conf.Transform(*id)
+				return &tid
+			},
+			Disabled: conf.Disabled,
+		}),
+	}
 }
 
 type Error struct {
@@ -32,7 +32,7 @@ func InitMagnetCachePullerWorker(conf *WorkerConfig) *Worker {
 		onEnd:      conf.OnEnd,
 	}
 
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(5 * time.Minute),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -71,13 +71,14 @@ func InitMagnetCachePullerWorker(conf *WorkerConfig) *Worker {
 				for i := range items {
 					item := &items[i]
 					hashes[i] = item.Hash
-					if _, seen := seenClientIp[item.ClientIP]; !seen {
-						clientIps = append(clientIps, item.ClientIP)
-						seenClientIp[item.ClientIP] = struct{}{}
+					clientIp, storeToken := string(item.ClientIP), string(item.StoreToken)
+					if _, seen := seenClientIp[clientIp]; !seen {
+						clientIps = append(clientIps, clientIp)
+						seenClientIp[clientIp] = struct{}{}
 					}
-					if _, seen := seenStoreToken[item.StoreToken]; !seen {
-						storeTokens = append(storeTokens, item.StoreToken)
-						seenStoreToken[item.StoreToken] = struct{}{}
+					if _, seen := seenStoreToken[storeToken]; !seen {
+						storeTokens = append(storeTokens, storeToken)
+						seenStoreToken[storeToken] = struct{}{}
 					}
 				}
 
@@ -574,7 +574,7 @@ func InitMapAniDBTorrentWorker(conf *WorkerConfig) *Worker {
 	}
 
 	isRunning := false
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(30 * time.Minute),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
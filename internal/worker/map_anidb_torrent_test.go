@@ -1535,3 +1535,94 @@ func TestPrepareAniDBTorrentsFromTVDBEpisodeMaps(t *testing.T) {
 		}
 	}
 }
+
+func TestAniDBTVDBEpisodeMapsToAniDBEpisode(t *testing.T) {
+	toEpisodeMaps := func(xmlContent string) anidb.AniDBTVDBEpisodeMaps {
+		parsed := struct {
+			Items []animelists.AnimeListItem `xml:"anime"`
+		}{}
+		err := xml.Unmarshal([]byte("<anime-list>"+xmlContent+"</anime-list>"), &parsed)
+		if err != nil {
+			panic(err)
+		}
+		return animelists.PrepareAniDBTVDBEpisodeMaps(parsed.Items[0].TVDBId, parsed.Items)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		tvdbMaps anidb.AniDBTVDBEpisodeMaps
+		season   int
+		episode  int
+		anidbId  string
+		anidbEp  int
+	}{
+		{
+			"multiple anidb episodes for same tvdb episode",
+			toEpisodeMaps(`
+  <anime anidbid="9001" tvdbid="90001" defaulttvdbseason="1">
+    <name>Example</name>
+    <mapping-list>
+      <mapping anidbseason="1" tvdbseason="1">;3-2;4-2;1-1;2-1;</mapping>
+    </mapping-list>
+  </anime>
+			`),
+			1, 1,
+			"9001", 1,
+		},
+		{
+			"multiple anidb ids for same tvdb episode",
+			toEpisodeMaps(`
+  <anime anidbid="9003" tvdbid="90002" defaulttvdbseason="1">
+    <name>Example (Recap)</name>
+    <mapping-list>
+      <mapping anidbseason="1" tvdbseason="1">;1-5;</mapping>
+    </mapping-list>
+  </anime>
+
+  <anime anidbid="9002" tvdbid="90002" defaulttvdbseason="1">
+    <name>Example</name>
+    <mapping-list>
+      <mapping anidbseason="1" tvdbseason="1">;5-5;</mapping>
+    </mapping-list>
+  </anime>
+			`),
+			1, 5,
+			"9002", 5,
+		},
+		{
+			"offset",
+			toEpisodeMaps(`
+  <anime anidbid="3303" tvdbid="76906" defaulttvdbseason="a">
+    <name>Medarot</name>
+    <mapping-list>
+      <mapping anidbseason="1" tvdbseason="1" start="1" end="26" offset="0"/>
+      <mapping anidbseason="1" tvdbseason="2" start="27" end="52" offset="-26"/>
+    </mapping-list>
+  </anime>
+			`),
+			2, 1,
+			"3303", 27,
+		},
+		{
+			"missing",
+			toEpisodeMaps(`
+  <anime anidbid="9001" tvdbid="90001" defaulttvdbseason="1">
+    <name>Example</name>
+    <mapping-list>
+      <mapping anidbseason="1" tvdbseason="1" start="1" end="12" offset="0"/>
+    </mapping-list>
+  </anime>
+			`),
+			1, 13,
+			"", -1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for range 10 {
+				anidbId, anidbEp := tc.tvdbMaps.ToAniDBEpisode(tc.season, tc.episode)
+				assert.Equal(t, tc.anidbId, anidbId)
+				assert.Equal(t, tc.anidbEp, anidbEp)
+			}
+		})
+	}
+}
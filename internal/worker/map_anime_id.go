@@ -33,7 +33,7 @@ func InitMapAnimeIdWorker(conf *WorkerConfig) *Worker {
 	}
 
 	isRunning := false
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(5 * time.Minute),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -30,7 +30,7 @@ func InitMapIMDBTorrentWorker(conf *WorkerConfig) *Worker {
 	}
 
 	isRunning := false
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(30 * time.Minute),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -22,7 +22,7 @@ func InitCrawlStoreWorker(conf *WorkerConfig) *Worker {
 		onEnd:      conf.OnEnd,
 	}
 
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(30 * time.Minute),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -61,7 +61,7 @@ func InitCrawlStoreWorker(conf *WorkerConfig) *Worker {
 						Limit:  limit,
 						Offset: offset,
 					}
-					params.APIKey = item.StoreToken
+					params.APIKey = string(item.StoreToken)
 					res, err := s.ListMagnets(params)
 					if err != nil {
 						log.Error("failed to list magnets", "err", err)
@@ -49,7 +49,7 @@ func InitSyncAnimeAPIWorker(conf *WorkerConfig) *Worker {
 	}
 
 	jobId := ""
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(1 * 24 * time.Hour),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -239,7 +239,7 @@ func InitSyncDMMHashlistWorker(conf *WorkerConfig) *Worker {
 	}
 
 	jobId := ""
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(6 * time.Hour),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -26,22 +26,20 @@ func isIMDBSyncedToday() bool {
 	return job != nil && job.Status == "done"
 }
 
-func InitSyncIMDBWorker(conf *WorkerConfig) *Worker {
-	if !config.Feature.IsEnabled("imdb_title") {
-		return nil
-	}
-
-	log := logger.Scoped("worker/sync_imdb")
-
-	syncIMDBJobTracker = NewJobTracker("sync-imdb", func(id string, job *Job[struct{}]) bool {
+func newIMDBSyncJobTracker(name string) JobTracker[struct{}] {
+	return NewJobTracker(name, func(id string, job *Job[struct{}]) bool {
 		date, err := time.Parse(time.DateOnly, id)
 		if err != nil {
 			return true
 		}
 		return date.Before(time.Now().Add(-7 * 24 * time.Hour))
 	})
+}
 
-	jobTracker := syncIMDBJobTracker
+// initSyncIMDBDatasetWorker runs the dataset sync once a day, starting after
+// initialDelay.
+func initSyncIMDBDatasetWorker(conf *WorkerConfig, name string, jobTracker JobTracker[struct{}], initialDelay time.Duration, syncDataset func() error) *Worker {
+	log := logger.Scoped("worker/" + name)
 
 	worker := &Worker{
 		scheduler:  tasks.New(),
@@ -51,7 +49,7 @@ func InitSyncIMDBWorker(conf *WorkerConfig) *Worker {
 	}
 
 	jobId := ""
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(24 * time.Hour),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -97,7 +95,7 @@ func InitSyncIMDBWorker(conf *WorkerConfig) *Worker {
 				return err
 			}
 
-			if err := imdb_title.SyncDataset(); err != nil {
+			if err := syncDataset(); err != nil {
 				return err
 			}
 
@@ -130,10 +128,20 @@ func InitSyncIMDBWorker(conf *WorkerConfig) *Worker {
 
 	if task, err := worker.scheduler.Lookup(id); err == nil && task != nil {
 		t := task.Clone()
-		t.Interval = 30 * time.Second
+		t.Interval = initialDelay
 		t.RunOnce = true
 		worker.scheduler.Add(t)
 	}
 
 	return worker
 }
+
+func InitSyncIMDBWorker(conf *WorkerConfig) *Worker {
+	if !config.Feature.IsEnabled("imdb_title") {
+		return nil
+	}
+
+	syncIMDBJobTracker = newIMDBSyncJobTracker("sync-imdb")
+
+	return initSyncIMDBDatasetWorker(conf, "sync_imdb", syncIMDBJobTracker, 30*time.Second, imdb_title.SyncDataset)
+}
@@ -0,0 +1,16 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/imdb_title"
+)
+
+func InitSyncIMDBRatingWorker(conf *WorkerConfig) *Worker {
+	if !config.Feature.IsEnabled(config.FeatureIMDBTitle) {
+		return nil
+	}
+
+	return initSyncIMDBDatasetWorker(conf, "sync_imdb_rating", newIMDBSyncJobTracker("sync-imdb-rating"), 45*time.Second, imdb_title.SyncRatingDataset)
+}
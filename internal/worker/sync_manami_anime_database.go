@@ -49,7 +49,7 @@ func InitSyncManamiAnimeDatabaseWorker(conf *WorkerConfig) *Worker {
 	}
 
 	jobId := ""
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(6 * 24 * time.Hour),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/kv"
+	"github.com/MunifTanjim/stremthru/internal/logger"
+	"github.com/MunifTanjim/stremthru/internal/torrent_info"
+	"github.com/MunifTanjim/stremthru/internal/torrent_meta"
+	"github.com/MunifTanjim/stremthru/internal/torrent_stream"
+	"github.com/MunifTanjim/stremthru/internal/util"
+	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
+	"github.com/madflojo/tasks"
+)
+
+var errTorrentMetaNotFound = errors.New("torrent metadata not found")
+
+func InitFetchTorrentMetaWorker(conf *WorkerConfig) *Worker {
+	if worker_queue.TorrentMetaFetcherQueue.Disabled {
+		return nil
+	}
+
+	log := logger.Scoped("worker/torrent_meta_fetcher")
+
+	// hashes are queued in batches, resuming after the last queued hash
+	cursorStore := kv.NewKVStore[string](&kv.KVStoreConfig{
+		Type: "worker:torrent_meta_fetcher",
+	})
+
+	queueHashesWithMissingFiles := func() error {
+		cursor := ""
+		if err := cursorStore.Get("cursor", &cursor); err != nil {
+			return err
+		}
+		hashes, err := torrent_info.GetHashesWithMissingFiles(cursor, 500)
+		if err != nil {
+			return err
+		}
+		items := make([]worker_queue.TorrentMetaFetcherQueueItem, len(hashes))
+		for i, hash := range hashes {
+			items[i] = worker_queue.TorrentMetaFetcherQueueItem{Hash: hash}
+		}
+		worker_queue.TorrentMetaFetcherQueue.QueueMany(items)
+		log.Info("queued hashes with missing files", "count", len(hashes))
+
+		cursor = ""
+		if len(hashes) == 500 {
+			cursor = hashes[len(hashes)-1]
+		}
+		return cursorStore.Set("cursor", cursor)
+	}
+
+	worker := &Worker{
+		scheduler:  tasks.New(),
+		shouldWait: conf.ShouldWait,
+		onStart:    conf.OnStart,
+		onEnd:      conf.OnEnd,
+	}
+
+	id, err := worker.addTask(log, &tasks.Task{
+		Interval:          time.Duration(15 * time.Minute),
+		RunSingleInstance: true,
+		TaskFunc: func() (err error) {
+			defer func() {
+				if perr, stack := util.HandlePanic(recover(), true); perr != nil {
+					err = perr
+					log.Error("Worker Panic", "error", err, "stack", stack)
+				}
+				worker.onEnd()
+			}()
+
+			for {
+				wait, reason := worker.shouldWait()
+				if !wait {
+					break
+				}
+				log.Info("waiting, " + reason)
+				time.Sleep(5 * time.Minute)
+			}
+			worker.onStart()
+
+			if err := queueHashesWithMissingFiles(); err != nil {
+				log.Error("failed to queue hashes with missing files", "error", err)
+			}
+
+			worker_queue.TorrentMetaFetcherQueue.Process(func(item worker_queue.TorrentMetaFetcherQueueItem) error {
+				info, err := torrent_meta.Fetch(item.Hash)
+				if err != nil {
+					return err
+				}
+				if info == nil {
+					return errTorrentMetaNotFound
+				}
+
+				files := info.ToFiles()
+				torrent_stream.TrackFiles(map[string]torrent_stream.Files{item.Hash: files}, false)
+				if err := torrent_info.SetMissingSize(item.Hash, info.Size()); err != nil {
+					return err
+				}
+				log.Debug("filled files from torrent metadata", "hash", item.Hash, "file_count", len(files))
+				return nil
+			})
+
+			return nil
+		},
+		ErrFunc: func(err error) {
+			log.Error("Worker Failure", "error", err)
+		},
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	log.Info("Started Worker", "id", id)
+
+	return worker
+}
@@ -39,7 +39,7 @@ func InitParseTorrentWorker(conf *WorkerConfig) *Worker {
 		onEnd:      conf.OnEnd,
 	}
 
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(5 * time.Minute),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -14,14 +14,15 @@ import (
 	"github.com/madflojo/tasks"
 )
 
-var TorrentPusherQueue = IdQueue{
-	debounceTime: 5 * time.Minute,
-	transform: func(sid string) string {
+var TorrentPusherQueue = NewIdQueue(&IdQueueConfig{
+	Name:         "torrent_pusher",
+	DebounceTime: 5 * time.Minute,
+	Transform: func(sid string) string {
 		sid, _, _ = strings.Cut(sid, ":")
 		return sid
 	},
-	disabled: !config.HasPeer || config.PeerAuthToken == "",
-}
+	Disabled: !config.HasPeer || config.PeerAuthToken == "",
+})
 
 var Peer = peer.NewAPIClient(&peer.APIClientConfig{
 	BaseURL: config.PeerURL,
@@ -29,7 +30,7 @@ var Peer = peer.NewAPIClient(&peer.APIClientConfig{
 })
 
 func InitPushTorrentsWorker(conf *WorkerConfig) *Worker {
-	if TorrentPusherQueue.disabled {
+	if TorrentPusherQueue.Disabled {
 		return nil
 	}
 
@@ -42,7 +43,7 @@ func InitPushTorrentsWorker(conf *WorkerConfig) *Worker {
 		onEnd:      conf.OnEnd,
 	}
 
-	id, err := worker.scheduler.Add(&tasks.Task{
+	id, err := worker.addTask(log, &tasks.Task{
 		Interval:          time.Duration(10 * time.Minute),
 		RunSingleInstance: true,
 		TaskFunc: func() (err error) {
@@ -64,31 +65,28 @@ func InitPushTorrentsWorker(conf *WorkerConfig) *Worker {
 			}
 			worker.onStart()
 
-			TorrentPusherQueue.m.Range(func(k, v any) bool {
-				sid, sidOk := k.(string)
-				t, tOk := v.(time.Time)
-				if sidOk && tOk && t.Before(time.Now()) {
-					if tss.ShouldPush(sid) {
-						if data, err := torrent_info.ListByStremId(sid, false); err == nil {
-							params := &peer.PushTorrentsParams{
-								Items: data.Items,
-							}
-							start := time.Now()
-							if _, err := Peer.PushTorrents(params); err != nil {
-								log.Error("failed to push torrents", "error", core.PackError(err), "duration", time.Since(start), "count", data.TotalItems)
-							} else {
-								log.Info("pushed torrents", "duration", time.Since(start), "count", data.TotalItems)
-								tss.MarkPushed(sid)
-							}
-						} else {
-							log.Error("failed to list torrents", "error", core.PackError(err), "sid", sid)
-						}
-
-					}
-
-					TorrentPusherQueue.delete(sid)
+			TorrentPusherQueue.Process(func(sid string) error {
+				if !tss.ShouldPush(sid) {
+					return nil
+				}
+
+				data, err := torrent_info.ListByStremId(sid, false)
+				if err != nil {
+					log.Error("failed to list torrents", "error", core.PackError(err), "sid", sid)
+					return err
+				}
+
+				params := &peer.PushTorrentsParams{
+					Items: data.Items,
+				}
+				start := time.Now()
+				if _, err := Peer.PushTorrents(params); err != nil {
+					log.Error("failed to push torrents", "error", core.PackError(err), "duration", time.Since(start), "count", data.TotalItems)
+					return err
 				}
-				return true
+				log.Info("pushed torrents", "duration", time.Since(start), "count", data.TotalItems)
+				tss.MarkPushed(sid)
+				return nil
 			})
 
 			return nil
@@ -1,8 +1,10 @@
 package worker
 
 import (
+	"log/slog"
 	"sync"
 
+	"github.com/MunifTanjim/stremthru/internal/lock"
 	"github.com/madflojo/tasks"
 )
 
@@ -15,6 +17,16 @@ var running_worker struct {
 	sync_animeapi               bool
 	sync_anidb_tvdb_episode_map bool
 	sync_manami_anime_database  bool
+	sync_imdb_episode           bool
+	sync_imdb_rating            bool
+}
+
+var leader *lock.Leader
+
+// When multiple instances share the same database, workers run only on
+// the leader.
+func isLeader() bool {
+	return leader == nil || leader.IsLeader()
 }
 
 type Worker struct {
@@ -24,6 +36,20 @@ type Worker struct {
 	onEnd      func()
 }
 
+// addTask schedules the task, which is skipped when not running on the
+// leader.
+func (w *Worker) addTask(log *slog.Logger, task *tasks.Task) (string, error) {
+	taskFunc := task.TaskFunc
+	task.TaskFunc = func() error {
+		if !isLeader() {
+			log.Debug("skipped, not leader")
+			return nil
+		}
+		return taskFunc()
+	}
+	return w.scheduler.Add(task)
+}
+
 type WorkerConfig struct {
 	ShouldWait func() (bool, string)
 	OnStart    func()
@@ -31,6 +57,8 @@ type WorkerConfig struct {
 }
 
 func InitWorkers() func() {
+	leader = lock.NewLeader("worker")
+
 	workers := []*Worker{}
 
 	if worker := InitParseTorrentWorker(&WorkerConfig{
@@ -106,6 +134,58 @@ func InitWorkers() func() {
 		workers = append(workers, worker)
 	}
 
+	if worker := InitSyncIMDBEpisodeWorker(&WorkerConfig{
+		ShouldWait: func() (bool, string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if running_worker.sync_imdb {
+				return true, "sync_imdb is running"
+			}
+			return false, ""
+		},
+		OnStart: func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			running_worker.sync_imdb_episode = true
+		},
+		OnEnd: func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			running_worker.sync_imdb_episode = false
+		},
+	}); worker != nil {
+		workers = append(workers, worker)
+	}
+
+	if worker := InitSyncIMDBRatingWorker(&WorkerConfig{
+		ShouldWait: func() (bool, string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if running_worker.sync_imdb {
+				return true, "sync_imdb is running"
+			}
+			return false, ""
+		},
+		OnStart: func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			running_worker.sync_imdb_rating = true
+		},
+		OnEnd: func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			running_worker.sync_imdb_rating = false
+		},
+	}); worker != nil {
+		workers = append(workers, worker)
+	}
+
 	if worker := InitSyncDMMHashlistWorker(&WorkerConfig{
 		ShouldWait: func() (bool, string) {
 			mutex.Lock()
@@ -171,6 +251,16 @@ func InitWorkers() func() {
 		workers = append(workers, worker)
 	}
 
+	if worker := InitFetchTorrentMetaWorker(&WorkerConfig{
+		ShouldWait: func() (bool, string) {
+			return false, ""
+		},
+		OnStart: func() {},
+		OnEnd:   func() {},
+	}); worker != nil {
+		workers = append(workers, worker)
+	}
+
 	if worker := InitMapAnimeIdWorker(&WorkerConfig{
 		ShouldWait: func() (bool, string) {
 			return false, ""
@@ -319,5 +409,6 @@ func InitWorkers() func() {
 		for _, worker := range workers {
 			worker.scheduler.Stop()
 		}
+		leader.Stop()
 	}
 }
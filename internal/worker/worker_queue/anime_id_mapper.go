@@ -11,16 +11,17 @@ type AnimeIdMapperQueueItem struct {
 	Id      string
 }
 
-var AnimeIdMapperQueue = WorkerQueue[AnimeIdMapperQueueItem]{
-	debounceTime: 1 * time.Minute,
-	getKey: func(item AnimeIdMapperQueueItem) string {
+var AnimeIdMapperQueue = NewWorkerQueue(&WorkerQueueConfig[AnimeIdMapperQueueItem]{
+	Name:         "anime_id_mapper",
+	DebounceTime: 1 * time.Minute,
+	GetKey: func(item AnimeIdMapperQueueItem) string {
 		return item.Service + ":" + item.Id
 	},
-	getGroupKey: func(item AnimeIdMapperQueueItem) string {
+	GetGroupKey: func(item AnimeIdMapperQueueItem) string {
 		return item.Service
 	},
-	transform: func(item *AnimeIdMapperQueueItem) *AnimeIdMapperQueueItem {
+	Transform: func(item *AnimeIdMapperQueueItem) *AnimeIdMapperQueueItem {
 		return item
 	},
 	Disabled: !config.Feature.IsEnabled("anime"),
-}
+})
@@ -0,0 +1,478 @@
+package worker_queue
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/util"
+)
+
+const TableName = "worker_queue_item"
+
+type ColumnStruct struct {
+	Queue       string
+	Key         string
+	GroupKey    string
+	Payload     string
+	ProcessAt   string
+	Status      string
+	Attempts    string
+	Error       string
+	LockedUntil string
+	CreatedAt   string
+	UpdatedAt   string
+}
+
+var Column = ColumnStruct{
+	Queue:       "queue",
+	Key:         "item_key",
+	GroupKey:    "group_key",
+	Payload:     "payload",
+	ProcessAt:   "process_at",
+	Status:      "status",
+	Attempts:    "attempts",
+	Error:       "error",
+	LockedUntil: "locked_until",
+	CreatedAt:   "cat",
+	UpdatedAt:   "uat",
+}
+
+type QueueItemStatus string
+
+const (
+	QueueItemStatusPending QueueItemStatus = "pending"
+	QueueItemStatusDead    QueueItemStatus = "dead"
+)
+
+type queueItem struct {
+	Key       string
+	GroupKey  string
+	Payload   string
+	ProcessAt db.Timestamp
+	Attempts  int
+}
+
+var query_upsert_before_values = fmt.Sprintf(
+	`INSERT INTO %s (%s) VALUES `,
+	TableName,
+	strings.Join([]string{
+		Column.Queue,
+		Column.Key,
+		Column.GroupKey,
+		Column.Payload,
+		Column.ProcessAt,
+	}, ","),
+)
+var query_upsert_values_placeholder = "(" + util.RepeatJoin("?", 5, ",") + ")"
+
+// Queuing an item again pushes it back by the debounce time, but never
+// earlier than its current backoff. Dead items are left alone.
+var query_upsert_after_values = fmt.Sprintf(
+	` ON CONFLICT (%s, %s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s, %s = CASE WHEN %s.%s > EXCLUDED.%s THEN %s.%s ELSE EXCLUDED.%s END, %s = %s WHERE %s.%s != '%s'`,
+	Column.Queue,
+	Column.Key,
+	Column.GroupKey,
+	Column.GroupKey,
+	Column.Payload,
+	Column.Payload,
+	Column.ProcessAt,
+	TableName,
+	Column.ProcessAt,
+	Column.ProcessAt,
+	TableName,
+	Column.ProcessAt,
+	Column.ProcessAt,
+	Column.UpdatedAt,
+	db.CurrentTimestamp,
+	TableName,
+	Column.Status,
+	QueueItemStatusDead,
+)
+
+func upsertItems(queue string, items []queueItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	for cItems := range slices.Chunk(items, 200) {
+		query := query_upsert_before_values +
+			util.RepeatJoin(query_upsert_values_placeholder, len(cItems), ",") +
+			query_upsert_after_values
+		args := make([]any, 0, len(cItems)*5)
+		for i := range cItems {
+			item := &cItems[i]
+			args = append(args, queue, item.Key, item.GroupKey, item.Payload, item.ProcessAt)
+		}
+		if _, err := db.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var query_claim_due_cond = fmt.Sprintf(
+	`%s = ? AND %s = '%s' AND %s <= ? AND (%s IS NULL OR %s <= ?)`,
+	Column.Queue,
+	Column.Status,
+	QueueItemStatusPending,
+	Column.ProcessAt,
+	Column.LockedUntil,
+	Column.LockedUntil,
+)
+
+var query_claim_due = fmt.Sprintf(
+	`UPDATE %s SET %s = ? WHERE %s AND %s IN (SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT ?%s) RETURNING %s`,
+	TableName,
+	Column.LockedUntil,
+	query_claim_due_cond,
+	Column.Key,
+	Column.Key,
+	TableName,
+	query_claim_due_cond,
+	Column.ProcessAt,
+	func() string {
+		if db.Dialect == db.DBDialectPostgres {
+			return " FOR UPDATE SKIP LOCKED"
+		}
+		return ""
+	}(),
+	strings.Join([]string{
+		Column.Key,
+		Column.GroupKey,
+		Column.Payload,
+		Column.ProcessAt,
+		Column.Attempts,
+	}, ","),
+)
+
+// Claimed items are hidden from others until the visibility timeout, after
+// which they are picked up again if they were neither done nor failed, e.g.
+// the process crashed. At most limit items are claimed, earliest first.
+func claimDueItems(queue string, visibilityTimeout time.Duration, limit int) ([]queueItem, error) {
+	now := db.Timestamp{Time: time.Now()}
+	rows, err := db.Query(query_claim_due, db.Timestamp{Time: now.Add(visibilityTimeout)}, queue, now, now, queue, now, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []queueItem{}
+	for rows.Next() {
+		item := queueItem{}
+		if err := rows.Scan(&item.Key, &item.GroupKey, &item.Payload, &item.ProcessAt, &item.Attempts); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(items, func(a, b queueItem) int {
+		return a.ProcessAt.Compare(b.ProcessAt.Time)
+	})
+
+	return items, nil
+}
+
+var query_delete = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ? AND %s = ? AND %s = ?`,
+	TableName,
+	Column.Queue,
+	Column.Key,
+	Column.ProcessAt,
+)
+
+var query_unlock = fmt.Sprintf(
+	`UPDATE %s SET %s = NULL, %s = 0, %s = '' WHERE %s = ? AND %s = ?`,
+	TableName,
+	Column.LockedUntil,
+	Column.Attempts,
+	Column.Error,
+	Column.Queue,
+	Column.Key,
+)
+
+// Items queued again while being processed have a newer process_at, and are
+// only unlocked.
+func completeItem(queue string, item *queueItem) error {
+	result, err := db.Exec(query_delete, queue, item.Key, item.ProcessAt)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err != nil || count > 0 {
+		return err
+	}
+	_, err = db.Exec(query_unlock, queue, item.Key)
+	return err
+}
+
+var query_fail = fmt.Sprintf(
+	`UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = CASE WHEN %s > ? THEN %s ELSE ? END, %s = NULL, %s = %s WHERE %s = ? AND %s = ?`,
+	TableName,
+	Column.Status,
+	Column.Attempts,
+	Column.Error,
+	Column.ProcessAt,
+	Column.ProcessAt,
+	Column.ProcessAt,
+	Column.LockedUntil,
+	Column.UpdatedAt,
+	db.CurrentTimestamp,
+	Column.Queue,
+	Column.Key,
+)
+
+func failItem(queue string, item *queueItem, cause error, status QueueItemStatus, retryAt time.Time) error {
+	retryAtTs := db.Timestamp{Time: retryAt}
+	_, err := db.Exec(query_fail, status, item.Attempts+1, cause.Error(), retryAtTs, retryAtTs, queue, item.Key)
+	return err
+}
+
+var query_purge_dead = fmt.Sprintf(
+	`DELETE FROM %s WHERE %s = ? AND %s = '%s' AND %s < ?`,
+	TableName,
+	Column.Queue,
+	Column.Status,
+	QueueItemStatusDead,
+	Column.UpdatedAt,
+)
+
+func purgeDeadItems(queue string, olderThan time.Duration) error {
+	_, err := db.Exec(query_purge_dead, queue, db.Timestamp{Time: time.Now().Add(-olderThan)})
+	return err
+}
+
+type QueueStats struct {
+	Queue      string `json:"queue"`
+	Pending    int    `json:"pending"`
+	Processing int    `json:"processing"`
+	Retrying   int    `json:"retrying"`
+	Dead       int    `json:"dead"`
+}
+
+var query_get_stats = fmt.Sprintf(
+	`SELECT %s, %s, CASE WHEN %s > ? THEN 1 ELSE 0 END, CASE WHEN %s > 0 THEN 1 ELSE 0 END, COUNT(*) FROM %s GROUP BY 1, 2, 3, 4`,
+	Column.Queue,
+	Column.Status,
+	Column.LockedUntil,
+	Column.Attempts,
+	TableName,
+)
+
+func GetStats() ([]QueueStats, error) {
+	rows, err := db.Query(query_get_stats, db.Timestamp{Time: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statsByQueue := map[string]*QueueStats{}
+	for rows.Next() {
+		var queue, status string
+		var isLocked, isRetrying, count int
+		if err := rows.Scan(&queue, &status, &isLocked, &isRetrying, &count); err != nil {
+			return nil, err
+		}
+		stats, ok := statsByQueue[queue]
+		if !ok {
+			stats = &QueueStats{Queue: queue}
+			statsByQueue[queue] = stats
+		}
+		switch {
+		case status == string(QueueItemStatusDead):
+			stats.Dead += count
+		case isLocked == 1:
+			stats.Processing += count
+		case isRetrying == 1:
+			stats.Retrying += count
+		default:
+			stats.Pending += count
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]QueueStats, 0, len(statsByQueue))
+	for _, stats := range statsByQueue {
+		result = append(result, *stats)
+	}
+	slices.SortFunc(result, func(a, b QueueStats) int {
+		return strings.Compare(a.Queue, b.Queue)
+	})
+	return result, nil
+}
+
+type QueueItem struct {
+	Key         string          `json:"key"`
+	GroupKey    string          `json:"group_key"`
+	Status      QueueItemStatus `json:"status"`
+	Attempts    int             `json:"attempts"`
+	Error       string          `json:"error"`
+	ProcessAt   db.Timestamp    `json:"process_at"`
+	LockedUntil *db.Timestamp   `json:"locked_until,omitempty"`
+	CreatedAt   db.Timestamp    `json:"created_at"`
+	UpdatedAt   db.Timestamp    `json:"updated_at"`
+}
+
+var query_list_items = fmt.Sprintf(
+	`SELECT %s FROM %s WHERE %s = ?`,
+	strings.Join([]string{
+		Column.Key,
+		Column.GroupKey,
+		Column.Status,
+		Column.Attempts,
+		Column.Error,
+		Column.ProcessAt,
+		Column.LockedUntil,
+		Column.CreatedAt,
+		Column.UpdatedAt,
+	}, ","),
+	TableName,
+	Column.Queue,
+)
+var query_list_items_cond_status = fmt.Sprintf(
+	` AND %s = ?`,
+	Column.Status,
+)
+var query_list_items_order_limit = fmt.Sprintf(
+	` ORDER BY %s LIMIT ? OFFSET ?`,
+	Column.ProcessAt,
+)
+
+func ListItems(queue string, status QueueItemStatus, limit, offset int) ([]QueueItem, error) {
+	query := query_list_items
+	args := []any{queue}
+	if status != "" {
+		query += query_list_items_cond_status
+		args = append(args, status)
+	}
+	query += query_list_items_order_limit
+	args = append(args, limit, offset)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []QueueItem{}
+	for rows.Next() {
+		item := QueueItem{}
+		if err := rows.Scan(
+			&item.Key,
+			&item.GroupKey,
+			&item.Status,
+			&item.Attempts,
+			&item.Error,
+			&item.ProcessAt,
+			&item.LockedUntil,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+var query_retry_dead = fmt.Sprintf(
+	`UPDATE %s SET %s = '%s', %s = 0, %s = ?, %s = %s WHERE %s = ? AND %s = '%s'`,
+	TableName,
+	Column.Status,
+	QueueItemStatusPending,
+	Column.Attempts,
+	Column.ProcessAt,
+	Column.UpdatedAt,
+	db.CurrentTimestamp,
+	Column.Queue,
+	Column.Status,
+	QueueItemStatusDead,
+)
+
+// RetryDeadItems moves the dead items of the queue back to pending, and
+// returns the number of items moved.
+func RetryDeadItems(queue string) (int64, error) {
+	result, err := db.Exec(query_retry_dead, db.Timestamp{Time: time.Now()}, queue)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+var query_list_payloads = fmt.Sprintf(
+	`SELECT %s, %s, %s FROM %s`,
+	Column.Queue,
+	Column.Key,
+	Column.Payload,
+	TableName,
+)
+
+var query_update_payload = fmt.Sprintf(
+	`UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?`,
+	TableName,
+	Column.Payload,
+	Column.Queue,
+	Column.Key,
+)
+
+// EncryptAll encrypts the secrets in the payloads with the active key, for
+// the items queued before rotating the key. It returns the count of the
+// updated items.
+func EncryptAll() (int, error) {
+	if !config.EncryptionKey.IsEnabled() {
+		return 0, nil
+	}
+
+	rows, err := db.Query(query_list_payloads)
+	if err != nil {
+		return 0, err
+	}
+	type payloadItem struct {
+		queue   string
+		key     string
+		payload string
+	}
+	items := []payloadItem{}
+	for rows.Next() {
+		item := payloadItem{}
+		if err := rows.Scan(&item.queue, &item.key, &item.payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, item := range items {
+		payload, changed, err := resealPayload(config.EncryptionKey, item.payload)
+		if err != nil {
+			return count, fmt.Errorf("failed to encrypt %s/%s: %w", item.queue, item.key, err)
+		}
+		if !changed {
+			continue
+		}
+		if _, err := db.Exec(query_update_payload, payload, item.queue, item.key); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
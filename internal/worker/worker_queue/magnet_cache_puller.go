@@ -7,23 +7,24 @@ import (
 )
 
 type MagnetCachePullerQueueItem struct {
-	ClientIP   string
+	ClientIP   Secret
 	Hash       string
 	SId        string
 	StoreCode  string
-	StoreToken string
+	StoreToken Secret
 }
 
-var MagnetCachePullerQueue = WorkerQueue[MagnetCachePullerQueueItem]{
-	debounceTime: 5 * time.Minute,
-	getKey: func(item MagnetCachePullerQueueItem) string {
+var MagnetCachePullerQueue = NewWorkerQueue(&WorkerQueueConfig[MagnetCachePullerQueueItem]{
+	Name:         "magnet_cache_puller",
+	DebounceTime: 5 * time.Minute,
+	GetKey: func(item MagnetCachePullerQueueItem) string {
 		return item.StoreCode + ":" + item.SId + ":" + item.Hash
 	},
-	getGroupKey: func(item MagnetCachePullerQueueItem) string {
+	GetGroupKey: func(item MagnetCachePullerQueueItem) string {
 		return item.StoreCode + ":" + item.SId
 	},
-	transform: func(item *MagnetCachePullerQueueItem) *MagnetCachePullerQueueItem {
+	Transform: func(item *MagnetCachePullerQueueItem) *MagnetCachePullerQueueItem {
 		return item
 	},
 	Disabled: !config.LazyPeer,
-}
+})
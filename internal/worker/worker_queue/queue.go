@@ -1,75 +1,286 @@
 package worker_queue
 
 import (
+	"encoding/json"
+	"errors"
 	"sync"
 	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/db"
 )
 
-type WorkerQueueItem[T any] struct {
-	v T
-	t time.Time
+// WorkerQueue keeps the pending items in the database, so that they
+// survive restarts and are shared by every instance using the same
+// database.
+//
+// Failed items are retried with exponential backoff, and moved to the dead
+// state after MaxAttempts. Items being processed are hidden until the
+// visibility timeout, so that items of a crashed process are picked up
+// again later.
+type WorkerQueue[T any] struct {
+	name              string
+	getKey            func(item T) string
+	getGroupKey       func(item T) string
+	transform         func(item *T) *T
+	debounceTime      time.Duration
+	maxAttempts       int
+	retryBackoff      time.Duration
+	maxRetryBackoff   time.Duration
+	visibilityTimeout time.Duration
+	deadRetention     time.Duration
+	claimLimit        int
+	concurrency       int
+	Disabled          bool
+
+	bufferMu       sync.Mutex
+	buffer         []queueItem
+	bufferIdxByKey map[string]int
 }
 
-type WorkerQueue[T any] struct {
-	m            sync.Map
-	getKey       func(item T) string
-	getGroupKey  func(item T) string
-	transform    func(item *T) *T
-	debounceTime time.Duration
-	Disabled     bool
+const (
+	bufferFlushDelay = 1 * time.Second
+	bufferFlushSize  = 1000
+)
+
+var errUndecodable = errors.New("failed to decode item")
+
+func (q *WorkerQueue[T]) toQueueItem(item T, processAt time.Time) (*queueItem, error) {
+	item = *q.transform(&item)
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	qItem := &queueItem{
+		Key:       q.getKey(item),
+		Payload:   string(payload),
+		ProcessAt: db.Timestamp{Time: processAt},
+	}
+	if q.getGroupKey != nil {
+		qItem.GroupKey = q.getGroupKey(item)
+	}
+	return qItem, nil
 }
 
 func (q *WorkerQueue[T]) Queue(item T) {
-	if q.Disabled {
+	q.QueueMany([]T{item})
+}
+
+// QueueMany buffers the items in memory, and saves them in background, so
+// that it does not block the caller (e.g. request handlers). Items with the
+// same key are merged, last one wins.
+func (q *WorkerQueue[T]) QueueMany(items []T) {
+	if q.Disabled || len(items) == 0 {
 		return
 	}
-	item = *q.transform(&item)
-	q.m.Swap(q.getKey(item), WorkerQueueItem[T]{
-		v: item,
-		t: time.Now().Add(q.debounceTime),
-	})
+	processAt := time.Now().Add(q.debounceTime)
+
+	q.bufferMu.Lock()
+	defer q.bufferMu.Unlock()
+
+	wasEmpty := len(q.buffer) == 0
+	for i := range items {
+		qItem, err := q.toQueueItem(items[i], processAt)
+		if err != nil {
+			log.Error("WorkerQueue failed to encode item", "error", err, "queue", q.name)
+			continue
+		}
+		if idx, seen := q.bufferIdxByKey[qItem.Key]; seen {
+			q.buffer[idx] = *qItem
+			continue
+		}
+		q.bufferIdxByKey[qItem.Key] = len(q.buffer)
+		q.buffer = append(q.buffer, *qItem)
+	}
+	if len(q.buffer) >= bufferFlushSize {
+		go q.flush()
+	} else if wasEmpty && len(q.buffer) > 0 {
+		time.AfterFunc(bufferFlushDelay, q.flush)
+	}
+}
+
+// flush saves the buffered items.
+func (q *WorkerQueue[T]) flush() {
+	q.bufferMu.Lock()
+	qItems := q.buffer
+	q.buffer = nil
+	clear(q.bufferIdxByKey)
+	q.bufferMu.Unlock()
+
+	if err := upsertItems(q.name, qItems); err != nil {
+		log.Error("WorkerQueue failed to queue", "error", err, "queue", q.name, "count", len(qItems))
+	}
 }
 
-func (q *WorkerQueue[T]) delete(item T) {
-	q.m.Delete(q.getKey(item))
+func (q *WorkerQueue[T]) complete(item *queueItem) {
+	if err := completeItem(q.name, item); err != nil {
+		log.Error("WorkerQueue failed to complete", "error", err, "queue", q.name, "key", item.Key)
+	}
+}
+
+func (q *WorkerQueue[T]) getRetryBackoff(attempts int) time.Duration {
+	backoff := q.retryBackoff
+	for i := 1; i < attempts && backoff < q.maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, q.maxRetryBackoff)
+}
+
+func (q *WorkerQueue[T]) fail(item *queueItem, cause error) {
+	attempts := item.Attempts + 1
+	status := QueueItemStatusPending
+	retryAt := time.Now().Add(q.getRetryBackoff(attempts))
+	if attempts >= q.maxAttempts || errors.Is(cause, errUndecodable) {
+		status = QueueItemStatusDead
+		log.Warn("WorkerQueue item is dead", "error", cause, "queue", q.name, "key", item.Key, "attempts", attempts)
+	} else {
+		log.Error("WorkerQueue process failed", "error", cause, "queue", q.name, "key", item.Key, "attempts", attempts, "retry_at", retryAt)
+	}
+	if err := failItem(q.name, item, cause, status, retryAt); err != nil {
+		log.Error("WorkerQueue failed to record failure", "error", err, "queue", q.name, "key", item.Key)
+	}
+}
+
+func (q *WorkerQueue[T]) claimDue() []queueItem {
+	q.flush()
+	if err := purgeDeadItems(q.name, q.deadRetention); err != nil {
+		log.Warn("WorkerQueue failed to purge dead items", "error", err, "queue", q.name)
+	}
+	items, err := claimDueItems(q.name, q.visibilityTimeout, q.claimLimit)
+	if err != nil {
+		log.Error("WorkerQueue failed to claim", "error", err, "queue", q.name)
+		return nil
+	}
+	return items
+}
+
+func (q *WorkerQueue[T]) decode(item *queueItem) (T, bool) {
+	var val T
+	if err := json.Unmarshal([]byte(item.Payload), &val); err != nil {
+		if errors.Is(err, errMissingSecret) {
+			// retried, the instance holding the secret may process it
+			q.fail(item, err)
+		} else {
+			q.fail(item, errors.Join(errUndecodable, err))
+		}
+		return val, false
+	}
+	return val, true
 }
 
 func (q *WorkerQueue[T]) Process(f func(item T) error) {
-	q.m.Range(func(k, v any) bool {
-		_, keyOk := k.(string)
-		val, valOk := v.(WorkerQueueItem[T])
-		if keyOk && valOk && val.t.Before(time.Now()) {
-			if err := f(val.v); err != nil {
-				log.Error("WorkerQueue process failed", "error", err, "key", q.getKey(val.v))
+	items := q.claimDue()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, q.concurrency)
+	for i := range items {
+		item := &items[i]
+		val, ok := q.decode(item)
+		if !ok {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := f(val); err != nil {
+				q.fail(item, err)
 			} else {
-				q.delete(val.v)
+				q.complete(item)
 			}
-		}
-		return true
-	})
+		}()
+	}
+	wg.Wait()
 }
 
 func (q *WorkerQueue[T]) ProcessGroup(f func(groupKey string, items []T) error) {
 	byGroupKey := map[string][]T{}
-	q.m.Range(func(k, v any) bool {
-		_, keyOk := k.(string)
-		val, valOk := v.(WorkerQueueItem[T])
-		if keyOk && valOk && val.t.Before(time.Now()) {
-			groupKey := q.getGroupKey(val.v)
-			if _, ok := byGroupKey[groupKey]; !ok {
-				byGroupKey[groupKey] = []T{}
-			}
-			byGroupKey[groupKey] = append(byGroupKey[groupKey], val.v)
+	qItemsByGroupKey := map[string][]*queueItem{}
+	items := q.claimDue()
+	for i := range items {
+		item := &items[i]
+		val, ok := q.decode(item)
+		if !ok {
+			continue
 		}
-		return true
-	})
+		byGroupKey[item.GroupKey] = append(byGroupKey[item.GroupKey], val)
+		qItemsByGroupKey[item.GroupKey] = append(qItemsByGroupKey[item.GroupKey], item)
+	}
 	for groupKey, items := range byGroupKey {
 		if err := f(groupKey, items); err != nil {
-			log.Error("WorkerQueue processGroup failed", "error", err, "group_key", groupKey)
+			for _, item := range qItemsByGroupKey[groupKey] {
+				q.fail(item, err)
+			}
 		} else {
-			for i := range items {
-				q.delete(items[i])
+			for _, item := range qItemsByGroupKey[groupKey] {
+				q.complete(item)
 			}
 		}
 	}
 }
+
+type WorkerQueueConfig[T any] struct {
+	Name              string
+	GetKey            func(item T) string
+	GetGroupKey       func(item T) string
+	Transform         func(item *T) *T
+	DebounceTime      time.Duration
+	MaxAttempts       int           // default: 5
+	RetryBackoff      time.Duration // default: 1m, doubled on each attempt
+	MaxRetryBackoff   time.Duration // default: 6h
+	VisibilityTimeout time.Duration // default: 30m
+	DeadRetention     time.Duration // default: 7d
+	ClaimLimit        int           // default: 1000, items processed per run
+	Concurrency       int           // default: 1, items processed at the same time
+	Disabled          bool
+}
+
+func NewWorkerQueue[T any](conf *WorkerQueueConfig[T]) *WorkerQueue[T] {
+	if conf.Name == "" {
+		panic("worker queue name is required")
+	}
+	if conf.Transform == nil {
+		conf.Transform = func(item *T) *T {
+			return item
+		}
+	}
+	if conf.MaxAttempts == 0 {
+		conf.MaxAttempts = 5
+	}
+	if conf.RetryBackoff == 0 {
+		conf.RetryBackoff = 1 * time.Minute
+	}
+	if conf.MaxRetryBackoff == 0 {
+		conf.MaxRetryBackoff = 6 * time.Hour
+	}
+	if conf.VisibilityTimeout == 0 {
+		conf.VisibilityTimeout = 30 * time.Minute
+	}
+	if conf.DeadRetention == 0 {
+		conf.DeadRetention = 7 * 24 * time.Hour
+	}
+	if conf.ClaimLimit == 0 {
+		conf.ClaimLimit = 1000
+	}
+	if conf.Concurrency == 0 {
+		conf.Concurrency = 1
+	}
+	return &WorkerQueue[T]{
+		name:              conf.Name,
+		getKey:            conf.GetKey,
+		getGroupKey:       conf.GetGroupKey,
+		transform:         conf.Transform,
+		debounceTime:      conf.DebounceTime,
+		maxAttempts:       conf.MaxAttempts,
+		retryBackoff:      conf.RetryBackoff,
+		maxRetryBackoff:   conf.MaxRetryBackoff,
+		visibilityTimeout: conf.VisibilityTimeout,
+		deadRetention:     conf.DeadRetention,
+		claimLimit:        conf.ClaimLimit,
+		concurrency:       conf.Concurrency,
+		Disabled:          conf.Disabled,
+		bufferIdxByKey:    map[string]int{},
+	}
+}
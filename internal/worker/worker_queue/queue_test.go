@@ -0,0 +1,410 @@
+package worker_queue
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+	"github.com/MunifTanjim/stremthru/internal/db"
+	"github.com/MunifTanjim/stremthru/internal/db/dbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type testQueueItem struct {
+	Id    string
+	Token Secret
+}
+
+func newTestQueue(t *testing.T, conf *WorkerQueueConfig[testQueueItem]) *WorkerQueue[testQueueItem] {
+	t.Helper()
+	dbtest.Setup(t)
+
+	conf.Name = "test:" + t.Name()
+	conf.GetKey = func(item testQueueItem) string {
+		return item.Id
+	}
+	return NewWorkerQueue(conf)
+}
+
+func getPayload(t *testing.T, queue, key string) string {
+	t.Helper()
+	payload := ""
+	row := db.QueryRow("SELECT "+Column.Payload+" FROM "+TableName+" WHERE "+Column.Queue+" = ? AND "+Column.Key+" = ?", queue, key)
+	assert.NoError(t, row.Scan(&payload))
+	return payload
+}
+
+func TestWorkerQueueProcess(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{})
+
+	q.QueueMany([]testQueueItem{{Id: "a"}, {Id: "b"}, {Id: "a"}})
+
+	processed := []string{}
+	q.Process(func(item testQueueItem) error {
+		processed = append(processed, item.Id)
+		return nil
+	})
+	assert.ElementsMatch(t, []string{"a", "b"}, processed)
+
+	q.Process(func(item testQueueItem) error {
+		t.Errorf("completed item processed again: %s", item.Id)
+		return nil
+	})
+
+	items, err := ListItems(q.name, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, items, 0)
+}
+
+func TestWorkerQueueBuffer(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{})
+
+	q.Queue(testQueueItem{Id: "a", Token: "old"})
+	q.Queue(testQueueItem{Id: "a", Token: "new"})
+
+	items, err := ListItems(q.name, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, items, 0)
+
+	assert.Eventually(t, func() bool {
+		items, err := ListItems(q.name, "", 10, 0)
+		return err == nil && len(items) == 1
+	}, 5*bufferFlushDelay, bufferFlushDelay/10)
+
+	q.Process(func(item testQueueItem) error {
+		assert.Equal(t, Secret("new"), item.Token)
+		return nil
+	})
+}
+
+func TestWorkerQueueDebounce(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{
+		DebounceTime: time.Hour,
+	})
+
+	q.Queue(testQueueItem{Id: "a"})
+
+	q.Process(func(item testQueueItem) error {
+		t.Errorf("item processed before debounce time: %s", item.Id)
+		return nil
+	})
+}
+
+func TestWorkerQueueClaimVisibility(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{})
+
+	q.Queue(testQueueItem{Id: "a"})
+
+	assert.Len(t, q.claimDue(), 1)
+	assert.Len(t, q.claimDue(), 0, "claimed item should be hidden")
+
+	items, err := ListItems(q.name, QueueItemStatusPending, 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.NotNil(t, items[0].LockedUntil)
+	}
+}
+
+func TestWorkerQueueRetry(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+	})
+
+	q.Queue(testQueueItem{Id: "a"})
+
+	attempts := 0
+	for range 5 {
+		q.Process(func(item testQueueItem) error {
+			attempts++
+			return errors.New("failed")
+		})
+	}
+	assert.Equal(t, 3, attempts)
+
+	items, err := ListItems(q.name, QueueItemStatusDead, 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, 3, items[0].Attempts)
+		assert.Equal(t, "failed", items[0].Error)
+	}
+
+	q.Queue(testQueueItem{Id: "a"})
+	q.Process(func(item testQueueItem) error {
+		t.Errorf("dead item processed again: %s", item.Id)
+		return nil
+	})
+}
+
+func TestWorkerQueueRetryBackoff(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{
+		RetryBackoff: time.Hour,
+	})
+
+	q.Queue(testQueueItem{Id: "a"})
+	q.Process(func(item testQueueItem) error {
+		return errors.New("failed")
+	})
+	q.Process(func(item testQueueItem) error {
+		t.Errorf("item processed before backoff: %s", item.Id)
+		return nil
+	})
+
+	items, err := ListItems(q.name, QueueItemStatusPending, 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, 1, items[0].Attempts)
+		assert.Nil(t, items[0].LockedUntil)
+	}
+
+	assert.Equal(t, time.Hour, q.getRetryBackoff(1))
+	assert.Equal(t, 4*time.Hour, q.getRetryBackoff(3))
+	assert.Equal(t, 6*time.Hour, q.getRetryBackoff(10))
+}
+
+func TestWorkerQueueUndecodable(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{})
+
+	err := upsertItems(q.name, []queueItem{
+		{Key: "a", Payload: "{", ProcessAt: db.Timestamp{Time: time.Now()}},
+	})
+	assert.NoError(t, err)
+
+	q.Process(func(item testQueueItem) error {
+		t.Errorf("undecodable item processed: %s", item.Id)
+		return nil
+	})
+
+	items, err := ListItems(q.name, QueueItemStatusDead, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestWorkerQueueSecret(t *testing.T) {
+	t.Run("without encryption key", func(t *testing.T) {
+		q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{})
+
+		q.Queue(testQueueItem{Id: "a", Token: "secret-token"})
+		q.flush()
+		assert.NotContains(t, getPayload(t, q.name, "a"), "secret-token")
+
+		q.Process(func(item testQueueItem) error {
+			assert.Equal(t, Secret("secret-token"), item.Token)
+			return nil
+		})
+	})
+
+	t.Run("with encryption key", func(t *testing.T) {
+		keys := config.EncryptionKey
+		config.EncryptionKey = config.EncryptionKeyList{"test-key"}
+		t.Cleanup(func() { config.EncryptionKey = keys })
+
+		q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{})
+
+		q.Queue(testQueueItem{Id: "a", Token: "secret-token"})
+		q.flush()
+		assert.NotContains(t, getPayload(t, q.name, "a"), "secret-token")
+
+		q.Process(func(item testQueueItem) error {
+			assert.Equal(t, Secret("secret-token"), item.Token)
+			return nil
+		})
+	})
+
+	t.Run("rotated encryption key", func(t *testing.T) {
+		keys := config.EncryptionKey
+		config.EncryptionKey = config.EncryptionKeyList{"old-key"}
+		t.Cleanup(func() { config.EncryptionKey = keys })
+
+		q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{})
+
+		q.Queue(testQueueItem{Id: "e.a", Token: "secret-token"})
+		q.flush()
+		oldPayload := getPayload(t, q.name, "e.a")
+
+		config.EncryptionKey = config.EncryptionKeyList{"new-key", "old-key"}
+		count, err := EncryptAll()
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, count, 1)
+
+		payload := getPayload(t, q.name, "e.a")
+		assert.NotEqual(t, oldPayload, payload)
+		assert.NotContains(t, payload, "secret-token")
+		assert.Contains(t, payload, `"e.a"`)
+
+		count, err = EncryptAll()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		config.EncryptionKey = config.EncryptionKeyList{"new-key"}
+		processed := false
+		q.Process(func(item testQueueItem) error {
+			processed = true
+			assert.Equal(t, Secret("secret-token"), item.Token)
+			return nil
+		})
+		assert.True(t, processed)
+	})
+
+	t.Run("missing reference", func(t *testing.T) {
+		q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{})
+
+		err := upsertItems(q.name, []queueItem{
+			{Key: "a", Payload: `{"Id":"a","Token":"r.unknown"}`, ProcessAt: db.Timestamp{Time: time.Now()}},
+		})
+		assert.NoError(t, err)
+
+		q.Process(func(item testQueueItem) error {
+			t.Errorf("item with missing secret processed: %s", item.Id)
+			return nil
+		})
+
+		items, err := ListItems(q.name, QueueItemStatusPending, 10, 0)
+		assert.NoError(t, err)
+		if assert.Len(t, items, 1) {
+			assert.Equal(t, 1, items[0].Attempts)
+			assert.Contains(t, items[0].Error, errMissingSecret.Error())
+		}
+
+		items, err = ListItems(q.name, QueueItemStatusDead, 10, 0)
+		assert.NoError(t, err)
+		assert.Len(t, items, 0)
+	})
+
+	t.Run("store crawler key", func(t *testing.T) {
+		key := StoreCrawlerQueue.getKey(StoreCrawlerQueueItem{StoreCode: "rd", StoreToken: "secret-token"})
+		assert.NotContains(t, key, "secret-token")
+	})
+}
+
+func TestWorkerQueueClaimLimit(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{
+		ClaimLimit: 2,
+	})
+
+	now := time.Now()
+	err := upsertItems(q.name, []queueItem{
+		{Key: "c", Payload: `{"Id":"c"}`, ProcessAt: db.Timestamp{Time: now.Add(-1 * time.Minute)}},
+		{Key: "a", Payload: `{"Id":"a"}`, ProcessAt: db.Timestamp{Time: now.Add(-3 * time.Minute)}},
+		{Key: "b", Payload: `{"Id":"b"}`, ProcessAt: db.Timestamp{Time: now.Add(-2 * time.Minute)}},
+	})
+	assert.NoError(t, err)
+
+	processed := []string{}
+	q.Process(func(item testQueueItem) error {
+		processed = append(processed, item.Id)
+		return nil
+	})
+	assert.Equal(t, []string{"a", "b"}, processed)
+
+	processed = []string{}
+	q.Process(func(item testQueueItem) error {
+		processed = append(processed, item.Id)
+		return nil
+	})
+	assert.Equal(t, []string{"c"}, processed)
+}
+
+func TestWorkerQueueConcurrency(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{
+		MaxAttempts: 1,
+		Concurrency: 2,
+	})
+
+	q.QueueMany([]testQueueItem{{Id: "a"}, {Id: "b"}, {Id: "c"}, {Id: "d"}})
+
+	var running, maxRunning atomic.Int32
+	q.Process(func(item testQueueItem) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if item.Id == "d" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.Equal(t, int32(2), maxRunning.Load())
+
+	items, err := ListItems(q.name, "", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "d", items[0].Key)
+		assert.Equal(t, QueueItemStatusDead, items[0].Status)
+	}
+}
+
+func TestWorkerQueueRetryDeadItems(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{
+		MaxAttempts: 1,
+	})
+
+	q.QueueMany([]testQueueItem{{Id: "a"}, {Id: "b"}})
+	q.Process(func(item testQueueItem) error {
+		if item.Id == "a" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	count, err := RetryDeadItems(q.name)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	items, err := ListItems(q.name, QueueItemStatusPending, 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "a", items[0].Key)
+		assert.Equal(t, 0, items[0].Attempts)
+	}
+
+	processed := []string{}
+	q.Process(func(item testQueueItem) error {
+		processed = append(processed, item.Id)
+		return nil
+	})
+	assert.Equal(t, []string{"a"}, processed)
+}
+
+func TestWorkerQueuePurgeDeadItems(t *testing.T) {
+	q := newTestQueue(t, &WorkerQueueConfig[testQueueItem]{
+		MaxAttempts:   1,
+		DeadRetention: time.Hour,
+	})
+
+	q.QueueMany([]testQueueItem{{Id: "old"}, {Id: "new"}})
+	q.Process(func(item testQueueItem) error {
+		return errors.New("failed")
+	})
+
+	_, err := db.Exec("UPDATE "+TableName+" SET "+Column.UpdatedAt+" = ? WHERE "+Column.Queue+" = ? AND "+Column.Key+" = ?", db.Timestamp{Time: time.Now().Add(-2 * time.Hour)}, q.name, "old")
+	assert.NoError(t, err)
+
+	q.claimDue()
+
+	items, err := ListItems(q.name, QueueItemStatusDead, 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "new", items[0].Key)
+	}
+}
+
+func TestNewWorkerQueueDefaults(t *testing.T) {
+	assert.Equal(t, 5, StoreCrawlerQueue.maxAttempts)
+	assert.Equal(t, 7*24*time.Hour, StoreCrawlerQueue.deadRetention)
+	assert.Equal(t, 1000, StoreCrawlerQueue.claimLimit)
+	assert.Equal(t, 1, StoreCrawlerQueue.concurrency)
+
+	assert.Equal(t, 5, MagnetCachePullerQueue.maxAttempts)
+	assert.Equal(t, 5, AnimeIdMapperQueue.maxAttempts)
+	assert.Equal(t, 3, TorrentMetaFetcherQueue.maxAttempts)
+	assert.Equal(t, 100, TorrentMetaFetcherQueue.claimLimit)
+	assert.Equal(t, 5, TorrentMetaFetcherQueue.concurrency)
+}
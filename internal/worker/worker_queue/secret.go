@@ -0,0 +1,169 @@
+package worker_queue
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/cache"
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
+
+// Secret is a value (e.g. store token, client ip) that is never saved in
+// the payload as-is.
+//
+// With STREMTHRU_ENCRYPTION_KEY, it is saved encrypted. Otherwise only an
+// opaque reference is saved, and the value is kept in memory. So items
+// queued before a restart, or by another instance, can not be processed
+// without the encryption key. Such items are retried, in case the instance
+// holding the value becomes the leader, until MaxAttempts.
+type Secret string
+
+const (
+	secretEncryptedPrefix = "e."
+	secretRefPrefix       = "r."
+)
+
+var errMissingSecret = errors.New("missing secret, queued by another process")
+
+var secretRefKey = []byte(rand.Text())
+
+var secretByRef = cache.NewLRUCache[string](&cache.CacheConfig{
+	Lifetime:      24 * time.Hour,
+	Name:          "worker_queue:secret",
+	LocalCapacity: 8192,
+})
+
+func getSecretRef(value string) string {
+	mac := hmac.New(sha256.New, secretRefKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func sealSecret(keys config.EncryptionKeyList, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if keys.IsEnabled() {
+		encrypted, err := keys.Encrypt(value)
+		if err != nil {
+			return "", err
+		}
+		return secretEncryptedPrefix + encrypted, nil
+	}
+	ref := getSecretRef(value)
+	secretByRef.Add(ref, value)
+	return secretRefPrefix + ref, nil
+}
+
+// Values saved before secrets were sealed are read as-is.
+func unsealSecret(keys config.EncryptionKeyList, sealed string) (string, error) {
+	if encrypted, ok := strings.CutPrefix(sealed, secretEncryptedPrefix); ok {
+		return keys.Decrypt(encrypted)
+	}
+	if ref, ok := strings.CutPrefix(sealed, secretRefPrefix); ok {
+		value := ""
+		if !secretByRef.Get(ref, &value) {
+			return "", errMissingSecret
+		}
+		return value, nil
+	}
+	return sealed, nil
+}
+
+// resealSecret encrypts the encrypted secret with the active key, if not
+// already. It returns false if unchanged, also for the value that is not an
+// encrypted secret.
+func resealSecret(keys config.EncryptionKeyList, sealed string) (string, bool, error) {
+	encrypted, ok := strings.CutPrefix(sealed, secretEncryptedPrefix)
+	if !ok {
+		return sealed, false, nil
+	}
+	if activeKeyId, _ := keys.GetActive(); keys.GetKeyId(encrypted) == activeKeyId {
+		return sealed, false, nil
+	}
+	value, err := keys.Decrypt(encrypted)
+	if err != nil {
+		return sealed, false, nil
+	}
+	resealed, err := sealSecret(keys, value)
+	if err != nil {
+		return "", false, err
+	}
+	return resealed, true, nil
+}
+
+func resealValue(keys config.EncryptionKeyList, value any) (any, bool, error) {
+	switch v := value.(type) {
+	case string:
+		return resealSecret(keys, v)
+	case []any:
+		changed := false
+		for i := range v {
+			resealed, c, err := resealValue(keys, v[i])
+			if err != nil {
+				return nil, false, err
+			}
+			v[i], changed = resealed, changed || c
+		}
+		return v, changed, nil
+	case map[string]any:
+		changed := false
+		for k := range v {
+			resealed, c, err := resealValue(keys, v[k])
+			if err != nil {
+				return nil, false, err
+			}
+			v[k], changed = resealed, changed || c
+		}
+		return v, changed, nil
+	}
+	return value, false, nil
+}
+
+// resealPayload encrypts the secrets in the payload with the active key. It
+// returns false if unchanged, also for the undecodable payload, which fails
+// when processed anyway.
+func resealPayload(keys config.EncryptionKeyList, payload string) (string, bool, error) {
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return payload, false, nil
+	}
+	value, changed, err := resealValue(keys, value)
+	if err != nil || !changed {
+		return payload, false, err
+	}
+	blob, err := json.Marshal(value)
+	if err != nil {
+		return "", false, err
+	}
+	return string(blob), true, nil
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	sealed, err := sealSecret(config.EncryptionKey, string(s))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealed)
+}
+
+func (s *Secret) UnmarshalJSON(data []byte) error {
+	var sealed string
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return err
+	}
+	value, err := unsealSecret(config.EncryptionKey, sealed)
+	if err != nil {
+		return err
+	}
+	*s = Secret(value)
+	return nil
+}
@@ -2,19 +2,22 @@ package worker_queue
 
 import (
 	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/util"
 )
 
 type StoreCrawlerQueueItem struct {
 	StoreCode  string
-	StoreToken string
+	StoreToken Secret
 }
 
-var StoreCrawlerQueue = WorkerQueue[StoreCrawlerQueueItem]{
-	debounceTime: 15 * time.Minute,
-	getKey: func(item StoreCrawlerQueueItem) string {
-		return item.StoreCode + ":" + item.StoreToken
+var StoreCrawlerQueue = NewWorkerQueue(&WorkerQueueConfig[StoreCrawlerQueueItem]{
+	Name:         "store_crawler",
+	DebounceTime: 15 * time.Minute,
+	GetKey: func(item StoreCrawlerQueueItem) string {
+		return item.StoreCode + ":" + util.HashString(string(item.StoreToken))
 	},
-	transform: func(item *StoreCrawlerQueueItem) *StoreCrawlerQueueItem {
+	Transform: func(item *StoreCrawlerQueueItem) *StoreCrawlerQueueItem {
 		return item
 	},
-}
+})
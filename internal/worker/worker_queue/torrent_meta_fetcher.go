@@ -0,0 +1,26 @@
+package worker_queue
+
+import (
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/config"
+)
+
+type TorrentMetaFetcherQueueItem struct {
+	Hash string
+}
+
+var TorrentMetaFetcherQueue = NewWorkerQueue(&WorkerQueueConfig[TorrentMetaFetcherQueueItem]{
+	Name:         "torrent_meta_fetcher",
+	DebounceTime: 1 * time.Minute,
+	GetKey: func(item TorrentMetaFetcherQueueItem) string {
+		return item.Hash
+	},
+	MaxAttempts:     3,
+	RetryBackoff:    1 * time.Hour,
+	MaxRetryBackoff: 24 * time.Hour,
+	// at most 100 / 5 * peer timeout (30s), well within the visibility timeout
+	ClaimLimit:  100,
+	Concurrency: 5,
+	Disabled:    !config.TorrentMeta.IsEnabled(),
+})
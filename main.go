@@ -30,6 +30,7 @@ func main() {
 	defer db.Close()
 	db.Ping()
 	RunSchemaMigration(database.URI, database)
+	RunDataMigration()
 
 	stopWorkers := worker.InitWorkers()
 	defer stopWorkers()
@@ -39,12 +40,16 @@ func main() {
 	endpoint.AddRootEndpoint(mux)
 	endpoint.AddAuthEndpoints(mux)
 	endpoint.AddHealthEndpoints(mux)
+	endpoint.AddOpenAPIEndpoints(mux)
 	endpoint.AddProxyEndpoints(mux)
 	endpoint.AddStoreEndpoints(mux)
 	endpoint.AddStremioEndpoints(mux)
 	endpoint.AddTorrentEndpoints(mux)
 	endpoint.AddTorznabEndpoints(mux)
 	endpoint.AddExperimentEndpoints(mux)
+	endpoint.AddWorkerEndpoints(mux)
+	endpoint.AddUserAccountEndpoints(mux)
+	endpoint.AddAPIKeyEndpoints(mux)
 
 	handler := shared.RootServerContext(mux)
 
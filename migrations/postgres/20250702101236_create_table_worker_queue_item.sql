@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "public"."worker_queue_item" (
+  "queue" text NOT NULL,
+  "item_key" text NOT NULL,
+  "group_key" text NOT NULL DEFAULT '',
+  "payload" text NOT NULL,
+  "process_at" timestamptz NOT NULL,
+  "cat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "uat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY ("queue", "item_key")
+);
+
+CREATE INDEX IF NOT EXISTS "worker_queue_item_idx_queue_process_at"
+  ON "public"."worker_queue_item" ("queue", "process_at");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "public"."worker_queue_item";
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "public"."worker_queue_item" ADD COLUMN "status" text NOT NULL DEFAULT 'pending';
+ALTER TABLE "public"."worker_queue_item" ADD COLUMN "attempts" int NOT NULL DEFAULT 0;
+ALTER TABLE "public"."worker_queue_item" ADD COLUMN "error" text NOT NULL DEFAULT '';
+ALTER TABLE "public"."worker_queue_item" ADD COLUMN "locked_until" timestamptz;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "public"."worker_queue_item" DROP COLUMN "locked_until";
+ALTER TABLE "public"."worker_queue_item" DROP COLUMN "error";
+ALTER TABLE "public"."worker_queue_item" DROP COLUMN "attempts";
+ALTER TABLE "public"."worker_queue_item" DROP COLUMN "status";
+-- +goose StatementEnd
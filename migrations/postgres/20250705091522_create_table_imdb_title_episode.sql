@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "public"."imdb_title_episode" (
+  "tid" text NOT NULL PRIMARY KEY,
+  "parent_tid" text NOT NULL,
+  "season" int NOT NULL,
+  "episode" int NOT NULL
+);
+
+CREATE INDEX "imdb_title_episode_idx_parent_tid"
+  ON "public"."imdb_title_episode" ("parent_tid");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "public"."imdb_title_episode";
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "public"."imdb_title_rating" (
+  "tid" text NOT NULL PRIMARY KEY,
+  "rating" real NOT NULL,
+  "votes" int NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "public"."imdb_title_rating";
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS "anidb_tvdb_episode_map_idx_tvdb_id"
+  ON "public"."anidb_tvdb_episode_map" ("tvdb_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "anidb_tvdb_episode_map_idx_tvdb_id";
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "public"."user_account" (
+  "name" text NOT NULL,
+  "password_hash" text NOT NULL,
+  "secret" text NOT NULL DEFAULT '',
+  "is_admin" boolean NOT NULL DEFAULT false,
+  "is_disabled" boolean NOT NULL DEFAULT false,
+  "stores" text NOT NULL DEFAULT '',
+  "store_tokens" text NOT NULL DEFAULT '{}',
+  "cp_conn_limit" int NOT NULL DEFAULT -1,
+  "cat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "uat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY ("name")
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "public"."user_account";
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "public"."api_key" (
+  "id" text NOT NULL,
+  "user_name" text NOT NULL,
+  "name" text NOT NULL DEFAULT '',
+  "secret_hash" text NOT NULL,
+  "scopes" text NOT NULL DEFAULT '',
+  "ips" text NOT NULL DEFAULT '',
+  "expires_at" timestamptz,
+  "revoked_at" timestamptz,
+  "cat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "uat" timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "api_key_idx_user_name" ON "public"."api_key" ("user_name");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "public"."api_key_idx_user_name";
+DROP TABLE IF EXISTS "public"."api_key";
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "public"."imdb_title_map" ADD COLUMN "type" text NOT NULL DEFAULT '';
+
+UPDATE "public"."imdb_title_map" itm SET "type" = CASE
+  WHEN it."type" IN ('movie', 'tvMovie', 'short', 'tvShort', 'video', 'tvSpecial') THEN 'movie'
+  WHEN it."type" IN ('tvSeries', 'tvMiniSeries') THEN 'show'
+  ELSE ''
+END
+FROM "public"."imdb_title" it
+WHERE it."tid" = itm."imdb";
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "public"."imdb_title_map" DROP COLUMN "type";
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `worker_queue_item` (
+  `queue` varchar NOT NULL,
+  `item_key` varchar NOT NULL,
+  `group_key` varchar NOT NULL DEFAULT '',
+  `payload` varchar NOT NULL,
+  `process_at` datetime NOT NULL,
+  `cat` datetime NOT NULL DEFAULT (unixepoch()),
+  `uat` datetime NOT NULL DEFAULT (unixepoch()),
+
+  PRIMARY KEY (`queue`, `item_key`)
+);
+
+CREATE INDEX IF NOT EXISTS `worker_queue_item_idx_queue_process_at`
+  ON `worker_queue_item` (`queue`, `process_at`);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `worker_queue_item`;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE `worker_queue_item` ADD COLUMN `status` varchar NOT NULL DEFAULT 'pending';
+ALTER TABLE `worker_queue_item` ADD COLUMN `attempts` int NOT NULL DEFAULT 0;
+ALTER TABLE `worker_queue_item` ADD COLUMN `error` varchar NOT NULL DEFAULT '';
+ALTER TABLE `worker_queue_item` ADD COLUMN `locked_until` datetime;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE `worker_queue_item` DROP COLUMN `locked_until`;
+ALTER TABLE `worker_queue_item` DROP COLUMN `error`;
+ALTER TABLE `worker_queue_item` DROP COLUMN `attempts`;
+ALTER TABLE `worker_queue_item` DROP COLUMN `status`;
+-- +goose StatementEnd
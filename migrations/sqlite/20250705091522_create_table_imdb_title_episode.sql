@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `imdb_title_episode` (
+  `tid` varchar NOT NULL PRIMARY KEY,
+  `parent_tid` varchar NOT NULL,
+  `season` int NOT NULL,
+  `episode` int NOT NULL
+);
+
+CREATE INDEX `imdb_title_episode_idx_parent_tid`
+  ON `imdb_title_episode` (`parent_tid`);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `imdb_title_episode`;
+-- +goose StatementEnd
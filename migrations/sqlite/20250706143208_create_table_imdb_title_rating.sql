@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `imdb_title_rating` (
+  `tid` varchar NOT NULL PRIMARY KEY,
+  `rating` real NOT NULL,
+  `votes` int NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `imdb_title_rating`;
+-- +goose StatementEnd
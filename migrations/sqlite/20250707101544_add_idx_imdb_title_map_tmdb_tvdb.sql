@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS `imdb_title_map_idx_tmdb`
+  ON `imdb_title_map` (`tmdb`);
+
+CREATE INDEX IF NOT EXISTS `imdb_title_map_idx_tvdb`
+  ON `imdb_title_map` (`tvdb`);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS `imdb_title_map_idx_tvdb`;
+
+DROP INDEX IF EXISTS `imdb_title_map_idx_tmdb`;
+-- +goose StatementEnd
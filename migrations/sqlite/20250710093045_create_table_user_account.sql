@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `user_account` (
+  `name` varchar NOT NULL,
+  `password_hash` varchar NOT NULL,
+  `secret` varchar NOT NULL DEFAULT '',
+  `is_admin` bool NOT NULL DEFAULT false,
+  `is_disabled` bool NOT NULL DEFAULT false,
+  `stores` varchar NOT NULL DEFAULT '',
+  `store_tokens` varchar NOT NULL DEFAULT '{}',
+  `cp_conn_limit` int NOT NULL DEFAULT -1,
+  `cat` datetime NOT NULL DEFAULT (unixepoch()),
+  `uat` datetime NOT NULL DEFAULT (unixepoch()),
+
+  PRIMARY KEY (`name`)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `user_account`;
+-- +goose StatementEnd
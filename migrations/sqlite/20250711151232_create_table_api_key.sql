@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `api_key` (
+  `id` varchar NOT NULL,
+  `user_name` varchar NOT NULL,
+  `name` varchar NOT NULL DEFAULT '',
+  `secret_hash` varchar NOT NULL,
+  `scopes` varchar NOT NULL DEFAULT '',
+  `ips` varchar NOT NULL DEFAULT '',
+  `expires_at` datetime,
+  `revoked_at` datetime,
+  `cat` datetime NOT NULL DEFAULT (unixepoch()),
+  `uat` datetime NOT NULL DEFAULT (unixepoch()),
+
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `api_key_idx_user_name` ON `api_key` (`user_name`);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS `api_key_idx_user_name`;
+DROP TABLE IF EXISTS `api_key`;
+-- +goose StatementEnd
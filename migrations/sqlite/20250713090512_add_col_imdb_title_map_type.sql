@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE `imdb_title_map` ADD COLUMN `type` varchar NOT NULL DEFAULT '';
+
+UPDATE `imdb_title_map` SET `type` = CASE
+  WHEN it.`type` IN ('movie', 'tvMovie', 'short', 'tvShort', 'video', 'tvSpecial') THEN 'movie'
+  WHEN it.`type` IN ('tvSeries', 'tvMiniSeries') THEN 'show'
+  ELSE ''
+END
+FROM `imdb_title` it
+WHERE it.`tid` = `imdb_title_map`.`imdb`;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE `imdb_title_map` DROP COLUMN `type`;
+-- +goose StatementEnd
@@ -5,7 +5,11 @@ import (
 	"log"
 	"os"
 
+	"github.com/MunifTanjim/stremthru/internal/config"
 	"github.com/MunifTanjim/stremthru/internal/db"
+	stremio_userdata "github.com/MunifTanjim/stremthru/internal/stremio/userdata"
+	"github.com/MunifTanjim/stremthru/internal/user_account"
+	"github.com/MunifTanjim/stremthru/internal/worker/worker_queue"
 	"github.com/pressly/goose/v3"
 )
 
@@ -48,3 +52,38 @@ func RunSchemaMigration(uri db.ConnectionURI, database *db.DB) {
 	l.Println()
 	l.Print("========================\n\n")
 }
+
+// RunDataMigration encrypts the saved data (stremio userdata, store tokens of
+// user accounts and secrets in worker queue) with the active encryption key,
+// for the data saved before enabling encryption or rotating the key.
+func RunDataMigration() {
+	if !config.EncryptionKey.IsEnabled() {
+		return
+	}
+
+	l := log.New(os.Stderr, "=", 0)
+
+	l.Println("==== Data Encryption ===")
+	l.Println()
+
+	count, err := stremio_userdata.EncryptAll()
+	if err != nil {
+		l.Fatalf(" Failed to encrypt stremio userdata: %v\n", err)
+	}
+	l.Printf(" stremio userdata: %d encrypted\n", count)
+
+	count, err = user_account.EncryptAll()
+	if err != nil {
+		l.Fatalf(" Failed to encrypt user account: %v\n", err)
+	}
+	l.Printf(" user account: %d encrypted\n", count)
+
+	count, err = worker_queue.EncryptAll()
+	if err != nil {
+		l.Fatalf(" Failed to encrypt worker queue: %v\n", err)
+	}
+	l.Printf(" worker queue: %d encrypted\n", count)
+
+	l.Println()
+	l.Print("========================\n\n")
+}
@@ -0,0 +1,259 @@
+// Package stremthru is the client for the StremThru HTTP API.
+package stremthru
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/internal/request"
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+const userAgent = "stremthru:sdk:go"
+
+var DefaultHTTPClient = &http.Client{
+	Timeout: 90 * time.Second,
+}
+
+type Ctx = request.Ctx
+
+type APIResponse[T any] = request.APIResponse[T]
+
+type APIClientConfig struct {
+	BaseURL string
+	// `user:pass`, or base64 encoded, checked against `STREMTHRU_PROXY_AUTH`
+	Auth string
+	// defaults to the preferred store of the user, with `Auth`
+	StoreName store.StoreName
+	// defaults to the store token of the user, with `Auth`
+	StoreToken string
+	UserAgent  string
+	HTTPClient *http.Client
+}
+
+type APIClient struct {
+	BaseURL    *url.URL
+	HTTPClient *http.Client
+	auth       string
+	storeName  store.StoreName
+	storeToken string
+	agent      string
+
+	reqQuery  func(query *url.Values, params request.Context)
+	reqHeader func(query *http.Header, params request.Context)
+}
+
+func NewAPIClient(conf *APIClientConfig) *APIClient {
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = DefaultHTTPClient
+	}
+
+	c := &APIClient{}
+
+	baseUrl, err := url.Parse(conf.BaseURL)
+	if err != nil {
+		panic(err)
+	}
+	c.BaseURL = baseUrl
+
+	c.HTTPClient = conf.HTTPClient
+	c.auth = conf.Auth
+	if strings.Contains(c.auth, ":") {
+		c.auth = base64.StdEncoding.EncodeToString([]byte(strings.TrimSpace(c.auth)))
+	}
+	c.storeName = conf.StoreName
+	c.storeToken = conf.StoreToken
+	c.agent = strings.TrimSpace(userAgent + " " + conf.UserAgent)
+
+	c.reqQuery = func(query *url.Values, params request.Context) {
+	}
+
+	c.reqHeader = func(header *http.Header, params request.Context) {
+		if c.auth != "" {
+			header.Set("X-StremThru-Authorization", "Basic "+c.auth)
+		}
+		if c.storeName != "" {
+			header.Set("X-StremThru-Store-Name", string(c.storeName))
+		}
+		if token := params.GetAPIKey(c.storeToken); token != "" {
+			header.Set("X-StremThru-Store-Authorization", "Bearer "+token)
+		}
+		header.Set("User-Agent", c.agent)
+	}
+
+	return c
+}
+
+type ResponseEnvelop interface {
+	GetError() error
+}
+
+type ResponseError struct {
+	RequestId  string         `json:"request_id"`
+	Type       core.ErrorType `json:"type"`
+	Code       core.ErrorCode `json:"code"`
+	Message    string         `json:"message"`
+	Method     string         `json:"method"`
+	Path       string         `json:"path"`
+	StatusCode int            `json:"status_code"`
+	StoreName  string         `json:"store_name"`
+}
+
+// toError converts it to the core error for the type, i.e.
+// `*core.APIError`, `*core.StoreError`, `*core.UpstreamError` or
+// `*core.Error`.
+func (e *ResponseError) toError() error {
+	var err core.StremThruError
+	switch e.Type {
+	case core.ErrorTypeAPI:
+		err = core.NewAPIError(e.Message)
+	case core.ErrorTypeStore:
+		err = core.NewStoreError(e.Message)
+	case core.ErrorTypeUpstream:
+		err = core.NewUpstreamError(e.Message)
+	default:
+		err = core.NewError(e.Message)
+	}
+	cErr := err.GetError()
+	if e.Type != "" {
+		cErr.Type = e.Type
+	}
+	cErr.RequestId = e.RequestId
+	cErr.Code = e.Code
+	cErr.Method = e.Method
+	cErr.Path = e.Path
+	cErr.StatusCode = e.StatusCode
+	cErr.StoreName = e.StoreName
+	return err.(error)
+}
+
+type Response[D any] struct {
+	Data  D              `json:"data,omitempty"`
+	Error *ResponseError `json:"error,omitempty"`
+}
+
+func (r Response[any]) GetError() error {
+	if r.Error == nil {
+		return nil
+	}
+	return r.Error.toError()
+}
+
+func processResponseBody(res *http.Response, err error, v ResponseEnvelop) error {
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	defer res.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest && !strings.Contains(res.Header.Get("Content-Type"), "application/json") {
+		rerr := &ResponseError{
+			RequestId:  res.Header.Get("Request-ID"),
+			Message:    strings.TrimSpace(string(body)),
+			StatusCode: res.StatusCode,
+		}
+		if rerr.Message == "" {
+			rerr.Message = res.Status
+		}
+		if res.Request != nil {
+			rerr.Method = res.Request.Method
+			rerr.Path = res.Request.URL.Path
+		}
+		return rerr.toError()
+	}
+
+	if res.StatusCode == http.StatusNoContent || len(body) == 0 {
+		return nil
+	}
+
+	err = core.UnmarshalJSON(res.StatusCode, body, v)
+	if err != nil {
+		return err
+	}
+
+	return v.GetError()
+}
+
+func (c APIClient) Request(method, path string, params request.Context, v ResponseEnvelop) (*http.Response, error) {
+	if params == nil {
+		params = &Ctx{}
+	}
+	req, err := params.NewRequest(c.BaseURL, method, path, c.reqHeader, c.reqQuery)
+	if err != nil {
+		error := core.NewAPIError("failed to create request")
+		error.Cause = err
+		return nil, error
+	}
+	res, err := c.HTTPClient.Do(req)
+	err = processResponseBody(res, err, v)
+	return res, err
+}
+
+type HealthData struct {
+	Status string `json:"status"`
+}
+
+func (c APIClient) GetHealth(params *Ctx) (APIResponse[HealthData], error) {
+	if params == nil {
+		params = &Ctx{}
+	}
+	response := &Response[HealthData]{}
+	res, err := c.Request("GET", "/v0/health", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+type HealthDebugDataIP struct {
+	Machine string            `json:"machine"`
+	Tunnel  map[string]string `json:"tunnel"`
+	Exposed map[string]string `json:"exposed"`
+}
+
+type HealthDebugDataStore struct {
+	Default string   `json:"default"`
+	Names   []string `json:"names"`
+}
+
+type HealthDebugDataUser struct {
+	Name  string               `json:"name"`
+	Store HealthDebugDataStore `json:"store"`
+}
+
+type HealthDebugDataCache struct {
+	Name          string `json:"name"`
+	Hits          uint64 `json:"hits"`
+	StaleHits     uint64 `json:"stale_hits"`
+	Misses        uint64 `json:"misses"`
+	Sets          uint64 `json:"sets"`
+	Removes       uint64 `json:"removes"`
+	Invalidations uint64 `json:"invalidations"`
+	Revalidations uint64 `json:"revalidations"`
+	FetchErrors   uint64 `json:"fetch_errors"`
+}
+
+type HealthDebugData struct {
+	Time    string                 `json:"time"`
+	Version string                 `json:"version"`
+	User    *HealthDebugDataUser   `json:"user,omitempty"`
+	IP      *HealthDebugDataIP     `json:"ip,omitempty"`
+	Cache   []HealthDebugDataCache `json:"cache,omitempty"`
+}
+
+// GetHealthDebug includes the user, ip and cache details only with `Auth`.
+func (c APIClient) GetHealthDebug(params *Ctx) (APIResponse[HealthDebugData], error) {
+	if params == nil {
+		params = &Ctx{}
+	}
+	response := &Response[HealthDebugData]{}
+	res, err := c.Request("GET", "/v0/health/__debug__", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
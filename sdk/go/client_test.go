@@ -0,0 +1,298 @@
+package stremthru
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/core"
+	"github.com/MunifTanjim/stremthru/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, conf *APIClientConfig, handler http.HandlerFunc) *APIClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	conf.BaseURL = server.URL
+	return NewAPIClient(conf)
+}
+
+func sendJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestAPIClientHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		conf      APIClientConfig
+		params    store.GetUserParams
+		auth      string
+		storeName string
+		storeAuth string
+		userAgent string
+	}{
+		{
+			name:      "proxy auth",
+			conf:      APIClientConfig{Auth: "user:pass"},
+			auth:      "Basic dXNlcjpwYXNz",
+			userAgent: "stremthru:sdk:go",
+		},
+		{
+			name:      "encoded proxy auth",
+			conf:      APIClientConfig{Auth: "dXNlcjpwYXNz", UserAgent: "tool/1.0"},
+			auth:      "Basic dXNlcjpwYXNz",
+			userAgent: "stremthru:sdk:go tool/1.0",
+		},
+		{
+			name:      "store auth",
+			conf:      APIClientConfig{StoreName: store.StoreNameRealDebrid, StoreToken: "token"},
+			storeName: "realdebrid",
+			storeAuth: "Bearer token",
+			userAgent: "stremthru:sdk:go",
+		},
+		{
+			name:      "store token override",
+			conf:      APIClientConfig{StoreName: store.StoreNameTorBox, StoreToken: "token"},
+			params:    store.GetUserParams{Ctx: Ctx{APIKey: "other-token"}},
+			storeName: "torbox",
+			storeAuth: "Bearer other-token",
+			userAgent: "stremthru:sdk:go",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, &tc.conf, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v0/store/user", r.URL.Path)
+				assert.Equal(t, tc.auth, r.Header.Get("X-StremThru-Authorization"))
+				assert.Equal(t, tc.storeName, r.Header.Get("X-StremThru-Store-Name"))
+				assert.Equal(t, tc.storeAuth, r.Header.Get("X-StremThru-Store-Authorization"))
+				assert.Equal(t, tc.userAgent, r.Header.Get("User-Agent"))
+				sendJSON(w, 200, map[string]any{"data": map[string]any{
+					"id":                  "1",
+					"email":               "user@example.com",
+					"subscription_status": "premium",
+				}})
+			})
+
+			res, err := client.GetUser(&tc.params)
+			assert.NoError(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, store.User{
+				Id:                 "1",
+				Email:              "user@example.com",
+				SubscriptionStatus: store.UserSubscriptionStatusPremium,
+			}, res.Data)
+		})
+	}
+}
+
+func TestAPIClientStore(t *testing.T) {
+	client := newTestClient(t, &APIClientConfig{StoreName: store.StoreNameAlldebrid, StoreToken: "token"}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v0/store/magnets":
+			assert.Equal(t, "10", r.URL.Query().Get("limit"))
+			assert.Equal(t, "20", r.URL.Query().Get("offset"))
+			sendJSON(w, 200, map[string]any{"data": map[string]any{
+				"items":       []map[string]any{{"id": "1", "hash": "abc", "status": "downloaded", "added_at": time.Unix(0, 0).UTC()}},
+				"total_items": 21,
+			}})
+		case "POST /v0/store/magnets":
+			assert.Equal(t, "1.2.3.4", r.URL.Query().Get("client_ip"))
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"magnet":"magnet:?xt=urn:btih:abc"}`, string(body))
+			sendJSON(w, 201, map[string]any{"data": map[string]any{"id": "1", "hash": "abc", "status": "queued"}})
+		case "GET /v0/store/magnets/check":
+			assert.Equal(t, []string{"abc", "def"}, r.URL.Query()["magnet"])
+			assert.Equal(t, "tt0000000", r.URL.Query().Get("sid"))
+			sendJSON(w, 200, map[string]any{"data": map[string]any{"items": []map[string]any{{"hash": "abc", "status": "cached"}}}})
+		case "GET /v0/store/magnets/a b":
+			sendJSON(w, 200, map[string]any{"data": map[string]any{"id": "a b", "hash": "abc", "status": "downloaded"}})
+		case "DELETE /v0/store/magnets/1":
+			sendJSON(w, 200, map[string]any{"data": map[string]any{"id": "1"}})
+		case "POST /v0/store/link/generate":
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"link":"https://example.com/file"}`, string(body))
+			sendJSON(w, 200, map[string]any{"data": map[string]any{"link": "https://stremthru.example.com/file"}})
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(404)
+		}
+	})
+
+	listRes, err := client.ListMagnets(&store.ListMagnetsParams{Limit: 10, Offset: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 21, listRes.Data.TotalItems)
+	assert.Equal(t, store.MagnetStatusDownloaded, listRes.Data.Items[0].Status)
+
+	addRes, err := client.AddMagnet(&store.AddMagnetParams{Magnet: "magnet:?xt=urn:btih:abc", ClientIP: "1.2.3.4"})
+	assert.NoError(t, err)
+	assert.Equal(t, 201, addRes.StatusCode)
+	assert.Equal(t, store.MagnetStatusQueued, addRes.Data.Status)
+
+	checkRes, err := client.CheckMagnet(&store.CheckMagnetParams{Magnets: []string{"abc", "def"}, SId: "tt0000000"})
+	assert.NoError(t, err)
+	assert.Equal(t, store.MagnetStatusCached, checkRes.Data.Items[0].Status)
+
+	getRes, err := client.GetMagnet(&store.GetMagnetParams{Id: "a b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a b", getRes.Data.Id)
+
+	removeRes, err := client.RemoveMagnet(&store.RemoveMagnetParams{Id: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", removeRes.Data.Id)
+
+	linkRes, err := client.GenerateLink(&store.GenerateLinkParams{Link: "https://example.com/file"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://stremthru.example.com/file", linkRes.Data.Link)
+}
+
+func TestAPIClientProxifyLinks(t *testing.T) {
+	client := newTestClient(t, &APIClientConfig{Auth: "user:pass"}, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v0/proxy", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, []string{"https://example.com/a", "https://example.com/b"}, r.PostForm["url"])
+		assert.Equal(t, "3600", r.PostForm.Get("exp"))
+		assert.Equal(t, "Referer: https://example.com", r.PostForm.Get("req_headers"))
+		assert.Equal(t, "b.mkv", r.PostForm.Get("filename[1]"))
+		sendJSON(w, 200, map[string]any{"data": map[string]any{"items": []string{"x", "y"}, "total_items": 2}})
+	})
+
+	res, err := client.ProxifyLinks(&ProxifyLinksParams{
+		Links:          []string{"https://example.com/a", "https://example.com/b"},
+		Expiration:     time.Hour,
+		RequestHeaders: map[string]string{"Referer": "https://example.com"},
+		Filenames:      map[int]string{1: "b.mkv"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ProxifyLinksData{Items: []string{"x", "y"}, TotalItems: 2}, res.Data)
+}
+
+func TestAPIClientTorrents(t *testing.T) {
+	client := newTestClient(t, &APIClientConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v0/torrents":
+			assert.Equal(t, "tt0000000:1:2", r.URL.Query().Get("sid"))
+			assert.Equal(t, "1", r.URL.Query().Get("no_missing_size"))
+			assert.Equal(t, "", r.URL.Query().Get("local_only"))
+			sendJSON(w, 200, map[string]any{"data": map[string]any{
+				"items":       []map[string]any{{"hash": "abc", "name": "Title", "size": 1, "src": "dmm", "category": "series", "files": []map[string]any{{"n": "a.mkv", "i": 0, "s": 1}}}},
+				"total_items": 1,
+			}})
+		case "POST /v0/torrents":
+			assert.Equal(t, "peer-token", r.Header.Get("X-StremThru-Peer-Token"))
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"items":[{"hash":"abc","name":"Title","size":1,"src":"dmm","category":"movie","files":null}]}`, string(body))
+			w.WriteHeader(204)
+		case "GET /v0/torrents/stats":
+			sendJSON(w, 200, map[string]any{"data": map[string]any{"total_count": 1, "count_by_source": map[string]int{"dmm": 1}}})
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(404)
+		}
+	})
+
+	listRes, err := client.ListTorrents(&ListTorrentsParams{SId: "tt0000000:1:2", NoMissingSize: true})
+	assert.NoError(t, err)
+	assert.Equal(t, ListTorrentsData{
+		Items: []TorrentItem{{
+			Hash:     "abc",
+			Name:     "Title",
+			Size:     1,
+			Source:   "dmm",
+			Category: "series",
+			Files:    []TorrentFile{{Name: "a.mkv", Idx: 0, Size: 1}},
+		}},
+		TotalItems: 1,
+	}, listRes.Data)
+
+	recordRes, err := client.RecordTorrents(&RecordTorrentsParams{
+		PeerToken: "peer-token",
+		Items:     []TorrentItem{{Hash: "abc", Name: "Title", Size: 1, Source: "dmm", Category: "movie"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 204, recordRes.StatusCode)
+
+	statsRes, err := client.GetTorrentStats(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, statsRes.Data.CountBySource["dmm"])
+}
+
+func TestAPIClientError(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		check   func(t *testing.T, err error)
+	}{
+		{
+			name: "store error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				sendJSON(w, 422, map[string]any{"error": map[string]any{
+					"request_id":  "req-1",
+					"type":        "store_error",
+					"code":        "STORE_MAGNET_TOO_LARGE",
+					"message":     "magnet too large",
+					"status_code": 422,
+					"store_name":  "realdebrid",
+					"__cause__":   map[string]any{},
+				}})
+			},
+			check: func(t *testing.T, err error) {
+				var storeErr *core.StoreError
+				if assert.True(t, errors.As(err, &storeErr)) {
+					assert.Equal(t, core.ErrorTypeStore, storeErr.Type)
+					assert.Equal(t, core.ErrorCodeStoreMagnetTooLarge, storeErr.Code)
+					assert.Equal(t, "magnet too large", storeErr.Msg)
+					assert.Equal(t, 422, storeErr.StatusCode)
+					assert.Equal(t, "realdebrid", storeErr.StoreName)
+					assert.Equal(t, "req-1", storeErr.RequestId)
+				}
+			},
+		},
+		{
+			name: "api error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				sendJSON(w, 401, map[string]any{"error": map[string]any{
+					"type":        "api_error",
+					"code":        "UNAUTHORIZED",
+					"message":     "Unauthorized",
+					"status_code": 401,
+				}})
+			},
+			check: func(t *testing.T, err error) {
+				var apiErr *core.APIError
+				if assert.True(t, errors.As(err, &apiErr)) {
+					assert.Equal(t, core.ErrorCodeUnauthorized, apiErr.Code)
+				}
+			},
+		},
+		{
+			name: "non-json error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(502)
+				w.Write([]byte("Bad Gateway\n"))
+			},
+			check: func(t *testing.T, err error) {
+				var cErr *core.Error
+				if assert.True(t, errors.As(err, &cErr)) {
+					assert.Equal(t, 502, cErr.StatusCode)
+					assert.Equal(t, "Bad Gateway", cErr.Msg)
+					assert.Equal(t, "/v0/store/user", cErr.Path)
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, &APIClientConfig{}, tc.handler)
+			_, err := client.GetUser(&store.GetUserParams{})
+			tc.check(t, err)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package stremthru
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MunifTanjim/stremthru/internal/request"
+)
+
+type ProxifyLinksParams struct {
+	Ctx
+	Links []string
+	// zero for no expiration
+	Expiration time.Duration
+	// request headers for all the links
+	RequestHeaders map[string]string
+	// filename by index of the link
+	Filenames map[int]string
+}
+
+type ProxifyLinksData struct {
+	Items      []string `json:"items"`
+	TotalItems int      `json:"total_items"`
+}
+
+// ProxifyLinks requires `Auth`.
+func (c APIClient) ProxifyLinks(params *ProxifyLinksParams) (APIResponse[ProxifyLinksData], error) {
+	form := &url.Values{"url": params.Links}
+	if params.Expiration > 0 {
+		form.Set("exp", strconv.Itoa(int(params.Expiration.Seconds())))
+	}
+	if len(params.RequestHeaders) > 0 {
+		headers := make([]string, 0, len(params.RequestHeaders))
+		for k, v := range params.RequestHeaders {
+			headers = append(headers, k+": "+v)
+		}
+		form.Set("req_headers", strings.Join(headers, "\n"))
+	}
+	for idx, filename := range params.Filenames {
+		form.Set("filename["+strconv.Itoa(idx)+"]", filename)
+	}
+	params.Form = form
+
+	response := &Response[ProxifyLinksData]{}
+	res, err := c.Request("POST", "/v0/proxy", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
@@ -0,0 +1,95 @@
+package stremthru
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/MunifTanjim/stremthru/internal/request"
+	"github.com/MunifTanjim/stremthru/store"
+)
+
+func setClientIP(query *url.Values, clientIP string) {
+	if clientIP != "" {
+		query.Set("client_ip", clientIP)
+	}
+}
+
+func (c APIClient) GetUser(params *store.GetUserParams) (APIResponse[store.User], error) {
+	response := &Response[store.User]{}
+	res, err := c.Request("GET", "/v0/store/user", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+func (c APIClient) ListMagnets(params *store.ListMagnetsParams) (APIResponse[store.ListMagnetsData], error) {
+	params.Query = &url.Values{}
+	if params.Limit != 0 {
+		params.Query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset != 0 {
+		params.Query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	setClientIP(params.Query, params.ClientIP)
+
+	response := &Response[store.ListMagnetsData]{}
+	res, err := c.Request("GET", "/v0/store/magnets", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+type addMagnetPayload struct {
+	Magnet string `json:"magnet"`
+}
+
+func (c APIClient) AddMagnet(params *store.AddMagnetParams) (APIResponse[store.AddMagnetData], error) {
+	params.Query = &url.Values{}
+	setClientIP(params.Query, params.ClientIP)
+	params.JSON = &addMagnetPayload{Magnet: params.Magnet}
+
+	response := &Response[store.AddMagnetData]{}
+	res, err := c.Request("POST", "/v0/store/magnets", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+// CheckMagnet accepts max 500 magnets.
+func (c APIClient) CheckMagnet(params *store.CheckMagnetParams) (APIResponse[store.CheckMagnetData], error) {
+	params.Query = &url.Values{"magnet": params.Magnets}
+	if params.SId != "" {
+		params.Query.Set("sid", params.SId)
+	}
+	if params.LocalOnly {
+		params.Query.Set("local_only", "1")
+	}
+	setClientIP(params.Query, params.ClientIP)
+
+	response := &Response[store.CheckMagnetData]{}
+	res, err := c.Request("GET", "/v0/store/magnets/check", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+func (c APIClient) GetMagnet(params *store.GetMagnetParams) (APIResponse[store.GetMagnetData], error) {
+	params.Query = &url.Values{}
+	setClientIP(params.Query, params.ClientIP)
+
+	response := &Response[store.GetMagnetData]{}
+	res, err := c.Request("GET", "/v0/store/magnets/"+url.PathEscape(params.Id), params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+func (c APIClient) RemoveMagnet(params *store.RemoveMagnetParams) (APIResponse[store.RemoveMagnetData], error) {
+	response := &Response[store.RemoveMagnetData]{}
+	res, err := c.Request("DELETE", "/v0/store/magnets/"+url.PathEscape(params.Id), params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+type generateLinkPayload struct {
+	Link string `json:"link"`
+}
+
+func (c APIClient) GenerateLink(params *store.GenerateLinkParams) (APIResponse[store.GenerateLinkData], error) {
+	params.Query = &url.Values{}
+	setClientIP(params.Query, params.ClientIP)
+	params.JSON = &generateLinkPayload{Link: params.Link}
+
+	response := &Response[store.GenerateLinkData]{}
+	res, err := c.Request("POST", "/v0/store/link/generate", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
@@ -0,0 +1,95 @@
+package stremthru
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/MunifTanjim/stremthru/internal/request"
+)
+
+type TorrentFile struct {
+	Name   string `json:"n"`
+	Idx    int    `json:"i"`
+	Size   int64  `json:"s"`
+	SId    string `json:"sid,omitempty"`
+	ASId   string `json:"asid,omitempty"`
+	Source string `json:"src,omitempty"`
+}
+
+type TorrentItem struct {
+	Hash     string        `json:"hash"`
+	Name     string        `json:"name"`
+	Size     int64         `json:"size"`
+	Source   string        `json:"src"`
+	Category string        `json:"category"`
+	Files    []TorrentFile `json:"files"`
+}
+
+type ListTorrentsParams struct {
+	Ctx
+	// IMDb id, with season and episode for series, e.g. `tt0000000:1:2`
+	SId           string
+	LocalOnly     bool
+	NoMissingSize bool
+}
+
+type ListTorrentsData struct {
+	Items      []TorrentItem `json:"items"`
+	TotalItems int           `json:"total_items"`
+}
+
+func (c APIClient) ListTorrents(params *ListTorrentsParams) (APIResponse[ListTorrentsData], error) {
+	params.Query = &url.Values{"sid": []string{params.SId}}
+	if params.LocalOnly {
+		params.Query.Set("local_only", "1")
+	}
+	if params.NoMissingSize {
+		params.Query.Set("no_missing_size", "1")
+	}
+
+	response := &Response[ListTorrentsData]{}
+	res, err := c.Request("GET", "/v0/torrents", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+type RecordTorrentsParams struct {
+	Ctx
+	PeerToken string
+	Items     []TorrentItem
+}
+
+type recordTorrentsPayload struct {
+	Items []TorrentItem `json:"items"`
+}
+
+type RecordTorrentsData struct{}
+
+func (c APIClient) RecordTorrents(params *RecordTorrentsParams) (APIResponse[RecordTorrentsData], error) {
+	params.Headers = &http.Header{}
+	params.Headers.Set("X-StremThru-Peer-Token", params.PeerToken)
+	params.JSON = &recordTorrentsPayload{Items: params.Items}
+
+	response := &Response[RecordTorrentsData]{}
+	res, err := c.Request("POST", "/v0/torrents", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
+
+type TorrentStreamStats struct {
+	TotalCount    int            `json:"total_count"`
+	CountBySource map[string]int `json:"count_by_source"`
+}
+
+type TorrentStats struct {
+	TotalCount    int                 `json:"total_count"`
+	CountBySource map[string]int      `json:"count_by_source"`
+	Streams       *TorrentStreamStats `json:"streams,omitempty"`
+}
+
+func (c APIClient) GetTorrentStats(params *Ctx) (APIResponse[TorrentStats], error) {
+	if params == nil {
+		params = &Ctx{}
+	}
+	response := &Response[TorrentStats]{}
+	res, err := c.Request("GET", "/v0/torrents/stats", params, response)
+	return request.NewAPIResponse(res, response.Data), err
+}
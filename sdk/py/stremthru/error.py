@@ -15,8 +15,10 @@ ErrorCode = Literal[
     "PAYMENT_REQUIRED",
     "PROXY_AUTHENTICATION_REQUIRED",
     "SERVICE_UNAVAILABLE",
+    "STORE_IP_BLOCKED",
     "STORE_LIMIT_EXCEEDED",
     "STORE_MAGNET_INVALID",
+    "STORE_MAGNET_TOO_LARGE",
     "TOO_MANY_REQUESTS",
     "UNAUTHORIZED",
     "UNAVAILABLE_FOR_LEGAL_REASONS",
@@ -100,7 +100,7 @@ var errorCodeByErrorCode = map[ErrorCode]core.ErrorCode{
 	ErrorCodeAuthBanned:        core.ErrorCodeForbidden,
 
 	ErrorCodeAlreadySent: core.ErrorCodeUnknown,
-	ErrorCodeNoServer:    core.ErrorCodeForbidden,
+	ErrorCodeNoServer:    core.ErrorCodeStoreIPBlocked,
 
 	ErrorCodeLinkIsMissing:            core.ErrorCodeUnknown,
 	ErrorCodeBadLink:                  core.ErrorCodeBadRequest,
@@ -133,15 +133,15 @@ var errorCodeByErrorCode = map[ErrorCode]core.ErrorCode{
 	ErrorCodeMagnetTooManyActive:    core.ErrorCodeStoreLimitExceeded,
 	ErrorCodeMagnetTooMany:          core.ErrorCodeStoreLimitExceeded,
 	ErrorCodeMagnetMustBePremium:    core.ErrorCodePaymentRequired,
-	ErrorCodeMagnetTooLarge:         core.ErrorCodeBadRequest,
+	ErrorCodeMagnetTooLarge:         core.ErrorCodeStoreMagnetTooLarge,
 	ErrorCodeMagnetUploadFailed:     core.ErrorCodeInternalServerError,
 	ErrorCodeMagnetInternalError:    core.ErrorCodeInternalServerError,
 	ErrorCodeMagnetCantBootstrap:    core.ErrorCodeUnprocessableEntity,
-	ErrorCodeMagnetTooBig:           core.ErrorCodeBadRequest,
+	ErrorCodeMagnetTooBig:           core.ErrorCodeStoreMagnetTooLarge,
 	ErrorCodeMagnetTookTooLong:      core.ErrorCodeUnprocessableEntity,
 	ErrorCodeMagnetLinksRemoved:     core.ErrorCodeNotFound,
 	ErrorCodeMagnetProcessingFailed: core.ErrorCodeUnprocessableEntity,
-	ErrorCodeMagnetNoServer:         core.ErrorCodeForbidden,
+	ErrorCodeMagnetNoServer:         core.ErrorCodeStoreIPBlocked,
 
 	ErrorCodePinAlreadyAuthed: core.ErrorCodeUnknown,
 	ErrorCodePinExpired:       core.ErrorCodeBadRequest,
@@ -70,7 +70,7 @@ var errorCodeByErrorCode = map[ErrorCode]core.ErrorCode{
 	ErrorCodeBadArguments:            core.ErrorCodeBadRequest,
 	ErrorCodeBadId:                   core.ErrorCodeBadRequest,
 	ErrorCodeFloodDetected:           core.ErrorCodeTooManyRequests,
-	ErrorCodeServerNotAllowed:        core.ErrorCodeForbidden,
+	ErrorCodeServerNotAllowed:        core.ErrorCodeStoreIPBlocked,
 	ErrorCodeFreeServerOverload:      core.ErrorCodeServiceUnavailable,
 	ErrorCodeMaxAttempts:             core.ErrorCodeStoreLimitExceeded,
 	ErrorCodeCaptchaRequired:         core.ErrorCodeForbidden,
@@ -90,7 +90,7 @@ var errorCodeByErrorCode = map[ErrorCode]core.ErrorCode{
 	ErrorCodeMaxDataHost:             core.ErrorCodeStoreLimitExceeded,
 	ErrorCodeDisabledServerHost:      core.ErrorCodeServiceUnavailable,
 	ErrorCodeNotAddTorrent:           core.ErrorCodeBadRequest,
-	ErrorCodeTorrentTooBig:           core.ErrorCodeUnprocessableEntity,
+	ErrorCodeTorrentTooBig:           core.ErrorCodeStoreMagnetTooLarge,
 	ErrorCodeMaxTorrent:              core.ErrorCodeStoreLimitExceeded,
 	ErrorCodeMaxTransfer:             core.ErrorCodeStoreLimitExceeded,
 }
@@ -71,14 +71,14 @@ var errorCodeByErrorCode = map[ErrorCode]core.ErrorCode{
 	ErrorCodeHosterTemporarilyUnavailable:   core.ErrorCodeServiceUnavailable,
 	ErrorCodeHosterNotAvailableForFreeUsers: core.ErrorCodePaymentRequired,
 	ErrorCodeTooManyActiveDownloads:         core.ErrorCodeStoreLimitExceeded,
-	ErrorCodeIPAddressNotAllowed:            core.ErrorCodeForbidden,
+	ErrorCodeIPAddressNotAllowed:            core.ErrorCodeStoreIPBlocked,
 	ErrorCodeTrafficExhausted:               core.ErrorCodeStoreLimitExceeded,
 	ErrorCodeFileUnavailable:                core.ErrorCodeNotFound,
 	ErrorCodeServiceUnavailable:             core.ErrorCodeServiceUnavailable,
 	ErrorCodeUploadTooBig:                   core.ErrorCodeUnprocessableEntity,
 	ErrorCodeUploadError:                    core.ErrorCodeUnknown,
 	ErrorCodeFileNotAllowed:                 core.ErrorCodeUnprocessableEntity,
-	ErrorCodeTorrentTooBig:                  core.ErrorCodeUnprocessableEntity,
+	ErrorCodeTorrentTooBig:                  core.ErrorCodeStoreMagnetTooLarge,
 	ErrorCodeTorrentFileInvalid:             core.ErrorCodeBadRequest,
 	ErrorCodeActionAlreadyDone:              core.ErrorCodeConflict,
 	ErrorCodeImageResolutionError:           core.ErrorCodeBadRequest,
@@ -56,7 +56,7 @@ var errorCodeByErrorCode = map[ErrorCode]core.ErrorCode{
 	ErrorCodeBozoRssFeed:             core.ErrorCodeBadRequest,
 	ErrorCodeSellixError:             core.ErrorCodeInternalServerError,
 	ErrorCodeTooMuchData:             core.ErrorCodeUnprocessableEntity,
-	ErrorCodeDownloadTooLarge:        core.ErrorCodeUnprocessableEntity,
+	ErrorCodeDownloadTooLarge:        core.ErrorCodeStoreMagnetTooLarge,
 	ErrorCodeMissingRequiredOption:   core.ErrorCodeBadRequest,
 	ErrorCodeTooManyOptions:          core.ErrorCodeBadRequest,
 	ErrorCodeBozoTorrent:             core.ErrorCodeBadRequest,